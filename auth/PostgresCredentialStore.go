@@ -0,0 +1,176 @@
+package auth
+
+import (
+	"reflect"
+
+	"github.com/jackc/pgx/v4"
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cauth "github.com/pip-services3-go/pip-services3-components-go/auth"
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+)
+
+/*
+Credential store that keeps credentials in a PostgreSQL table encrypted at rest.
+Credentials are serialized into a parameter string and encrypted
+with pgcrypto symmetric encryption, so they can only be read back with the same encryption key.
+
+### Configuration parameters ###
+
+- table:                       (optional) PostgreSQL table name (default: "credentials")
+- schema:                      (optional) PostgreSQL schema, default "public"
+- connection(s):
+   - discovery_key:             (optional) a key to retrieve the connection from IDiscovery
+   - host:                      host name or IP address
+   - port:                      port number (default: 5432)
+   - uri:                       resource URI or connection string with all parameters in it
+- credential(s):
+   - username:                  (optional) user name
+   - password:                  (optional) user password
+- options:
+   - encryption_key:       a secret key used to encrypt and decrypt stored credentials
+
+### References ###
+
+- \*:logger:\*:\*:1.0           (optional) ILogger components to pass log messages
+- \*:connection:postgres:\*:1.0 (optional) Shared PostgreSQL connection
+- \*:discovery:\*:\*:1.0        (optional) IDiscovery services
+
+### Example ###
+
+    store := NewPostgresCredentialStore()
+    store.Configure(cconf.NewConfigParamsFromTuples(
+        "connection.host", "localhost",
+        "connection.port", 5432,
+        "connection.database", "test",
+        "options.encryption_key", "my secret",
+    ))
+    err := store.Open("123")
+    ...
+    err = store.Store("123", "key1", cauth.NewCredentialParamsFromTuples(
+        "user", "jdoe",
+        "pass", "pass123",
+    ))
+    credential, err := store.Lookup("123", "key1")
+    // Result: user=jdoe;pass=pass123
+*/
+type PostgresCredentialStore struct {
+	*persist.PostgresPersistence
+
+	encryptionKey string
+}
+
+// Creates a new instance of the credential store component.
+func NewPostgresCredentialStore() *PostgresCredentialStore {
+	c := &PostgresCredentialStore{}
+	c.PostgresPersistence = persist.InheritPostgresPersistence(c, reflect.TypeOf(map[string]interface{}{}), "credentials")
+	return c
+}
+
+// Configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *PostgresCredentialStore) Configure(config *cconf.ConfigParams) {
+	c.PostgresPersistence.Configure(config)
+
+	c.encryptionKey = config.GetAsStringWithDefault("options.encryption_key", c.encryptionKey)
+}
+
+// Defines a database schema for the credentials table
+func (c *PostgresCredentialStore) DefineSchema() {
+	c.ClearSchema()
+	c.PostgresPersistence.DefineSchema()
+	c.EnsureSchema("CREATE EXTENSION IF NOT EXISTS pgcrypto")
+	c.EnsureSchema("CREATE TABLE IF NOT EXISTS " + c.QuotedTableName() +
+		" (\"key\" TEXT PRIMARY KEY, \"value\" BYTEA NOT NULL, \"update_time\" TIMESTAMPTZ NOT NULL DEFAULT now())")
+}
+
+// Opens the component.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+//   - Returns 			 error or nil no errors occured.
+func (c *PostgresCredentialStore) Open(correlationId string) error {
+	if c.encryptionKey == "" {
+		return cerr.NewConfigError(correlationId, "NO_ENCRYPTION_KEY", "Encryption key for credentials is not set")
+	}
+	return c.PostgresPersistence.Open(correlationId)
+}
+
+func (c *PostgresCredentialStore) checkOpen(correlationId string) error {
+	if !c.IsOpen() {
+		return cerr.NewInvalidStateError(correlationId, "NOT_OPENED", "The credential store is not opened")
+	}
+	return nil
+}
+
+// Stores credential parameters into the store.
+// If credential is nil it removes the stored credential.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - key               a key to uniquely identify the credential parameters.
+//   - credential        a credential parameters to be stored.
+// Returns error or nil for success.
+func (c *PostgresCredentialStore) Store(correlationId string, key string, credential *cauth.CredentialParams) (err error) {
+	ctx, done := c.BeginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkOpen(correlationId); err != nil {
+		return err
+	}
+	if err = c.CheckWritable(correlationId); err != nil {
+		return err
+	}
+	if err = c.CheckNotDryRun(correlationId); err != nil {
+		return err
+	}
+
+	if credential == nil {
+		query := "DELETE FROM " + c.QuotedTableName() + " WHERE \"key\"=$1"
+		_, err = c.Client.Exec(ctx, query, key)
+		if err == nil {
+			c.Logger.Trace(correlationId, "Removed credential %s from %s", key, c.TableName)
+		}
+		return err
+	}
+
+	query := "INSERT INTO " + c.QuotedTableName() + " (\"key\", \"value\") VALUES ($1, pgp_sym_encrypt($2, $3))" +
+		" ON CONFLICT (\"key\") DO UPDATE SET \"value\"=EXCLUDED.\"value\", \"update_time\"=now()"
+	_, err = c.Client.Exec(ctx, query, key, credential.String(), c.encryptionKey)
+	if err != nil {
+		return err
+	}
+
+	c.Logger.Trace(correlationId, "Stored credential %s in %s", key, c.TableName)
+	return nil
+}
+
+// Lookups credential parameters by its key.
+// If credential is missing in the store it returns nil.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - key               a key to uniquely identify the credential parameters.
+// Returns found credential parameters or error.
+func (c *PostgresCredentialStore) Lookup(correlationId string, key string) (credential *cauth.CredentialParams, err error) {
+	ctx, done := c.BeginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkOpen(correlationId); err != nil {
+		return nil, err
+	}
+
+	query := "SELECT pgp_sym_decrypt(\"value\", $2) FROM " + c.QuotedTableName() + " WHERE \"key\"=$1"
+	var value string
+	err = c.Client.QueryRow(ctx, query, key, c.encryptionKey).Scan(&value)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, cerr.NewInternalError(correlationId, "DECRYPT_FAILED",
+			"Failed to retrieve credential "+key).WithCause(err)
+	}
+
+	c.Logger.Trace(correlationId, "Retrieved credential %s from %s", key, c.TableName)
+	return cauth.NewCredentialParamsFromString(value), nil
+}
@@ -0,0 +1,252 @@
+package benchmark
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+	cmpersist "github.com/pip-services3-go/pip-services3-data-go/persistence"
+)
+
+// Operation executed by the load runner. The iteration is a sequential number of the call across all workers.
+type LoadOperation func(correlationId string, iteration int64) error
+
+// Persistence that is loaded by RunCrud, e.g. IdentifiablePostgresPersistence.
+type ILoadTarget interface {
+	Create(correlationId string, item interface{}) (interface{}, error)
+	GetOneById(correlationId string, id interface{}) (interface{}, error)
+	GetPageByFilter(correlationId string, filter interface{}, paging *cdata.PagingParams,
+		sort interface{}, sel interface{}) (*cdata.DataPage, error)
+}
+
+// Throughput and latency measured for one operation.
+type LoadStats struct {
+	// Name of the operation
+	Name string
+	// Number of executed calls
+	Operations int64
+	// Number of failed calls
+	Errors int64
+	// Wall time of the run
+	Elapsed time.Duration
+	// Calls per second
+	Throughput float64
+
+	MinLatency  time.Duration
+	MeanLatency time.Duration
+	P50Latency  time.Duration
+	P95Latency  time.Duration
+	P99Latency  time.Duration
+	MaxLatency  time.Duration
+}
+
+// Formats the stats as a single report line.
+func (c *LoadStats) String() string {
+	return fmt.Sprintf("%s: %d ops (%d errors) in %v, %.1f ops/s, latency min=%v mean=%v p50=%v p95=%v p99=%v max=%v",
+		c.Name, c.Operations, c.Errors, c.Elapsed, c.Throughput,
+		c.MinLatency, c.MeanLatency, c.P50Latency, c.P95Latency, c.P99Latency, c.MaxLatency)
+}
+
+/*
+Runs operations against a target database from concurrent workers and measures their throughput and latency,
+to evaluate performance of persistence components quantitatively.
+
+A run stops when the configured number of iterations is executed or the duration elapses, whatever comes first.
+
+### Configuration parameters ###
+
+- options:
+   - concurrency:          (optional) number of concurrent workers (default: 4)
+   - duration:             (optional) maximum duration of a run in milliseconds, 0 to run without a time limit (default: 10000)
+   - iterations:           (optional) maximum number of calls in a run, 0 to run without a limit (default: 0)
+
+### References ###
+
+- \*:logger:\*:\*:1.0           (optional) ILogger components to pass log messages
+
+### Example ###
+
+    runner := NewPostgresLoadRunner()
+    runner.Configure(cconf.NewConfigParamsFromTuples(
+        "options.concurrency", 8,
+        "options.iterations", 10000,
+    ))
+    stats, err := runner.RunCrud("123", &persistence.IdentifiablePostgresPersistence, func(iteration int64) interface{} {
+        return MyData{Key: "key" + strconv.FormatInt(iteration, 10)}
+    })
+    for _, s := range stats {
+        fmt.Println(s)
+    }
+*/
+type PostgresLoadRunner struct {
+	concurrency int
+	duration    int64
+	iterations  int64
+
+	//The logger.
+	Logger *clog.CompositeLogger
+}
+
+// Creates a new instance of the load runner.
+func NewPostgresLoadRunner() *PostgresLoadRunner {
+	return &PostgresLoadRunner{
+		concurrency: 4,
+		duration:    10000,
+		Logger:      clog.NewCompositeLogger(),
+	}
+}
+
+// Configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *PostgresLoadRunner) Configure(config *cconf.ConfigParams) {
+	c.concurrency = config.GetAsIntegerWithDefault("options.concurrency", c.concurrency)
+	c.duration = config.GetAsLongWithDefault("options.duration", c.duration)
+	c.iterations = config.GetAsLongWithDefault("options.iterations", c.iterations)
+}
+
+// Sets references to dependent components.
+//   - references 	references to locate the component dependencies.
+func (c *PostgresLoadRunner) SetReferences(references cref.IReferences) {
+	c.Logger.SetReferences(references)
+}
+
+// Runs an operation from concurrent workers until the iterations are executed or the duration elapses.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+//   - name             a name of the operation in the stats.
+//   - operation        the operation to be executed.
+// Returns measured stats.
+func (c *PostgresLoadRunner) Run(correlationId string, name string, operation LoadOperation) *LoadStats {
+	concurrency := c.concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	iterations := c.iterations
+	if iterations <= 0 && c.duration <= 0 {
+		// Without limits every worker makes a single call
+		iterations = int64(concurrency)
+	}
+
+	var counter, errors int64
+	latencies := make([][]time.Duration, concurrency)
+	start := time.Now()
+	deadline := start.Add(time.Duration(c.duration) * time.Millisecond)
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < concurrency; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for {
+				if c.duration > 0 && time.Now().After(deadline) {
+					return
+				}
+				iteration := atomic.AddInt64(&counter, 1) - 1
+				if iterations > 0 && iteration >= iterations {
+					return
+				}
+
+				callStart := time.Now()
+				err := operation(correlationId, iteration)
+				latencies[worker] = append(latencies[worker], time.Since(callStart))
+				if err != nil {
+					if atomic.AddInt64(&errors, 1) == 1 {
+						c.Logger.Error(correlationId, err, "Operation %s failed", name)
+					}
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+
+	stats := newLoadStats(name, time.Since(start), latencies)
+	stats.Errors = errors
+	c.Logger.Debug(correlationId, "%s", stats.String())
+	return stats
+}
+
+// Runs Create, GetOneById and GetPageByFilter operations one after another against a persistence.
+// GetOneById reads the items created in the first run.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+//   - target           a persistence to be loaded.
+//   - newItem          a function that creates a new item for an iteration.
+// Returns stats for every operation or error if no items were created.
+func (c *PostgresLoadRunner) RunCrud(correlationId string, target ILoadTarget,
+	newItem func(iteration int64) interface{}) ([]*LoadStats, error) {
+	var idsLock sync.Mutex
+	ids := make([]interface{}, 0)
+
+	createStats := c.Run(correlationId, "create", func(correlationId string, iteration int64) error {
+		item, err := target.Create(correlationId, newItem(iteration))
+		if err == nil && item != nil {
+			idsLock.Lock()
+			ids = append(ids, cmpersist.GetObjectId(item))
+			idsLock.Unlock()
+		}
+		return err
+	})
+	if len(ids) == 0 {
+		return []*LoadStats{createStats}, cerr.NewInvalidStateError(correlationId, "NO_ITEMS_CREATED",
+			"Load run has not created any items")
+	}
+
+	getStats := c.Run(correlationId, "get_one_by_id", func(correlationId string, iteration int64) error {
+		_, err := target.GetOneById(correlationId, ids[iteration%int64(len(ids))])
+		return err
+	})
+
+	pageStats := c.Run(correlationId, "get_page_by_filter", func(correlationId string, iteration int64) error {
+		_, err := target.GetPageByFilter(correlationId, "", cdata.NewPagingParams(0, 100, false), nil, nil)
+		return err
+	})
+
+	return []*LoadStats{createStats, getStats, pageStats}, nil
+}
+
+// Calculates stats from latencies collected by the workers
+func newLoadStats(name string, elapsed time.Duration, latencies [][]time.Duration) *LoadStats {
+	all := make([]time.Duration, 0)
+	for _, workerLatencies := range latencies {
+		all = append(all, workerLatencies...)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i] < all[j] })
+
+	stats := &LoadStats{
+		Name:       name,
+		Operations: int64(len(all)),
+		Elapsed:    elapsed,
+	}
+	if len(all) == 0 {
+		return stats
+	}
+
+	var total time.Duration
+	for _, latency := range all {
+		total += latency
+	}
+	if elapsed > 0 {
+		stats.Throughput = float64(len(all)) / elapsed.Seconds()
+	}
+	stats.MinLatency = all[0]
+	stats.MeanLatency = total / time.Duration(len(all))
+	stats.P50Latency = percentile(all, 50)
+	stats.P95Latency = percentile(all, 95)
+	stats.P99Latency = percentile(all, 99)
+	stats.MaxLatency = all[len(all)-1]
+	return stats
+}
+
+// Gets a percentile from sorted latencies
+func percentile(sorted []time.Duration, p int) time.Duration {
+	index := (len(sorted)*p+99)/100 - 1
+	if index < 0 {
+		index = 0
+	}
+	return sorted[index]
+}
@@ -3,12 +3,25 @@ package build
 import (
 	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
 	cbuild "github.com/pip-services3-go/pip-services3-components-go/build"
+	auth "github.com/pip-services3-go/pip-services3-postgres-go/auth"
+	cache "github.com/pip-services3-go/pip-services3-postgres-go/cache"
 	conn "github.com/pip-services3-go/pip-services3-postgres-go/connect"
+	count "github.com/pip-services3-go/pip-services3-postgres-go/count"
+	lock "github.com/pip-services3-go/pip-services3-postgres-go/lock"
+	queues "github.com/pip-services3-go/pip-services3-postgres-go/queues"
+	state "github.com/pip-services3-go/pip-services3-postgres-go/state"
 )
 
 // Creates Postgres components by their descriptors.
 // See Factory
 // See PostgresConnection
+// See PostgresMessageQueue
+// See PostgresLock
+// See PostgresCache
+// See PostgresStateStore
+// See PostgresCounters
+// See PostgresCredentialStore
+// See PostgresDiscovery
 type DefaultPostgresFactory struct {
 	cbuild.Factory
 }
@@ -19,8 +32,28 @@ func NewDefaultPostgresFactory() *DefaultPostgresFactory {
 	c := &DefaultPostgresFactory{}
 
 	postgresConnectionDescriptor := cref.NewDescriptor("pip-services", "connection", "postgres", "*", "1.0")
+	postgresMessageQueueDescriptor := cref.NewDescriptor("pip-services", "message-queue", "postgres", "*", "1.0")
+	postgresLockDescriptor := cref.NewDescriptor("pip-services", "lock", "postgres", "*", "1.0")
+	postgresCacheDescriptor := cref.NewDescriptor("pip-services", "cache", "postgres", "*", "1.0")
+	postgresStateStoreDescriptor := cref.NewDescriptor("pip-services", "state-store", "postgres", "*", "1.0")
+	postgresCountersDescriptor := cref.NewDescriptor("pip-services", "counters", "postgres", "*", "1.0")
+	postgresCredentialStoreDescriptor := cref.NewDescriptor("pip-services", "credential-store", "postgres", "*", "1.0")
+	postgresDiscoveryDescriptor := cref.NewDescriptor("pip-services", "discovery", "postgres", "*", "1.0")
 
 	c.RegisterType(postgresConnectionDescriptor, conn.NewPostgresConnection)
+	c.Register(postgresMessageQueueDescriptor, func(locator interface{}) interface{} {
+		name := ""
+		if descriptor, ok := locator.(*cref.Descriptor); ok {
+			name = descriptor.Name()
+		}
+		return queues.NewPostgresMessageQueue(name)
+	})
+	c.RegisterType(postgresLockDescriptor, lock.NewPostgresLock)
+	c.RegisterType(postgresCacheDescriptor, cache.NewPostgresCache)
+	c.RegisterType(postgresStateStoreDescriptor, state.NewPostgresStateStore)
+	c.RegisterType(postgresCountersDescriptor, count.NewPostgresCounters)
+	c.RegisterType(postgresCredentialStoreDescriptor, auth.NewPostgresCredentialStore)
+	c.RegisterType(postgresDiscoveryDescriptor, conn.NewPostgresDiscovery)
 
 	return c
 }
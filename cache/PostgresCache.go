@@ -0,0 +1,297 @@
+package cache
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+)
+
+/*
+Distributed cache that stores values as JSON in an UNLOGGED PostgreSQL table.
+Unlogged tables skip write-ahead log, so they are fast but are truncated after a crash,
+which is acceptable for cached data. Expired entries are ignored on reads
+and periodically removed by a background cleanup.
+
+### Configuration parameters ###
+
+- table:                       (optional) PostgreSQL table name (default: "cache")
+- schema:                      (optional) PostgreSQL schema, default "public"
+- connection(s):
+   - discovery_key:             (optional) a key to retrieve the connection from IDiscovery
+   - host:                      host name or IP address
+   - port:                      port number (default: 5432)
+   - uri:                       resource URI or connection string with all parameters in it
+- credential(s):
+   - store_key:                 (optional) a key to retrieve the credentials from ICredentialStore
+   - username:                  (optional) user name
+   - password:                  (optional) user password
+- options:
+   - timeout:              (optional) default caching timeout in milliseconds (default: 60000)
+   - cleanup_interval:     (optional) interval in milliseconds to remove expired entries, 0 to disable (default: 60000)
+
+### References ###
+
+- \*:logger:\*:\*:1.0           (optional) ILogger components to pass log messages
+- \*:connection:postgres:\*:1.0 (optional) Shared PostgreSQL connection
+- \*:discovery:\*:\*:1.0        (optional) IDiscovery services
+- \*:credential-store:\*:\*:1.0 (optional) Credential stores to resolve credentials
+
+### Example ###
+
+    cache := NewPostgresCache()
+    cache.Configure(cconf.NewConfigParamsFromTuples(
+        "connection.host", "localhost",
+        "connection.port", 5432,
+        "connection.database", "test",
+    ))
+    err := cache.Open("123")
+    ...
+    cache.Store("123", "key1", "ABC", 10000)
+    value, err := cache.Retrieve("123", "key1")   // Result: "ABC"
+*/
+type PostgresCache struct {
+	*persist.PostgresPersistence
+
+	timeout         int64
+	cleanupInterval int64
+	cleanupStop     chan bool
+}
+
+// Creates a new instance of the cache component.
+func NewPostgresCache() *PostgresCache {
+	c := &PostgresCache{
+		timeout:         60000,
+		cleanupInterval: 60000,
+	}
+	c.PostgresPersistence = persist.InheritPostgresPersistence(c, reflect.TypeOf(map[string]interface{}{}), "cache")
+	return c
+}
+
+// Configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *PostgresCache) Configure(config *cconf.ConfigParams) {
+	c.PostgresPersistence.Configure(config)
+
+	c.timeout = config.GetAsLongWithDefault("options.timeout", c.timeout)
+	c.cleanupInterval = config.GetAsLongWithDefault("options.cleanup_interval", c.cleanupInterval)
+}
+
+// Defines a database schema for the cache table
+func (c *PostgresCache) DefineSchema() {
+	c.ClearSchema()
+	c.PostgresPersistence.DefineSchema()
+	c.EnsureSchema("CREATE UNLOGGED TABLE IF NOT EXISTS " + c.QuotedTableName() +
+		" (\"key\" TEXT PRIMARY KEY, \"value\" JSONB, \"expiration\" TIMESTAMPTZ NOT NULL)")
+	c.EnsureSchema("CREATE INDEX IF NOT EXISTS " + c.QuoteIdentifier(c.TableName+"_expiration") +
+		" ON " + c.QuotedTableName() + " (\"expiration\")")
+}
+
+// Converts a cache row into a map with key, value and expiration.
+//   - rows  a current row
+// Returns a converted row
+func (c *PostgresCache) ConvertToPublic(rows pgx.Rows) interface{} {
+	values, valErr := rows.Values()
+	if valErr != nil || values == nil {
+		return persist.NewDataConversionError("", "", nil, valErr)
+	}
+
+	result := make(map[string]interface{})
+	for index, column := range rows.FieldDescriptions() {
+		result[string(column.Name)] = values[index]
+	}
+	return result
+}
+
+// Opens the component and starts the expiration cleanup.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+//   - Returns 			 error or nil no errors occured.
+func (c *PostgresCache) Open(correlationId string) error {
+	if c.IsOpen() {
+		return nil
+	}
+
+	err := c.PostgresPersistence.Open(correlationId)
+	if err != nil {
+		return err
+	}
+
+	if c.cleanupInterval > 0 {
+		c.cleanupStop = make(chan bool)
+		go c.cleanup(correlationId, c.cleanupStop)
+	}
+	return nil
+}
+
+// Closes component, stops the expiration cleanup and frees used resources.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+//   - Returns 			error or nil no errors occured.
+func (c *PostgresCache) Close(correlationId string) error {
+	if c.cleanupStop != nil {
+		close(c.cleanupStop)
+		c.cleanupStop = nil
+	}
+	return c.PostgresPersistence.Close(correlationId)
+}
+
+func (c *PostgresCache) cleanup(correlationId string, stop chan bool) {
+	ticker := time.NewTicker(time.Duration(c.cleanupInterval) * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			err := c.RemoveExpired(correlationId)
+			if err != nil {
+				c.Logger.Error(correlationId, err, "Failed to remove expired cache entries")
+			}
+		}
+	}
+}
+
+// Removes all expired entries from the cache.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+// Returns error or nil for success.
+func (c *PostgresCache) RemoveExpired(correlationId string) (err error) {
+	ctx, done := c.BeginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkOpen(correlationId); err != nil {
+		return err
+	}
+
+	query := "DELETE FROM " + c.QuotedTableName() + " WHERE \"expiration\"<=now()"
+	result, err := c.Client.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() > 0 {
+		c.Logger.Trace(correlationId, "Removed %d expired entries from %s", result.RowsAffected(), c.TableName)
+	}
+	return nil
+}
+
+func (c *PostgresCache) checkOpen(correlationId string) error {
+	if !c.IsOpen() {
+		return cerr.NewInvalidStateError(correlationId, "NOT_OPENED", "The cache is not opened")
+	}
+	return nil
+}
+
+// Retrieves cached value from the cache using its key.
+// If value is missing in the cache or expired it returns nil.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - key               a unique value key.
+// Returns a cached value decoded from JSON or error.
+func (c *PostgresCache) Retrieve(correlationId string, key string) (interface{}, error) {
+	var value interface{}
+	ok, err := c.RetrieveAs(correlationId, key, &value)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Retrieves cached value from the cache using its key and decodes it into the given result.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - key               a unique value key.
+//   - result            a pointer to a value to decode into.
+// Returns true if the value was found or error.
+func (c *PostgresCache) RetrieveAs(correlationId string, key string, result interface{}) (found bool, err error) {
+	ctx, done := c.BeginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkOpen(correlationId); err != nil {
+		return false, err
+	}
+
+	query := "SELECT \"value\" FROM " + c.QuotedTableName() + " WHERE \"key\"=$1 AND \"expiration\">now()"
+	var buffer []byte
+	err = c.Client.QueryRow(ctx, query, key).Scan(&buffer)
+	if err == pgx.ErrNoRows {
+		c.Logger.Trace(correlationId, "Cache miss for key %s in %s", key, c.TableName)
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err = json.Unmarshal(buffer, result); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Stores value in the cache with expiration time.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - key               a unique value key.
+//   - value             a value to store.
+//   - timeout           expiration timeout in milliseconds, 0 to use the default timeout.
+// Returns the stored value or error.
+func (c *PostgresCache) Store(correlationId string, key string, value interface{}, timeout int64) (stored interface{}, err error) {
+	ctx, done := c.BeginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkOpen(correlationId); err != nil {
+		return nil, err
+	}
+
+	if timeout <= 0 {
+		timeout = c.timeout
+	}
+
+	buffer, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "INSERT INTO " + c.QuotedTableName() + " (\"key\", \"value\", \"expiration\")" +
+		" VALUES ($1, $2, now()+$3*interval '1 millisecond')" +
+		" ON CONFLICT (\"key\") DO UPDATE SET \"value\"=EXCLUDED.\"value\", \"expiration\"=EXCLUDED.\"expiration\""
+	_, err = c.Client.Exec(ctx, query, key, string(buffer), timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Logger.Trace(correlationId, "Stored key %s in %s", key, c.TableName)
+	return value, nil
+}
+
+// Removes a value from the cache by its key.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - key               a unique value key.
+// Returns error or nil for success.
+func (c *PostgresCache) Remove(correlationId string, key string) (err error) {
+	ctx, done := c.BeginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkOpen(correlationId); err != nil {
+		return err
+	}
+
+	query := "DELETE FROM " + c.QuotedTableName() + " WHERE \"key\"=$1"
+	_, err = c.Client.Exec(ctx, query, key)
+	if err == nil {
+		c.Logger.Trace(correlationId, "Removed key %s from %s", key, c.TableName)
+	}
+	return err
+}
@@ -2,12 +2,21 @@ package connect
 
 import (
 	"context"
+	"math"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"sync"
 	"time"
 
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgconn/stmtcache"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
 	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
 	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	cinfo "github.com/pip-services3-go/pip-services3-components-go/info"
 	clog "github.com/pip-services3-go/pip-services3-components-go/log"
 )
 
@@ -24,20 +33,47 @@ import (
   - host:                      host name or IP address
   - port:                      port number (default: 27017)
   - uri:                       resource URI or connection string with all parameters in it
+  - role:                      (optional) primary or replica. Replicas get a separate pool for reads (default: primary)
+  - target_session_attrs:      (optional) session type required from hosts: read-write, read-only or any (default: any)
+  - sslmode:                   (optional) disable, allow, prefer, require, verify-ca or verify-full (default: prefer)
+  - sslrootcert:               (optional) path to the root certificate to verify the server
+  - sslcert:                   (optional) path to the client certificate
+  - sslkey:                    (optional) path to the client private key
 - credential(s):
   - store_key:                 (optional) a key to retrieve the credentials from ICredentialStore
   - username:                  user name
   - password:                  user password
+  - sslmode, sslrootcert, sslcert, sslkey: (optional) TLS parameters that override ones of connections
 - options:
   - connect_timeout:      (optional) number of milliseconds to wait before timing out when connecting a new client (default: 0)
   - idle_timeout:         (optional) number of milliseconds a client must sit idle in the pool and not be checked out (default: 10000)
   - max_pool_size:        (optional) maximum number of clients the pool should contain (default: 10)
+  - min_pool_size:        (optional) minimum number of clients kept open in the pool, 0 to open clients on demand (default: 0)
+                          the clients are established during Open to avoid a latency spike on the first burst of traffic
+  - max_lifetime:         (optional) number of milliseconds after which a client is closed and replaced, 0 to disable (default: 3600000)
+                          recycles connections that are silently dropped by NAT or load balancers
+  - health_check_period:  (optional) number of milliseconds between checks of idle clients and their lifetime (default: 60000)
+  - statement_cache_mode:     (optional) how statements are cached per connection: prepare, describe or disabled (default: prepare)
+                              in prepare mode repeated queries, like GetOneById, Create or Update, are prepared once per connection
+  - statement_cache_capacity: (optional) maximum number of cached statements per connection (default: 512)
+  - application_name:     (optional) name of the client shown in pg_stat_activity (default: name of the context info or "application_name" in uri)
+  - statement_timeout:    (optional) number of milliseconds after which queries are cancelled by the server, 0 to disable (default: 0)
+  - default_transaction_read_only: (optional) start sessions in read-only mode, so the server rejects changes of data (default: false)
+  - auto_reconnect:       (optional) reconnect in background when the database becomes unavailable (default: true)
+                          the database is checked on a dedicated connection every health_check_period
+                          and the pool is recreated with exponential backoff when it is not available
+  - reconnect_delay:      (optional) number of milliseconds before the first reconnect attempt (default: 1000)
+  - max_reconnect_delay:  (optional) maximum number of milliseconds between reconnect attempts (default: 30000)
+- pools:                  (optional) partitioning of the pool by workload classes
+  - <class>:              percentage of max_pool_size dedicated to the class, e.g. "pools.batch": 20
+                          the remaining connections belong to the default pool
 
 ### References ###
 
  - \*:logger:\*:\*:1.0           (optional) ILogger components to pass log messages
  - \*:discovery:\*:\*:1.0        (optional) IDiscovery services
  - \*:credential-store:\*:\*:1.0 (optional) Credential stores to resolve credentials
+ - \*:context-info:\*:\*:1.0     (optional) Context info with the default application name
 
 */
 type PostgresConnection struct {
@@ -52,8 +88,32 @@ type PostgresConnection struct {
 	Connection *pgxpool.Pool
 	// The PostgreSQL database name.
 	DatabaseName string
+
+	poolShares map[string]int
+	pools      map[string]*pgxpool.Pool
+	replica    *pgxpool.Pool
+	statements map[string]string
+	appName    string
+	lock       sync.RWMutex
+	stop       chan struct{}
+	listeners  []func()
+	// Serializes recreation of pools by reconfigure and reconnect
+	reconnectLock sync.Mutex
 }
 
+// The name of the default pool class
+const DefaultPoolClass = "default"
+
+// Modes of statement caches
+const (
+	// Statements are prepared once per connection and executed by name
+	StatementCacheModePrepare = "prepare"
+	// Only descriptions of statements are cached, that works with transaction pooling of pgbouncer
+	StatementCacheModeDescribe = "describe"
+	// Statements are not cached
+	StatementCacheModeDisabled = "disabled"
+)
+
 // NewPostgresConnection creates a new instance of the connection component.
 func NewPostgresConnection() *PostgresConnection {
 	c := &PostgresConnection{
@@ -61,20 +121,116 @@ func NewPostgresConnection() *PostgresConnection {
 			"options.connect_timeout", 0,
 			"options.idle_timeout", 10000,
 			"options.max_pool_size", 3,
+			"options.max_lifetime", 3600000,
+			"options.health_check_period", 60000,
+			"options.statement_cache_mode", StatementCacheModePrepare,
+			"options.statement_cache_capacity", 512,
+			"options.auto_reconnect", true,
+			"options.reconnect_delay", 1000,
+			"options.max_reconnect_delay", 30000,
 		),
 		Logger:             clog.NewCompositeLogger(),
 		ConnectionResolver: NewPostgresConnectionResolver(),
 		Options:            cconf.NewEmptyConfigParams(),
+		poolShares:         make(map[string]int),
+		pools:              make(map[string]*pgxpool.Pool),
+		statements:         make(map[string]string),
 	}
 	return c
 }
 
 // Configures component by passing configuration parameters.
+// When the connection is open, changed pool shares, pool and timeout options are applied by recreating the pools.
 //   - config    configuration parameters to be set.
 func (c *PostgresConnection) Configure(config *cconf.ConfigParams) {
 	config = config.SetDefaults(c.defaultConfig)
+	if c.IsOpen() {
+		c.reconfigure(config)
+		return
+	}
+
 	c.ConnectionResolver.Configure(config)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
 	c.Options = c.Options.Override(config.GetSection("options"))
+	c.poolShares = readPoolShares(config)
+}
+
+// Reads shares of workload classes from the pools section of a configuration
+func readPoolShares(config *cconf.ConfigParams) map[string]int {
+	shares := make(map[string]int)
+	pools := config.GetSection("pools")
+	for _, poolClass := range pools.Keys() {
+		share := pools.GetAsInteger(poolClass)
+		if share > 0 && poolClass != DefaultPoolClass {
+			shares[poolClass] = share
+		}
+	}
+	return shares
+}
+
+// Gets the current options. Options are replaced as a whole, so the result is safe to read without the lock.
+func (c *PostgresConnection) options() *cconf.ConfigParams {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.Options
+}
+
+// Options that are applied by recreating pools of an open connection
+var reloadedOptions = []string{
+	"connect_timeout", "idle_timeout", "max_pool_size", "min_pool_size", "max_lifetime",
+	"health_check_period", "statement_cache_mode", "statement_cache_capacity", "statement_timeout",
+	"application_name", "default_transaction_read_only",
+}
+
+// Options that are applied by restarting monitoring of an open connection
+var monitorOptions = []string{
+	"auto_reconnect", "reconnect_delay", "max_reconnect_delay", "health_check_period",
+}
+
+// Applies options and pool shares to an open connection. Connection parameters and credentials are not changed.
+// The pools are recreated only when pool or timeout options have changed, operations in flight
+// complete on the previous pools. Reconfiguration waits for a reconnect in progress to complete.
+//   - config    configuration parameters to be set.
+func (c *PostgresConnection) reconfigure(config *cconf.ConfigParams) {
+	c.reconnectLock.Lock()
+	defer c.reconnectLock.Unlock()
+
+	c.lock.Lock()
+	options := c.Options.Override(config.GetSection("options"))
+	poolShares := readPoolShares(config)
+	poolsChanged := optionsChanged(c.Options, options, reloadedOptions) || !reflect.DeepEqual(c.poolShares, poolShares)
+	monitorChanged := optionsChanged(c.Options, options, monitorOptions)
+	c.Options = options
+	c.poolShares = poolShares
+	if monitorChanged {
+		c.stopMonitor()
+		c.startMonitor("")
+	}
+	c.lock.Unlock()
+
+	if !poolsChanged {
+		return
+	}
+
+	c.Logger.Info("", "Recreating postgres connection pools with changed options")
+	pools, replica, databaseName, err := c.connect("")
+	if err != nil {
+		c.Logger.Error("", err, "Failed to recreate postgres connection pools, previous pools are kept")
+		return
+	}
+	c.replacePools(pools, replica, databaseName)
+}
+
+// Checks if any of the options has a different value
+func optionsChanged(oldOptions *cconf.ConfigParams, newOptions *cconf.ConfigParams, keys []string) bool {
+	for _, key := range keys {
+		if oldOptions.GetAsString(key) != newOptions.GetAsString(key) {
+			return true
+		}
+	}
+	return false
 }
 
 // Sets references to dependent components.
@@ -82,11 +238,19 @@ func (c *PostgresConnection) Configure(config *cconf.ConfigParams) {
 func (c *PostgresConnection) SetReferences(references cref.IReferences) {
 	c.Logger.SetReferences(references)
 	c.ConnectionResolver.SetReferences(references)
+
+	contextInfo := references.GetOneOptional(cref.NewDescriptor("pip-services", "context-info", "*", "*", "1.0"))
+	if info, ok := contextInfo.(*cinfo.ContextInfo); ok && info != nil {
+		c.appName = info.Name
+	}
 }
 
 // Checks if the component is opened.
 // Returns true if the component has been opened and false otherwise.
 func (c *PostgresConnection) IsOpen() bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
 	return c.Connection != nil
 }
 
@@ -94,66 +258,532 @@ func (c *PostgresConnection) IsOpen() bool {
 //   - correlationId 	(optional) transaction id to trace execution through call chain.
 //   - Return 			error or nil no errors occured.
 func (c *PostgresConnection) Open(correlationId string) error {
+	pools, replica, databaseName, err := c.connect(correlationId)
+	if err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	c.Connection = pools[DefaultPoolClass]
+	c.pools = pools
+	c.replica = replica
+	c.DatabaseName = databaseName
+	c.startMonitor(correlationId)
+	c.lock.Unlock()
+
+	return nil
+}
+
+// Creates connection pools for all workload classes and read replicas
+// Returns the pools by classes, the replica pool, the database name or error.
+func (c *PostgresConnection) connect(correlationId string) (map[string]*pgxpool.Pool, *pgxpool.Pool, string, error) {
 
-	uri, err := c.ConnectionResolver.Resolve(correlationId)
+	config, err := c.ConnectionResolver.ResolveConfig(correlationId)
 
 	if err != nil {
 		c.Logger.Error(correlationId, err, "Failed to resolve Postgres connection")
-		return nil
+		return nil, nil, "", err
 	}
+	c.applyPoolOptions(config)
 
-	maxPoolSize := c.Options.GetAsNullableInteger("max_pool_size")
-	idleTimeoutMS := c.Options.GetAsNullableInteger("idle_timeout")
-	connectTimeoutMS := c.Options.GetAsNullableInteger("connect_timeout")
+	c.Logger.Debug(correlationId, "Connecting to postgres")
 
-	config, err := pgxpool.ParseConfig(uri)
+	// Split the pool between workload classes
+	poolSizes := c.calculatePoolSizes(config.MaxConns)
+	pools := make(map[string]*pgxpool.Pool)
+	for poolClass, poolSize := range poolSizes {
+		poolConfig := config.Copy()
+		poolConfig.MaxConns = poolSize
+		if poolConfig.MinConns > poolSize {
+			poolConfig.MinConns = poolSize
+		}
+
+		pool, poolErr := pgxpool.ConnectConfig(context.Background(), poolConfig)
+		if poolErr != nil || pool == nil {
+			err = poolErr
+			break
+		}
+		pools[poolClass] = pool
+		c.warmUp(correlationId, pool, poolConfig.MinConns)
+	}
+
+	var replica *pgxpool.Pool
+	if err == nil {
+		replica, err = c.openReplica(correlationId)
+	}
 
 	if err != nil {
-		c.Logger.Error(correlationId, err, "Failed to parse Postgres config string")
-		return nil
+		for _, pool := range pools {
+			pool.Close()
+		}
+		err = cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "Connection to postgres failed").WithCause(err)
+		return nil, nil, "", err
 	}
+	return pools, replica, config.ConnConfig.Database, nil
+}
+
+// Applies configured options to a pool configuration
+func (c *PostgresConnection) applyPoolOptions(config *pgxpool.Config) {
+	options := c.options()
+	maxPoolSize := options.GetAsNullableInteger("max_pool_size")
+	minPoolSize := options.GetAsNullableInteger("min_pool_size")
+	idleTimeoutMS := options.GetAsNullableInteger("idle_timeout")
+	maxLifetimeMS := options.GetAsNullableInteger("max_lifetime")
+	healthCheckPeriodMS := options.GetAsNullableInteger("health_check_period")
+	connectTimeoutMS := options.GetAsNullableInteger("connect_timeout")
 
 	if connectTimeoutMS != nil && *connectTimeoutMS != 0 {
 		config.ConnConfig.ConnectTimeout = time.Duration((int64)(*connectTimeoutMS)) * time.Millisecond
 	}
+	if idleTimeoutMS != nil && *idleTimeoutMS != 0 {
+		config.MaxConnIdleTime = time.Duration((int64)(*idleTimeoutMS)) * time.Millisecond
+	}
+	if maxPoolSize != nil && *maxPoolSize != 0 {
+		config.MaxConns = (int32)(*maxPoolSize)
+	}
+	if minPoolSize != nil && *minPoolSize > 0 {
+		config.MinConns = (int32)(*minPoolSize)
+		if config.MinConns > config.MaxConns {
+			config.MinConns = config.MaxConns
+		}
+	}
+	if maxLifetimeMS != nil {
+		if *maxLifetimeMS > 0 {
+			config.MaxConnLifetime = time.Duration((int64)(*maxLifetimeMS)) * time.Millisecond
+		} else {
+			// The pool has no option to disable the lifetime, so it is made practically infinite
+			config.MaxConnLifetime = time.Duration(math.MaxInt64)
+		}
+	}
+	if healthCheckPeriodMS != nil && *healthCheckPeriodMS > 0 {
+		config.HealthCheckPeriod = time.Duration((int64)(*healthCheckPeriodMS)) * time.Millisecond
+	}
 
-	c.Logger.Debug(correlationId, "Connecting to postgres")
+	capacity := options.GetAsIntegerWithDefault("statement_cache_capacity", 512)
+	switch options.GetAsStringWithDefault("statement_cache_mode", StatementCacheModePrepare) {
+	case StatementCacheModeDisabled:
+		config.ConnConfig.BuildStatementCache = nil
+	case StatementCacheModeDescribe:
+		config.ConnConfig.BuildStatementCache = func(conn *pgconn.PgConn) stmtcache.Cache {
+			return stmtcache.New(conn, stmtcache.ModeDescribe, capacity)
+		}
+	default:
+		config.ConnConfig.BuildStatementCache = func(conn *pgconn.PgConn) stmtcache.Cache {
+			return stmtcache.New(conn, stmtcache.ModePrepare, capacity)
+		}
+	}
+
+	c.applySessionVariables(config)
 
+	// Sessions are attributed to the service in pg_stat_activity
+	if appName := options.GetAsString("application_name"); appName != "" {
+		config.ConnConfig.RuntimeParams["application_name"] = appName
+	} else if _, ok := config.ConnConfig.RuntimeParams["application_name"]; !ok && c.appName != "" {
+		config.ConnConfig.RuntimeParams["application_name"] = c.appName
+	}
+
+	// Sessions reject changes of data, e.g. for reporting deployments
+	if options.GetAsBooleanWithDefault("default_transaction_read_only", false) {
+		config.ConnConfig.RuntimeParams["default_transaction_read_only"] = "on"
+	}
+
+	statementTimeout := options.GetAsIntegerWithDefault("statement_timeout", 0)
+	statements := c.preparedStatements()
+	if statementTimeout > 0 || len(statements) > 0 {
+		config.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+			if statementTimeout > 0 {
+				query := "SET statement_timeout = " + strconv.Itoa(statementTimeout)
+				if _, err := conn.Exec(ctx, query); err != nil {
+					return err
+				}
+			}
+			for name, sql := range statements {
+				if _, err := conn.Prepare(ctx, name, sql); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+}
+
+// Registers a named statement that is prepared on every new connection of the pool.
+// The statement can be executed by its name instead of SQL text to skip parsing and planning.
+// Statements shall be registered before the connection is opened.
+//   - name      a name of the statement
+//   - sql       SQL text of the statement with positional parameters like $1
+func (c *PostgresConnection) PrepareStatement(name string, sql string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.statements[name] = sql
+}
+
+// Gets a copy of the registered statements, so pools use them without the lock
+func (c *PostgresConnection) preparedStatements() map[string]string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	statements := make(map[string]string, len(c.statements))
+	for name, sql := range c.statements {
+		statements[name] = sql
+	}
+	return statements
+}
+
+// Opens a pool to read replicas, if they are configured
+// Returns the pool or nil when there are no replicas
+func (c *PostgresConnection) openReplica(correlationId string) (*pgxpool.Pool, error) {
+	config, err := c.ConnectionResolver.ResolveReplicasConfig(correlationId)
+	if err != nil || config == nil {
+		return nil, err
+	}
+	c.applyPoolOptions(config)
+
+	c.Logger.Debug(correlationId, "Connecting to postgres read replicas")
 	pool, err := pgxpool.ConnectConfig(context.Background(), config)
+	if err != nil {
+		return nil, err
+	}
+	c.warmUp(correlationId, pool, config.MinConns)
+	return pool, nil
+}
 
-	if err != nil || pool == nil {
-		err = cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "Connection to postgres failed").WithCause(err)
-	} else {
-		if idleTimeoutMS != nil && *idleTimeoutMS != 0 {
-			pool.Config().MaxConnIdleTime = time.Duration((int64)(*idleTimeoutMS)) * time.Millisecond
+// Pre-establishes the minimum number of connections in a pool.
+// The pool maintains the minimum in background, so warm-up failures are only logged.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+//   - pool             a connection pool to warm up
+//   - size             a number of connections to establish
+func (c *PostgresConnection) warmUp(correlationId string, pool *pgxpool.Pool, size int32) {
+	if size <= 0 {
+		return
+	}
+
+	// Connections are held until all are acquired, otherwise the pool reuses the first one
+	conns := make(chan *pgxpool.Conn, size)
+	errs := make(chan error, size)
+	for index := int32(0); index < size; index++ {
+		go func() {
+			conn, err := pool.Acquire(context.Background())
+			if err != nil {
+				errs <- err
+				return
+			}
+			conns <- conn
+		}()
+	}
+
+	var err error
+	acquired := make([]*pgxpool.Conn, 0, size)
+	for index := int32(0); index < size; index++ {
+		select {
+		case conn := <-conns:
+			acquired = append(acquired, conn)
+		case err = <-errs:
+		}
+	}
+	for _, conn := range acquired {
+		conn.Release()
+	}
+
+	if err != nil {
+		c.Logger.Warn(correlationId, "Failed to warm up postgres connection pool: %s", err.Error())
+		return
+	}
+	c.Logger.Debug(correlationId, "Warmed up %d postgres connections", len(acquired))
+}
+
+// Calculates sizes of pools for configured workload classes.
+// Each class gets its percentage of the total size, but at least one connection.
+// The default class gets the remaining connections.
+func (c *PostgresConnection) calculatePoolSizes(maxPoolSize int32) map[string]int32 {
+	sizes := map[string]int32{}
+	remaining := maxPoolSize
+
+	c.lock.RLock()
+	poolShares := c.poolShares
+	c.lock.RUnlock()
+	for poolClass, share := range poolShares {
+		size := maxPoolSize * int32(share) / 100
+		if size < 1 {
+			size = 1
+		}
+		sizes[poolClass] = size
+		remaining -= size
+	}
+
+	if remaining < 1 {
+		remaining = 1
+	}
+	sizes[DefaultPoolClass] = remaining
+	return sizes
+}
+
+// Starts monitoring of the connection if options.auto_reconnect is enabled.
+// It is called with the lock held.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+func (c *PostgresConnection) startMonitor(correlationId string) {
+	if c.Options.GetAsBooleanWithDefault("auto_reconnect", true) {
+		c.stop = make(chan struct{})
+		go c.monitor(correlationId, c.stop)
+	}
+}
+
+// Stops monitoring of the connection
+func (c *PostgresConnection) stopMonitor() {
+	if c.stop != nil {
+		close(c.stop)
+		c.stop = nil
+	}
+}
+
+// Checks the pool periodically and recreates it when the database becomes unavailable,
+// e.g. after a server restart or a failover.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+//   - stop             a channel that is closed to stop monitoring
+func (c *PostgresConnection) monitor(correlationId string, stop chan struct{}) {
+	period := time.Duration(c.options().GetAsLongWithDefault("health_check_period", 60000)) * time.Millisecond
+	if period <= 0 {
+		period = time.Minute
+	}
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
 		}
-		if maxPoolSize != nil && *maxPoolSize != 0 {
-			pool.Config().MaxConns = (int32)(*maxPoolSize)
+
+		pool := c.GetConnection()
+		if pool == nil {
+			continue
+		}
+		if err := c.ping(pool, period); err != nil {
+			c.Logger.Error(correlationId, err, "Lost connection to postgres database %s", c.GetDatabaseName())
+			c.reconnect(correlationId, stop)
 		}
-		c.Connection = pool
-		c.DatabaseName = config.ConnConfig.Database
 	}
-	return err
+}
+
+// Checks that the database accepts connections. The check is made on a dedicated connection,
+// so a pool that is busy with long operations is not taken for a lost connection.
+//   - pool      a pool to check
+//   - timeout   a timeout of the check
+// Returns error if the database is not available.
+func (c *PostgresConnection) ping(pool *pgxpool.Pool, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	conn, err := pgx.ConnectConfig(ctx, pool.Config().ConnConfig)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+	return conn.Ping(ctx)
+}
+
+// Recreates connection pools with exponential backoff and jitter until it succeeds or monitoring is stopped
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+//   - stop             a channel that is closed to stop reconnecting
+func (c *PostgresConnection) reconnect(correlationId string, stop chan struct{}) {
+	options := c.options()
+	minDelay := time.Duration(options.GetAsLongWithDefault("reconnect_delay", 1000)) * time.Millisecond
+	maxDelay := time.Duration(options.GetAsLongWithDefault("max_reconnect_delay", 30000)) * time.Millisecond
+
+	for attempt := 0; ; attempt++ {
+		select {
+		case <-stop:
+			return
+		case <-time.After(reconnectDelay(minDelay, maxDelay, attempt)):
+		}
+
+		done, err := c.tryReconnect(correlationId, stop)
+		if err != nil {
+			c.Logger.Warn(correlationId, "Reconnect attempt %d to postgres failed: %s", attempt+1, err.Error())
+			continue
+		}
+		if done {
+			return
+		}
+	}
+}
+
+// Makes a reconnect attempt, serialized with reconfiguration of the connection
+// Returns true if reconnecting is complete or was stopped, or error of the attempt.
+func (c *PostgresConnection) tryReconnect(correlationId string, stop chan struct{}) (bool, error) {
+	c.reconnectLock.Lock()
+	defer c.reconnectLock.Unlock()
+
+	select {
+	case <-stop:
+		// Monitoring was stopped by Close or reconfiguration while waiting
+		return true, nil
+	default:
+	}
+
+	pools, replica, databaseName, err := c.connect(correlationId)
+	if err != nil {
+		return false, err
+	}
+
+	select {
+	case <-stop:
+		// The connection was closed while reconnecting
+		closePools(pools, replica)
+		return true, nil
+	default:
+	}
+	if c.replacePools(pools, replica, databaseName) {
+		c.Logger.Info(correlationId, "Reconnected to postgres database %s", databaseName)
+	}
+	return true, nil
+}
+
+// Replaces pools of the open connection and notifies listeners.
+// Previous pools are closed in background after operations in flight release their connections.
+// Returns false if the connection was closed meanwhile and the new pools were discarded.
+func (c *PostgresConnection) replacePools(pools map[string]*pgxpool.Pool, replica *pgxpool.Pool, databaseName string) bool {
+	c.lock.Lock()
+	if c.Connection == nil {
+		c.lock.Unlock()
+		closePools(pools, replica)
+		return false
+	}
+	oldPools, oldReplica := c.pools, c.replica
+	c.Connection = pools[DefaultPoolClass]
+	c.pools = pools
+	c.replica = replica
+	c.DatabaseName = databaseName
+	listeners := append([]func(){}, c.listeners...)
+	c.lock.Unlock()
+
+	for _, listener := range listeners {
+		listener()
+	}
+	go closePools(oldPools, oldReplica)
+	return true
+}
+
+// Calculates a delay before a reconnect attempt. The delay grows exponentially up to the maximum,
+// and is randomized by half to spread reconnects of multiple instances.
+func reconnectDelay(minDelay time.Duration, maxDelay time.Duration, attempt int) time.Duration {
+	if minDelay <= 0 {
+		minDelay = time.Second
+	}
+	if maxDelay < minDelay {
+		maxDelay = minDelay
+	}
+	delay := minDelay
+	for index := 0; index < attempt && delay < maxDelay; index++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// Closes connection pools
+func closePools(pools map[string]*pgxpool.Pool, replica *pgxpool.Pool) {
+	for _, pool := range pools {
+		pool.Close()
+	}
+	if replica != nil {
+		replica.Close()
+	}
+}
+
+// Adds a listener that is called after the connection pools were recreated by reconnect.
+// Components that keep references to the pools shall get them again, e.g. by GetConnection.
+//   - listener  a function to call
+func (c *PostgresConnection) AddReconnectListener(listener func()) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.listeners = append(c.listeners, listener)
 }
 
 // Closes component and frees used resources.
 //   - correlationId 	(optional) transaction id to trace execution through call chain.
 // Return			 error or nil no errors occured
 func (c *PostgresConnection) Close(correlationId string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
 	if c.Connection == nil {
 		return nil
 	}
-	c.Connection.Close()
+	c.stopMonitor()
+	closePools(c.pools, c.replica)
 	c.Logger.Debug(correlationId, "Disconnected from postgres database %s", c.DatabaseName)
 	c.Connection = nil
+	c.pools = make(map[string]*pgxpool.Pool)
+	c.replica = nil
 	c.DatabaseName = ""
 	return nil
 }
 
 func (c *PostgresConnection) GetConnection() *pgxpool.Pool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.Connection
+}
+
+// Gets the connection pool dedicated to a workload class.
+// If the class is not configured it returns the default pool.
+//   - poolClass     a workload class name, e.g. "batch"
+// Returns a connection pool
+func (c *PostgresConnection) GetConnectionByClass(poolClass string) *pgxpool.Pool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if pool, ok := c.pools[poolClass]; ok {
+		return pool
+	}
+	return c.Connection
+}
+
+// Gets the connection pool to read replicas.
+// Returns the replica pool or nil if replicas are not configured
+func (c *PostgresConnection) GetReplicaConnection() *pgxpool.Pool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.replica
+}
+
+// Gets the connection pool for reads: the replica pool if replicas are configured
+// or the default pool otherwise.
+// Returns a connection pool
+func (c *PostgresConnection) GetReadConnection() *pgxpool.Pool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.replica != nil {
+		return c.replica
+	}
 	return c.Connection
 }
 
+// Gets names of workload classes the pool is partitioned into.
+// Returns a list of pool classes including the default one
+func (c *PostgresConnection) GetPoolClasses() []string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	result := []string{DefaultPoolClass}
+	for poolClass := range c.poolShares {
+		result = append(result, poolClass)
+	}
+	return result
+}
+
 func (c *PostgresConnection) GetDatabaseName() string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
 	return c.DatabaseName
 }
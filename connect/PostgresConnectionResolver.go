@@ -1,7 +1,12 @@
 package connect
 
 import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgconn"
 	pgx4 "github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
 	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
 	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
 	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
@@ -10,6 +15,7 @@ import (
 	ccon "github.com/pip-services3-go/pip-services3-components-go/connect"
 	"net/url"
 	"strconv"
+	"strings"
 )
 
 /*
@@ -25,16 +31,50 @@ Configuration parameters
    - port:                        port number (default: 27017)
    - database:                    database name
    - uri:                         resource URI or connection string with all parameters in it
+   - role:                        (optional) role of the host: primary or replica (default: primary)
+   - target_session_attrs:        (optional) required session type: read-write, read-only or any (default: any)
+   - sslmode:                     (optional) disable, allow, prefer, require, verify-ca or verify-full (default: prefer)
+   - sslrootcert:                 (optional) path to the root certificate to verify the server
+   - sslcert:                     (optional) path to the client certificate
+   - sslkey:                      (optional) path to the client private key
 - credential(s):
    - store_key:                   (optional) a key to retrieve the credentials from ICredentialStore
    - username:                    user name
    - password:                    user password
+   - sslmode, sslrootcert, sslcert, sslkey: (optional) TLS parameters that override ones of connections
 
  References
 
 - *:discovery:*:*:1.0             (optional) IDiscovery services
 - *:credential-store:*:*:1.0      (optional) Credential stores to resolve credentials
 */
+// Roles of connections to Postgres cluster nodes
+const (
+	// A primary node that accepts reads and writes
+	ConnectionRolePrimary = "primary"
+	// A read replica that accepts only reads
+	ConnectionRoleReplica = "replica"
+)
+
+// Types of sessions required from hosts, when multiple hosts are configured.
+// Hosts that cannot open such session are skipped while connecting.
+const (
+	// Any host is accepted
+	TargetSessionAttrsAny = "any"
+	// Only hosts that accept writes, i.e. the current primary
+	TargetSessionAttrsReadWrite = "read-write"
+	// Only hosts in read-only mode, i.e. standby servers
+	TargetSessionAttrsReadOnly = "read-only"
+)
+
+// TLS parameters of connections, that can be set in connections or credentials
+var sslParameters = []string{"sslmode", "sslrootcert", "sslcert", "sslkey"}
+
+// Supported values of sslmode
+var sslModes = map[string]bool{
+	"disable": true, "allow": true, "prefer": true, "require": true, "verify-ca": true, "verify-full": true,
+}
+
 type PostgresConnectionResolver struct {
 	//The connections resolver.
 	ConnectionResolver ccon.ConnectionResolver
@@ -70,6 +110,10 @@ func (c *PostgresConnectionResolver) SetReferences(references crefer.IReferences
 }
 
 func (c *PostgresConnectionResolver) validateConnection(correlationId string, connection *ccon.ConnectionParams) error {
+	if sslMode := connection.GetAsString("sslmode"); sslMode != "" && !sslModes[sslMode] {
+		return cerr.NewConfigError(correlationId, "INVALID_SSLMODE", "Connection sslmode "+sslMode+" is not supported")
+	}
+
 	uri := connection.Uri()
 	if uri != "" {
 		return nil
@@ -87,6 +131,12 @@ func (c *PostgresConnectionResolver) validateConnection(correlationId string, co
 	if *database == "" {
 		return cerr.NewConfigError(correlationId, "NO_DATABASE", "Connection database is not set")
 	}
+	switch attrs := connection.GetAsString("target_session_attrs"); attrs {
+	case "", TargetSessionAttrsAny, TargetSessionAttrsReadWrite, TargetSessionAttrsReadOnly:
+	default:
+		return cerr.NewConfigError(correlationId, "INVALID_TARGET_SESSION_ATTRS",
+			"Connection target_session_attrs "+attrs+" is not supported")
+	}
 	return nil
 }
 
@@ -106,35 +156,14 @@ func (c *PostgresConnectionResolver) validateConnections(correlationId string, c
 
 func (c *PostgresConnectionResolver) composeConfig(connections []*ccon.ConnectionParams, credential *auth.CredentialParams) (*pgx4.ConnConfig, error) {
 
-	config := &pgx4.ConnConfig{}
-	var e error
-
-	// Define connection part
-	for _, connection := range connections {
-		uri := connection.Uri()
-		if uri != "" {
-			cfg, err := pgx4.ParseConfig(uri)
-			if err == nil {
-				config = cfg
-			} else {
-				e = err
-			}
-		}
-
-		host := connection.Host()
-		if host != "" {
-			config.Host = host
-		}
-
-		port := connection.Port()
-		if port != 0 {
-			config.Port = uint16(port)
-		}
+	config, err := pgx4.ParseConfig(c.composeUri(connections, credential))
+	if err != nil {
+		return nil, err
+	}
 
-		database := connection.GetAsNullableString("database")
-		if database != nil && *database != "" {
-			config.Database = *database
-		}
+	// Other connections become fallback hosts instead of overriding the first one
+	if err = c.composeFallbacks(&config.Config, connections); err != nil {
+		return nil, err
 	}
 
 	// Define authentication part
@@ -150,16 +179,102 @@ func (c *PostgresConnectionResolver) composeConfig(connections []*ccon.Connectio
 		}
 	}
 
-	return config, e
+	return config, nil
+
+}
+
+// Adds hosts of all connections to fallbacks, that are tried in order when connecting to a host fails.
+// Hosts of connections given by URIs are not included into the composed URI, so they are added here.
+func (c *PostgresConnectionResolver) composeFallbacks(config *pgconn.Config, connections []*ccon.ConnectionParams) error {
+	known := map[string]bool{fallbackKey(config.Host, config.Port, config.TLSConfig != nil): true}
+	for _, fallback := range config.Fallbacks {
+		known[fallbackKey(fallback.Host, fallback.Port, fallback.TLSConfig != nil)] = true
+	}
+
+	for _, connection := range connections {
+		hosts := make([]*pgconn.FallbackConfig, 0)
+		if uri := connection.Uri(); uri != "" {
+			cfg, err := pgconn.ParseConfig(uri)
+			if err != nil {
+				return err
+			}
+			hosts = append(hosts, &pgconn.FallbackConfig{Host: cfg.Host, Port: cfg.Port, TLSConfig: cfg.TLSConfig})
+			hosts = append(hosts, cfg.Fallbacks...)
+		} else if connection.Host() != "" {
+			hosts = append(hosts, &pgconn.FallbackConfig{
+				Host:      connection.Host(),
+				Port:      uint16(connection.Port()),
+				TLSConfig: config.TLSConfig,
+			})
+		}
+
+		for _, host := range hosts {
+			key := fallbackKey(host.Host, host.Port, host.TLSConfig != nil)
+			if !known[key] {
+				known[key] = true
+				config.Fallbacks = append(config.Fallbacks, host)
+			}
+		}
+	}
+	return nil
+}
+
+func fallbackKey(host string, port uint16, tls bool) string {
+	return host + ":" + strconv.Itoa(int(port)) + ":" + strconv.FormatBool(tls)
+}
+
+func (c *PostgresConnectionResolver) validateCredential(correlationId string, credential *auth.CredentialParams) error {
+	if credential == nil {
+		return nil
+	}
+	if sslMode := credential.GetAsString("sslmode"); sslMode != "" && !sslModes[sslMode] {
+		return cerr.NewConfigError(correlationId, "INVALID_SSLMODE", "Credential sslmode "+sslMode+" is not supported")
+	}
+	return nil
+}
+
+// Adds TLS parameters of a connection and credential into a connection URI.
+// Parameters of the credential take precedence, parameters set in the URI are kept otherwise.
+func (c *PostgresConnectionResolver) composeSslParameters(uri string, connection *ccon.ConnectionParams,
+	credential *auth.CredentialParams) string {
 
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		// Leave key=value connection strings and invalid URIs to the driver
+		return uri
+	}
+
+	query := parsed.Query()
+	changed := false
+	for _, name := range sslParameters {
+		value := ""
+		if credential != nil {
+			value = credential.GetAsString(name)
+		}
+		if value == "" && query.Get(name) != "" {
+			continue
+		}
+		if value == "" {
+			value = connection.GetAsString(name)
+		}
+		if value != "" {
+			query.Set(name, value)
+			changed = true
+		}
+	}
+	if !changed {
+		return uri
+	}
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
 }
 
 func (c *PostgresConnectionResolver) composeUri(connections []*ccon.ConnectionParams, credential *auth.CredentialParams) string {
-	// If there is a uri then return it immediately
+	// If there is a uri then return it with TLS parameters from configuration
 	for _, connection := range connections {
 		uri := connection.Uri()
 		if uri != "" {
-			return uri
+			return c.composeSslParameters(uri, connection, credential)
 		}
 	}
 
@@ -169,11 +284,15 @@ func (c *PostgresConnectionResolver) composeUri(connections []*ccon.ConnectionPa
 		host := connection.Host()
 		port := connection.Port()
 
+		if host == "" {
+			continue
+		}
 		if len(hosts) > 0 {
 			hosts += ","
 		}
+		hosts += host
 		if port != 0 {
-			hosts += host + ":" + strconv.Itoa(port)
+			hosts += ":" + strconv.Itoa(port)
 		}
 	}
 
@@ -218,6 +337,9 @@ func (c *PostgresConnectionResolver) composeUri(connections []*ccon.ConnectionPa
 	options.Remove("database")
 	options.Remove("username")
 	options.Remove("password")
+	options.Remove("role")
+	// Applied by validation of connections in the pool config to support all values by the driver
+	options.Remove("target_session_attrs")
 	params := ""
 	keys := options.Keys()
 	for _, key := range keys {
@@ -255,6 +377,7 @@ func (c *PostgresConnectionResolver) Resolve(correlationId string) (uri string,
 	if err != nil {
 		return "", err
 	}
+	connections = filterConnectionsByRole(connections, ConnectionRolePrimary)
 	err = c.validateConnections(correlationId, connections)
 	if err != nil {
 		return "", err
@@ -263,5 +386,147 @@ func (c *PostgresConnectionResolver) Resolve(correlationId string) (uri string,
 	if err != nil {
 		return "", err
 	}
+	if err = c.validateCredential(correlationId, credential); err != nil {
+		return "", err
+	}
 	return c.composeUri(connections, credential), nil
 }
+
+// ResolveConfig resolves pgx pool configuration from connection and credential parameters.
+// Multiple hosts are tried in order and hosts that do not match target_session_attrs are skipped.
+// Parameters:
+//   - correlationId  string
+//   (optional) transaction id to trace execution through call chain.
+// Returns config *pgxpool.Config, err error
+// resolved configuration and error, if this occured.
+func (c *PostgresConnectionResolver) ResolveConfig(correlationId string) (config *pgxpool.Config, err error) {
+	connections, err := c.ConnectionResolver.ResolveAll(correlationId)
+	if err != nil {
+		return nil, err
+	}
+	return c.resolveConfigByRole(correlationId, connections, ConnectionRolePrimary)
+}
+
+// ResolveReplicasConfig resolves pgx pool configuration of read replicas.
+// Parameters:
+//   - correlationId  string
+//   (optional) transaction id to trace execution through call chain.
+// Returns config *pgxpool.Config, err error
+// resolved configuration or nil when replicas are not configured, and error, if this occured.
+func (c *PostgresConnectionResolver) ResolveReplicasConfig(correlationId string) (config *pgxpool.Config, err error) {
+	connections, err := c.ConnectionResolver.ResolveAll(correlationId)
+	if err != nil {
+		return nil, err
+	}
+	if len(filterConnectionsByRole(connections, ConnectionRoleReplica)) == 0 {
+		return nil, nil
+	}
+	return c.resolveConfigByRole(correlationId, connections, ConnectionRoleReplica)
+}
+
+func (c *PostgresConnectionResolver) resolveConfigByRole(correlationId string, connections []*ccon.ConnectionParams,
+	role string) (*pgxpool.Config, error) {
+
+	connections = filterConnectionsByRole(connections, role)
+	err := c.validateConnections(correlationId, connections)
+	if err != nil {
+		return nil, err
+	}
+	credential, err := c.CredentialResolver.Lookup(correlationId)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.validateCredential(correlationId, credential); err != nil {
+		return nil, err
+	}
+
+	config, err := pgxpool.ParseConfig(c.composeUri(connections, credential))
+	if err == nil {
+		err = c.composeFallbacks(&config.ConnConfig.Config, connections)
+	}
+	if err != nil {
+		return nil, cerr.NewConfigError(correlationId, "INVALID_CONNECTION", "Postgres connection is invalid").
+			WithCause(err)
+	}
+
+	for _, connection := range connections {
+		switch connection.GetAsString("target_session_attrs") {
+		case TargetSessionAttrsReadWrite:
+			config.ConnConfig.ValidateConnect = validateReadWriteSession
+		case TargetSessionAttrsReadOnly:
+			config.ConnConfig.ValidateConnect = validateReadOnlySession
+		}
+	}
+	return config, nil
+}
+
+// Accepts only hosts that allow writes, like libpq target_session_attrs=read-write
+func validateReadWriteSession(ctx context.Context, conn *pgconn.PgConn) error {
+	readOnly, err := isReadOnlySession(ctx, conn)
+	if err == nil && readOnly {
+		err = errors.New("read only connection")
+	}
+	return err
+}
+
+// Accepts only hosts in read only mode, like libpq target_session_attrs=read-only
+func validateReadOnlySession(ctx context.Context, conn *pgconn.PgConn) error {
+	readOnly, err := isReadOnlySession(ctx, conn)
+	if err == nil && !readOnly {
+		err = errors.New("connection is not read only")
+	}
+	return err
+}
+
+func isReadOnlySession(ctx context.Context, conn *pgconn.PgConn) (bool, error) {
+	result := conn.ExecParams(ctx, "show transaction_read_only", nil, nil, nil, nil).Read()
+	if result.Err != nil {
+		return false, result.Err
+	}
+	return len(result.Rows) > 0 && string(result.Rows[0][0]) == "on", nil
+}
+
+// ResolveReplicas resolves Postgres connection URI of read replicas,
+// that are connections with "replica" role.
+// Parameters:
+//   - correlationId  string
+//   (optional) transaction id to trace execution through call chain.
+// Returns uri string, err error
+// resolved URI or empty string when replicas are not configured, and error, if this occured.
+func (c *PostgresConnectionResolver) ResolveReplicas(correlationId string) (uri string, err error) {
+	connections, err := c.ConnectionResolver.ResolveAll(correlationId)
+	if err != nil {
+		return "", err
+	}
+	connections = filterConnectionsByRole(connections, ConnectionRoleReplica)
+	if len(connections) == 0 {
+		return "", nil
+	}
+	err = c.validateConnections(correlationId, connections)
+	if err != nil {
+		return "", err
+	}
+	credential, err := c.CredentialResolver.Lookup(correlationId)
+	if err != nil {
+		return "", err
+	}
+	if err = c.validateCredential(correlationId, credential); err != nil {
+		return "", err
+	}
+	return c.composeUri(connections, credential), nil
+}
+
+// Selects connections with a role. Connections without role are primary
+func filterConnectionsByRole(connections []*ccon.ConnectionParams, role string) []*ccon.ConnectionParams {
+	result := make([]*ccon.ConnectionParams, 0, len(connections))
+	for _, connection := range connections {
+		connectionRole := strings.ToLower(connection.GetAsString("role"))
+		if connectionRole == "" {
+			connectionRole = ConnectionRolePrimary
+		}
+		if connectionRole == role {
+			result = append(result, connection)
+		}
+	}
+	return result
+}
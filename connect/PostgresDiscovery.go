@@ -0,0 +1,418 @@
+package connect
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	ccon "github.com/pip-services3-go/pip-services3-components-go/connect"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+)
+
+/*
+Discovery service that keeps service registrations in a shared PostgreSQL table.
+Registrations expire when they are not refreshed, so the component periodically
+sends heartbeats for connections it registered and removes them on close.
+
+### Configuration parameters ###
+
+- table:                       (optional) PostgreSQL table name (default: "discovery")
+- schema:                      (optional) PostgreSQL schema, default "public"
+- connection(s):
+   - host:                      host name or IP address
+   - port:                      port number (default: 5432)
+   - uri:                       resource URI or connection string with all parameters in it
+- credential(s):
+   - store_key:                 (optional) a key to retrieve the credentials from ICredentialStore
+   - username:                  (optional) user name
+   - password:                  (optional) user password
+- options:
+   - ttl:                  (optional) registration time to live in milliseconds (default: 30000)
+   - heartbeat_interval:   (optional) interval in milliseconds to refresh own registrations (default: 10000)
+   - operation_timeout:    (optional) number of milliseconds after which a statement is cancelled, 0 to disable (default: 10000)
+
+### References ###
+
+- \*:logger:\*:\*:1.0           (optional) ILogger components to pass log messages
+- \*:connection:postgres:\*:1.0 (optional) Shared PostgreSQL connection
+- \*:credential-store:\*:\*:1.0 (optional) Credential stores to resolve credentials
+
+### Example ###
+
+    discovery := NewPostgresDiscovery()
+    discovery.Configure(cconf.NewConfigParamsFromTuples(
+        "connection.host", "localhost",
+        "connection.port", 5432,
+        "connection.database", "test",
+    ))
+    err := discovery.Open("123")
+    ...
+    discovery.Register("123", "service1", ccon.NewConnectionParamsFromTuples(
+        "host", "10.1.1.100",
+        "port", 8080,
+    ))
+    connection, err := discovery.ResolveOne("123", "service1")
+    // Result: host=10.1.1.100;port=8080
+*/
+type PostgresDiscovery struct {
+	defaultConfig *cconf.ConfigParams
+
+	config            *cconf.ConfigParams
+	references        cref.IReferences
+	localConnection   bool
+	ttl               int64
+	heartbeatInterval int64
+	operationTimeout  int64
+	heartbeatStop     chan bool
+	registrations     map[discoveryRegistration]bool
+	lock              sync.Mutex
+
+	//The dependency resolver.
+	DependencyResolver *cref.DependencyResolver
+	//The logger.
+	Logger *clog.CompositeLogger
+	//The PostgreSQL connection component.
+	Connection *PostgresConnection
+	//The PostgreSQL database schema name. If not set use "public" by default
+	SchemaName string
+	//The PostgreSQL table name.
+	TableName string
+}
+
+// Connection registered by this discovery instance
+type discoveryRegistration struct {
+	key        string
+	connection string
+}
+
+// Creates a new instance of the discovery component.
+func NewPostgresDiscovery() *PostgresDiscovery {
+	c := &PostgresDiscovery{
+		defaultConfig: cconf.NewConfigParamsFromTuples(
+			"dependencies.connection", "*:connection:postgres:*:1.0",
+			"options.ttl", 30000,
+			"options.heartbeat_interval", 10000,
+			"options.operation_timeout", 10000,
+		),
+		Logger:            clog.NewCompositeLogger(),
+		TableName:         "discovery",
+		ttl:               30000,
+		heartbeatInterval: 10000,
+		operationTimeout:  10000,
+		registrations:     make(map[discoveryRegistration]bool),
+	}
+	c.DependencyResolver = cref.NewDependencyResolver()
+	c.DependencyResolver.Configure(c.defaultConfig)
+	return c
+}
+
+// Configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *PostgresDiscovery) Configure(config *cconf.ConfigParams) {
+	config = config.SetDefaults(c.defaultConfig)
+	c.config = config
+
+	c.DependencyResolver.Configure(config)
+
+	c.TableName = config.GetAsStringWithDefault("table", c.TableName)
+	c.SchemaName = config.GetAsStringWithDefault("schema", c.SchemaName)
+	c.ttl = config.GetAsLongWithDefault("options.ttl", c.ttl)
+	c.heartbeatInterval = config.GetAsLongWithDefault("options.heartbeat_interval", c.heartbeatInterval)
+	c.operationTimeout = config.GetAsLongWithDefault("options.operation_timeout", c.operationTimeout)
+}
+
+// Sets references to dependent components.
+//   - references 	references to locate the component dependencies.
+func (c *PostgresDiscovery) SetReferences(references cref.IReferences) {
+	c.references = references
+	c.Logger.SetReferences(references)
+
+	// Get connection
+	c.DependencyResolver.SetReferences(references)
+	result := c.DependencyResolver.GetOneOptional("connection")
+	if dep, ok := result.(*PostgresConnection); ok {
+		c.Connection = dep
+	}
+	// Or create a local one
+	if c.Connection == nil {
+		c.Connection = c.createConnection()
+		c.localConnection = true
+	} else {
+		c.localConnection = false
+	}
+}
+
+// Unsets (clears) previously set references to dependent components.
+func (c *PostgresDiscovery) UnsetReferences() {
+	c.Connection = nil
+}
+
+func (c *PostgresDiscovery) createConnection() *PostgresConnection {
+	connection := NewPostgresConnection()
+	if c.config != nil {
+		connection.Configure(c.config)
+	}
+	if c.references != nil {
+		connection.SetReferences(c.references)
+	}
+	return connection
+}
+
+// Checks if the component is opened.
+// Returns true if the component has been opened and false otherwise.
+func (c *PostgresDiscovery) IsOpen() bool {
+	return c.heartbeatStop != nil
+}
+
+func (c *PostgresDiscovery) quotedTableName() string {
+	table := quoteIdentifier(c.TableName)
+	if c.SchemaName != "" {
+		table = quoteIdentifier(c.SchemaName) + "." + table
+	}
+	return table
+}
+
+// Creates a context of a statement limited by options.operation_timeout
+func (c *PostgresDiscovery) operationContext() (context.Context, context.CancelFunc) {
+	if c.operationTimeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), time.Duration(c.operationTimeout)*time.Millisecond)
+}
+
+// Quotes an identifier and escapes quotes inside it
+func quoteIdentifier(value string) string {
+	return "\"" + strings.ReplaceAll(value, "\"", "\"\"") + "\""
+}
+
+// Opens the component, creates the registrations table and starts heartbeats.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+//   - Returns 			 error or nil no errors occured.
+func (c *PostgresDiscovery) Open(correlationId string) error {
+	if c.IsOpen() {
+		return nil
+	}
+
+	if c.Connection == nil {
+		c.Connection = c.createConnection()
+		c.localConnection = true
+	}
+
+	if c.localConnection {
+		err := c.Connection.Open(correlationId)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !c.Connection.IsOpen() {
+		return cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "PostgreSQL connection is not opened")
+	}
+
+	query := "CREATE TABLE IF NOT EXISTS " + c.quotedTableName() +
+		" (\"key\" TEXT NOT NULL, \"connection\" TEXT NOT NULL, \"expiration\" TIMESTAMPTZ NOT NULL," +
+		" PRIMARY KEY (\"key\", \"connection\"))"
+	if c.SchemaName != "" {
+		query = "CREATE SCHEMA IF NOT EXISTS " + quoteIdentifier(c.SchemaName) + "; " + query
+	}
+	ctx, cancel := c.operationContext()
+	_, err := c.Connection.GetConnection().Exec(ctx, query)
+	cancel()
+	if err != nil {
+		if c.localConnection {
+			c.Connection.Close(correlationId)
+		}
+		return cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "Failed to create discovery table").WithCause(err)
+	}
+
+	c.heartbeatStop = make(chan bool)
+	if c.heartbeatInterval > 0 {
+		go c.heartbeat(correlationId, c.heartbeatStop)
+	}
+	return nil
+}
+
+// Closes component, removes own registrations and frees used resources.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+//   - Returns 			error or nil no errors occured.
+func (c *PostgresDiscovery) Close(correlationId string) error {
+	if !c.IsOpen() {
+		return nil
+	}
+
+	close(c.heartbeatStop)
+	c.heartbeatStop = nil
+
+	c.lock.Lock()
+	registrations := c.registrations
+	c.registrations = make(map[discoveryRegistration]bool)
+	c.lock.Unlock()
+
+	query := "DELETE FROM " + c.quotedTableName() + " WHERE \"key\"=$1 AND \"connection\"=$2"
+	for registration := range registrations {
+		ctx, cancel := c.operationContext()
+		_, err := c.Connection.GetConnection().Exec(ctx, query, registration.key, registration.connection)
+		cancel()
+		if err != nil {
+			c.Logger.Error(correlationId, err, "Failed to unregister %s", registration.key)
+		}
+	}
+
+	if c.localConnection {
+		return c.Connection.Close(correlationId)
+	}
+	return nil
+}
+
+func (c *PostgresDiscovery) heartbeat(correlationId string, stop chan bool) {
+	ticker := time.NewTicker(time.Duration(c.heartbeatInterval) * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			c.lock.Lock()
+			registrations := make([]discoveryRegistration, 0, len(c.registrations))
+			for registration := range c.registrations {
+				registrations = append(registrations, registration)
+			}
+			c.lock.Unlock()
+
+			for _, registration := range registrations {
+				err := c.save(registration.key, registration.connection)
+				if err != nil {
+					c.Logger.Error(correlationId, err, "Failed to refresh registration %s", registration.key)
+				}
+			}
+		}
+	}
+}
+
+func (c *PostgresDiscovery) save(key string, connection string) error {
+	query := "INSERT INTO " + c.quotedTableName() + " (\"key\", \"connection\", \"expiration\")" +
+		" VALUES ($1, $2, now() + $3::INTERVAL)" +
+		" ON CONFLICT (\"key\", \"connection\") DO UPDATE SET \"expiration\"=EXCLUDED.\"expiration\""
+	ttl := strconv.FormatInt(c.ttl, 10) + " milliseconds"
+	ctx, cancel := c.operationContext()
+	defer cancel()
+	_, err := c.Connection.GetConnection().Exec(ctx, query, key, connection, ttl)
+	return err
+}
+
+func (c *PostgresDiscovery) checkOpen(correlationId string) error {
+	if !c.IsOpen() {
+		return cerr.NewInvalidStateError(correlationId, "NOT_OPENED", "The discovery is not opened")
+	}
+	return nil
+}
+
+// Registers connection parameters into the discovery service.
+// The registration is kept alive by heartbeats until the component is closed.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - key               a key to uniquely identify the connection parameters.
+//   - connection        a connection to be registered.
+// Returns the registered connection parameters or error.
+func (c *PostgresDiscovery) Register(correlationId string, key string,
+	connection *ccon.ConnectionParams) (result *ccon.ConnectionParams, err error) {
+
+	if err := c.checkOpen(correlationId); err != nil {
+		return nil, err
+	}
+	if connection == nil {
+		return nil, nil
+	}
+
+	value := connection.String()
+	err = c.save(key, value)
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	c.registrations[discoveryRegistration{key: key, connection: value}] = true
+	c.lock.Unlock()
+
+	c.Logger.Trace(correlationId, "Registered %s in %s", key, c.TableName)
+	return connection, nil
+}
+
+// Removes connection parameters registered with Register.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - key               a key to uniquely identify the connection parameters.
+//   - connection        a connection to be removed.
+// Returns error or nil for success.
+func (c *PostgresDiscovery) Unregister(correlationId string, key string, connection *ccon.ConnectionParams) error {
+	if err := c.checkOpen(correlationId); err != nil {
+		return err
+	}
+	if connection == nil {
+		return nil
+	}
+
+	value := connection.String()
+	c.lock.Lock()
+	delete(c.registrations, discoveryRegistration{key: key, connection: value})
+	c.lock.Unlock()
+
+	query := "DELETE FROM " + c.quotedTableName() + " WHERE \"key\"=$1 AND \"connection\"=$2"
+	ctx, cancel := c.operationContext()
+	defer cancel()
+	_, err := c.Connection.GetConnection().Exec(ctx, query, key, value)
+	if err != nil {
+		return err
+	}
+
+	c.Logger.Trace(correlationId, "Unregistered %s from %s", key, c.TableName)
+	return nil
+}
+
+// Resolves a single connection parameters by its key.
+// Expired registrations are ignored.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - key               a key to uniquely identify the connection.
+// Returns a resolved connection or nil if nothing was found.
+func (c *PostgresDiscovery) ResolveOne(correlationId string, key string) (result *ccon.ConnectionParams, err error) {
+	connections, err := c.ResolveAll(correlationId, key)
+	if err != nil || len(connections) == 0 {
+		return nil, err
+	}
+	return connections[0], nil
+}
+
+// Resolves all connection parameters by their key.
+// Expired registrations are ignored.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - key               a key to uniquely identify the connections.
+// Returns a list with resolved connections or error.
+func (c *PostgresDiscovery) ResolveAll(correlationId string, key string) (result []*ccon.ConnectionParams, err error) {
+	if err := c.checkOpen(correlationId); err != nil {
+		return nil, err
+	}
+
+	query := "SELECT \"connection\" FROM " + c.quotedTableName() +
+		" WHERE \"key\"=$1 AND \"expiration\">now() ORDER BY \"expiration\" DESC"
+	ctx, cancel := c.operationContext()
+	defer cancel()
+	rows, err := c.Connection.GetConnection().Query(ctx, query, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result = make([]*ccon.ConnectionParams, 0)
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		result = append(result, ccon.NewConnectionParamsFromString(value))
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,162 @@
+package connect
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type sessionVariablesKey struct{}
+type searchPathKey struct{}
+
+// Names of custom session variables must be qualified by a prefix, e.g. app.tenant_id
+var sessionVariableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)+$`)
+
+// Creates a context with session variables that are set on a connection
+// for operations executed within the context, e.g. app.tenant_id or app.user_id.
+// The variables can be read by row-level security policies and triggers with current_setting()
+// and are cleared when the connection is returned to the pool.
+// To use it with persistence components pass the context to WithContext:
+//     persistence.WithContext(connect.WithSessionVariables(ctx, map[string]string{"app.tenant_id": "1"}))
+//   - ctx           a parent context
+//   - variables     names and values of variables. Names must be qualified like app.tenant_id
+// Returns a context with the variables added to variables of the parent context.
+func WithSessionVariables(ctx context.Context, variables map[string]string) context.Context {
+	merged := make(map[string]string)
+	for name, value := range GetSessionVariables(ctx) {
+		merged[name] = value
+	}
+	for name, value := range variables {
+		merged[name] = value
+	}
+	return context.WithValue(ctx, sessionVariablesKey{}, merged)
+}
+
+// Gets session variables of a context
+//   - ctx           a context
+// Returns variables set by WithSessionVariables or nil.
+func GetSessionVariables(ctx context.Context) map[string]string {
+	if ctx == nil {
+		return nil
+	}
+	variables, _ := ctx.Value(sessionVariablesKey{}).(map[string]string)
+	return variables
+}
+
+// Creates a context with a schema search path that is set on a connection
+// for operations executed within the context, so unqualified names refer to objects in the schemas.
+// The search path is reset when the connection is returned to the pool.
+//   - ctx           a parent context
+//   - searchPath    a list of schemas like "tenant_1", public
+// Returns a context with the search path.
+func WithSearchPath(ctx context.Context, searchPath string) context.Context {
+	return context.WithValue(ctx, searchPathKey{}, searchPath)
+}
+
+// Gets a schema search path of a context
+//   - ctx           a context
+// Returns the search path set by WithSearchPath or empty string.
+func GetSearchPath(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	searchPath, _ := ctx.Value(searchPathKey{}).(string)
+	return searchPath
+}
+
+// Tracks session variables set on pooled connections to clear them on release
+type sessionVariablesTracker struct {
+	lock  sync.Mutex
+	names map[*pgx.Conn][]string
+}
+
+// The name of the search path setting, it is reset instead of clearing
+const searchPathSetting = "search_path"
+
+// Sets hooks that apply session variables of contexts to acquired connections
+func (c *PostgresConnection) applySessionVariables(config *pgxpool.Config) {
+	tracker := &sessionVariablesTracker{names: make(map[*pgx.Conn][]string)}
+
+	config.BeforeAcquire = func(ctx context.Context, conn *pgx.Conn) bool {
+		variables := GetSessionVariables(ctx)
+		searchPath := GetSearchPath(ctx)
+		if len(variables) == 0 && searchPath == "" {
+			return true
+		}
+
+		names := make([]string, 0, len(variables))
+		for name := range variables {
+			if !sessionVariableName.MatchString(name) {
+				c.Logger.Warn("", "Session variable %s is skipped, names must be qualified like app.name", name)
+				continue
+			}
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		values := make([]string, len(names))
+		for index, name := range names {
+			values[index] = variables[name]
+		}
+		if searchPath != "" {
+			names = append(names, searchPathSetting)
+			values = append(values, searchPath)
+		}
+		if len(names) == 0 {
+			return true
+		}
+
+		calls := make([]string, len(names))
+		args := make([]interface{}, 0, 2*len(names))
+		for index, name := range names {
+			calls[index] = "set_config($" + strconv.Itoa(2*index+1) + ",$" + strconv.Itoa(2*index+2) + ",false)"
+			args = append(args, name, values[index])
+		}
+		if _, err := conn.Exec(ctx, "SELECT "+strings.Join(calls, ","), args...); err != nil {
+			// The broken connection is destroyed and another one is acquired
+			return false
+		}
+
+		tracker.lock.Lock()
+		tracker.names[conn] = names
+		tracker.lock.Unlock()
+		return true
+	}
+
+	config.AfterRelease = func(conn *pgx.Conn) bool {
+		tracker.lock.Lock()
+		names, ok := tracker.names[conn]
+		delete(tracker.names, conn)
+		// Connections destroyed by the pool are never released
+		for other := range tracker.names {
+			if other.IsClosed() {
+				delete(tracker.names, other)
+			}
+		}
+		tracker.lock.Unlock()
+
+		if !ok {
+			return true
+		}
+		calls := make([]string, 0, len(names))
+		args := make([]interface{}, 0, len(names))
+		from := ""
+		for _, name := range names {
+			if name == searchPathSetting {
+				// The search path is restored to the session default
+				calls = append(calls, "set_config(name,reset_val,false)")
+				from = " FROM pg_settings WHERE name='search_path'"
+				continue
+			}
+			args = append(args, name)
+			calls = append(calls, "set_config($"+strconv.Itoa(len(args))+",'',false)")
+		}
+		_, err := conn.Exec(context.Background(), "SELECT "+strings.Join(calls, ",")+from, args...)
+		return err == nil
+	}
+}
@@ -0,0 +1,174 @@
+package count
+
+import (
+	"reflect"
+	"strconv"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	ccount "github.com/pip-services3-go/pip-services3-components-go/count"
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+)
+
+/*
+Performance counters that periodically dump accumulated measurements into a PostgreSQL table.
+Each dump appends one row per counter, so the table keeps the history of measurements
+that can be queried and aggregated with SQL.
+
+### Configuration parameters ###
+
+- table:                       (optional) PostgreSQL table name (default: "counters")
+- schema:                      (optional) PostgreSQL schema, default "public"
+- connection(s):
+   - discovery_key:             (optional) a key to retrieve the connection from IDiscovery
+   - host:                      host name or IP address
+   - port:                      port number (default: 5432)
+   - uri:                       resource URI or connection string with all parameters in it
+- credential(s):
+   - store_key:                 (optional) a key to retrieve the credentials from ICredentialStore
+   - username:                  (optional) user name
+   - password:                  (optional) user password
+- options:
+   - interval:             (optional) interval in milliseconds to save current counters measurements (default: 5 mins)
+   - reset_timeout:        (optional) timeout in milliseconds to reset the counters. 0 disables the reset (default: 0)
+
+### References ###
+
+- \*:logger:\*:\*:1.0           (optional) ILogger components to pass log messages
+- \*:connection:postgres:\*:1.0 (optional) Shared PostgreSQL connection
+- \*:discovery:\*:\*:1.0        (optional) IDiscovery services
+- \*:credential-store:\*:\*:1.0 (optional) Credential stores to resolve credentials
+
+### Example ###
+
+    counters := NewPostgresCounters()
+    counters.Configure(cconf.NewConfigParamsFromTuples(
+        "connection.host", "localhost",
+        "connection.port", 5432,
+        "connection.database", "test",
+    ))
+    err := counters.Open("123")
+    ...
+    counters.Increment("mycomponent.mymethod.calls", 1)
+    timing := counters.BeginTiming("mycomponent.mymethod.exec_time")
+    defer timing.EndTiming()
+    ...
+    counters.Dump()
+*/
+type PostgresCounters struct {
+	*ccount.CachedCounters
+	persistence *countersPersistence
+}
+
+// Creates a new instance of the performance counters.
+func NewPostgresCounters() *PostgresCounters {
+	c := &PostgresCounters{}
+	c.CachedCounters = ccount.InheritCacheCounters(c)
+	c.persistence = newCountersPersistence()
+	return c
+}
+
+// Configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *PostgresCounters) Configure(config *cconf.ConfigParams) {
+	c.CachedCounters.Configure(config)
+	c.persistence.Configure(config)
+}
+
+// Sets references to dependent components.
+//   - references 	references to locate the component dependencies.
+func (c *PostgresCounters) SetReferences(references cref.IReferences) {
+	c.persistence.SetReferences(references)
+}
+
+// Checks if the component is opened.
+// Returns true if the component has been opened and false otherwise.
+func (c *PostgresCounters) IsOpen() bool {
+	return c.persistence.IsOpen()
+}
+
+// Opens the component.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns error or nil no errors occured.
+func (c *PostgresCounters) Open(correlationId string) error {
+	return c.persistence.Open(correlationId)
+}
+
+// Closes component and frees used resources.
+// Accumulated measurements are saved before closing.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns error or nil no errors occured.
+func (c *PostgresCounters) Close(correlationId string) error {
+	if c.persistence.IsOpen() {
+		if err := c.Dump(); err != nil {
+			c.persistence.Logger.Error(correlationId, err, "Failed to save counters to %s", c.persistence.TableName)
+		}
+	}
+	return c.persistence.Close(correlationId)
+}
+
+// Saves the current counters measurements.
+//   - counters      current counters measurements to be saves.
+// Returns error or nil no errors occured.
+func (c *PostgresCounters) Save(counters []*ccount.Counter) error {
+	if len(counters) == 0 || !c.persistence.IsOpen() {
+		return nil
+	}
+	return c.persistence.save(counters)
+}
+
+// Persistence that stores counters measurements.
+type countersPersistence struct {
+	*persist.PostgresPersistence
+}
+
+func newCountersPersistence() *countersPersistence {
+	c := &countersPersistence{}
+	c.PostgresPersistence = persist.InheritPostgresPersistence(c, reflect.TypeOf(ccount.Counter{}), "counters")
+	return c
+}
+
+func (c *countersPersistence) DefineSchema() {
+	c.ClearSchema()
+	c.PostgresPersistence.DefineSchema()
+	c.EnsureSchema("CREATE TABLE IF NOT EXISTS " + c.QuotedTableName() +
+		" (\"id\" BIGSERIAL PRIMARY KEY, \"name\" TEXT NOT NULL, \"type\" INTEGER, \"last\" REAL," +
+		" \"count\" INTEGER, \"min\" REAL, \"max\" REAL, \"average\" REAL, \"time\" TIMESTAMPTZ NOT NULL)")
+	c.EnsureSchema("CREATE INDEX IF NOT EXISTS " + c.QuoteIdentifier(c.TableName+"_name_time") +
+		" ON " + c.QuotedTableName() + " (\"name\", \"time\")")
+}
+
+func (c *countersPersistence) save(counters []*ccount.Counter) (err error) {
+	ctx, done := c.BeginOperation("", &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	query := "INSERT INTO " + c.QuotedTableName() +
+		" (\"name\", \"type\", \"last\", \"count\", \"min\", \"max\", \"average\", \"time\") VALUES "
+	values := make([]interface{}, 0, len(counters)*8)
+	for index, counter := range counters {
+		if index > 0 {
+			query += ","
+		}
+		query += "("
+		for i := 1; i <= 8; i++ {
+			if i > 1 {
+				query += ","
+			}
+			query += "$" + strconv.Itoa(index*8+i)
+		}
+		query += ")"
+		values = append(values, counter.Name, counter.Type, counter.Last, counter.Count,
+			counter.Min, counter.Max, counter.Average, counter.Time)
+	}
+
+	_, err = c.Client.Exec(ctx, query, values...)
+	if err != nil {
+		return err
+	}
+
+	c.Logger.Trace("", "Saved %d counters to %s", len(counters), c.TableName)
+	return nil
+}
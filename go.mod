@@ -3,9 +3,13 @@ module github.com/pip-services3-go/pip-services3-postgres-go
 go 1.16
 
 require (
+	github.com/jackc/pgconn v1.8.1
+	github.com/jackc/pgproto3/v2 v2.0.6
+	github.com/jackc/pgtype v1.7.0
 	github.com/jackc/pgx/v4 v4.11.0
 	github.com/pip-services3-go/pip-services3-commons-go v1.1.0
 	github.com/pip-services3-go/pip-services3-components-go v1.1.0
 	github.com/pip-services3-go/pip-services3-data-go v1.1.1
+	github.com/pip-services3-go/pip-services3-messaging-go v1.1.0
 	github.com/stretchr/testify v1.7.0
 )
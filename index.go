@@ -1,7 +1,13 @@
 package postgres
 
 import (
+	_ "github.com/pip-services3-go/pip-services3-postgres-go/auth"
 	_ "github.com/pip-services3-go/pip-services3-postgres-go/build"
+	_ "github.com/pip-services3-go/pip-services3-postgres-go/cache"
 	_ "github.com/pip-services3-go/pip-services3-postgres-go/connect"
+	_ "github.com/pip-services3-go/pip-services3-postgres-go/count"
+	_ "github.com/pip-services3-go/pip-services3-postgres-go/lock"
 	_ "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	_ "github.com/pip-services3-go/pip-services3-postgres-go/queues"
+	_ "github.com/pip-services3-go/pip-services3-postgres-go/state"
 )
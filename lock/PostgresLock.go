@@ -0,0 +1,377 @@
+package lock
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+	conn "github.com/pip-services3-go/pip-services3-postgres-go/connect"
+)
+
+/*
+Distributed lock that is implemented based on PostgreSQL session advisory locks.
+
+Every acquired lock holds a dedicated connection from the pool until the lock is released
+or its time to live expires, so the pool size limits the number of simultaneously held locks.
+Lock keys are hashed into 64-bit advisory lock ids.
+
+### Configuration parameters ###
+
+- connection(s):
+   - discovery_key:             (optional) a key to retrieve the connection from IDiscovery
+   - host:                      host name or IP address
+   - port:                      port number (default: 5432)
+   - uri:                       resource URI or connection string with all parameters in it
+- credential(s):
+   - store_key:                 (optional) a key to retrieve the credentials from ICredentialStore
+   - username:                  (optional) user name
+   - password:                  (optional) user password
+- options:
+   - retry_timeout:        (optional) number of milliseconds to wait between lock acquisition attempts (default: 100)
+
+### References ###
+
+- \*:logger:\*:\*:1.0           (optional) ILogger components to pass log messages
+- \*:connection:postgres:\*:1.0 (optional) Shared PostgreSQL connection
+- \*:discovery:\*:\*:1.0        (optional) IDiscovery services
+- \*:credential-store:\*:\*:1.0 (optional) Credential stores to resolve credentials
+
+### Example ###
+
+    lock := NewPostgresLock()
+    lock.Configure(cconf.NewConfigParamsFromTuples(
+        "connection.host", "localhost",
+        "connection.port", 5432,
+        "connection.database", "test",
+    ))
+    err := lock.Open("123")
+    ...
+    err = lock.AcquireLock("123", "key1", 3000, 1000)
+    if err == nil {
+        defer lock.ReleaseLock("123", "key1")
+        // Processing...
+    }
+*/
+type PostgresLock struct {
+	defaultConfig *cconf.ConfigParams
+
+	config          *cconf.ConfigParams
+	references      cref.IReferences
+	opened          bool
+	localConnection bool
+	retryTimeout    int64
+
+	locksLock sync.Mutex
+	locks     map[string]*postgresLockEntry
+
+	//The dependency resolver.
+	DependencyResolver *cref.DependencyResolver
+	//The logger.
+	Logger *clog.CompositeLogger
+	//The PostgreSQL connection component.
+	Connection *conn.PostgresConnection
+	//The PostgreSQL connection pool object at the time of open. Locks are acquired from the current pool of Connection.
+	Client *pgxpool.Pool
+}
+
+type postgresLockEntry struct {
+	id    int64
+	conn  *pgxpool.Conn
+	timer *time.Timer
+}
+
+// Creates a new instance of the lock component.
+func NewPostgresLock() *PostgresLock {
+	c := &PostgresLock{
+		defaultConfig: cconf.NewConfigParamsFromTuples(
+			"dependencies.connection", "*:connection:postgres:*:1.0",
+			"options.retry_timeout", 100,
+		),
+		retryTimeout: 100,
+		locks:        make(map[string]*postgresLockEntry),
+		Logger:       clog.NewCompositeLogger(),
+	}
+
+	c.DependencyResolver = cref.NewDependencyResolver()
+	c.DependencyResolver.Configure(c.defaultConfig)
+
+	return c
+}
+
+// Configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *PostgresLock) Configure(config *cconf.ConfigParams) {
+	config = config.SetDefaults(c.defaultConfig)
+	c.config = config
+
+	c.DependencyResolver.Configure(config)
+
+	c.retryTimeout = config.GetAsLongWithDefault("options.retry_timeout", c.retryTimeout)
+}
+
+// Sets references to dependent components.
+//   - references 	references to locate the component dependencies.
+func (c *PostgresLock) SetReferences(references cref.IReferences) {
+	c.references = references
+	c.Logger.SetReferences(references)
+
+	// Get connection
+	c.DependencyResolver.SetReferences(references)
+	result := c.DependencyResolver.GetOneOptional("connection")
+	if dep, ok := result.(*conn.PostgresConnection); ok {
+		c.Connection = dep
+	}
+	// Or create a local one
+	if c.Connection == nil {
+		c.Connection = c.createConnection()
+		c.localConnection = true
+	} else {
+		c.localConnection = false
+	}
+}
+
+// Unsets (clears) previously set references to dependent components.
+func (c *PostgresLock) UnsetReferences() {
+	c.Connection = nil
+}
+
+func (c *PostgresLock) createConnection() *conn.PostgresConnection {
+	connection := conn.NewPostgresConnection()
+	if c.config != nil {
+		connection.Configure(c.config)
+	}
+	if c.references != nil {
+		connection.SetReferences(c.references)
+	}
+	return connection
+}
+
+// Checks if the component is opened.
+// Returns true if the component has been opened and false otherwise.
+func (c *PostgresLock) IsOpen() bool {
+	return c.opened
+}
+
+// Opens the component.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+//   - Returns 			 error or nil no errors occured.
+func (c *PostgresLock) Open(correlationId string) (err error) {
+	if c.opened {
+		return nil
+	}
+
+	if c.Connection == nil {
+		c.Connection = c.createConnection()
+		c.localConnection = true
+	}
+
+	if c.localConnection {
+		err = c.Connection.Open(correlationId)
+	}
+
+	if err == nil && !c.Connection.IsOpen() {
+		err = cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "PostgreSQL connection is not opened")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	c.Client = c.Connection.GetConnection()
+	c.opened = true
+	return nil
+}
+
+// Closes component, releases all held locks and frees used resources.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+//   - Returns 			error or nil no errors occured.
+func (c *PostgresLock) Close(correlationId string) (err error) {
+	if !c.opened {
+		return nil
+	}
+
+	c.locksLock.Lock()
+	keys := make([]string, 0, len(c.locks))
+	for key := range c.locks {
+		keys = append(keys, key)
+	}
+	c.locksLock.Unlock()
+
+	for _, key := range keys {
+		c.ReleaseLock(correlationId, key)
+	}
+
+	if c.localConnection {
+		err = c.Connection.Close(correlationId)
+	}
+	if err != nil {
+		return err
+	}
+
+	c.opened = false
+	c.Client = nil
+	return nil
+}
+
+func (c *PostgresLock) lockId(key string) int64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(key))
+	return int64(hash.Sum64())
+}
+
+// Makes a single attempt to acquire a lock by its key.
+// It returns immediately a positive or negative result.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - key               a unique lock key to acquire.
+//   - ttl               a lock timeout (time to live) in milliseconds.
+// Returns a lock result or error.
+func (c *PostgresLock) TryAcquireLock(correlationId string, key string, ttl int64) (result bool, err error) {
+	return c.tryAcquireLock(context.Background(), correlationId, key, ttl)
+}
+
+// Makes a single attempt to acquire a lock, waiting for a pooled connection within the context
+func (c *PostgresLock) tryAcquireLock(ctx context.Context, correlationId string, key string, ttl int64) (result bool, err error) {
+	if !c.opened {
+		return false, cerr.NewInvalidStateError(correlationId, "NOT_OPENED", "The lock is not opened")
+	}
+
+	c.locksLock.Lock()
+	_, held := c.locks[key]
+	c.locksLock.Unlock()
+	if held {
+		return false, nil
+	}
+
+	// The pool is looked up per call, as reconnect replaces pools of the connection
+	pool := c.Connection.GetConnection()
+	if pool == nil {
+		return false, cerr.NewInvalidStateError(correlationId, "NO_CONNECTION", "PostgreSQL connection is closed")
+	}
+	connection, err := pool.Acquire(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	id := c.lockId(key)
+	err = connection.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", id).Scan(&result)
+	if err != nil || !result {
+		connection.Release()
+		return false, err
+	}
+
+	entry := &postgresLockEntry{id: id, conn: connection}
+
+	c.locksLock.Lock()
+	if _, held = c.locks[key]; held {
+		c.locksLock.Unlock()
+		// Another goroutine of this process got the lock in between
+		if err = c.unlock(connection, id); err != nil {
+			c.Logger.Error(correlationId, err, "Failed to release lock %s", key)
+		}
+		return false, nil
+	}
+	c.locks[key] = entry
+	if ttl > 0 {
+		entry.timer = time.AfterFunc(time.Duration(ttl)*time.Millisecond, func() {
+			c.releaseEntry(correlationId, key, entry)
+		})
+	}
+	c.locksLock.Unlock()
+
+	c.Logger.Trace(correlationId, "Acquired lock %s", key)
+	return true, nil
+}
+
+// Makes multiple attempts to acquire a lock by its key within give time interval.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - key               a unique lock key to acquire.
+//   - ttl               a lock timeout (time to live) in milliseconds.
+//   - timeout           a lock acquisition timeout in milliseconds.
+// Returns error or nil for success.
+func (c *PostgresLock) AcquireLock(correlationId string, key string, ttl int64, timeout int64) (err error) {
+	retryTime := time.Now().Add(time.Duration(timeout) * time.Millisecond)
+
+	// Waits for pooled connections are bounded by the timeout, as held locks keep their connections
+	ctx, cancel := context.WithDeadline(context.Background(), retryTime)
+	defer cancel()
+
+	for {
+		ok, err := c.tryAcquireLock(ctx, correlationId, key, ttl)
+		if err != nil {
+			if ctx.Err() == context.DeadlineExceeded {
+				return cerr.NewConflictError(correlationId, "LOCK_TIMEOUT",
+					"Acquiring lock "+key+" failed on timeout").WithDetails("key", key).WithCause(err)
+			}
+			return err
+		}
+		if ok {
+			return nil
+		}
+
+		if time.Now().Add(time.Duration(c.retryTimeout) * time.Millisecond).After(retryTime) {
+			return cerr.NewConflictError(correlationId, "LOCK_TIMEOUT",
+				"Acquiring lock "+key+" failed on timeout").WithDetails("key", key)
+		}
+
+		time.Sleep(time.Duration(c.retryTimeout) * time.Millisecond)
+	}
+}
+
+// Releases the lock with the given key.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - key               the key of the lock that is to be released.
+// Returns error or nil for success.
+func (c *PostgresLock) ReleaseLock(correlationId string, key string) (err error) {
+	c.locksLock.Lock()
+	entry, held := c.locks[key]
+	c.locksLock.Unlock()
+
+	if !held {
+		return nil
+	}
+
+	return c.releaseEntry(correlationId, key, entry)
+}
+
+func (c *PostgresLock) releaseEntry(correlationId string, key string, entry *postgresLockEntry) error {
+	c.locksLock.Lock()
+	if c.locks[key] != entry {
+		c.locksLock.Unlock()
+		return nil
+	}
+	delete(c.locks, key)
+	c.locksLock.Unlock()
+
+	if entry.timer != nil {
+		entry.timer.Stop()
+	}
+
+	err := c.unlock(entry.conn, entry.id)
+	if err == nil {
+		c.Logger.Trace(correlationId, "Released lock %s", key)
+	}
+	return err
+}
+
+// Releases an advisory lock and returns its connection to the pool.
+// If unlocking fails, the session may still hold the lock, so the connection is closed instead.
+func (c *PostgresLock) unlock(connection *pgxpool.Conn, id int64) error {
+	var unlocked bool
+	err := connection.QueryRow(context.Background(), "SELECT pg_advisory_unlock($1)", id).Scan(&unlocked)
+	if err != nil {
+		connection.Conn().Close(context.Background())
+		connection.Release()
+		return err
+	}
+	connection.Release()
+	if !unlocked {
+		return cerr.NewInvalidStateError("", "LOCK_NOT_HELD", "Advisory lock is not held by the session")
+	}
+	return nil
+}
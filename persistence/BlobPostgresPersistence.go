@@ -0,0 +1,243 @@
+package persistence
+
+import (
+	"io"
+	"reflect"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+)
+
+// Metadata of a stored blob.
+type BlobInfo struct {
+	// Unique id of the blob
+	Id string `json:"id"`
+	// Name of the blob, for instance a file name
+	Name string `json:"name"`
+	// MIME type of the blob content
+	ContentType string `json:"content_type"`
+	// Size of the content in bytes
+	Size int64 `json:"size"`
+	// True when the content was completely uploaded
+	Completed bool `json:"completed"`
+	// Time when the blob was created
+	CreateTime time.Time `json:"create_time"`
+}
+
+/*
+Persistence component that stores binary objects in PostgreSQL.
+
+Blob metadata is stored in the main table and content is split into BYTEA chunks
+stored in a separate table with "_chunks" suffix. Chunks are written and read one by one,
+so content of any size can be streamed without loading it into memory.
+Metadata can be managed with regular CRUD operations inherited from IdentifiablePostgresPersistence,
+deleting metadata also deletes the content.
+
+### Configuration parameters ###
+
+- table:                       (optional) PostgreSQL table name for blob metadata
+- schema:                      (optional) PostgreSQL schema, default "public"
+- connection(s):
+   - discovery_key:             (optional) a key to retrieve the connection from IDiscovery
+   - host:                      host name or IP address
+   - port:                      port number (default: 5432)
+   - uri:                       resource URI or connection string with all parameters in it
+- credential(s):
+   - store_key:                 (optional) a key to retrieve the credentials from ICredentialStore
+   - username:                  (optional) user name
+   - password:                  (optional) user password
+- options:
+   - chunk_size:           (optional) size of content chunks in bytes (default: 1048576)
+
+### References ###
+
+- \*:logger:\*:\*:1.0           (optional) ILogger components to pass log messages
+- \*:discovery:\*:\*:1.0        (optional) IDiscovery services
+- \*:credential-store:\*:\*:1.0 (optional) Credential stores to resolve credentials
+
+### Example ###
+
+    persistence := NewBlobPostgresPersistence("blobs")
+    ...
+    file, _ := os.Open("report.pdf")
+    info, err := persistence.Upload("123", &BlobInfo{Name: "report.pdf", ContentType: "application/pdf"}, file)
+    ...
+    err = persistence.Download("123", info.Id, writer)
+*/
+type BlobPostgresPersistence struct {
+	*IdentifiablePostgresPersistence
+
+	chunkSize int
+}
+
+// Creates a new instance of the blob persistence.
+//   - tableName    a table name for blob metadata.
+func NewBlobPostgresPersistence(tableName string) *BlobPostgresPersistence {
+	c := &BlobPostgresPersistence{
+		chunkSize: 1024 * 1024,
+	}
+	c.IdentifiablePostgresPersistence = InheritIdentifiablePostgresPersistence(c, reflect.TypeOf(BlobInfo{}), tableName)
+	return c
+}
+
+// Configures component by passing configuration parameters.
+// Column tenancy is not supported, as the tables have no tenant column: Open returns ConfigError.
+//   - config    configuration parameters to be set.
+func (c *BlobPostgresPersistence) Configure(config *cconf.ConfigParams) {
+	c.IdentifiablePostgresPersistence.Configure(config)
+	c.rejectColumnTenancy()
+
+	c.chunkSize = config.GetAsIntegerWithDefault("options.chunk_size", c.chunkSize)
+}
+
+// Gets quoted name of the chunks table
+func (c *BlobPostgresPersistence) QuotedChunkTableName() string {
+	if c.SchemaName != "" {
+		return c.QuoteIdentifier(c.SchemaName) + "." + c.QuoteIdentifier(c.TableName+"_chunks")
+	}
+	return c.QuoteIdentifier(c.TableName + "_chunks")
+}
+
+// Defines a database schema for blob metadata and chunks
+func (c *BlobPostgresPersistence) DefineSchema() {
+	c.ClearSchema()
+	c.IdentifiablePostgresPersistence.DefineSchema()
+	c.EnsureSchema("CREATE TABLE IF NOT EXISTS " + c.QuotedTableName() +
+		" (\"id\" TEXT PRIMARY KEY, \"name\" TEXT, \"content_type\" TEXT, \"size\" BIGINT NOT NULL DEFAULT 0," +
+		" \"completed\" BOOLEAN NOT NULL DEFAULT FALSE, \"create_time\" TIMESTAMPTZ NOT NULL DEFAULT now())")
+	c.EnsureSchema("CREATE TABLE IF NOT EXISTS " + c.QuotedChunkTableName() +
+		" (\"blob_id\" TEXT NOT NULL REFERENCES " + c.QuotedTableName() + " (\"id\") ON DELETE CASCADE," +
+		" \"chunk_index\" INTEGER NOT NULL, \"data\" BYTEA NOT NULL, PRIMARY KEY (\"blob_id\", \"chunk_index\"))")
+}
+
+// Uploads blob content from a reader and saves its metadata.
+// The upload is made in a single transaction, so incomplete content is never visible.
+// If a blob with the same id exists its content is replaced.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - info              blob metadata, id is generated when it is not set.
+//   - reader            a reader of blob content.
+// Returns the saved metadata or error.
+func (c *BlobPostgresPersistence) Upload(correlationId string, info *BlobInfo, reader io.Reader) (uploaded *BlobInfo, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkWritable(correlationId); err != nil {
+		return nil, err
+	}
+	if err = c.checkNotDryRun(correlationId); err != nil {
+		return nil, err
+	}
+
+	if info == nil {
+		info = &BlobInfo{}
+	}
+	var item interface{} = *info
+	c.GenerateObjectId(&item)
+	result := item.(BlobInfo)
+
+	tx, err := c.Client.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := "INSERT INTO " + c.QuotedTableName() + " (\"id\", \"name\", \"content_type\", \"size\", \"completed\")" +
+		" VALUES ($1, $2, $3, 0, FALSE) ON CONFLICT (\"id\") DO UPDATE" +
+		" SET \"name\"=EXCLUDED.\"name\", \"content_type\"=EXCLUDED.\"content_type\", \"size\"=0, \"completed\"=FALSE"
+	_, err = tx.Exec(ctx, query, result.Id, result.Name, result.ContentType)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(ctx, "DELETE FROM "+c.QuotedChunkTableName()+" WHERE \"blob_id\"=$1", result.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	query = "INSERT INTO " + c.QuotedChunkTableName() + " (\"blob_id\", \"chunk_index\", \"data\") VALUES ($1, $2, $3)"
+	buffer := make([]byte, c.chunkSize)
+	var size int64
+	for index := 0; ; index++ {
+		n, readErr := io.ReadFull(reader, buffer)
+		if n > 0 {
+			_, err = tx.Exec(ctx, query, result.Id, index, buffer[:n])
+			if err != nil {
+				return nil, err
+			}
+			size += int64(n)
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			return nil, cerr.NewInternalError(correlationId, "READ_FAILED", "Failed to read blob content").
+				WithCause(readErr)
+		}
+	}
+
+	query = "UPDATE " + c.QuotedTableName() + " SET \"size\"=$2, \"completed\"=TRUE WHERE \"id\"=$1 RETURNING \"create_time\""
+	err = tx.QueryRow(ctx, query, result.Id, size).Scan(&result.CreateTime)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Size = size
+	result.Completed = true
+	c.Logger.Trace(correlationId, "Uploaded blob %s with %d bytes to %s", result.Id, size, c.TableName)
+	return &result, nil
+}
+
+// Downloads blob content into a writer chunk by chunk.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - id                an id of the blob.
+//   - writer            a writer for blob content.
+// Returns error or NotFoundError if the blob does not exist.
+func (c *BlobPostgresPersistence) Download(correlationId string, id string, writer io.Writer) (err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	var completed bool
+	query := "SELECT \"completed\" FROM " + c.QuotedTableName() + " WHERE \"id\"=$1"
+	err = c.Client.QueryRow(ctx, query, id).Scan(&completed)
+	if err != nil || !completed {
+		return cerr.NewNotFoundError(correlationId, "BLOB_NOT_FOUND", "Blob "+id+" was not found").
+			WithDetails("id", id)
+	}
+
+	query = "SELECT \"data\" FROM " + c.QuotedChunkTableName() + " WHERE \"blob_id\"=$1 ORDER BY \"chunk_index\""
+	qResult, qErr := c.Client.Query(ctx, query, id)
+	if qErr != nil {
+		return qErr
+	}
+	defer qResult.Close()
+
+	var size int64
+	for qResult.Next() {
+		var data []byte
+		if err := qResult.Scan(&data); err != nil {
+			return err
+		}
+		n, err := writer.Write(data)
+		if err != nil {
+			return err
+		}
+		size += int64(n)
+	}
+	if err := qResult.Err(); err != nil {
+		return err
+	}
+
+	c.Logger.Trace(correlationId, "Downloaded blob %s with %d bytes from %s", id, size, c.TableName)
+	return nil
+}
@@ -0,0 +1,139 @@
+package persistence
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/jackc/pgx/v4"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+)
+
+/*
+Error raised when a database row cannot be converted into a data item.
+It names the column and the value that failed to convert.
+
+Default converters return it instead of a data item. Custom ConvertToPublic
+overrides may return it as well to report their failures.
+*/
+type DataConversionError struct {
+	*cerr.ApplicationError
+	// The name of the column that failed to convert. Empty when the row could not be read.
+	Column string
+	// The column value that failed to convert.
+	Value interface{}
+}
+
+// Creates a new instance of data conversion error.
+//   - correlationId   (optional) transaction id to trace execution through call chain.
+//   - column          a name of the column that failed to convert.
+//   - value           a value that failed to convert.
+//   - cause           an original conversion error.
+// Returns a new error
+func NewDataConversionError(correlationId string, column string, value interface{}, cause error) *DataConversionError {
+	message := "Failed to read the row"
+	if column != "" {
+		message = fmt.Sprintf("Failed to convert column %s with value %v", column, value)
+	}
+	if cause != nil {
+		message += ": " + cause.Error()
+	}
+
+	err := cerr.NewInternalError(correlationId, "DATA_CONVERSION_FAILED", message).
+		WithDetails("column", column).
+		WithDetails("value", value)
+	if cause != nil {
+		err = err.WithCause(cause)
+	}
+
+	return &DataConversionError{
+		ApplicationError: err,
+		Column:           column,
+		Value:            value,
+	}
+}
+
+// Converts a current row into a data item using the overriden ConvertToPublic method.
+// Rows that fail to convert cause a DataConversionError in strict mode (options.strict_conversion)
+// and are skipped with a warning otherwise.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - rows              rows positioned at the current row
+// Returns a converted item, nil if the row was skipped, or error.
+func (c *PostgresPersistence) ConvertRowToPublic(correlationId string, rows pgx.Rows) (interface{}, error) {
+	item := c.Overrides.ConvertToPublic(rows)
+
+	convErr, failed := item.(*DataConversionError)
+	if item == nil {
+		convErr = NewDataConversionError(correlationId, "", nil, nil)
+		failed = true
+	}
+	if !failed {
+		return item, nil
+	}
+
+	if convErr.CorrelationId == "" {
+		convErr.CorrelationId = correlationId
+	}
+	if c.strictConversion {
+		return nil, convErr
+	}
+
+	c.Logger.Warn(correlationId, "Skipped row from %s: %s", c.TableName, convErr.Error())
+	return nil, nil
+}
+
+func (c *PostgresPersistence) logSkippedRows(correlationId string, skipped int, retrieved int) {
+	if skipped > 0 {
+		c.Logger.Warn(correlationId, "Skipped %d of %d rows from %s that failed conversion",
+			skipped, skipped+retrieved, c.TableName)
+	}
+}
+
+// Decodes a map of column values into a new object of the persistence prototype.
+// When decoding fails it finds the failed column and returns DataConversionError.
+//   - values    a map with column names and values
+// Returns a decoded object or DataConversionError
+func (c *PostgresPersistence) decodeToPrototype(values interface{}) interface{} {
+	docPointer := c.NewObjectByPrototype()
+	jsonValues, sqlValues := c.splitSqlValues(values)
+	jsonBuf, err := json.Marshal(jsonValues)
+	if err == nil {
+		err = json.Unmarshal(jsonBuf, docPointer.Interface())
+	}
+	if err == nil {
+		if convErr := c.assignSqlValues(docPointer, sqlValues); convErr != nil {
+			return convErr
+		}
+		if convErr := c.assignBytesValues(docPointer, values); convErr != nil {
+			return convErr
+		}
+		if convErr := c.assignConvertedValues(docPointer, sqlValues); convErr != nil {
+			return convErr
+		}
+		c.assignTimeValues(docPointer, values)
+		return c.DereferenceObject(docPointer)
+	}
+
+	// Find the column that failed
+	if columns, ok := jsonValues.(map[string]interface{}); ok {
+		names := make([]string, 0, len(columns))
+		for name := range columns {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			probe := reflect.New(docPointer.Elem().Type())
+			buf, probeErr := json.Marshal(map[string]interface{}{name: columns[name]})
+			if probeErr == nil {
+				probeErr = json.Unmarshal(buf, probe.Interface())
+			}
+			if probeErr != nil {
+				return NewDataConversionError("", name, columns[name], probeErr)
+			}
+		}
+	}
+
+	return NewDataConversionError("", "", values, err)
+}
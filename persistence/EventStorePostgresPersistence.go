@@ -0,0 +1,425 @@
+package persistence
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+)
+
+// Expected version that disables optimistic concurrency check on append
+const ExpectedVersionAny int64 = -1
+
+// Immutable event stored in the event store.
+type EventRecord struct {
+	// Global position of the event in the store, assigned on append
+	Sequence int64 `json:"sequence"`
+	// Id of the aggregate the event belongs to
+	AggregateId string `json:"aggregate_id"`
+	// Position of the event within the aggregate stream starting from 1, assigned on append
+	Version int64 `json:"version"`
+	// Type of the event
+	EventType string `json:"event_type"`
+	// Event payload stored as JSON
+	Data interface{} `json:"data"`
+	// Time when the event was appended
+	Time time.Time `json:"time"`
+}
+
+// Snapshot of aggregate state at a certain version.
+type EventSnapshot struct {
+	// Id of the aggregate
+	AggregateId string `json:"aggregate_id"`
+	// Version of the last event included into the snapshot
+	Version int64 `json:"version"`
+	// Aggregate state stored as JSON
+	Data interface{} `json:"data"`
+	// Time when the snapshot was saved
+	Time time.Time `json:"time"`
+}
+
+/*
+Persistence component that stores immutable events per aggregate in PostgreSQL.
+
+Events of each aggregate get monotonically increasing versions starting from 1.
+Appends can be made conditional on the expected aggregate version, which gives optimistic
+concurrency for event sourced aggregates. Snapshots are kept in a separate table
+with "_snapshots" suffix to speed up aggregate rehydration.
+
+Global sequence numbers are assigned from a database sequence, so they always increase
+but may have gaps. Appends are serialized by a table-wide lock, so events are committed
+in the order of their sequence numbers and readers that continue from the last read sequence
+with ReadAllEvents or StreamAllEvents do not miss events of concurrent appends.
+
+### Configuration parameters ###
+
+- table:                       (optional) PostgreSQL table name for events
+- schema:                      (optional) PostgreSQL schema, default "public"
+- connection(s):
+   - discovery_key:             (optional) a key to retrieve the connection from IDiscovery
+   - host:                      host name or IP address
+   - port:                      port number (default: 5432)
+   - uri:                       resource URI or connection string with all parameters in it
+- credential(s):
+   - store_key:                 (optional) a key to retrieve the credentials from ICredentialStore
+   - username:                  (optional) user name
+   - password:                  (optional) user password
+
+### References ###
+
+- \*:logger:\*:\*:1.0           (optional) ILogger components to pass log messages
+- \*:discovery:\*:\*:1.0        (optional) IDiscovery services
+- \*:credential-store:\*:\*:1.0 (optional) Credential stores to resolve credentials
+
+### Example ###
+
+    persistence := NewEventStorePostgresPersistence("events")
+    persistence.Configure(cconf.NewConfigParamsFromTuples(
+        "connection.host", "localhost",
+        "connection.port", 5432,
+        "connection.database", "test",
+    ))
+    err := persistence.Open("123")
+    ...
+    events, err := persistence.AppendEvents("123", "order1", 0, []*EventRecord{
+        {EventType: "OrderCreated", Data: map[string]interface{}{"total": 100}},
+    })
+    events, err = persistence.ReadAggregateEvents("123", "order1", 0, 100)
+*/
+type EventStorePostgresPersistence struct {
+	*PostgresPersistence
+}
+
+// Creates a new instance of the event store persistence.
+//   - tableName    a table name for events.
+func NewEventStorePostgresPersistence(tableName string) *EventStorePostgresPersistence {
+	c := &EventStorePostgresPersistence{}
+	c.PostgresPersistence = InheritPostgresPersistence(c, reflect.TypeOf(EventRecord{}), tableName)
+	return c
+}
+
+// Configures component by passing configuration parameters.
+// Column tenancy is not supported, as the tables have no tenant column: Open returns ConfigError.
+//   - config    configuration parameters to be set.
+func (c *EventStorePostgresPersistence) Configure(config *cconf.ConfigParams) {
+	c.PostgresPersistence.Configure(config)
+	c.rejectColumnTenancy()
+}
+
+// Gets quoted name of the snapshots table
+func (c *EventStorePostgresPersistence) QuotedSnapshotTableName() string {
+	if c.SchemaName != "" {
+		return c.QuoteIdentifier(c.SchemaName) + "." + c.QuoteIdentifier(c.TableName+"_snapshots")
+	}
+	return c.QuoteIdentifier(c.TableName + "_snapshots")
+}
+
+// Defines a database schema for events and snapshots
+func (c *EventStorePostgresPersistence) DefineSchema() {
+	c.ClearSchema()
+	c.PostgresPersistence.DefineSchema()
+	c.EnsureSchema("CREATE TABLE IF NOT EXISTS " + c.QuotedTableName() +
+		" (\"sequence\" BIGSERIAL PRIMARY KEY, \"aggregate_id\" TEXT NOT NULL, \"version\" BIGINT NOT NULL," +
+		" \"event_type\" TEXT NOT NULL, \"data\" JSONB, \"time\" TIMESTAMPTZ NOT NULL DEFAULT now()," +
+		" UNIQUE (\"aggregate_id\", \"version\"))")
+	c.EnsureSchema("CREATE TABLE IF NOT EXISTS " + c.QuotedSnapshotTableName() +
+		" (\"aggregate_id\" TEXT PRIMARY KEY, \"version\" BIGINT NOT NULL, \"data\" JSONB," +
+		" \"time\" TIMESTAMPTZ NOT NULL DEFAULT now())")
+}
+
+// Clears all events and snapshots.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+//   - Returns 			error or nil no errors occured.
+func (c *EventStorePostgresPersistence) Clear(correlationId string) (err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.PostgresPersistence.Clear(correlationId); err != nil {
+		return err
+	}
+
+	_, err = c.Client.Exec(ctx, "DELETE FROM "+c.QuotedSnapshotTableName())
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "Connection to postgres failed").
+			WithCause(err)
+	}
+	return nil
+}
+
+// Gets the current version of aggregate, which is the version of its last event.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - aggregateId       an id of the aggregate.
+// Returns the current version or 0 if aggregate has no events.
+func (c *EventStorePostgresPersistence) GetAggregateVersion(correlationId string, aggregateId string) (version int64, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	query := "SELECT COALESCE(MAX(\"version\"), 0) FROM " + c.QuotedTableName() + " WHERE \"aggregate_id\"=$1"
+	err = c.Client.QueryRow(ctx, query, aggregateId).Scan(&version)
+	return version, err
+}
+
+// Appends events to the aggregate stream.
+// When expectedVersion is not ExpectedVersionAny the events are appended only if
+// the aggregate current version is equal to it, otherwise ConflictError is returned.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - aggregateId       an id of the aggregate.
+//   - expectedVersion   the version the aggregate is expected to have, 0 for a new aggregate.
+//   - events            events to append.
+// Returns appended events with assigned versions and sequence numbers or error.
+func (c *EventStorePostgresPersistence) AppendEvents(correlationId string, aggregateId string,
+	expectedVersion int64, events []*EventRecord) (result []*EventRecord, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkWritable(correlationId); err != nil {
+		return nil, err
+	}
+	if err = c.checkNotDryRun(correlationId); err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return []*EventRecord{}, nil
+	}
+
+	tx, err := c.Client.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Serialize appends to the table within the transaction. Otherwise a transaction
+	// with a lower sequence may commit after a reader has already read higher sequences.
+	_, err = tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", c.QuotedTableName())
+	if err != nil {
+		return nil, err
+	}
+
+	var version int64
+	query := "SELECT COALESCE(MAX(\"version\"), 0) FROM " + c.QuotedTableName() + " WHERE \"aggregate_id\"=$1"
+	err = tx.QueryRow(ctx, query, aggregateId).Scan(&version)
+	if err != nil {
+		return nil, err
+	}
+
+	if expectedVersion != ExpectedVersionAny && expectedVersion != version {
+		return nil, c.versionConflict(correlationId, aggregateId, expectedVersion, version)
+	}
+
+	query = "INSERT INTO " + c.QuotedTableName() + " (\"aggregate_id\", \"version\", \"event_type\", \"data\")" +
+		" VALUES ($1, $2, $3, $4) RETURNING \"sequence\", \"time\""
+	result = make([]*EventRecord, 0, len(events))
+	for _, event := range events {
+		data, err := json.Marshal(event.Data)
+		if err != nil {
+			return nil, err
+		}
+
+		version++
+		appended := &EventRecord{
+			AggregateId: aggregateId,
+			Version:     version,
+			EventType:   event.EventType,
+			Data:        event.Data,
+		}
+		err = tx.QueryRow(ctx, query, aggregateId, version, event.EventType, string(data)).
+			Scan(&appended.Sequence, &appended.Time)
+		if isUniqueViolation(err) {
+			return nil, c.versionConflict(correlationId, aggregateId, expectedVersion, version-1)
+		}
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, appended)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Logger.Trace(correlationId, "Appended %d events to %s in %s", len(result), aggregateId, c.TableName)
+	return result, nil
+}
+
+func (c *EventStorePostgresPersistence) versionConflict(correlationId string, aggregateId string,
+	expectedVersion int64, version int64) error {
+	return cerr.NewConflictError(correlationId, "VERSION_CONFLICT",
+		"Aggregate "+aggregateId+" was expected at version "+strconv.FormatInt(expectedVersion, 10)+
+			" but it is at version "+strconv.FormatInt(version, 10)).
+		WithDetails("aggregate_id", aggregateId).
+		WithDetails("expected_version", expectedVersion).
+		WithDetails("version", version)
+}
+
+// Reads events of the aggregate with versions greater than fromVersion.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - aggregateId       an id of the aggregate.
+//   - fromVersion       a version after which events are read, 0 to read from the beginning.
+//   - maxCount          a maximum number of events to read, 0 for no limit.
+// Returns events ordered by version or error.
+func (c *EventStorePostgresPersistence) ReadAggregateEvents(correlationId string, aggregateId string,
+	fromVersion int64, maxCount int) (result []*EventRecord, err error) {
+
+	query := "SELECT * FROM " + c.QuotedTableName() +
+		" WHERE \"aggregate_id\"=$1 AND \"version\">$2 ORDER BY \"version\""
+	if maxCount > 0 {
+		query += " LIMIT " + strconv.Itoa(maxCount)
+	}
+	return c.readEvents(correlationId, query, aggregateId, fromVersion)
+}
+
+// Reads events of all aggregates with sequence numbers greater than fromSequence.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - fromSequence      a sequence number after which events are read, 0 to read from the beginning.
+//   - maxCount          a maximum number of events to read, 0 for no limit.
+// Returns events ordered by sequence number or error.
+func (c *EventStorePostgresPersistence) ReadAllEvents(correlationId string,
+	fromSequence int64, maxCount int) (result []*EventRecord, err error) {
+
+	query := "SELECT * FROM " + c.QuotedTableName() + " WHERE \"sequence\">$1 ORDER BY \"sequence\""
+	if maxCount > 0 {
+		query += " LIMIT " + strconv.Itoa(maxCount)
+	}
+	return c.readEvents(correlationId, query, fromSequence)
+}
+
+// Streams events of all aggregates in batches starting after fromSequence.
+// The streaming stops at the end of the store or when callback returns an error.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - fromSequence      a sequence number after which events are read, 0 to read from the beginning.
+//   - batchSize         a number of events read from the database at once.
+//   - callback          a function called for every event.
+// Returns the sequence number of the last processed event or error.
+func (c *EventStorePostgresPersistence) StreamAllEvents(correlationId string, fromSequence int64, batchSize int,
+	callback func(event *EventRecord) error) (lastSequence int64, err error) {
+
+	if batchSize <= 0 {
+		batchSize = c.MaxPageSize
+	}
+
+	lastSequence = fromSequence
+	for {
+		events, err := c.ReadAllEvents(correlationId, lastSequence, batchSize)
+		if err != nil {
+			return lastSequence, err
+		}
+		for _, event := range events {
+			if err := callback(event); err != nil {
+				return lastSequence, err
+			}
+			lastSequence = event.Sequence
+		}
+		if len(events) < batchSize {
+			return lastSequence, nil
+		}
+	}
+}
+
+func (c *EventStorePostgresPersistence) readEvents(correlationId string, query string,
+	args ...interface{}) (result []*EventRecord, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	qResult, qErr := c.Client.Query(ctx, query, args...)
+	if qErr != nil {
+		return nil, qErr
+	}
+	defer qResult.Close()
+
+	result = make([]*EventRecord, 0)
+	skipped := 0
+	for qResult.Next() {
+		item, convErr := c.ConvertRowToPublic(correlationId, qResult)
+		if convErr != nil {
+			return nil, convErr
+		}
+		event, ok := item.(EventRecord)
+		if !ok {
+			skipped++
+			continue
+		}
+		result = append(result, &event)
+	}
+	c.logSkippedRows(correlationId, skipped, len(result))
+
+	c.Logger.Trace(correlationId, "Retrieved %d events from %s", len(result), c.TableName)
+	return result, qResult.Err()
+}
+
+// Saves a snapshot of the aggregate state replacing the previous one.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - aggregateId       an id of the aggregate.
+//   - version           a version of the last event included into the snapshot.
+//   - data              the aggregate state.
+// Returns error or nil for success.
+func (c *EventStorePostgresPersistence) SaveSnapshot(correlationId string, aggregateId string,
+	version int64, data interface{}) (err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkWritable(correlationId); err != nil {
+		return err
+	}
+	if err = c.checkNotDryRun(correlationId); err != nil {
+		return err
+	}
+
+	buffer, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	query := "INSERT INTO " + c.QuotedSnapshotTableName() + " (\"aggregate_id\", \"version\", \"data\")" +
+		" VALUES ($1, $2, $3) ON CONFLICT (\"aggregate_id\") DO UPDATE" +
+		" SET \"version\"=EXCLUDED.\"version\", \"data\"=EXCLUDED.\"data\", \"time\"=now()"
+	_, err = c.Client.Exec(ctx, query, aggregateId, version, string(buffer))
+	if err != nil {
+		return err
+	}
+
+	c.Logger.Trace(correlationId, "Saved snapshot of %s at version %d", aggregateId, version)
+	return nil
+}
+
+// Loads the latest snapshot of the aggregate.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - aggregateId       an id of the aggregate.
+// Returns the snapshot, nil if it does not exist, or error.
+func (c *EventStorePostgresPersistence) LoadSnapshot(correlationId string, aggregateId string) (snapshot *EventSnapshot, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	query := "SELECT \"version\", \"data\", \"time\" FROM " + c.QuotedSnapshotTableName() + " WHERE \"aggregate_id\"=$1"
+	snapshot = &EventSnapshot{AggregateId: aggregateId}
+	err = c.Client.QueryRow(ctx, query, aggregateId).Scan(&snapshot.Version, &snapshot.Data, &snapshot.Time)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
@@ -0,0 +1,120 @@
+package persistence
+
+import (
+	"context"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	conn "github.com/pip-services3-go/pip-services3-postgres-go/connect"
+)
+
+// Database client used by persistence components to run statements.
+// It is implemented by pgxpool.Pool and can be replaced by a fake client in unit tests
+// or by a client of an alternative driver, see PostgresPersistence.SetClient.
+type IPostgresClient interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+// Client that hands out dedicated connections, like pgxpool.Pool
+type postgresConnectionPool interface {
+	Acquire(ctx context.Context) (*pgxpool.Conn, error)
+}
+
+// Sets a client that replaces connection pools, e.g. a fake client in unit tests.
+// When the client is set, Open and Close neither open nor close the connection.
+// Set it to nil to use the connection again.
+//   - client    a client to run statements or nil
+func (c *PostgresPersistence) SetClient(client IPostgresClient) {
+	c.customClient = client
+}
+
+// Acquires a dedicated connection from a client, for the calls that need a single session
+// like COPY, LISTEN or session locks. The connection must be released after use.
+//   - ctx           a context of the operation
+//   - client        a client to acquire the connection from, e.g. Client or ReadClient
+// Returns the acquired connection or error if the client doesn't support dedicated connections.
+func (c *PostgresPersistence) AcquireConnection(ctx context.Context, client IPostgresClient) (*pgxpool.Conn, error) {
+	if pool, ok := unwrapClient(client).(postgresConnectionPool); ok {
+		return pool.Acquire(ctx)
+	}
+	return nil, cerr.NewUnsupportedError("", "ACQUIRE_NOT_SUPPORTED",
+		"Client doesn't support dedicated connections")
+}
+
+// Closes a connection acquired from a pool, so it is destroyed on release instead of being reused.
+// It is used for connections left in an unknown state, like ones that still hold session locks.
+func closeAcquiredConnection(connection *pgxpool.Conn) {
+	connection.Conn().Close(context.Background())
+	connection.Release()
+}
+
+// Client that runs every statement on the current pool of a connection. The pools are recreated
+// by reconnect, so the persistence and its copies made by WithContext or ForTenant look them up per call
+// instead of keeping references to pools that get closed.
+type postgresPoolClient struct {
+	connection *conn.PostgresConnection
+	poolClass  string
+	replica    bool
+}
+
+// Gets the current pool: the replica pool for reads if it is configured, or the pool of the workload class
+func (c *postgresPoolClient) pool() *pgxpool.Pool {
+	if c.replica {
+		if pool := c.connection.GetReplicaConnection(); pool != nil {
+			return pool
+		}
+	}
+	return c.connection.GetConnectionByClass(c.poolClass)
+}
+
+func (c *postgresPoolClient) closedError() error {
+	return cerr.NewInvalidStateError("", "NO_CONNECTION", "PostgreSQL connection is closed")
+}
+
+func (c *postgresPoolClient) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	pool := c.pool()
+	if pool == nil {
+		err := c.closedError()
+		return &errorRows{err: err}, err
+	}
+	return pool.Query(ctx, sql, args...)
+}
+
+func (c *postgresPoolClient) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	pool := c.pool()
+	if pool == nil {
+		return &errorRows{err: c.closedError()}
+	}
+	return pool.QueryRow(ctx, sql, args...)
+}
+
+func (c *postgresPoolClient) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	pool := c.pool()
+	if pool == nil {
+		return nil, c.closedError()
+	}
+	return pool.Exec(ctx, sql, args...)
+}
+
+func (c *postgresPoolClient) Begin(ctx context.Context) (pgx.Tx, error) {
+	pool := c.pool()
+	if pool == nil {
+		return nil, c.closedError()
+	}
+	return pool.Begin(ctx)
+}
+
+func (c *postgresPoolClient) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string,
+	rowSrc pgx.CopyFromSource) (int64, error) {
+	pool := c.pool()
+	if pool == nil {
+		return 0, c.closedError()
+	}
+	return pool.CopyFrom(ctx, tableName, columnNames, rowSrc)
+}
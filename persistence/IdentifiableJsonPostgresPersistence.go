@@ -2,7 +2,6 @@ package persistence
 
 import (
 	"context"
-	"encoding/json"
 	"reflect"
 
 	"github.com/jackc/pgx/v4"
@@ -109,6 +108,26 @@ func InheritIdentifiableJsonPostgresPersistence(overrides IPostgresPersistenceOv
 	return c
 }
 
+// Creates a copy of the persistence that runs operations within a caller's context.
+// See PostgresPersistence.WithContext
+//   - ctx   a context of the caller
+// Returns the persistence copy bound to the context.
+func (c *IdentifiableJsonPostgresPersistence) WithContext(ctx context.Context) *IdentifiableJsonPostgresPersistence {
+	clone := *c
+	clone.IdentifiablePostgresPersistence = *c.IdentifiablePostgresPersistence.WithContext(ctx)
+	return &clone
+}
+
+// Creates a copy of the persistence that keeps data of a tenant.
+// See PostgresPersistence.ForTenant
+//   - tenantId      a tenant id
+// Returns the persistence copy bound to the tenant.
+func (c *IdentifiableJsonPostgresPersistence) ForTenant(tenantId string) *IdentifiableJsonPostgresPersistence {
+	clone := *c
+	clone.IdentifiablePostgresPersistence = *c.IdentifiablePostgresPersistence.ForTenant(tenantId)
+	return &clone
+}
+
 // Adds DML statement to automatically create JSON(B) table
 //   - idType type of the id column (default: TEXT)
 //   - dataType type of the data column (default: JSONB)
@@ -120,8 +139,11 @@ func (c *IdentifiableJsonPostgresPersistence) EnsureTable(idType string, dataTyp
 		dataType = "JSONB"
 	}
 
-	query := "CREATE TABLE IF NOT EXISTS " + c.QuotedTableName() +
-		" (\"id\" " + idType + " PRIMARY KEY, \"data\" " + dataType + ")"
+	columns := "\"id\" " + idType + " PRIMARY KEY, \"data\" " + dataType
+	if c.tenancy == TenancyColumn {
+		columns += ", " + c.QuoteIdentifier(c.tenantColumn) + " TEXT NOT NULL"
+	}
+	query := "CREATE TABLE IF NOT EXISTS " + c.QuotedTableName() + " (" + columns + ")"
 	c.EnsureSchema(query)
 }
 
@@ -132,7 +154,7 @@ func (c *IdentifiableJsonPostgresPersistence) ConvertToPublic(rows pgx.Rows) int
 
 	values, valErr := rows.Values()
 	if valErr != nil || values == nil {
-		return nil
+		return NewDataConversionError("", "", nil, valErr)
 	}
 	columns := rows.FieldDescriptions()
 
@@ -147,11 +169,7 @@ func (c *IdentifiableJsonPostgresPersistence) ConvertToPublic(rows pgx.Rows) int
 		item = buf
 	}
 
-	docPointer := c.NewObjectByPrototype()
-	jsonBuf, _ := json.Marshal(item)
-	json.Unmarshal(jsonBuf, docPointer.Interface())
-	return c.DereferenceObject(docPointer)
-
+	return c.decodeToPrototype(item)
 }
 
 // Convert object value from public to internal format.
@@ -183,15 +201,33 @@ func (c *IdentifiableJsonPostgresPersistence) ConvertFromPublicPartial(value int
 //   - data              a map with fields to be updated.
 // Returns          callback function that receives updated item or error.
 func (c *IdentifiableJsonPostgresPersistence) UpdatePartially(correlationId string, id interface{}, data *cdata.AnyValueMap) (result interface{}, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkWritable(correlationId); err != nil {
+		return nil, err
+	}
 
 	if data == nil {
 		return nil, nil
 	}
 
-	query := "UPDATE " + c.QuotedTableName() + " SET \"data\"=\"data\"||$2 WHERE \"id\"=$1 RETURNING *"
+	query := "UPDATE " + c.QuotedTableName() + " SET \"data\"=\"data\"||$2 WHERE \"id\"=$1" +
+		c.andScopeCondition() + " RETURNING *"
 	values := []interface{}{id, data.Value()}
+	if c.dryRun {
+		c.logDryRun(correlationId, query, values)
+		current, gErr := c.GetOneById(correlationId, id)
+		if gErr != nil || current == nil {
+			return nil, gErr
+		}
+		return c.mergeDryRunFields(current, data.Value()), nil
+	}
 
-	qResult, qErr := c.Client.Query(context.TODO(), query, values...)
+	qResult, qErr := c.Client.Query(ctx, query, values...)
 
 	if qErr != nil {
 		return nil, qErr
@@ -203,7 +239,10 @@ func (c *IdentifiableJsonPostgresPersistence) UpdatePartially(correlationId stri
 	}
 	rows, vErr := qResult.Values()
 	if vErr == nil && len(rows) > 0 {
-		result = c.Overrides.ConvertToPublic(qResult)
+		result, err = c.ConvertRowToPublic(correlationId, qResult)
+		if err != nil {
+			return nil, err
+		}
 		c.Logger.Trace(correlationId, "Updated partially in %s with id = %s", c.TableName, id)
 		return result, nil
 	}
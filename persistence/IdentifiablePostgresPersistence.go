@@ -4,6 +4,7 @@ import (
 	"context"
 	"reflect"
 	"strconv"
+	"strings"
 
 	cconv "github.com/pip-services3-go/pip-services3-commons-go/convert"
 	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
@@ -96,6 +97,8 @@ accessing c._collection and c._model properties.
 */
 type IdentifiablePostgresPersistence struct {
 	*PostgresPersistence
+
+	upsertConstraint string
 }
 
 // Creates a new instance of the persistence component.
@@ -112,25 +115,74 @@ func InheritIdentifiablePostgresPersistence(overrides IPostgresPersistenceOverri
 	return c
 }
 
+// Creates a copy of the persistence that runs operations within a caller's context.
+// See PostgresPersistence.WithContext
+//   - ctx   a context of the caller
+// Returns the persistence copy bound to the context.
+func (c *IdentifiablePostgresPersistence) WithContext(ctx context.Context) *IdentifiablePostgresPersistence {
+	clone := *c
+	clone.PostgresPersistence = c.PostgresPersistence.WithContext(ctx)
+	return &clone
+}
+
+// Creates a copy of the persistence that keeps data of a tenant.
+// See PostgresPersistence.ForTenant
+//   - tenantId      a tenant id
+// Returns the persistence copy bound to the tenant.
+func (c *IdentifiablePostgresPersistence) ForTenant(tenantId string) *IdentifiablePostgresPersistence {
+	clone := *c
+	clone.PostgresPersistence = c.PostgresPersistence.ForTenant(tenantId)
+	return &clone
+}
+
+// Assigns a unique id to the item if it is not set.
+// The id is generated according to options.id_generator configuration.
+//   - item  a pointer to the item to assign id
+func (c *IdentifiablePostgresPersistence) GenerateObjectId(item *interface{}) {
+	if c.idGenerator != IdGeneratorUuidV7 {
+		cmpersist.GenerateObjectId(item)
+		return
+	}
+
+	id := cmpersist.GetObjectId(*item)
+	if id == nil || id == "" {
+		cmpersist.SetObjectId(item, NewUuidV7())
+	}
+}
+
 // Gets a list of data items retrieved by given unique ids.
 //   - correlationId     (optional) transaction id to trace execution through call chain.
 //   - ids               ids of data items to be retrieved
 // Returns          a data list or error.
 func (c *IdentifiablePostgresPersistence) GetListByIds(correlationId string, ids []interface{}) (items []interface{}, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
 	params := c.GenerateParameters(ids)
-	query := "SELECT * FROM " + c.QuotedTableName() + " WHERE \"id\" IN(" + params + ")"
+	query := "SELECT * FROM " + c.QuotedTableName() + " WHERE \"id\" IN(" + params + ")" + c.andScopeCondition()
 
-	qResult, qErr := c.Client.Query(context.TODO(), query, ids...)
+	qResult, qErr := c.ReadClient.Query(ctx, query, ids...)
 	if qErr != nil {
 		return nil, qErr
 	}
 	defer qResult.Close()
 	items = make([]interface{}, 0, 0)
+	skipped := 0
 	for qResult.Next() {
-
-		item := c.Overrides.ConvertToPublic(qResult)
+		item, convErr := c.ConvertRowToPublic(correlationId, qResult)
+		if convErr != nil {
+			return nil, convErr
+		}
+		if item == nil {
+			skipped++
+			continue
+		}
 		items = append(items, item)
 	}
+	c.logSkippedRows(correlationId, skipped, len(items))
 
 	if items != nil {
 		c.Logger.Trace(correlationId, "Retrieved %d from %s", len(items), c.TableName)
@@ -144,10 +196,15 @@ func (c *IdentifiablePostgresPersistence) GetListByIds(correlationId string, ids
 //   - id                an id of data item to be retrieved.
 // Returns           data item or error.
 func (c *IdentifiablePostgresPersistence) GetOneById(correlationId string, id interface{}) (item interface{}, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
 
-	query := "SELECT * FROM " + c.QuotedTableName() + " WHERE \"id\"=$1"
+	query := "SELECT * FROM " + c.QuotedTableName() + " WHERE \"id\"=$1" + c.andScopeCondition()
 
-	qResult, qErr := c.Client.Query(context.TODO(), query, id)
+	qResult, qErr := c.ReadClient.Query(ctx, query, id)
 	if qErr != nil {
 		return nil, qErr
 	}
@@ -157,7 +214,10 @@ func (c *IdentifiablePostgresPersistence) GetOneById(correlationId string, id in
 	}
 	rows, vErr := qResult.Values()
 	if vErr == nil && len(rows) > 0 {
-		result := c.Overrides.ConvertToPublic(qResult)
+		result, convErr := c.ConvertRowToPublic(correlationId, qResult)
+		if convErr != nil {
+			return nil, convErr
+		}
 		if result == nil {
 			c.Logger.Trace(correlationId, "Nothing found from %s with id = %s", c.TableName, id)
 		} else {
@@ -179,17 +239,43 @@ func (c *IdentifiablePostgresPersistence) Create(correlationId string, item inte
 	// Assign unique id
 	var newItem interface{}
 	newItem = cmpersist.CloneObject(item, c.Prototype)
-	cmpersist.GenerateObjectId(&newItem)
+	c.GenerateObjectId(&newItem)
 
 	return c.PostgresPersistence.Create(correlationId, newItem)
 }
 
+// Sets a unique constraint used by Set to detect existing items instead of the id.
+//   - constraint    a name of a unique constraint, e.g. returned by EnsureUniqueConstraint, or empty for the id
+func (c *IdentifiablePostgresPersistence) SetUpsertConstraint(constraint string) {
+	c.upsertConstraint = constraint
+}
+
 // Sets a data item. If the data item exists it updates it,
 // otherwise it create a new data item.
 //   - correlation_id    (optional) transaction id to trace execution through call chain.
 //   - item              a item to be set.
 // Returns          (optional)  updated item or error.
 func (c *IdentifiablePostgresPersistence) Set(correlationId string, item interface{}) (result interface{}, err error) {
+	return c.SetOnConstraint(correlationId, item, c.upsertConstraint)
+}
+
+// Sets a data item using a unique constraint to detect the existing item.
+// The existing item keeps its id, when it was found by another constraint.
+//   - correlation_id    (optional) transaction id to trace execution through call chain.
+//   - item              a item to be set.
+//   - constraint        a name of a unique constraint or empty for the id
+// Returns          (optional)  updated item or error.
+func (c *IdentifiablePostgresPersistence) SetOnConstraint(correlationId string, item interface{},
+	constraint string) (result interface{}, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkWritable(correlationId); err != nil {
+		return nil, err
+	}
 
 	if item == nil {
 		return nil, nil
@@ -198,9 +284,9 @@ func (c *IdentifiablePostgresPersistence) Set(correlationId string, item interfa
 	// Assign unique id
 	var newItem interface{}
 	newItem = cmpersist.CloneObject(item, c.Prototype)
-	cmpersist.GenerateObjectId(&newItem)
+	c.GenerateObjectId(&newItem)
 
-	row := c.Overrides.ConvertFromPublic(item)
+	row := c.injectTenant(c.Overrides.ConvertFromPublic(newItem))
 	params := c.GenerateParameters(row)
 	setParams, columns := c.GenerateSetParameters(row)
 	values := c.GenerateValues(columns, row)
@@ -208,9 +294,14 @@ func (c *IdentifiablePostgresPersistence) Set(correlationId string, item interfa
 
 	query := "INSERT INTO " + c.QuotedTableName() + " (" + columns + ")" +
 		" VALUES (" + params + ")" +
-		" ON CONFLICT (\"id\") DO UPDATE SET " + setParams + " RETURNING *"
+		" ON CONFLICT " + c.conflictTarget(constraint) +
+		" DO UPDATE SET " + c.upsertSetParameters(setParams, constraint) + c.upsertTenantCondition() + c.returningClause("id")
+	if c.dryRun {
+		c.logDryRun(correlationId, query, values)
+		return newItem, nil
+	}
 
-	qResult, qErr := c.Client.Query(context.TODO(), query, values...)
+	qResult, qErr := c.Client.Query(ctx, query, values...)
 	if qErr != nil {
 		return nil, qErr
 	}
@@ -221,7 +312,10 @@ func (c *IdentifiablePostgresPersistence) Set(correlationId string, item interfa
 	}
 	rows, vErr := qResult.Values()
 	if vErr == nil && len(rows) > 0 {
-		result := c.Overrides.ConvertToPublic(qResult)
+		result, convErr := c.ConvertRowToPublic(correlationId, qResult)
+		if convErr != nil {
+			return nil, convErr
+		}
 		c.Logger.Trace(correlationId, "Set in %s with id = %s", c.TableName, id)
 		return result, nil
 	}
@@ -229,11 +323,42 @@ func (c *IdentifiablePostgresPersistence) Set(correlationId string, item interfa
 
 }
 
+// Composes ON CONFLICT target for a unique constraint or for the id
+func (c *IdentifiablePostgresPersistence) conflictTarget(constraint string) string {
+	if constraint == "" {
+		return "(\"id\")"
+	}
+	return "ON CONSTRAINT " + c.QuoteIdentifier(constraint)
+}
+
+// Removes the id from updated columns when items are matched by another constraint
+func (c *IdentifiablePostgresPersistence) upsertSetParameters(setParams string, constraint string) string {
+	if constraint == "" {
+		return setParams
+	}
+	params := make([]string, 0)
+	for _, param := range strings.Split(setParams, ",") {
+		if !strings.HasPrefix(param, "\"id\"=") {
+			params = append(params, param)
+		}
+	}
+	return strings.Join(params, ",")
+}
+
 // Updates a data item.
 //   - correlation_id    (optional) transaction id to trace execution through call chain.
 //   - item              an item to be updated.
 // Returns          (optional)  updated item or error.
 func (c *IdentifiablePostgresPersistence) Update(correlationId string, item interface{}) (result interface{}, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkWritable(correlationId); err != nil {
+		return nil, err
+	}
 
 	if item == nil {
 		return nil, nil
@@ -242,15 +367,23 @@ func (c *IdentifiablePostgresPersistence) Update(correlationId string, item inte
 	newItem = cmpersist.CloneObject(item, c.Prototype)
 	id := cmpersist.GetObjectId(newItem)
 
-	row := c.Overrides.ConvertFromPublic(newItem)
+	row := c.injectTenant(c.Overrides.ConvertFromPublic(newItem))
 	params, col := c.GenerateSetParameters(row)
 	values := c.GenerateValues(col, row)
 	values = append(values, id)
 
 	query := "UPDATE " + c.QuotedTableName() +
-		" SET " + params + " WHERE \"id\"=$" + strconv.FormatInt((int64)(len(values)), 10) + " RETURNING *"
+		" SET " + params + " WHERE \"id\"=$" + strconv.FormatInt((int64)(len(values)), 10) +
+		c.andScopeCondition() + c.returningClause("id")
+	if c.dryRun {
+		c.logDryRun(correlationId, query, values)
+		if current, gErr := c.GetOneById(correlationId, id); gErr != nil || current == nil {
+			return nil, gErr
+		}
+		return newItem, nil
+	}
 
-	qResult, qErr := c.Client.Query(context.TODO(), query, values...)
+	qResult, qErr := c.Client.Query(ctx, query, values...)
 
 	if qErr != nil {
 		return nil, qErr
@@ -261,7 +394,10 @@ func (c *IdentifiablePostgresPersistence) Update(correlationId string, item inte
 	}
 	rows, vErr := qResult.Values()
 	if vErr == nil && len(rows) > 0 {
-		result := c.Overrides.ConvertToPublic(qResult)
+		result, convErr := c.ConvertRowToPublic(correlationId, qResult)
+		if convErr != nil {
+			return nil, convErr
+		}
 		c.Logger.Trace(correlationId, "Updated in %s with id = %s", c.TableName, id)
 		return result, nil
 	}
@@ -274,20 +410,38 @@ func (c *IdentifiablePostgresPersistence) Update(correlationId string, item inte
 //   - data              a map with fields to be updated.
 // Returns           updated item or error.
 func (c *IdentifiablePostgresPersistence) UpdatePartially(correlationId string, id interface{}, data *cdata.AnyValueMap) (result interface{}, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkWritable(correlationId); err != nil {
+		return nil, err
+	}
 
 	if id == nil {
 		return nil, nil
 	}
 
-	row := c.Overrides.ConvertFromPublicPartial(data.Value())
+	row := c.injectTenant(c.Overrides.ConvertFromPublicPartial(data.Value()))
 	params, col := c.GenerateSetParameters(row)
 	values := c.GenerateValues(col, row)
 	values = append(values, id)
 
 	query := "UPDATE " + c.QuotedTableName() +
-		" SET " + params + " WHERE \"id\"=$" + strconv.FormatInt((int64)(len(values)), 10) + " RETURNING *"
+		" SET " + params + " WHERE \"id\"=$" + strconv.FormatInt((int64)(len(values)), 10) +
+		c.andScopeCondition() + c.returningClause("id")
+	if c.dryRun {
+		c.logDryRun(correlationId, query, values)
+		current, gErr := c.GetOneById(correlationId, id)
+		if gErr != nil || current == nil {
+			return nil, gErr
+		}
+		return c.mergeDryRunFields(current, data.Value()), nil
+	}
 
-	qResult, qErr := c.Client.Query(context.TODO(), query, values...)
+	qResult, qErr := c.Client.Query(ctx, query, values...)
 
 	if qErr != nil {
 		return nil, qErr
@@ -298,7 +452,10 @@ func (c *IdentifiablePostgresPersistence) UpdatePartially(correlationId string,
 	}
 	rows, vErr := qResult.Values()
 	if vErr == nil && len(rows) > 0 {
-		result := c.Overrides.ConvertToPublic(qResult)
+		result, convErr := c.ConvertRowToPublic(correlationId, qResult)
+		if convErr != nil {
+			return nil, convErr
+		}
 		c.Logger.Trace(correlationId, "Updated partially in %s with id = %s", c.TableName, id)
 		return result, nil
 	}
@@ -310,10 +467,23 @@ func (c *IdentifiablePostgresPersistence) UpdatePartially(correlationId string,
 //   - id                an id of the item to be deleted
 // Returns          (optional)  deleted item or error.
 func (c *IdentifiablePostgresPersistence) DeleteById(correlationId string, id interface{}) (result interface{}, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkWritable(correlationId); err != nil {
+		return nil, err
+	}
 
-	query := "DELETE FROM " + c.QuotedTableName() + " WHERE \"id\"=$1 RETURNING *"
+	query := c.deleteStatement() + " WHERE \"id\"=$1" + c.andScopeCondition() + c.returningClause("id")
+	if c.dryRun {
+		c.logDryRun(correlationId, query, []interface{}{id})
+		return c.GetOneById(correlationId, id)
+	}
 
-	qResult, qErr := c.Client.Query(context.TODO(), query, id)
+	qResult, qErr := c.Client.Query(ctx, query, id)
 
 	if qErr != nil {
 		return nil, qErr
@@ -324,7 +494,10 @@ func (c *IdentifiablePostgresPersistence) DeleteById(correlationId string, id in
 	}
 	rows, vErr := qResult.Values()
 	if vErr == nil && len(rows) > 0 {
-		result = c.Overrides.ConvertToPublic(qResult)
+		result, err = c.ConvertRowToPublic(correlationId, qResult)
+		if err != nil {
+			return nil, err
+		}
 		c.Logger.Trace(correlationId, "Deleted from %s with id = %s", c.TableName, id)
 		return result, nil
 	}
@@ -335,12 +508,25 @@ func (c *IdentifiablePostgresPersistence) DeleteById(correlationId string, id in
 //   - correlationId     (optional) transaction id to trace execution through call chain.
 //   - ids               ids of data items to be deleted.
 // Returns          (optional)  error or null for success.
-func (c *IdentifiablePostgresPersistence) DeleteByIds(correlationId string, ids []interface{}) error {
+func (c *IdentifiablePostgresPersistence) DeleteByIds(correlationId string, ids []interface{}) (err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err := c.checkWritable(correlationId); err != nil {
+		return err
+	}
 
 	params := c.GenerateParameters(ids)
-	query := "DELETE FROM " + c.QuotedTableName() + " WHERE \"id\" IN(" + params + ")"
+	query := c.deleteStatement() + " WHERE \"id\" IN(" + params + ")" + c.andScopeCondition()
+	if c.dryRun {
+		c.logDryRun(correlationId, query, ids)
+		return nil
+	}
 
-	qResult, qErr := c.Client.Query(context.TODO(), query, ids...)
+	qResult, qErr := c.Client.Query(ctx, query, ids...)
 
 	if qErr != nil {
 		return qErr
@@ -0,0 +1,381 @@
+package persistence
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+)
+
+const (
+	// Job waits for its run time
+	JobStatusPending = "pending"
+	// Job is claimed and executed by a worker
+	JobStatusRunning = "running"
+	// Job finished successfully
+	JobStatusCompleted = "completed"
+	// Job failed and exhausted its retries
+	JobStatusFailed = "failed"
+)
+
+// Scheduled background job.
+type Job struct {
+	// Unique id of the job
+	Id string `json:"id"`
+	// Type of the job that defines its handler
+	JobType string `json:"job_type"`
+	// Job data stored as JSON
+	Payload interface{} `json:"payload"`
+	// Current status: pending, running, completed or failed
+	Status string `json:"status"`
+	// Time when the job is due
+	RunAt time.Time `json:"run_at"`
+	// Interval in milliseconds for recurring jobs, 0 for one-time jobs
+	Interval int64 `json:"interval"`
+	// Number of executions started since the last success
+	Attempts int `json:"attempts"`
+	// Maximum number of attempts before the job fails
+	MaxAttempts int `json:"max_attempts"`
+	// Error of the last failed attempt
+	LastError *string `json:"last_error"`
+	// Worker that claimed the job
+	LockedBy *string `json:"locked_by"`
+	// Time until the claim is valid, extended by heartbeats
+	LockedUntil *time.Time `json:"locked_until"`
+	// Time when the job was enqueued
+	CreateTime time.Time `json:"create_time"`
+	// Time of the last job update
+	UpdateTime time.Time `json:"update_time"`
+}
+
+/*
+Persistence component that stores delayed and recurring jobs in PostgreSQL.
+
+Workers atomically claim due jobs with SELECT ... FOR UPDATE SKIP LOCKED.
+A claim is valid for a lock timeout and shall be extended by heartbeats while
+the job runs. Jobs whose claim expired, for instance because the worker crashed,
+are claimed again. Failed jobs are retried until they reach the maximum number of attempts.
+Recurring jobs are rescheduled after every successful run.
+
+### Configuration parameters ###
+
+- table:                       (optional) PostgreSQL table name
+- schema:                      (optional) PostgreSQL schema, default "public"
+- connection(s):
+   - discovery_key:             (optional) a key to retrieve the connection from IDiscovery
+   - host:                      host name or IP address
+   - port:                      port number (default: 5432)
+   - uri:                       resource URI or connection string with all parameters in it
+- credential(s):
+   - store_key:                 (optional) a key to retrieve the credentials from ICredentialStore
+   - username:                  (optional) user name
+   - password:                  (optional) user password
+
+### References ###
+
+- \*:logger:\*:\*:1.0           (optional) ILogger components to pass log messages
+- \*:discovery:\*:\*:1.0        (optional) IDiscovery services
+- \*:credential-store:\*:\*:1.0 (optional) Credential stores to resolve credentials
+
+### Example ###
+
+    persistence := NewJobQueuePostgresPersistence("jobs")
+    ...
+    job, err := persistence.Enqueue("123", "send_email", payload, time.Now().Add(time.Minute), 3)
+
+    jobs, err := persistence.ClaimDue("123", "worker1", 30*time.Second, 10)
+    for _, job := range jobs {
+        err = execute(job)
+        if err != nil {
+            persistence.Fail("123", job.Id, "worker1", err.Error(), time.Minute)
+        } else {
+            persistence.Complete("123", job.Id, "worker1")
+        }
+    }
+*/
+type JobQueuePostgresPersistence struct {
+	*IdentifiablePostgresPersistence
+}
+
+// Creates a new instance of the job queue persistence.
+//   - tableName    a table name for jobs.
+func NewJobQueuePostgresPersistence(tableName string) *JobQueuePostgresPersistence {
+	c := &JobQueuePostgresPersistence{}
+	c.IdentifiablePostgresPersistence = InheritIdentifiablePostgresPersistence(c, reflect.TypeOf(Job{}), tableName)
+	return c
+}
+
+// Configures component by passing configuration parameters.
+// Column tenancy is not supported, as the tables have no tenant column: Open returns ConfigError.
+//   - config    configuration parameters to be set.
+func (c *JobQueuePostgresPersistence) Configure(config *cconf.ConfigParams) {
+	c.IdentifiablePostgresPersistence.Configure(config)
+	c.rejectColumnTenancy()
+}
+
+// Defines a database schema for jobs
+func (c *JobQueuePostgresPersistence) DefineSchema() {
+	c.ClearSchema()
+	c.IdentifiablePostgresPersistence.DefineSchema()
+	c.EnsureSchema("CREATE TABLE IF NOT EXISTS " + c.QuotedTableName() +
+		" (\"id\" TEXT PRIMARY KEY, \"job_type\" TEXT NOT NULL, \"payload\" JSONB, \"status\" TEXT NOT NULL," +
+		" \"run_at\" TIMESTAMPTZ NOT NULL, \"interval\" BIGINT NOT NULL DEFAULT 0," +
+		" \"attempts\" INTEGER NOT NULL DEFAULT 0, \"max_attempts\" INTEGER NOT NULL DEFAULT 1, \"last_error\" TEXT," +
+		" \"locked_by\" TEXT, \"locked_until\" TIMESTAMPTZ," +
+		" \"create_time\" TIMESTAMPTZ NOT NULL DEFAULT now(), \"update_time\" TIMESTAMPTZ NOT NULL DEFAULT now())")
+	c.EnsureSchema("CREATE INDEX IF NOT EXISTS " + c.QuoteIdentifier(c.TableName+"_due") +
+		" ON " + c.QuotedTableName() + " (\"status\", \"run_at\")")
+}
+
+// Enqueues a one-time job.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - jobType           a type of the job.
+//   - payload           job data.
+//   - runAt             a time when the job is due.
+//   - maxAttempts       a maximum number of attempts, values below 1 mean a single attempt.
+// Returns the enqueued job or error.
+func (c *JobQueuePostgresPersistence) Enqueue(correlationId string, jobType string, payload interface{},
+	runAt time.Time, maxAttempts int) (*Job, error) {
+	return c.enqueue(correlationId, jobType, payload, runAt, 0, maxAttempts)
+}
+
+// Enqueues a recurring job that is rescheduled after every successful run.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - jobType           a type of the job.
+//   - payload           job data.
+//   - runAt             a time of the first run.
+//   - interval          an interval between runs.
+//   - maxAttempts       a maximum number of attempts per run, values below 1 mean a single attempt.
+// Returns the enqueued job or error.
+func (c *JobQueuePostgresPersistence) EnqueueRecurring(correlationId string, jobType string, payload interface{},
+	runAt time.Time, interval time.Duration, maxAttempts int) (*Job, error) {
+
+	if interval <= 0 {
+		return nil, cerr.NewBadRequestError(correlationId, "INVALID_INTERVAL", "Recurring job interval must be positive")
+	}
+	return c.enqueue(correlationId, jobType, payload, runAt, int64(interval/time.Millisecond), maxAttempts)
+}
+
+func (c *JobQueuePostgresPersistence) enqueue(correlationId string, jobType string, payload interface{},
+	runAt time.Time, interval int64, maxAttempts int) (*Job, error) {
+
+	if err := c.checkWritable(correlationId); err != nil {
+		return nil, err
+	}
+	if err := c.checkNotDryRun(correlationId); err != nil {
+		return nil, err
+	}
+
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	var item interface{} = Job{}
+	c.GenerateObjectId(&item)
+	id := item.(Job).Id
+
+	buffer, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "INSERT INTO " + c.QuotedTableName() +
+		" (\"id\", \"job_type\", \"payload\", \"status\", \"run_at\", \"interval\", \"max_attempts\")" +
+		" VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING *"
+	job, err := c.queryJob(correlationId, query, id, jobType, string(buffer), JobStatusPending, runAt, interval, maxAttempts)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Logger.Trace(correlationId, "Enqueued job %s in %s with id = %s", jobType, c.TableName, id)
+	return job, nil
+}
+
+// Claims due jobs and jobs with expired claims. Every claim counts as an attempt.
+// Jobs with expired claims that reached the maximum number of attempts are failed instead.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - owner             a unique name of the claiming worker.
+//   - lockTimeout       a time the claim stays valid without heartbeats.
+//   - maxCount          a maximum number of jobs to claim.
+// Returns claimed jobs or error.
+func (c *JobQueuePostgresPersistence) ClaimDue(correlationId string, owner string,
+	lockTimeout time.Duration, maxCount int) (result []*Job, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkWritable(correlationId); err != nil {
+		return nil, err
+	}
+	if err = c.checkNotDryRun(correlationId); err != nil {
+		return nil, err
+	}
+
+	if maxCount <= 0 {
+		maxCount = 1
+	}
+
+	// Jobs whose claims expired on the last attempt are not retried
+	query := "UPDATE " + c.QuotedTableName() +
+		" SET \"status\"='" + JobStatusFailed + "', \"last_error\"='Job claim expired on the last attempt'," +
+		" \"locked_by\"=NULL, \"locked_until\"=NULL, \"update_time\"=now()" +
+		" WHERE \"status\"='" + JobStatusRunning + "' AND \"locked_until\"<now() AND \"attempts\">=\"max_attempts\""
+	failed, err := c.Client.Exec(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if failed.RowsAffected() > 0 {
+		c.Logger.Warn(correlationId, "Failed %d jobs in %s with expired claims on the last attempt",
+			failed.RowsAffected(), c.TableName)
+	}
+
+	query = "UPDATE " + c.QuotedTableName() +
+		" SET \"status\"='" + JobStatusRunning + "', \"locked_by\"=$1, \"locked_until\"=now()+$2*interval '1 millisecond'," +
+		" \"attempts\"=\"attempts\"+1, \"update_time\"=now()" +
+		" WHERE \"id\" IN (SELECT \"id\" FROM " + c.QuotedTableName() +
+		" WHERE (\"status\"='" + JobStatusPending + "' AND \"run_at\"<=now())" +
+		" OR (\"status\"='" + JobStatusRunning + "' AND \"locked_until\"<now() AND \"attempts\"<\"max_attempts\")" +
+		" ORDER BY \"run_at\" LIMIT " + strconv.Itoa(maxCount) + " FOR UPDATE SKIP LOCKED)" +
+		" RETURNING *"
+
+	qResult, qErr := c.Client.Query(ctx, query, owner, int64(lockTimeout/time.Millisecond))
+	if qErr != nil {
+		return nil, qErr
+	}
+	defer qResult.Close()
+
+	result = make([]*Job, 0)
+	for qResult.Next() {
+		item, convErr := c.ConvertRowToPublic(correlationId, qResult)
+		if convErr != nil {
+			return nil, convErr
+		}
+		if job, ok := item.(Job); ok {
+			result = append(result, &job)
+		}
+	}
+
+	if len(result) > 0 {
+		c.Logger.Trace(correlationId, "Claimed %d jobs from %s by %s", len(result), c.TableName, owner)
+	}
+	return result, qResult.Err()
+}
+
+// Extends the claim of a running job.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - id                an id of the job.
+//   - owner             a name of the worker that claimed the job.
+//   - lockTimeout       a new time the claim stays valid.
+// Returns error or ConflictError if the job is no longer claimed by the owner.
+func (c *JobQueuePostgresPersistence) Heartbeat(correlationId string, id string, owner string, lockTimeout time.Duration) error {
+	query := "UPDATE " + c.QuotedTableName() +
+		" SET \"locked_until\"=now()+$3*interval '1 millisecond'" +
+		" WHERE \"id\"=$1 AND \"locked_by\"=$2 AND \"status\"='" + JobStatusRunning + "'"
+	return c.execClaimed(correlationId, query, id, owner, int64(lockTimeout/time.Millisecond))
+}
+
+// Completes a claimed job. Recurring jobs are rescheduled for the next run.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - id                an id of the job.
+//   - owner             a name of the worker that claimed the job.
+// Returns error or ConflictError if the job is no longer claimed by the owner.
+func (c *JobQueuePostgresPersistence) Complete(correlationId string, id string, owner string) error {
+	query := "UPDATE " + c.QuotedTableName() +
+		" SET \"status\"=CASE WHEN \"interval\">0 THEN '" + JobStatusPending + "' ELSE '" + JobStatusCompleted + "' END," +
+		" \"run_at\"=CASE WHEN \"interval\">0 THEN GREATEST(\"run_at\"+\"interval\"*interval '1 millisecond', now()) ELSE \"run_at\" END," +
+		" \"attempts\"=CASE WHEN \"interval\">0 THEN 0 ELSE \"attempts\" END," +
+		" \"last_error\"=NULL, \"locked_by\"=NULL, \"locked_until\"=NULL, \"update_time\"=now()" +
+		" WHERE \"id\"=$1 AND \"locked_by\"=$2 AND \"status\"='" + JobStatusRunning + "'"
+	err := c.execClaimed(correlationId, query, id, owner)
+	if err == nil {
+		c.Logger.Trace(correlationId, "Completed job %s in %s", id, c.TableName)
+	}
+	return err
+}
+
+// Fails an attempt of a claimed job. The job is retried after a delay
+// unless it reached the maximum number of attempts.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - id                an id of the job.
+//   - owner             a name of the worker that claimed the job.
+//   - message           an error message of the attempt.
+//   - retryDelay        a delay before the next attempt.
+// Returns error or ConflictError if the job is no longer claimed by the owner.
+func (c *JobQueuePostgresPersistence) Fail(correlationId string, id string, owner string,
+	message string, retryDelay time.Duration) error {
+
+	query := "UPDATE " + c.QuotedTableName() +
+		" SET \"status\"=CASE WHEN \"attempts\">=\"max_attempts\" THEN '" + JobStatusFailed + "' ELSE '" + JobStatusPending + "' END," +
+		" \"run_at\"=now()+$4*interval '1 millisecond', \"last_error\"=$3," +
+		" \"locked_by\"=NULL, \"locked_until\"=NULL, \"update_time\"=now()" +
+		" WHERE \"id\"=$1 AND \"locked_by\"=$2 AND \"status\"='" + JobStatusRunning + "'"
+	err := c.execClaimed(correlationId, query, id, owner, message, int64(retryDelay/time.Millisecond))
+	if err == nil {
+		c.Logger.Trace(correlationId, "Failed attempt of job %s in %s: %s", id, c.TableName, message)
+	}
+	return err
+}
+
+func (c *JobQueuePostgresPersistence) execClaimed(correlationId string, query string, id string,
+	owner string, args ...interface{}) (err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkWritable(correlationId); err != nil {
+		return err
+	}
+	if err = c.checkNotDryRun(correlationId); err != nil {
+		return err
+	}
+
+	result, err := c.Client.Exec(ctx, query, append([]interface{}{id, owner}, args...)...)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return cerr.NewConflictError(correlationId, "JOB_NOT_CLAIMED",
+			"Job "+id+" is not claimed by "+owner).
+			WithDetails("id", id).
+			WithDetails("owner", owner)
+	}
+	return nil
+}
+
+func (c *JobQueuePostgresPersistence) queryJob(correlationId string, query string, args ...interface{}) (result *Job, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	qResult, qErr := c.Client.Query(ctx, query, args...)
+	if qErr != nil {
+		return nil, qErr
+	}
+	defer qResult.Close()
+
+	if !qResult.Next() {
+		if err := qResult.Err(); err != nil {
+			return nil, err
+		}
+		return nil, pgx.ErrNoRows
+	}
+	item, err := c.ConvertRowToPublic(correlationId, qResult)
+	if err != nil {
+		return nil, err
+	}
+	job, ok := item.(Job)
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return &job, nil
+}
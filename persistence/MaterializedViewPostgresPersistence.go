@@ -0,0 +1,215 @@
+package persistence
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+)
+
+/*
+Abstract read-only persistence component that reads data from a materialized view.
+It suits heavy reporting queries which results are precomputed by the view
+and refreshed on demand or by schedule.
+
+The view is created on opening by EnsureMaterializedView called in DefineSchema.
+Read operations are inherited from PostgresPersistence, while Create, DeleteByFilter
+and Clear return UnsupportedError, because materialized views cannot be changed directly.
+
+Concurrent refresh does not block readers, but it requires a unique index on the view
+that can be defined with EnsureIndex.
+
+### Configuration parameters ###
+
+- view:                        (optional) PostgreSQL materialized view name
+- schema:                      (optional) PostgreSQL schema, default "public"
+- connection(s):
+   - discovery_key:             (optional) a key to retrieve the connection from IDiscovery
+   - host:                      host name or IP address
+   - port:                      port number (default: 5432)
+   - uri:                       resource URI or connection string with all parameters in it
+- credential(s):
+   - store_key:                 (optional) a key to retrieve the credentials from ICredentialStore
+   - username:                  (optional) user name
+   - password:                  (optional) user password
+- options:
+   - refresh_interval:      (optional) interval in milliseconds to refresh the view, 0 to disable (default: 0)
+   - refresh_concurrently:  (optional) true to refresh the view by schedule concurrently (default: false)
+
+### References ###
+
+- \*:logger:\*:\*:1.0           (optional) ILogger components to pass log messages
+- \*:discovery:\*:\*:1.0        (optional) IDiscovery services
+- \*:credential-store:\*:\*:1.0 (optional) Credential stores to resolve credentials
+
+### Example ###
+
+    type SalesReportPersistence struct {
+        *persist.MaterializedViewPostgresPersistence
+    }
+
+    func NewSalesReportPersistence() *SalesReportPersistence {
+        c := &SalesReportPersistence{}
+        c.MaterializedViewPostgresPersistence = persist.InheritMaterializedViewPostgresPersistence(c,
+            reflect.TypeOf(SalesReport{}), "sales_report")
+        return c
+    }
+
+    func (c *SalesReportPersistence) DefineSchema() {
+        c.ClearSchema()
+        c.MaterializedViewPostgresPersistence.DefineSchema()
+        c.EnsureMaterializedView("SELECT \"region\", sum(\"amount\") AS \"total\" FROM \"sales\" GROUP BY \"region\"")
+        c.EnsureIndex("sales_report_region", map[string]string{"\"region\"": "1"}, map[string]string{"unique": "true"})
+    }
+
+    ...
+    err := persistence.Refresh("123", true)
+    page, err := persistence.GetPageByFilter("123", "", nil, "\"total\" DESC", nil)
+*/
+type MaterializedViewPostgresPersistence struct {
+	*PostgresPersistence
+
+	refreshInterval     time.Duration
+	refreshConcurrently bool
+	refreshStop         chan struct{}
+	refreshDone         sync.WaitGroup
+}
+
+// Creates a new instance of the persistence component.
+//   - overrides    references to override virtual methods
+//   - proto        a type of data items
+//   - viewName     (optional) a materialized view name.
+func InheritMaterializedViewPostgresPersistence(overrides IPostgresPersistenceOverrides, proto reflect.Type,
+	viewName string) *MaterializedViewPostgresPersistence {
+
+	c := &MaterializedViewPostgresPersistence{}
+	c.PostgresPersistence = InheritPostgresPersistence(overrides, proto, viewName)
+	return c
+}
+
+// Configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *MaterializedViewPostgresPersistence) Configure(config *cconf.ConfigParams) {
+	c.PostgresPersistence.Configure(config)
+
+	c.baseTableName = config.GetAsStringWithDefault("view", c.baseTableName)
+	c.TableName = c.tablePrefix + c.baseTableName + c.tableSuffix
+	c.refreshInterval = time.Duration(config.GetAsLongWithDefault("options.refresh_interval",
+		int64(c.refreshInterval/time.Millisecond))) * time.Millisecond
+	c.refreshConcurrently = config.GetAsBooleanWithDefault("options.refresh_concurrently", c.refreshConcurrently)
+}
+
+// Adds a statement to create the materialized view on opening.
+// The view is populated when it is created.
+//   - definition    a SELECT query of the view
+func (c *MaterializedViewPostgresPersistence) EnsureMaterializedView(definition string) {
+	c.EnsureSchema("CREATE MATERIALIZED VIEW IF NOT EXISTS " + c.QuotedTableName() + " AS " + definition)
+}
+
+// Opens the component and starts scheduled refresh of the view when options.refresh_interval is set.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+//   - Returns 			 error or nil no errors occured.
+func (c *MaterializedViewPostgresPersistence) Open(correlationId string) error {
+	if c.IsOpen() {
+		return nil
+	}
+
+	err := c.PostgresPersistence.Open(correlationId)
+	if err != nil {
+		return err
+	}
+
+	// Scheduled refreshes would fail in dry run mode
+	if c.refreshInterval > 0 && !c.dryRun {
+		c.refreshStop = make(chan struct{})
+		c.refreshDone.Add(1)
+		go c.refreshBySchedule(correlationId, c.refreshStop)
+	}
+	return nil
+}
+
+// Stops scheduled refresh of the view and closes the component.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+//   - Returns 			error or nil no errors occured.
+func (c *MaterializedViewPostgresPersistence) Close(correlationId string) error {
+	if c.refreshStop != nil {
+		close(c.refreshStop)
+		c.refreshDone.Wait()
+		c.refreshStop = nil
+	}
+	return c.PostgresPersistence.Close(correlationId)
+}
+
+// Refreshes data of the materialized view.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - concurrently      true to refresh without locking out readers, it requires a unique index on the view.
+// Returns error or nil for success.
+func (c *MaterializedViewPostgresPersistence) Refresh(correlationId string, concurrently bool) (err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkNotDryRun(correlationId); err != nil {
+		return err
+	}
+
+	query := "REFRESH MATERIALIZED VIEW "
+	if concurrently {
+		query += "CONCURRENTLY "
+	}
+	query += c.QuotedTableName()
+
+	_, err = c.Client.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	c.Logger.Trace(correlationId, "Refreshed materialized view %s", c.TableName)
+	return nil
+}
+
+func (c *MaterializedViewPostgresPersistence) refreshBySchedule(correlationId string, stop chan struct{}) {
+	defer c.refreshDone.Done()
+
+	ticker := time.NewTicker(c.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := c.Refresh(correlationId, c.refreshConcurrently); err != nil {
+				c.Logger.Error(correlationId, err, "Failed to refresh materialized view %s", c.TableName)
+			}
+		}
+	}
+}
+
+// Materialized views cannot be changed directly.
+// Returns UnsupportedError
+func (c *MaterializedViewPostgresPersistence) Create(correlationId string, item interface{}) (result interface{}, err error) {
+	return nil, c.newReadOnlyError(correlationId)
+}
+
+// Materialized views cannot be changed directly.
+// Returns UnsupportedError
+func (c *MaterializedViewPostgresPersistence) DeleteByFilter(correlationId string, filter string) (err error) {
+	return c.newReadOnlyError(correlationId)
+}
+
+// Materialized views cannot be changed directly, use Refresh to update data.
+// Returns UnsupportedError
+func (c *MaterializedViewPostgresPersistence) Clear(correlationId string) error {
+	return c.newReadOnlyError(correlationId)
+}
+
+func (c *MaterializedViewPostgresPersistence) newReadOnlyError(correlationId string) error {
+	return cerr.NewUnsupportedError(correlationId, "READ_ONLY",
+		"Materialized view "+c.TableName+" is read-only").
+		WithDetails("view", c.TableName)
+}
@@ -0,0 +1,560 @@
+package persistence
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cconv "github.com/pip-services3-go/pip-services3-commons-go/convert"
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+	cmpersist "github.com/pip-services3-go/pip-services3-data-go/persistence"
+)
+
+/*
+In-memory persistence with the method set of IdentifiablePostgresPersistence,
+to replace the real persistence in unit tests of business logic that shall run without a database.
+
+A persistence implementation embeds MockPostgresPersistence instead of IdentifiablePostgresPersistence
+and is registered in references in place of the real one. Items are stored as copies in public format.
+
+Filters can be passed as func(item interface{}) bool. SQL filters passed as strings are evaluated
+by FilterMatcher, without it they are rejected with UnsupportedError. Sort parameters are
+comma separated field names with optional ASC or DESC, like ORDER BY clauses. Projections are ignored.
+
+Configuration parameters:
+
+- options:
+   - max_page_size:        (optional) maximum number of items returned in a single page (default: 100)
+
+References:
+
+- *:logger:*:*:1.0           (optional) ILogger components to pass log messages
+
+Example:
+
+    type MyMockPersistence struct {
+        *persist.MockPostgresPersistence
+    }
+
+    func NewMyMockPersistence() *MyMockPersistence {
+        return &MyMockPersistence{persist.NewMockPostgresPersistence(reflect.TypeOf(MyData{}))}
+    }
+
+    func (c *MyMockPersistence) GetPageByName(correlationId string, name string, paging *cdata.PagingParams) (*cdata.DataPage, error) {
+        return c.GetPageByFilter(correlationId, func(item interface{}) bool {
+            return item.(MyData).Name == name
+        }, paging, "name", nil)
+    }
+*/
+type MockPostgresPersistence struct {
+	// Type of stored data items
+	Prototype reflect.Type
+	// The logger
+	Logger *clog.CompositeLogger
+	// The maximum number of items returned in a single page
+	MaxPageSize int
+	// Evaluates SQL filters passed as strings, e.g. by parsing simple conditions used by the tested code
+	FilterMatcher func(filter string, item interface{}) bool
+
+	lock   sync.RWMutex
+	items  []interface{}
+	opened bool
+}
+
+// Creates a new instance of the in-memory persistence.
+//   - proto     a type of data items
+// Returns a new instance of the persistence.
+func NewMockPostgresPersistence(proto reflect.Type) *MockPostgresPersistence {
+	return &MockPostgresPersistence{
+		Prototype:   proto,
+		Logger:      clog.NewCompositeLogger(),
+		MaxPageSize: 100,
+		items:       make([]interface{}, 0),
+	}
+}
+
+// Configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *MockPostgresPersistence) Configure(config *cconf.ConfigParams) {
+	c.MaxPageSize = config.GetAsIntegerWithDefault("options.max_page_size", c.MaxPageSize)
+}
+
+// Sets references to dependent components.
+//   - references 	references to locate the component dependencies.
+func (c *MockPostgresPersistence) SetReferences(references cref.IReferences) {
+	c.Logger.SetReferences(references)
+}
+
+// Unsets (clears) previously set references to dependent components.
+func (c *MockPostgresPersistence) UnsetReferences() {
+}
+
+// Checks if the component is opened.
+// Returns true if the component has been opened and false otherwise.
+func (c *MockPostgresPersistence) IsOpen() bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.opened
+}
+
+// Opens the component.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns error or nil no errors occured.
+func (c *MockPostgresPersistence) Open(correlationId string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.opened = true
+	return nil
+}
+
+// Closes component. Stored items are kept until Clear is called.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns error or nil no errors occured.
+func (c *MockPostgresPersistence) Close(correlationId string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.opened = false
+	return nil
+}
+
+// Clears all stored items.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns error or nil no errors occured.
+func (c *MockPostgresPersistence) Clear(correlationId string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.items = make([]interface{}, 0)
+	return nil
+}
+
+// Gets a page of data items retrieved by a given filter and sorted according to sort parameters.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - filter            (optional) a filter function or SQL condition
+//   - paging            (optional) paging parameters
+//   - sort              (optional) comma separated fields with optional ASC or DESC
+//   - select            (optional) projection, it is ignored
+// Returns a data page or error.
+func (c *MockPostgresPersistence) GetPageByFilter(correlationId string, filter interface{}, paging *cdata.PagingParams,
+	sort interface{}, sel interface{}) (*cdata.DataPage, error) {
+	items, err := c.GetListByFilter(correlationId, filter, sort, sel)
+	if err != nil {
+		return nil, err
+	}
+
+	if paging == nil {
+		paging = cdata.NewEmptyPagingParams()
+	}
+	skip := paging.GetSkip(-1)
+	take := paging.GetTake(int64(c.MaxPageSize))
+
+	var total *int64
+	if paging.Total {
+		count := int64(len(items))
+		total = &count
+	}
+	if skip > 0 {
+		if skip > int64(len(items)) {
+			skip = int64(len(items))
+		}
+		items = items[skip:]
+	}
+	if take < int64(len(items)) {
+		items = items[:take]
+	}
+
+	c.Logger.Trace(correlationId, "Retrieved %d items", len(items))
+	return cdata.NewDataPage(total, items), nil
+}
+
+// Gets a list of data items retrieved by a given filter and sorted according to sort parameters.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - filter            (optional) a filter function or SQL condition
+//   - sort              (optional) comma separated fields with optional ASC or DESC
+//   - select            (optional) projection, it is ignored
+// Returns a data list or error.
+func (c *MockPostgresPersistence) GetListByFilter(correlationId string, filter interface{}, sort interface{},
+	sel interface{}) ([]interface{}, error) {
+	items, err := c.filterItems(correlationId, filter)
+	if err != nil {
+		return nil, err
+	}
+	if srt, ok := sort.(string); ok && srt != "" {
+		c.sortItems(items, srt)
+	}
+
+	result := make([]interface{}, len(items))
+	for index, item := range items {
+		result[index] = c.cloneItem(item)
+	}
+	return result, nil
+}
+
+// Gets a number of data items retrieved by a given filter.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - filter            (optional) a filter function or SQL condition
+// Returns a number of items or error.
+func (c *MockPostgresPersistence) GetCountByFilter(correlationId string, filter interface{}) (int64, error) {
+	items, err := c.filterItems(correlationId, filter)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(items)), nil
+}
+
+// Gets a random item from items that match to a given filter.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - filter            (optional) a filter function or SQL condition
+// Returns a random item, nil if no items match, or error.
+func (c *MockPostgresPersistence) GetOneRandom(correlationId string, filter interface{}) (interface{}, error) {
+	items, err := c.filterItems(correlationId, filter)
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+	return c.cloneItem(items[rand.Intn(len(items))]), nil
+}
+
+// Gets a list of data items retrieved by given unique ids.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - ids               ids of data items to be retrieved
+// Returns a data list or error.
+func (c *MockPostgresPersistence) GetListByIds(correlationId string, ids []interface{}) ([]interface{}, error) {
+	matched := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		matched[cconv.StringConverter.ToString(id)] = true
+	}
+	return c.GetListByFilter(correlationId, func(item interface{}) bool {
+		return matched[c.itemId(item)]
+	}, nil, nil)
+}
+
+// Gets a data item by its unique id.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - id                an id of data item to be retrieved.
+// Returns the found item, nil if it was not found, or error.
+func (c *MockPostgresPersistence) GetOneById(correlationId string, id interface{}) (interface{}, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if index := c.indexOf(id); index >= 0 {
+		return c.cloneItem(c.items[index]), nil
+	}
+	return nil, nil
+}
+
+// Creates a data item. Ids of items without ids are generated.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - item              an item to be created.
+// Returns the created item or error.
+func (c *MockPostgresPersistence) Create(correlationId string, item interface{}) (interface{}, error) {
+	if item == nil {
+		return nil, nil
+	}
+
+	newItem := cmpersist.CloneObject(item, c.Prototype)
+	cmpersist.GenerateObjectId(&newItem)
+	newItem = c.cloneItem(newItem)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	id := c.itemId(newItem)
+	if c.indexOf(id) >= 0 {
+		return nil, cerr.NewConflictError(correlationId, "DUPLICATE_ID", "Item with id "+id+" already exists").
+			WithDetails("id", id)
+	}
+	c.items = append(c.items, newItem)
+
+	c.Logger.Trace(correlationId, "Created item %s", id)
+	return c.cloneItem(newItem), nil
+}
+
+// Sets a data item. If the data item exists it updates it, otherwise it creates a new data item.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - item              an item to be set.
+// Returns the set item or error.
+func (c *MockPostgresPersistence) Set(correlationId string, item interface{}) (interface{}, error) {
+	if item == nil {
+		return nil, nil
+	}
+
+	newItem := cmpersist.CloneObject(item, c.Prototype)
+	cmpersist.GenerateObjectId(&newItem)
+	newItem = c.cloneItem(newItem)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	id := c.itemId(newItem)
+	if index := c.indexOf(id); index >= 0 {
+		c.items[index] = newItem
+	} else {
+		c.items = append(c.items, newItem)
+	}
+
+	c.Logger.Trace(correlationId, "Set item %s", id)
+	return c.cloneItem(newItem), nil
+}
+
+// Updates a data item.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - item              an item to be updated.
+// Returns the updated item, nil if it was not found, or error.
+func (c *MockPostgresPersistence) Update(correlationId string, item interface{}) (interface{}, error) {
+	if item == nil {
+		return nil, nil
+	}
+	newItem := c.cloneItem(item)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	id := c.itemId(newItem)
+	index := c.indexOf(id)
+	if index < 0 {
+		return nil, nil
+	}
+	c.items[index] = newItem
+
+	c.Logger.Trace(correlationId, "Updated item %s", id)
+	return c.cloneItem(newItem), nil
+}
+
+// Updates only few selected fields in a data item.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - id                an id of data item to be updated.
+//   - data              a map with fields to be updated.
+// Returns the updated item, nil if it was not found, or error.
+func (c *MockPostgresPersistence) UpdatePartially(correlationId string, id interface{},
+	data *cdata.AnyValueMap) (interface{}, error) {
+	if id == nil || data == nil {
+		return nil, nil
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	index := c.indexOf(id)
+	if index < 0 {
+		return nil, nil
+	}
+	values := mockItemToMap(c.items[index])
+	for key, value := range data.Value() {
+		values[key] = value
+	}
+	c.items[index] = c.cloneItem(values)
+
+	c.Logger.Trace(correlationId, "Partially updated item %s", id)
+	return c.cloneItem(c.items[index]), nil
+}
+
+// Deletes a data item by its unique id.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - id                an id of the item to be deleted
+// Returns the deleted item, nil if it was not found, or error.
+func (c *MockPostgresPersistence) DeleteById(correlationId string, id interface{}) (interface{}, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	index := c.indexOf(id)
+	if index < 0 {
+		return nil, nil
+	}
+	item := c.items[index]
+	c.items = append(c.items[:index], c.items[index+1:]...)
+
+	c.Logger.Trace(correlationId, "Deleted item %s", id)
+	return item, nil
+}
+
+// Deletes multiple data items by their unique ids.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - ids               ids of data items to be deleted.
+// Returns error or nil for success.
+func (c *MockPostgresPersistence) DeleteByIds(correlationId string, ids []interface{}) error {
+	matched := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		matched[cconv.StringConverter.ToString(id)] = true
+	}
+	return c.deleteItems(correlationId, func(item interface{}) bool {
+		return matched[c.itemId(item)]
+	})
+}
+
+// Deletes data items that match to a given filter.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - filter            (optional) a SQL condition evaluated by FilterMatcher
+// Returns error or nil for success.
+func (c *MockPostgresPersistence) DeleteByFilter(correlationId string, filter string) error {
+	matches, err := c.filterMatcher(correlationId, filter)
+	if err != nil {
+		return err
+	}
+	return c.deleteItems(correlationId, matches)
+}
+
+func (c *MockPostgresPersistence) deleteItems(correlationId string, matches func(item interface{}) bool) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	kept := make([]interface{}, 0, len(c.items))
+	for _, item := range c.items {
+		if !matches(item) {
+			kept = append(kept, item)
+		}
+	}
+	deleted := len(c.items) - len(kept)
+	c.items = kept
+
+	c.Logger.Trace(correlationId, "Deleted %d items", deleted)
+	return nil
+}
+
+// Converts a filter into a function that matches items
+func (c *MockPostgresPersistence) filterMatcher(correlationId string, filter interface{}) (func(item interface{}) bool, error) {
+	switch flt := filter.(type) {
+	case nil:
+		return func(interface{}) bool { return true }, nil
+	case func(item interface{}) bool:
+		return flt, nil
+	case string:
+		if flt == "" {
+			return func(interface{}) bool { return true }, nil
+		}
+		if c.FilterMatcher != nil {
+			return func(item interface{}) bool { return c.FilterMatcher(flt, item) }, nil
+		}
+	}
+	return nil, cerr.NewUnsupportedError(correlationId, "FILTER_NOT_SUPPORTED",
+		"Filter "+fmt.Sprint(filter)+" is not supported by the mock persistence")
+}
+
+// Gets stored items that match to a filter
+func (c *MockPostgresPersistence) filterItems(correlationId string, filter interface{}) ([]interface{}, error) {
+	matches, err := c.filterMatcher(correlationId, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	items := make([]interface{}, 0)
+	for _, item := range c.items {
+		if matches(item) {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// Sorts items by comma separated fields with optional ASC or DESC
+func (c *MockPostgresPersistence) sortItems(items []interface{}, sortFields string) {
+	keys := strings.Split(sortFields, ",")
+	fields := make([]string, len(keys))
+	descending := make([]bool, len(keys))
+	for index, key := range keys {
+		fields[index], descending[index] = parseSortKey(key)
+		fields[index] = strings.Trim(fields[index], "\"")
+	}
+
+	values := make([]map[string]interface{}, len(items))
+	for index, item := range items {
+		values[index] = mockItemToMap(item)
+	}
+	order := make([]int, len(items))
+	for index := range order {
+		order[index] = index
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		for index, field := range fields {
+			result := compareMockValues(values[order[i]][field], values[order[j]][field])
+			if result != 0 {
+				return (result < 0) != descending[index]
+			}
+		}
+		return false
+	})
+
+	sorted := make([]interface{}, len(items))
+	for index, position := range order {
+		sorted[index] = items[position]
+	}
+	copy(items, sorted)
+}
+
+// Finds the position of a stored item by id, it shall be called under the lock
+func (c *MockPostgresPersistence) indexOf(id interface{}) int {
+	key := cconv.StringConverter.ToString(id)
+	for index, item := range c.items {
+		if c.itemId(item) == key {
+			return index
+		}
+	}
+	return -1
+}
+
+// Gets an id of an item as a string
+func (c *MockPostgresPersistence) itemId(item interface{}) string {
+	return cconv.StringConverter.ToString(cmpersist.GetObjectId(item))
+}
+
+// Copies an item into a new object of the prototype
+func (c *MockPostgresPersistence) cloneItem(item interface{}) interface{} {
+	buffer, err := json.Marshal(item)
+	if err != nil {
+		return item
+	}
+	proto := c.Prototype
+	if proto.Kind() == reflect.Ptr {
+		proto = proto.Elem()
+	}
+	pointer := reflect.New(proto)
+	if err = json.Unmarshal(buffer, pointer.Interface()); err != nil {
+		return item
+	}
+	if c.Prototype.Kind() == reflect.Ptr {
+		return pointer.Interface()
+	}
+	return pointer.Elem().Interface()
+}
+
+// Converts an item into a map of json fields
+func mockItemToMap(item interface{}) map[string]interface{} {
+	values := make(map[string]interface{})
+	if buffer, err := json.Marshal(item); err == nil {
+		json.Unmarshal(buffer, &values)
+	}
+	return values
+}
+
+// Compares values of json fields: nulls go first, numbers and strings are compared by value
+func compareMockValues(a interface{}, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	if x, ok := a.(float64); ok {
+		if y, ok := b.(float64); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
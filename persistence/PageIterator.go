@@ -0,0 +1,141 @@
+package persistence
+
+/*
+Iterator over data pages retrieved by a filter. While the caller processes
+the current page the iterator prefetches the next one in background,
+which smooths latency in export and synchronization loops.
+
+### Example ###
+
+    iterator := persistence.NewPageIterator("123", "key LIKE 'A%'", "id", 100)
+    defer iterator.Close()
+    for iterator.Next() {
+        for _, item := range iterator.Page() {
+            ...
+        }
+    }
+    if err := iterator.Err(); err != nil {
+        ...
+    }
+*/
+type PageIterator struct {
+	persistence   *PostgresPersistence
+	correlationId string
+	filter        interface{}
+	sort          interface{}
+	pageSize      int64
+	skip          int64
+	total         int64
+
+	prefetch chan pageIteratorResult
+	page     []interface{}
+	err      error
+	finished bool
+}
+
+type pageIteratorResult struct {
+	items   []interface{}
+	skipped int
+	total   *int64
+	err     error
+}
+
+// Creates a new iterator over data pages retrieved by a given filter and sorted according to sort parameters.
+// To get consistent results the sort must define a stable order of items.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - filter            (optional) a filter JSON object
+//   - sort              (optional) sorting JSON object
+//   - pageSize          a number of items in a page, limited by MaxPageSize (default: MaxPageSize)
+// Returns a new page iterator.
+func (c *PostgresPersistence) NewPageIterator(correlationId string, filter interface{}, sort interface{}, pageSize int64) *PageIterator {
+	// Pages are limited by MaxPageSize, so larger sizes would skip items between them
+	if pageSize <= 0 || (c.MaxPageSize > 0 && pageSize > int64(c.MaxPageSize)) {
+		pageSize = int64(c.MaxPageSize)
+	}
+
+	iterator := &PageIterator{
+		persistence:   c,
+		correlationId: correlationId,
+		filter:        filter,
+		sort:          sort,
+		pageSize:      pageSize,
+		total:         -1,
+	}
+	iterator.fetchNext()
+	return iterator
+}
+
+func (c *PageIterator) fetchNext() {
+	prefetch := make(chan pageIteratorResult, 1)
+	c.prefetch = prefetch
+
+	// The exact total is counted with the first page only, approximate ones could end iteration too early
+	paging := NewPostgresPagingParams(c.skip, c.pageSize, c.skip == 0, CountStrategyExact)
+	c.skip += c.pageSize
+
+	go func() {
+		page, err := c.persistence.GetPageByFilterWithPaging(c.correlationId, c.filter, paging, c.sort, nil)
+		result := pageIteratorResult{err: err}
+		if page != nil {
+			result.items = page.Data
+			result.skipped = page.Skipped
+			result.total = page.Total
+		}
+		prefetch <- result
+	}()
+}
+
+// Advances the iterator to the next page and starts prefetching the page after it.
+// Returns true if the next page was retrieved or false when there are no more pages or an error occured.
+func (c *PageIterator) Next() bool {
+	if c.finished || c.prefetch == nil {
+		c.page = nil
+		return false
+	}
+
+	result := <-c.prefetch
+	c.prefetch = nil
+
+	if result.err != nil {
+		c.err = result.err
+		c.finished = true
+		c.page = nil
+		return false
+	}
+
+	c.page = result.items
+	if result.total != nil {
+		c.total = *result.total
+	}
+	// Pages can be shorter than requested when rows are skipped, so only an empty page or the total ends iteration
+	if len(result.items)+result.skipped == 0 || (c.total >= 0 && c.skip >= c.total) {
+		c.finished = true
+	} else {
+		c.fetchNext()
+	}
+
+	// Pages with all rows skipped are passed over
+	if len(c.page) == 0 && !c.finished {
+		return c.Next()
+	}
+	return len(c.page) > 0
+}
+
+// Gets items of the current page.
+// Returns a list of data items
+func (c *PageIterator) Page() []interface{} {
+	return c.page
+}
+
+// Gets an error occured during iteration.
+// Returns error or nil if no errors occured.
+func (c *PageIterator) Err() error {
+	return c.err
+}
+
+// Stops the iteration. A prefetched page that is still loading is discarded.
+func (c *PageIterator) Close() {
+	c.finished = true
+	c.prefetch = nil
+	c.page = nil
+}
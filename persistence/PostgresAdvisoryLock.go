@@ -0,0 +1,118 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+)
+
+// Acquires a session advisory lock scoped to the table of the persistence, to serialize critical sections
+// like counters or data migrations across processes without the PostgresLock component.
+// The lock holds a dedicated connection from the pool until it is released by ReleaseAdvisoryLock or Close.
+// Locks are not reentrant: acquiring a held key again waits until it is released.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - key               a lock key, unique within the table.
+//   - timeout           a lock acquisition timeout in milliseconds, 0 to wait within the operation timeout.
+// Returns error or nil when the lock was acquired. ConflictError is returned on timeout.
+func (c *PostgresPersistence) AcquireAdvisoryLock(correlationId string, key string, timeout int64) (err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Millisecond)
+		defer cancel()
+	}
+
+	connection, err := c.AcquireConnection(ctx, c.Client)
+	if err != nil {
+		return err
+	}
+
+	_, err = connection.Exec(ctx, "SELECT pg_advisory_lock(hashtext($1))", c.advisoryLockKey(key))
+	if err != nil {
+		connection.Release()
+		if timeout > 0 && ctx.Err() == context.DeadlineExceeded {
+			return cerr.NewConflictError(correlationId, "LOCK_TIMEOUT",
+				"Acquiring lock "+key+" on "+c.TableName+" failed on timeout").
+				WithDetails("key", key).WithCause(err)
+		}
+		return err
+	}
+
+	c.advisoryLocks.Store(key, connection)
+	c.Logger.Trace(correlationId, "Acquired advisory lock %s on %s", key, c.TableName)
+	return nil
+}
+
+// Makes a single attempt to acquire a session advisory lock scoped to the table of the persistence.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - key               a lock key, unique within the table.
+// Returns true if the lock was acquired, false if it is held by another session, or error.
+func (c *PostgresPersistence) TryAcquireAdvisoryLock(correlationId string, key string) (result bool, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	connection, err := c.AcquireConnection(ctx, c.Client)
+	if err != nil {
+		return false, err
+	}
+
+	err = connection.QueryRow(ctx, "SELECT pg_try_advisory_lock(hashtext($1))", c.advisoryLockKey(key)).Scan(&result)
+	if err != nil || !result {
+		connection.Release()
+		return false, err
+	}
+
+	c.advisoryLocks.Store(key, connection)
+	c.Logger.Trace(correlationId, "Acquired advisory lock %s on %s", key, c.TableName)
+	return true, nil
+}
+
+// Releases a session advisory lock acquired by AcquireAdvisoryLock or TryAcquireAdvisoryLock.
+// Keys that are not held by the persistence are ignored.
+// If unlocking fails, the connection that holds the lock is closed, which releases it on the server.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - key               a key of the lock to be released.
+// Returns error or nil no errors occured.
+func (c *PostgresPersistence) ReleaseAdvisoryLock(correlationId string, key string) error {
+	value, held := c.advisoryLocks.LoadAndDelete(key)
+	if !held {
+		return nil
+	}
+	connection := value.(*pgxpool.Conn)
+
+	// The lock is released on the connection that holds it, even if the persistence is closing
+	_, err := connection.Exec(context.Background(), "SELECT pg_advisory_unlock(hashtext($1))", c.advisoryLockKey(key))
+	if err != nil {
+		// The lock may still be held by the session, so its connection is closed instead of returning to the pool
+		closeAcquiredConnection(connection)
+		return err
+	}
+	connection.Release()
+	c.Logger.Trace(correlationId, "Released advisory lock %s on %s", key, c.TableName)
+	return nil
+}
+
+// Releases all advisory locks held by the persistence
+func (c *PostgresPersistence) releaseAdvisoryLocks(correlationId string) {
+	c.advisoryLocks.Range(func(key, value interface{}) bool {
+		if err := c.ReleaseAdvisoryLock(correlationId, key.(string)); err != nil {
+			c.Logger.Error(correlationId, err, "Failed to release advisory lock %s on %s", key, c.TableName)
+		}
+		return true
+	})
+}
+
+// Gets a key of an advisory lock in the namespace of the table
+func (c *PostgresPersistence) advisoryLockKey(key string) string {
+	return c.QuotedTableName() + ":" + key
+}
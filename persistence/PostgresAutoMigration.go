@@ -0,0 +1,262 @@
+package persistence
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Column definition generated from a prototype struct field.
+type PostgresColumn struct {
+	// Column name
+	Name string
+	// PostgreSQL column type
+	Type string
+	// True if the column is declared NOT NULL
+	NotNull bool
+	// True if the column is the primary key
+	PrimaryKey bool
+	// True if the column shall be indexed
+	Index bool
+	// True if the column values shall be unique
+	Unique bool
+	// True if the column is generated and is added by EnsureGeneratedColumn
+	Generated bool
+	// Default value expression of the column
+	Default string
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Generates column definitions from prototype struct fields.
+// Column names are taken from json tags, like the rest of the persistence does.
+// The postgres tag can override the name and set column options, for instance:
+//
+//     Key string `json:"key" postgres:"type=VARCHAR(64),notnull,unique"`
+//     Tags []string `json:"tags" postgres:"tags,index"`
+//     Created time.Time `json:"created" postgres:"notnull,default=now()"`
+//
+// Supported options are type, notnull, primarykey, index, unique, generated and default.
+// Default expressions cannot contain commas, use EnsureColumnDefault for such expressions.
+// Field named "id" becomes the primary key when no other field is marked as primary key.
+// Fields with postgres:"-" or json:"-" tags are skipped.
+//   - proto   a prototype struct type.
+// Returns generated column definitions.
+func GetPrototypeColumns(proto reflect.Type) []*PostgresColumn {
+	for proto.Kind() == reflect.Ptr {
+		proto = proto.Elem()
+	}
+	if proto.Kind() != reflect.Struct {
+		return []*PostgresColumn{}
+	}
+
+	columns := collectPrototypeColumns(proto)
+
+	hasPrimaryKey := false
+	for _, column := range columns {
+		hasPrimaryKey = hasPrimaryKey || column.PrimaryKey
+	}
+	if !hasPrimaryKey {
+		for _, column := range columns {
+			if column.Name == "id" {
+				column.PrimaryKey = true
+			}
+		}
+	}
+	return columns
+}
+
+func collectPrototypeColumns(proto reflect.Type) []*PostgresColumn {
+	columns := make([]*PostgresColumn, 0, proto.NumField())
+	for i := 0; i < proto.NumField(); i++ {
+		field := proto.Field(i)
+		jsonTag := field.Tag.Get("json")
+		pgTag := field.Tag.Get("postgres")
+		if jsonTag == "-" || pgTag == "-" {
+			continue
+		}
+
+		jsonName := strings.Split(jsonTag, ",")[0]
+		// Embedded structs are flattened the same way as JSON encoding does
+		if field.Anonymous && jsonName == "" && field.Type.Kind() == reflect.Struct {
+			columns = append(columns, collectPrototypeColumns(field.Type)...)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+
+		column := &PostgresColumn{Name: jsonName}
+		if column.Name == "" {
+			column.Name = field.Name
+		}
+
+		for index, option := range strings.Split(pgTag, ",") {
+			option = strings.TrimSpace(option)
+			switch {
+			case option == "":
+			case strings.HasPrefix(option, "type="):
+				column.Type = option[len("type="):]
+			case strings.HasPrefix(option, "default="):
+				column.Default = option[len("default="):]
+			case option == "notnull":
+				column.NotNull = true
+			case option == "primarykey":
+				column.PrimaryKey = true
+			case option == "index":
+				column.Index = true
+			case option == "unique":
+				column.Unique = true
+			case option == "generated":
+				column.Generated = true
+			case index == 0:
+				column.Name = option
+			}
+		}
+
+		if column.Type == "" {
+			column.Type = getColumnType(field.Type)
+		}
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+func getColumnType(fieldType reflect.Type) string {
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	if fieldType == timeType {
+		return "TIMESTAMPTZ"
+	}
+
+	switch fieldType.Kind() {
+	case reflect.String:
+		return "TEXT"
+	case reflect.Bool:
+		return "BOOLEAN"
+	case reflect.Int8, reflect.Int16, reflect.Uint8:
+		return "SMALLINT"
+	case reflect.Int32, reflect.Uint16:
+		return "INTEGER"
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return "BIGINT"
+	case reflect.Float32:
+		return "REAL"
+	case reflect.Float64:
+		return "DOUBLE PRECISION"
+	case reflect.Slice:
+		if fieldType.Elem().Kind() == reflect.Uint8 {
+			return "BYTEA"
+		}
+	}
+	return "JSONB"
+}
+
+func (c *PostgresPersistence) getColumnDefinition(column *PostgresColumn) string {
+	definition := c.QuoteIdentifier(c.GetColumnName(column.Name)) + " " + column.Type
+	if column.Default != "" {
+		definition += " DEFAULT " + column.Default
+	}
+	if column.PrimaryKey {
+		definition += " PRIMARY KEY"
+	} else if column.NotNull {
+		definition += " NOT NULL"
+	}
+	return definition
+}
+
+func (c *PostgresPersistence) getColumnIndexStatement(column *PostgresColumn) string {
+	statement := "CREATE"
+	if column.Unique {
+		statement += " UNIQUE"
+	}
+	name := c.GetColumnName(column.Name)
+	return statement + " INDEX IF NOT EXISTS " + c.QuoteIdentifier(c.TableName+"_"+name) +
+		" ON " + c.QuotedTableName() + " (" + c.QuoteIdentifier(name) + ")"
+}
+
+// Adds statements to schema definition that create the table and indexes
+// from the prototype struct fields. See GetPrototypeColumns for supported tags.
+// Fields tagged as generated are skipped, their columns are added by EnsureGeneratedColumn.
+func (c *PostgresPersistence) EnsureTableFromPrototype() {
+	columns := GetPrototypeColumns(c.Prototype)
+
+	definitions := make([]string, 0, len(columns))
+	for _, column := range columns {
+		if !column.Generated {
+			definitions = append(definitions, c.getColumnDefinition(column))
+		}
+	}
+	c.EnsureSchema("CREATE TABLE IF NOT EXISTS " + c.QuotedTableName() + " (" + strings.Join(definitions, ", ") + ")")
+
+	for _, column := range columns {
+		if !column.PrimaryKey && (column.Index || column.Unique) {
+			c.EnsureSchema(c.getColumnIndexStatement(column))
+		}
+	}
+}
+
+// Compares the live table with the prototype struct fields and adds missing columns and indexes.
+// Existing columns are never changed or dropped. New NOT NULL columns are added as nullable
+// because existing rows have no values for them.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns error or nil no errors occured.
+func (c *PostgresPersistence) AutoMigrate(correlationId string) error {
+	schemaName := c.currentSchemaName()
+
+	query := "SELECT column_name FROM information_schema.columns WHERE table_schema=$1 AND table_name=$2"
+	qResult, err := c.Client.Query(c.schemaContext(), query, schemaName, c.TableName)
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]bool)
+	for qResult.Next() {
+		var name string
+		if err = qResult.Scan(&name); err != nil {
+			break
+		}
+		existing[name] = true
+	}
+	qResult.Close()
+	if err == nil {
+		err = qResult.Err()
+	}
+	if err != nil {
+		return err
+	}
+
+	// Table does not exist yet, it shall be created by schema definition
+	if len(existing) == 0 {
+		return nil
+	}
+
+	for _, column := range GetPrototypeColumns(c.Prototype) {
+		name := c.GetColumnName(column.Name)
+		if !existing[name] {
+			columnType := column.Type
+			if column.Generated {
+				generated, ok := c.generatedColumns[name]
+				if !ok {
+					continue
+				}
+				columnType = generated
+			} else if column.Default != "" {
+				columnType += " DEFAULT " + column.Default
+			}
+			statement := "ALTER TABLE " + c.QuotedTableName() + " ADD COLUMN IF NOT EXISTS " +
+				c.QuoteIdentifier(name) + " " + columnType
+			if _, err = c.Client.Exec(c.schemaContext(), statement); err != nil {
+				return err
+			}
+			c.Logger.Info(correlationId, "Added column %s to %s", name, c.TableName)
+		}
+
+		if !column.PrimaryKey && (column.Index || column.Unique) {
+			if _, err = c.Client.Exec(c.schemaContext(), c.getColumnIndexStatement(column)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package persistence
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+)
+
+// Result of a statement queued in PostgresBatch. It is set when the batch is sent.
+type PostgresBatchResult struct {
+	// Item returned by QueueGetOneById, nil if it was not found
+	Item interface{}
+	// Items returned by QueueQuery
+	Items []interface{}
+	// Number of rows affected by QueueExecNonQuery
+	Count int64
+	// Error of the statement
+	Err error
+}
+
+/*
+Statements that are sent to the database in one round trip, to reduce latency of handlers
+that make many small calls. Statements are queued by persistence components, e.g. QueueGetOneById,
+and their results are set when the batch is sent by SendBatch of any persistence that shares the connection.
+
+Example:
+
+    batch := persist.NewPostgresBatch()
+    user := users.QueueGetOneById(batch, "123", userId)
+    account := accounts.QueueGetOneById(batch, "123", accountId)
+    visits := users.QueueExecNonQuery(batch, "123", "UPDATE users SET visits=visits+1 WHERE id=$1", userId)
+
+    err := users.SendBatch("123", batch)
+    if err == nil {
+        fmt.Println(user.Item, account.Item, visits.Count)
+    }
+*/
+type PostgresBatch struct {
+	batch *pgx.Batch
+	items []*postgresBatchItem
+}
+
+type postgresBatchItem struct {
+	result *PostgresBatchResult
+	handle func(rows pgx.Rows, result *PostgresBatchResult) error
+}
+
+// Creates a new empty batch.
+func NewPostgresBatch() *PostgresBatch {
+	return &PostgresBatch{
+		batch: &pgx.Batch{},
+		items: make([]*postgresBatchItem, 0),
+	}
+}
+
+// Gets a number of queued statements.
+func (c *PostgresBatch) Len() int {
+	return len(c.items)
+}
+
+// Queues a statement with a handler of its rows
+func (c *PostgresBatch) queue(query string, args []interface{},
+	handle func(rows pgx.Rows, result *PostgresBatchResult) error) *PostgresBatchResult {
+	result := &PostgresBatchResult{}
+	c.batch.Queue(query, args...)
+	c.items = append(c.items, &postgresBatchItem{result: result, handle: handle})
+	return result
+}
+
+// Client that sends batches, like pgxpool.Pool
+type postgresBatchSender interface {
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+// Queues a query that returns data items, see GetListByFilter.
+//   - batch             a batch to queue the query in.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - query             a SELECT query with positional parameters like $1
+//   - args              values of the parameters
+// Returns a result that receives converted items when the batch is sent.
+func (c *PostgresPersistence) QueueQuery(batch *PostgresBatch, correlationId string, query string,
+	args ...interface{}) *PostgresBatchResult {
+	return batch.queue(query, args, func(rows pgx.Rows, result *PostgresBatchResult) error {
+		result.Items = make([]interface{}, 0)
+		for rows.Next() {
+			item, err := c.ConvertRowToPublic(correlationId, rows)
+			if err != nil {
+				return err
+			}
+			if item != nil {
+				result.Items = append(result.Items, item)
+			}
+		}
+		return rows.Err()
+	})
+}
+
+// Queues a statement that changes data, see ExecNonQuery.
+//   - batch             a batch to queue the statement in.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - query             a SQL statement with positional parameters like $1
+//   - args              values of the parameters
+// Returns a result that receives a number of affected rows when the batch is sent.
+// The result gets an error without queuing the statement when the persistence is read-only or in dry run mode.
+func (c *PostgresPersistence) QueueExecNonQuery(batch *PostgresBatch, correlationId string, query string,
+	args ...interface{}) *PostgresBatchResult {
+	if err := c.checkReadOnly(correlationId); err != nil {
+		return &PostgresBatchResult{Err: err}
+	}
+	if err := c.checkNotDryRun(correlationId); err != nil {
+		return &PostgresBatchResult{Err: err}
+	}
+	return batch.queue(query, args, func(rows pgx.Rows, result *PostgresBatchResult) error {
+		rows.Close()
+		result.Count = rows.CommandTag().RowsAffected()
+		return rows.Err()
+	})
+}
+
+// Queues reading of a data item by its unique id, see GetOneById.
+//   - batch             a batch to queue the query in.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - id                an id of data item to be retrieved.
+// Returns a result that receives the found item when the batch is sent.
+func (c *IdentifiablePostgresPersistence) QueueGetOneById(batch *PostgresBatch, correlationId string,
+	id interface{}) *PostgresBatchResult {
+	query := "SELECT * FROM " + c.QuotedTableName() + " WHERE \"id\"=$1" + c.andScopeCondition()
+	return batch.queue(query, []interface{}{id}, func(rows pgx.Rows, result *PostgresBatchResult) error {
+		if !rows.Next() {
+			return rows.Err()
+		}
+		item, err := c.ConvertRowToPublic(correlationId, rows)
+		result.Item = item
+		return err
+	})
+}
+
+// Sends queued statements in one round trip and sets their results.
+// Statements run on the primary pool within the context of this persistence, so persistences
+// that queue them must share its connection. Interceptors are not applied to batches.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - batch             a batch to be sent.
+// Returns the first error of the statements or error of the round trip.
+func (c *PostgresPersistence) SendBatch(correlationId string, batch *PostgresBatch) (err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if batch == nil || batch.Len() == 0 {
+		return nil
+	}
+	sender, ok := unwrapClient(c.Client).(postgresBatchSender)
+	if !ok {
+		return cerr.NewUnsupportedError(correlationId, "BATCH_NOT_SUPPORTED",
+			"Client doesn't support batches")
+	}
+
+	results := sender.SendBatch(ctx, batch.batch)
+	for _, item := range batch.items {
+		rows, qErr := results.Query()
+		if qErr == nil {
+			qErr = item.handle(rows, item.result)
+		}
+		rows.Close()
+		if qErr != nil {
+			item.result.Err = c.mapQueryError(correlationId, "send_batch", qErr)
+			if err == nil {
+				err = item.result.Err
+			}
+		}
+	}
+	if cErr := results.Close(); cErr != nil && err == nil {
+		err = cErr
+	}
+
+	c.Logger.Trace(correlationId, "Sent batch of %d statements", batch.Len())
+	return err
+}
@@ -0,0 +1,93 @@
+package persistence
+
+import (
+	"reflect"
+)
+
+var bytesType = reflect.TypeOf([]byte{})
+
+// Collects prototype fields of []byte type. JSON encoding turns them into base64 strings
+// that BYTEA columns would store as text, so they are read and written as they are.
+func getPrototypeBytesFields(proto reflect.Type) map[string][]int {
+	return getPrototypeFields(proto, func(fieldType reflect.Type) bool {
+		return fieldType == bytesType
+	})
+}
+
+// Sets binary values into a decoded object without base64 round trip
+func (c *PostgresPersistence) assignBytesValues(docPointer reflect.Value, values interface{}) *DataConversionError {
+	columns, ok := values.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	doc := docPointer.Elem()
+	if doc.Kind() == reflect.Map && doc.Type().Key().Kind() == reflect.String &&
+		doc.Type().Elem().Kind() == reflect.Interface {
+		for name, value := range columns {
+			if data, ok := value.([]byte); ok {
+				doc.SetMapIndex(reflect.ValueOf(name).Convert(doc.Type().Key()), reflect.ValueOf(data))
+			}
+		}
+		return nil
+	}
+
+	if doc.Kind() != reflect.Struct {
+		return nil
+	}
+	for name, index := range c.bytesFields {
+		switch value := columns[name].(type) {
+		case nil:
+			doc.FieldByIndex(index).SetBytes(nil)
+		case []byte:
+			doc.FieldByIndex(index).SetBytes(value)
+		case string:
+			doc.FieldByIndex(index).SetBytes([]byte(value))
+		default:
+			return NewDataConversionError("", name, value, nil)
+		}
+	}
+	return nil
+}
+
+// Replaces base64 encoded binary values with their bytes
+func (c *PostgresPersistence) convertBytesFromPublic(value interface{}, items map[string]interface{}) {
+	if values, ok := value.(map[string]interface{}); ok {
+		for name, item := range values {
+			if data, ok := item.([]byte); ok {
+				items[name] = data
+			}
+		}
+		return
+	}
+
+	if len(c.bytesFields) == 0 || value == nil {
+		return
+	}
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	proto := c.Prototype
+	for proto.Kind() == reflect.Ptr {
+		proto = proto.Elem()
+	}
+	if v.Type() != proto {
+		return
+	}
+
+	for name, index := range c.bytesFields {
+		if _, ok := items[name]; !ok {
+			continue
+		}
+		data := v.FieldByIndex(index).Bytes()
+		if data == nil {
+			items[name] = nil
+		} else {
+			items[name] = data
+		}
+	}
+}
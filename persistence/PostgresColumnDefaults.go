@@ -0,0 +1,113 @@
+package persistence
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Adds a statement to set a default value of a column on opening.
+// Create omits the column when its value is zero, so the default applies.
+// Must be called in DefineSchema after the table definition.
+//   - name          a column name
+//   - expression    a default expression, e.g. "now()", "gen_random_uuid()" or "'new'"
+func (c *PostgresPersistence) EnsureColumnDefault(name string, expression string) {
+	c.setColumnDefault(name)
+	c.EnsureSchema("ALTER TABLE " + c.QuotedTableName() + " ALTER COLUMN " + c.QuoteIdentifier(name) +
+		" SET DEFAULT " + expression)
+}
+
+func (c *PostgresPersistence) setColumnDefault(name string) {
+	if c.defaultColumns == nil {
+		c.defaultColumns = make(map[string]bool)
+	}
+	c.defaultColumns[name] = true
+}
+
+// Checks if a column definition declares a default value
+func isDefaultDefinition(definition string) bool {
+	return strings.Contains(strings.ToUpper(definition), " DEFAULT ")
+}
+
+// Collects json names of prototype fields tagged with default values
+func getPrototypeDefaultFields(proto reflect.Type) map[string]bool {
+	fields := make(map[string]bool)
+	if proto == nil {
+		return fields
+	}
+	for _, column := range GetPrototypeColumns(proto) {
+		if column.Default != "" {
+			fields[column.Name] = true
+		}
+	}
+	return fields
+}
+
+// Removes values of columns with defaults when the item has zero values for them
+func (c *PostgresPersistence) omitDefaultColumns(row interface{}, items map[string]interface{}) {
+	if len(c.defaultFields) == 0 && len(c.defaultColumns) == 0 {
+		return
+	}
+
+	item, isStruct := c.prototypeValue(row)
+	values, isMap := row.(map[string]interface{})
+
+	isZero := func(name string) bool {
+		if isStruct {
+			if index, ok := c.prototypeFields[name]; ok {
+				return item.FieldByIndex(index).IsZero()
+			}
+			return false
+		}
+		if isMap {
+			return values[name] == nil
+		}
+		return false
+	}
+
+	for name := range c.prototypeFields {
+		column := c.GetColumnName(name)
+		if (c.defaultFields[name] || c.defaultColumns[column]) && isZero(name) {
+			delete(items, column)
+		}
+	}
+	if isMap {
+		for name := range values {
+			column := c.GetColumnName(name)
+			if c.defaultColumns[column] && isZero(name) {
+				delete(items, column)
+			}
+		}
+	}
+}
+
+// Generates columns, parameters and values of an INSERT statement for an item.
+// Columns with defaults are omitted when the item has zero values for them.
+func (c *PostgresPersistence) generateInsert(row interface{}) (columns string, params string, values []interface{}) {
+	items := c.convertToMap(row)
+	if items == nil {
+		return "", "", nil
+	}
+	c.omitDefaultColumns(row, items)
+
+	names := make([]string, 0, len(items))
+	for name := range items {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	columnBuf := strings.Builder{}
+	paramBuf := strings.Builder{}
+	values = make([]interface{}, 0, len(names))
+	for index, name := range names {
+		if index > 0 {
+			columnBuf.WriteString(",")
+			paramBuf.WriteString(",")
+		}
+		columnBuf.WriteString(c.QuoteIdentifier(name))
+		paramBuf.WriteString("$" + strconv.Itoa(index+1))
+		values = append(values, items[name])
+	}
+	return columnBuf.String(), paramBuf.String(), values
+}
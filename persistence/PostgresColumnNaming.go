@@ -0,0 +1,104 @@
+package persistence
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Ways to name table columns after prototype fields
+const (
+	// Columns are named exactly as json names of fields
+	ColumnNamingJson = "json"
+	// Columns are named as json names of fields converted to snake_case, e.g. CreateTime -> create_time
+	ColumnNamingSnakeCase = "snake_case"
+)
+
+// Converts a name like "CreateTime", "createTime" or "UserID" into snake_case: "create_time", "user_id".
+//   - name      a name to convert
+// Returns the name in snake_case
+func ToSnakeCase(name string) string {
+	runes := []rune(name)
+	builder := strings.Builder{}
+	for index, r := range runes {
+		if unicode.IsUpper(r) {
+			if index > 0 && runes[index-1] != '_' {
+				prev := runes[index-1]
+				nextLower := index+1 < len(runes) && unicode.IsLower(runes[index+1])
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+					builder.WriteRune('_')
+				}
+			}
+			builder.WriteRune(unicode.ToLower(r))
+		} else {
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
+
+// Converts a snake_case name like "create_time" into camelCase: "createTime".
+//   - name      a name to convert
+// Returns the name in camelCase
+func ToCamelCase(name string) string {
+	parts := strings.Split(name, "_")
+	builder := strings.Builder{}
+	for index, part := range parts {
+		if part == "" {
+			continue
+		}
+		if index > 0 && builder.Len() > 0 {
+			runes := []rune(part)
+			runes[0] = unicode.ToUpper(runes[0])
+			part = string(runes)
+		}
+		builder.WriteString(part)
+	}
+	return builder.String()
+}
+
+// Gets a column name for a json name of a prototype field according to options.column_naming
+//   - name      a json name of the field
+// Returns the column name
+func (c *PostgresPersistence) GetColumnName(name string) string {
+	if c.columnNaming == ColumnNamingSnakeCase {
+		return ToSnakeCase(name)
+	}
+	return name
+}
+
+// Gets a json name of a prototype field for a column name.
+// Columns that match no struct field are converted to camelCase in snake_case mode,
+// that is the case of map prototypes.
+func (c *PostgresPersistence) getFieldName(column string) string {
+	if c.columnNaming != ColumnNamingSnakeCase {
+		return column
+	}
+	if name, ok := c.columnFields[column]; ok {
+		return name
+	}
+	if len(c.prototypeFields) > 0 {
+		return column
+	}
+	return ToCamelCase(column)
+}
+
+// Maps column names to json names of prototype fields
+func (c *PostgresPersistence) getColumnFields() map[string]string {
+	fields := make(map[string]string, len(c.prototypeFields))
+	for name := range c.prototypeFields {
+		fields[c.GetColumnName(name)] = name
+	}
+	return fields
+}
+
+// Renames keys of statement values from json names to column names
+func (c *PostgresPersistence) renameToColumns(items map[string]interface{}) map[string]interface{} {
+	if c.columnNaming != ColumnNamingSnakeCase {
+		return items
+	}
+	result := make(map[string]interface{}, len(items))
+	for name, value := range items {
+		result[c.GetColumnName(name)] = value
+	}
+	return result
+}
@@ -0,0 +1,72 @@
+package persistence
+
+import (
+	"strings"
+)
+
+// Adds a statement to create a foreign key constraint on opening, so referential integrity
+// between tables of related persistences is declared in code.
+// The referenced table must be created before, a table name without schema
+// refers to the schema of this persistence.
+// Must be called in DefineSchema after the table definition.
+//   - column        a column of this table
+//   - refTable      a referenced table name, optionally qualified with a schema like "schema.table"
+//   - refColumn     a referenced column, usually a primary key
+//   - onDelete      (optional) an action on delete of referenced rows: CASCADE, SET NULL, RESTRICT...
+func (c *PostgresPersistence) EnsureForeignKey(column string, refTable string, refColumn string, onDelete string) {
+	name := c.TableName + "_" + column + "_fkey"
+
+	statement := "ALTER TABLE " + c.QuotedTableName() + " ADD CONSTRAINT " + c.QuoteIdentifier(name) +
+		" FOREIGN KEY (" + c.QuoteIdentifier(column) + ") REFERENCES " + c.quoteTableReference(refTable) +
+		" (" + c.QuoteIdentifier(refColumn) + ")"
+	if onDelete != "" {
+		statement += " ON DELETE " + strings.ToUpper(onDelete)
+	}
+
+	c.ensureConstraint(name, statement)
+}
+
+// Adds a statement to create a CHECK constraint on opening, that enforces
+// invariants like allowed statuses or positive amounts at the database level.
+// Must be called in DefineSchema after the table definition.
+//   - name          a constraint name
+//   - expression    a boolean expression, e.g. "\"amount\" > 0"
+func (c *PostgresPersistence) EnsureCheck(name string, expression string) {
+	c.ensureConstraint(name, "ALTER TABLE "+c.QuotedTableName()+" ADD CONSTRAINT "+c.QuoteIdentifier(name)+
+		" CHECK ("+expression+")")
+}
+
+// Adds a statement to create a named UNIQUE constraint on opening.
+// Unlike unique indexes, constraints can be used as ON CONFLICT targets, e.g. by SetOnConstraint.
+// Must be called in DefineSchema after the table definition.
+//   - columns       columns of the constraint
+// Returns the constraint name composed as <table>_<column>_..._key.
+func (c *PostgresPersistence) EnsureUniqueConstraint(columns ...string) string {
+	name := c.TableName + "_" + strings.Join(columns, "_") + "_key"
+
+	fields := make([]string, 0, len(columns))
+	for _, column := range columns {
+		fields = append(fields, c.QuoteIdentifier(column))
+	}
+	c.ensureConstraint(name, "ALTER TABLE "+c.QuotedTableName()+" ADD CONSTRAINT "+c.QuoteIdentifier(name)+
+		" UNIQUE ("+strings.Join(fields, ", ")+")")
+	return name
+}
+
+// Adds a statement that runs a statement adding a constraint unless the constraint exists,
+// because constraints have no IF NOT EXISTS clause
+func (c *PostgresPersistence) ensureConstraint(name string, statement string) {
+	c.EnsureSchema("DO $$ BEGIN IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname=" + c.QuoteLiteral(name) +
+		" AND conrelid=" + c.QuoteLiteral(c.QuotedTableName()) + "::regclass) THEN " + statement + "; END IF; END $$")
+}
+
+// Quotes a table name that may be qualified with a schema
+func (c *PostgresPersistence) quoteTableReference(table string) string {
+	if index := strings.Index(table, "."); index > 0 && !strings.HasPrefix(table, "\"") {
+		return c.QuoteIdentifier(table[:index]) + "." + c.QuoteIdentifier(table[index+1:])
+	}
+	if c.SchemaName != "" {
+		return c.QuoteIdentifier(c.SchemaName) + "." + c.QuoteIdentifier(table)
+	}
+	return c.QuoteIdentifier(table)
+}
@@ -0,0 +1,160 @@
+package persistence
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Custom conversion of column values, like money, network addresses or domain types,
+// that replaces the default JSON based conversion.
+type PostgresTypeConverter struct {
+	// Converts a field value into a statement parameter. When nil the field value is passed as it is.
+	Encode func(value interface{}) (interface{}, error)
+	// Converts a column value returned by pgx into a field value. When nil the column value is set as it is.
+	Decode func(value interface{}) (interface{}, error)
+}
+
+// Registers a converter for a column. Column converters take precedence over type converters.
+// Converters shall be registered before the persistence is opened, e.g. in a constructor.
+//   - column        a column name that matches json name of the prototype field
+//   - converter     a converter of the column values
+func (c *PostgresPersistence) RegisterColumnConverter(column string, converter *PostgresTypeConverter) {
+	if c.columnConverters == nil {
+		c.columnConverters = make(map[string]*PostgresTypeConverter)
+	}
+	c.columnConverters[column] = converter
+}
+
+// Registers a converter for all prototype fields of a Go type.
+// Type converters apply to struct prototypes only, because maps keep no field types.
+// Converters shall be registered before the persistence is opened, e.g. in a constructor.
+//   - fieldType     a type of prototype fields
+//   - converter     a converter of the field values
+func (c *PostgresPersistence) RegisterTypeConverter(fieldType reflect.Type, converter *PostgresTypeConverter) {
+	if c.typeConverters == nil {
+		c.typeConverters = make(map[reflect.Type]*PostgresTypeConverter)
+	}
+	c.typeConverters[fieldType] = converter
+}
+
+// Finds a converter for a column
+func (c *PostgresPersistence) getConverter(column string) *PostgresTypeConverter {
+	if converter, ok := c.columnConverters[column]; ok {
+		return converter
+	}
+	if len(c.typeConverters) == 0 {
+		return nil
+	}
+	if index, ok := c.prototypeFields[column]; ok {
+		return c.typeConverters[c.prototypeStruct().FieldByIndex(index).Type]
+	}
+	return nil
+}
+
+// Gets the prototype struct type without pointers
+func (c *PostgresPersistence) prototypeStruct() reflect.Type {
+	proto := c.Prototype
+	for proto != nil && proto.Kind() == reflect.Ptr {
+		proto = proto.Elem()
+	}
+	return proto
+}
+
+// Gets a struct value of the prototype type from an item, if the item is one
+func (c *PostgresPersistence) prototypeValue(value interface{}) (reflect.Value, bool) {
+	if value == nil {
+		return reflect.Value{}, false
+	}
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+		v = v.Elem()
+	}
+	return v, v.Kind() == reflect.Struct && v.Type() == c.prototypeStruct()
+}
+
+// Sets values of columns with registered converters into a decoded object
+func (c *PostgresPersistence) assignConvertedValues(docPointer reflect.Value, values map[string]interface{}) *DataConversionError {
+	doc := docPointer.Elem()
+	for name, value := range values {
+		converter := c.getConverter(name)
+		if converter == nil {
+			continue
+		}
+
+		if converter.Decode != nil {
+			decoded, err := converter.Decode(value)
+			if err != nil {
+				return NewDataConversionError("", name, value, err)
+			}
+			value = decoded
+		}
+
+		if doc.Kind() == reflect.Map {
+			if value == nil {
+				doc.SetMapIndex(reflect.ValueOf(name), reflect.Zero(doc.Type().Elem()))
+			} else {
+				doc.SetMapIndex(reflect.ValueOf(name), reflect.ValueOf(value))
+			}
+			continue
+		}
+
+		index, ok := c.prototypeFields[name]
+		if !ok || doc.Kind() != reflect.Struct {
+			continue
+		}
+		field := doc.FieldByIndex(index)
+		if value == nil {
+			field.Set(reflect.Zero(field.Type()))
+			continue
+		}
+		v := reflect.ValueOf(value)
+		if !v.Type().ConvertibleTo(field.Type()) {
+			return NewDataConversionError("", name, value,
+				fmt.Errorf("%v cannot be assigned to field of type %v", v.Type(), field.Type()))
+		}
+		field.Set(v.Convert(field.Type()))
+	}
+	return nil
+}
+
+// Replaces JSON encoded values of columns with registered converters with encoded field values
+func (c *PostgresPersistence) convertValuesFromPublic(value interface{}, items map[string]interface{}) error {
+	if len(c.columnConverters) == 0 && len(c.typeConverters) == 0 {
+		return nil
+	}
+
+	values, isMap := value.(map[string]interface{})
+	item, isStruct := c.prototypeValue(value)
+	if !isMap && !isStruct {
+		return nil
+	}
+
+	for name := range items {
+		converter := c.getConverter(name)
+		if converter == nil {
+			continue
+		}
+
+		var field interface{}
+		if isMap {
+			field = values[name]
+		} else if index, ok := c.prototypeFields[name]; ok {
+			field = item.FieldByIndex(index).Interface()
+		} else {
+			continue
+		}
+
+		if converter.Encode != nil {
+			encoded, err := converter.Encode(field)
+			if err != nil {
+				return err
+			}
+			field = encoded
+		}
+		items[name] = field
+	}
+	return nil
+}
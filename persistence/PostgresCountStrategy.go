@@ -0,0 +1,107 @@
+package persistence
+
+import (
+	"encoding/json"
+	"strconv"
+
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+)
+
+// Strategies to calculate totals of data pages
+const (
+	// Count all matching rows with COUNT(*)
+	CountStrategyExact = "exact"
+	// Take the number of rows estimated by the query planner, that is fast but approximate
+	CountStrategyEstimated = "estimated"
+	// Count matching rows up to a limit, so totals of large tables stay cheap
+	CountStrategyCapped = "capped"
+	// Skip calculation of totals, pages are returned without total
+	CountStrategyNone = "none"
+)
+
+// Paging parameters extended with a strategy to calculate the total of the page.
+// The total is calculated only when it is requested by Total flag.
+type PostgresPagingParams struct {
+	*cdata.PagingParams
+	// A strategy to calculate the total: exact, estimated, capped or none. options.count_strategy when not set
+	CountStrategy string
+	// A maximum total for capped strategy. options.count_limit when not set
+	CountLimit int64
+}
+
+// Page of data items with a number of rows that were skipped because they failed conversion.
+// Rows are skipped unless options.strict_conversion is set, so pages can be shorter than requested.
+type PostgresDataPage struct {
+	*cdata.DataPage
+	// A number of rows of the page skipped because they failed conversion
+	Skipped int `json:"skipped"`
+}
+
+// Creates new paging parameters with a count strategy.
+//   - skip          the number of items to skip.
+//   - take          the number of items to return.
+//   - total         true to return the total number of items.
+//   - strategy      a strategy to calculate the total
+// Returns new paging parameters.
+func NewPostgresPagingParams(skip interface{}, take interface{}, total interface{}, strategy string) *PostgresPagingParams {
+	return &PostgresPagingParams{
+		PagingParams:  cdata.NewPagingParams(skip, take, total),
+		CountStrategy: strategy,
+	}
+}
+
+// Calculates a total of items that match a filter
+func (c *PostgresPersistence) countByStrategy(correlationId string, filter interface{}, strategy string,
+	limit int64) (total *int64, err error) {
+	ctx, done := c.operationContext(correlationId, &err)
+	defer done()
+
+	if strategy == "" {
+		strategy = c.countStrategy
+	}
+	if limit <= 0 {
+		limit = c.countLimit
+	}
+
+	var count int64
+	from := c.composeFrom(filter) + c.composeWhere(filter)
+
+	switch strategy {
+	case CountStrategyNone:
+		return nil, nil
+	case CountStrategyEstimated:
+		var plan string
+		query := "EXPLAIN (FORMAT JSON) SELECT 1 FROM " + from
+		if err := c.ReadClient.QueryRow(ctx, query).Scan(&plan); err != nil {
+			return nil, err
+		}
+		count = parsePlanRows(plan)
+	case CountStrategyCapped:
+		query := "SELECT COUNT(*) FROM (SELECT 1 FROM " + from + " LIMIT " + strconv.FormatInt(limit, 10) + ") AS " +
+			c.QuoteIdentifier("capped")
+		if err := c.ReadClient.QueryRow(ctx, query).Scan(&count); err != nil {
+			return nil, err
+		}
+	default:
+		query := "SELECT COUNT(*) AS count FROM " + from
+		if err := c.ReadClient.QueryRow(ctx, query).Scan(&count); err != nil {
+			return nil, err
+		}
+	}
+
+	c.Logger.Trace(correlationId, "Counted %d items in %s with %s strategy", count, c.TableName, strategy)
+	return &count, nil
+}
+
+// Gets the number of rows estimated by a query plan in JSON format
+func parsePlanRows(plan string) int64 {
+	var plans []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal([]byte(plan), &plans); err != nil || len(plans) == 0 {
+		return 0
+	}
+	return int64(plans[0].Plan.Rows)
+}
@@ -0,0 +1,65 @@
+package persistence
+
+import (
+	"encoding/json"
+	"reflect"
+
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+)
+
+// Checks if an operation can run in dry run mode. Writes other than Create, Set, Update and Delete
+// do not support dry run and are rejected, so nothing is changed while the mode is on.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+// Returns UnsupportedError in dry run mode or nil otherwise.
+func (c *PostgresPersistence) checkNotDryRun(correlationId string) error {
+	if !c.dryRun {
+		return nil
+	}
+	return cerr.NewUnsupportedError(correlationId, "DRY_RUN_NOT_SUPPORTED",
+		"Operation on "+c.TableName+" is not supported in dry run mode").
+		WithDetails("table", c.TableName)
+}
+
+// Checks if an operation of a component built on the persistence can run in dry run mode.
+// See checkNotDryRun.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+// Returns UnsupportedError in dry run mode or nil otherwise.
+func (c *PostgresPersistence) CheckNotDryRun(correlationId string) error {
+	return c.checkNotDryRun(correlationId)
+}
+
+// Logs a write statement that is skipped in dry run mode
+func (c *PostgresPersistence) logDryRun(correlationId string, query string, values []interface{}) {
+	c.Logger.Info(correlationId, "Dry run on %s: %s with parameters %v", c.TableName, query, values)
+}
+
+// Gets an item that would be stored by a partial update: the current item with updated fields
+func (c *PostgresPersistence) mergeDryRunFields(item interface{}, fields map[string]interface{}) interface{} {
+	values := make(map[string]interface{})
+	buffer, err := json.Marshal(item)
+	if err == nil {
+		err = json.Unmarshal(buffer, &values)
+	}
+	if err != nil {
+		return item
+	}
+	for key, value := range fields {
+		values[key] = value
+	}
+
+	if buffer, err = json.Marshal(values); err != nil {
+		return item
+	}
+	proto := c.Prototype
+	if proto.Kind() == reflect.Ptr {
+		proto = proto.Elem()
+	}
+	pointer := reflect.New(proto)
+	if err = json.Unmarshal(buffer, pointer.Interface()); err != nil {
+		return item
+	}
+	if c.Prototype.Kind() == reflect.Ptr {
+		return pointer.Interface()
+	}
+	return pointer.Elem().Interface()
+}
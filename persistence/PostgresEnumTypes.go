@@ -0,0 +1,169 @@
+package persistence
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+)
+
+// Enum type defined by the persistence
+type postgresEnumType struct {
+	name   string
+	values []string
+}
+
+// Registers an enum type that is created on opening before the table definition.
+// When the type already exists, values missing in it are added with ALTER TYPE ... ADD VALUE,
+// existing values are never removed or reordered.
+// Must be called in DefineSchema.
+//   - name      a name of the enum type
+//   - values    allowed values of the type in their sort order
+func (c *PostgresPersistence) EnsureEnumType(name string, values ...string) {
+	enumType := &postgresEnumType{name: name, values: values}
+	for index, existing := range c.enumTypes {
+		if existing.name == name {
+			c.enumTypes[index] = enumType
+			return
+		}
+	}
+	c.enumTypes = append(c.enumTypes, enumType)
+}
+
+// Gets quoted name of an enum type in the persistence schema
+//   - name      a name of the enum type
+func (c *PostgresPersistence) QuotedEnumTypeName(name string) string {
+	if c.SchemaName != "" {
+		return c.QuoteIdentifier(c.SchemaName) + "." + c.QuoteIdentifier(name)
+	}
+	return c.QuoteIdentifier(name)
+}
+
+// Gets values allowed by an enum type registered with EnsureEnumType.
+//   - name      a name of the enum type
+// Returns the values or nil if the type is not registered.
+func (c *PostgresPersistence) GetEnumValues(name string) []string {
+	for _, enumType := range c.enumTypes {
+		if enumType.name == name {
+			return enumType.values
+		}
+	}
+	return nil
+}
+
+// Checks that a value is allowed by an enum type registered with EnsureEnumType.
+// It lets to report invalid values before they reach the database.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - name              a name of the enum type
+//   - value             a value to check
+// Returns BadRequestError when the value is not allowed or nil otherwise.
+func (c *PostgresPersistence) ValidateEnumValue(correlationId string, name string, value interface{}) error {
+	str := EnumToString(value)
+	for _, allowed := range c.GetEnumValues(name) {
+		if allowed == str {
+			return nil
+		}
+	}
+	return cerr.NewBadRequestError(correlationId, "INVALID_ENUM_VALUE",
+		"Value "+str+" is not allowed by enum type "+name).
+		WithDetails("type", name).
+		WithDetails("value", str)
+}
+
+// Converts a Go enum constant into a value of an enum column.
+// Named string types are converted to their underlying string
+// and types that implement fmt.Stringer are converted with String method.
+// Use it in ConvertFromPublic to write enum fields.
+//   - value     an enum constant
+// Returns the enum column value
+func EnumToString(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	if stringer, ok := value.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.String {
+		return v.String()
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// Creates registered enum types or adds their missing values
+func (c *PostgresPersistence) ensureEnumTypes(correlationId string) error {
+	if len(c.enumTypes) == 0 {
+		return nil
+	}
+
+	err := c.EnsureSchemaExists(correlationId)
+	if err != nil {
+		return err
+	}
+
+	schemaName := c.currentSchemaName()
+
+	for _, enumType := range c.enumTypes {
+		query := "SELECT e.enumlabel FROM pg_type t JOIN pg_namespace n ON n.oid=t.typnamespace" +
+			" LEFT JOIN pg_enum e ON e.enumtypid=t.oid WHERE t.typname=$1 AND n.nspname=$2"
+		qResult, qErr := c.Client.Query(c.schemaContext(), query, enumType.name, schemaName)
+		if qErr != nil {
+			return qErr
+		}
+		exists := false
+		existing := make(map[string]bool)
+		for qResult.Next() {
+			var label *string
+			if err = qResult.Scan(&label); err != nil {
+				break
+			}
+			exists = true
+			if label != nil {
+				existing[*label] = true
+			}
+		}
+		qResult.Close()
+		if err == nil {
+			err = qResult.Err()
+		}
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			values := make([]string, 0, len(enumType.values))
+			for _, value := range enumType.values {
+				values = append(values, c.QuoteLiteral(value))
+			}
+			statement := "CREATE TYPE " + c.QuotedEnumTypeName(enumType.name) +
+				" AS ENUM (" + strings.Join(values, ", ") + ")"
+			if _, err = c.Client.Exec(c.schemaContext(), statement); err != nil {
+				return err
+			}
+			c.Logger.Debug(correlationId, "Created enum type %s", enumType.name)
+			continue
+		}
+
+		// ADD VALUE cannot run inside a transaction block before PostgreSQL 12,
+		// so every value is added by a separate statement
+		for _, value := range enumType.values {
+			if existing[value] {
+				continue
+			}
+			statement := "ALTER TYPE " + c.QuotedEnumTypeName(enumType.name) +
+				" ADD VALUE IF NOT EXISTS " + c.QuoteLiteral(value)
+			if _, err = c.Client.Exec(c.schemaContext(), statement); err != nil {
+				return err
+			}
+			c.Logger.Info(correlationId, "Added value %s to enum type %s", value, enumType.name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package persistence
+
+import (
+	"strconv"
+	"time"
+
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+)
+
+// Configuration of expiration of rows by a time column
+type postgresExpirationOptions struct {
+	column       string
+	ttl          time.Duration
+	batchSize    int
+	archiveTable string
+	period       time.Duration
+}
+
+// Deletes expired rows in batches, configured by options.expire_column and options.expire_ttl.
+// Without a TTL the column holds expiration times, otherwise rows expire the TTL after the column time.
+// When options.expire_archive_table is set, expired rows are moved into that table
+// with the same columns in the same statement. Rows of all tenants are processed.
+// Batches are processed until there are no more expired rows or the persistence is closed.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns a number of expired rows or error.
+func (c *PostgresPersistence) DeleteExpired(correlationId string) (int64, error) {
+	if c.expiration.column == "" {
+		return 0, cerr.NewInvalidStateError(correlationId, "NO_EXPIRE_COLUMN",
+			"Expiration column is not configured for "+c.TableName)
+	}
+	if err := c.checkWritable(correlationId); err != nil {
+		return 0, err
+	}
+	if err := c.checkNotDryRun(correlationId); err != nil {
+		return 0, err
+	}
+
+	batchSize := c.expiration.batchSize
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	cutoff := time.Now()
+	if c.expiration.ttl > 0 {
+		cutoff = cutoff.Add(-c.expiration.ttl)
+	}
+
+	// Conditions are repeated on deleted rows since ctid is unique only within a partition
+	condition := c.QuoteIdentifier(c.expiration.column) + "<$1"
+	query := "DELETE FROM " + c.QuotedTableName() +
+		" WHERE ctid IN (SELECT ctid FROM " + c.QuotedTableName() +
+		" WHERE " + condition + " LIMIT " + strconv.Itoa(batchSize) + ") AND " + condition
+	if c.expiration.archiveTable != "" {
+		query = "WITH expired AS (" + query + " RETURNING *)" +
+			" INSERT INTO " + c.quotedArchiveTableName() + " SELECT * FROM expired"
+	}
+
+	var total int64
+	for {
+		// Every batch is tracked as an operation, so closing stops the cleanup between batches
+		if !c.operations.enter() {
+			break
+		}
+		result, err := c.Client.Exec(c.schemaContext(), query, cutoff)
+		c.operations.exit()
+		if err != nil {
+			return total, err
+		}
+		total += result.RowsAffected()
+		if result.RowsAffected() < int64(batchSize) {
+			break
+		}
+	}
+
+	if total > 0 {
+		c.Logger.Debug(correlationId, "Expired %d items in %s", total, c.TableName)
+	}
+	return total, nil
+}
+
+// Gets the quoted name of the table that keeps expired rows
+func (c *PostgresPersistence) quotedArchiveTableName() string {
+	if len(c.SchemaName) > 0 {
+		return c.QuoteIdentifier(c.SchemaName) + "." + c.QuoteIdentifier(c.expiration.archiveTable)
+	}
+	return c.QuoteIdentifier(c.expiration.archiveTable)
+}
+
+// Starts periodic cleanup of expired rows if the expiration column is configured.
+// In schema tenancy mode the cleanup runs per tenant, see ForTenant and DeleteExpired.
+func (c *PostgresPersistence) startExpiration(correlationId string) {
+	if c.expiration.column == "" || c.expiration.period <= 0 || c.view || c.tenancy == TenancySchema || c.dryRun {
+		return
+	}
+
+	stop := make(chan struct{})
+	c.expireStop = stop
+	go func() {
+		ticker := time.NewTicker(c.expiration.period)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if _, err := c.DeleteExpired(correlationId); err != nil {
+					c.Logger.Error(correlationId, err, "Failed to delete expired items from %s", c.TableName)
+				}
+			}
+		}
+	}()
+}
+
+// Stops periodic cleanup of expired rows
+func (c *PostgresPersistence) stopExpiration() {
+	if c.expireStop != nil {
+		close(c.expireStop)
+		c.expireStop = nil
+	}
+}
@@ -0,0 +1,59 @@
+package persistence
+
+import (
+	"io"
+
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+)
+
+// Formats of exported and imported data
+const (
+	// JSON Lines: one JSON object per row with column names as keys
+	DataFormatJson = "json"
+	// CSV with a header row of column names
+	DataFormatCsv = "csv"
+)
+
+// Streams rows retrieved by a given filter into a writer using COPY TO, e.g. for backups or data sharing.
+// Rows are exported as they are stored in the table, without conversion to the public format.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - filter            (optional) a filter SQL condition
+//   - format            a data format: DataFormatJson or DataFormatCsv
+//   - writer            a writer to stream the data into
+// Returns a number of exported rows or error.
+func (c *PostgresPersistence) ExportByFilter(correlationId string, filter string, format string,
+	writer io.Writer) (count int64, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	query := "SELECT * FROM " + c.QuotedTableName() + c.composeWhere(filter)
+	switch format {
+	case DataFormatCsv:
+		query = "COPY (" + query + ") TO STDOUT WITH (FORMAT csv, HEADER true)"
+	case DataFormatJson:
+		// Quote and delimiter characters never appear in JSON text, so objects are written without escaping
+		query = "COPY (SELECT row_to_json(items) FROM (" + query + ") AS items)" +
+			" TO STDOUT WITH (FORMAT csv, QUOTE e'\\x01', DELIMITER e'\\x02')"
+	default:
+		return 0, cerr.NewBadRequestError(correlationId, "INVALID_FORMAT", "Data format "+format+" is not supported").
+			WithDetails("format", format)
+	}
+
+	conn, aErr := c.AcquireConnection(ctx, c.ReadClient)
+	if aErr != nil {
+		return 0, aErr
+	}
+	defer conn.Release()
+
+	tag, cErr := conn.Conn().PgConn().CopyTo(ctx, writer, query)
+	if cErr != nil {
+		return 0, cErr
+	}
+
+	count = tag.RowsAffected()
+	c.Logger.Trace(correlationId, "Exported %d items from %s", count, c.TableName)
+	return count, nil
+}
@@ -0,0 +1,60 @@
+package persistence
+
+import (
+	"strings"
+
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+)
+
+// Composes a projection from the standard "fields" entry of filter parameters,
+// a comma separated list of json field names like "id,name". Field names are validated
+// against the prototype, so the projection can be safely passed as select parameter
+// to GetPageByFilter, GetListByFilter or GetOneRandom.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - filter            (optional) filter parameters with "fields" entry
+// Returns the projection, empty string to select all columns, or BadRequestError for unknown fields.
+func (c *PostgresPersistence) ComposeFieldsSelect(correlationId string, filter *cdata.FilterParams) (string, error) {
+	if filter == nil {
+		return "", nil
+	}
+	fields := strings.TrimSpace(filter.GetAsString("fields"))
+	if fields == "" {
+		return "", nil
+	}
+
+	columns := make([]string, 0)
+	added := make(map[string]bool)
+	for _, field := range strings.Split(fields, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" || added[field] {
+			continue
+		}
+		if !c.isSelectableField(field) {
+			return "", cerr.NewBadRequestError(correlationId, "INVALID_FIELDS",
+				"Field "+field+" cannot be selected from "+c.TableName).
+				WithDetails("field", field)
+		}
+		added[field] = true
+		columns = append(columns, c.QuoteIdentifier(c.GetColumnName(field)))
+	}
+	return strings.Join(columns, ","), nil
+}
+
+// Checks if a field is defined in the prototype. Prototypes without fields, like maps,
+// accept any simple name, that is still checked to keep the projection safe.
+func (c *PostgresPersistence) isSelectableField(field string) bool {
+	if _, ok := c.relations[field]; ok {
+		return false
+	}
+	if len(c.prototypeFields) > 0 {
+		_, ok := c.prototypeFields[field]
+		return ok
+	}
+	for index := 0; index < len(field); index++ {
+		if !isNameChar(field[index]) {
+			return false
+		}
+	}
+	return !isDigit(field[0])
+}
@@ -0,0 +1,147 @@
+package persistence
+
+import (
+	"strconv"
+	"strings"
+
+	cconv "github.com/pip-services3-go/pip-services3-commons-go/convert"
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+)
+
+// Ways to maintain the full-text search vector column
+const (
+	SearchVectorGenerated = "generated"
+	SearchVectorTrigger   = "trigger"
+)
+
+// Adds statements to add a tsvector column built from text columns of the table on opening.
+// Depending on options.search_vector_mode the column is a stored generated column
+// or it is updated by a trigger, that also works on PostgreSQL versions before 12.
+// Text is parsed with the configuration set in options.text_search_config.
+// Must be called in DefineSchema after the table definition.
+//   - name      a name of the search vector column
+//   - columns   text columns to build the search vector from
+func (c *PostgresPersistence) EnsureSearchVectorColumn(name string, columns ...string) {
+	c.searchColumn = name
+
+	if c.searchVectorMode == SearchVectorTrigger {
+		fields := make([]string, 0, len(columns))
+		for _, column := range columns {
+			fields = append(fields, c.QuoteIdentifier(column))
+		}
+		trigger := c.QuoteIdentifier(c.TableName + "_" + name + "_update")
+
+		c.EnsureSchema("ALTER TABLE " + c.QuotedTableName() + " ADD COLUMN IF NOT EXISTS " +
+			c.QuoteIdentifier(name) + " tsvector")
+		c.EnsureSchema("DROP TRIGGER IF EXISTS " + trigger + " ON " + c.QuotedTableName())
+		c.EnsureSchema("CREATE TRIGGER " + trigger + " BEFORE INSERT OR UPDATE ON " + c.QuotedTableName() +
+			" FOR EACH ROW EXECUTE PROCEDURE tsvector_update_trigger(" + c.QuoteIdentifier(name) + ", " +
+			c.quoteTextSearchConfig(true) + ", " + strings.Join(fields, ", ") + ")")
+		return
+	}
+
+	fields := make([]string, 0, len(columns))
+	for _, column := range columns {
+		fields = append(fields, "coalesce("+c.QuoteIdentifier(column)+"::text, '')")
+	}
+	c.EnsureSchema("ALTER TABLE " + c.QuotedTableName() + " ADD COLUMN IF NOT EXISTS " +
+		c.QuoteIdentifier(name) + " tsvector GENERATED ALWAYS AS (to_tsvector(" + c.quoteTextSearchConfig(false) +
+		", " + strings.Join(fields, " || ' ' || ") + ")) STORED")
+}
+
+// Adds a GIN index definition over the column defined by EnsureSearchVectorColumn
+// to create it on opening.
+//   - name      an index name
+func (c *PostgresPersistence) EnsureFullTextIndex(name string) {
+	c.EnsureSchema("CREATE INDEX IF NOT EXISTS " + c.QuoteIdentifier(name) + " ON " + c.QuotedTableName() +
+		" USING gin (" + c.QuoteIdentifier(c.searchColumn) + ")")
+}
+
+// Gets a page of data items that match a text query.
+// The query uses web search syntax: quoted phrases, "or" and "-" to exclude words.
+// Items are ordered by relevance computed with ts_rank.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - query             a text query
+//   - paging            (optional) paging parameters
+// Returns              a data page or error.
+func (c *PostgresPersistence) SearchByText(correlationId string, query string,
+	paging *cdata.PagingParams) (page *cdata.DataPage, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if c.searchColumn == "" {
+		return nil, cerr.NewInvalidStateError(correlationId, "NO_SEARCH_COLUMN",
+			"Search vector column is not defined. Call EnsureSearchVectorColumn in DefineSchema")
+	}
+
+	if paging == nil {
+		paging = cdata.NewEmptyPagingParams()
+	}
+	if err = c.validatePaging(correlationId, paging); err != nil {
+		return nil, err
+	}
+	skip := paging.GetSkip(-1)
+	take := paging.GetTake((int64)(c.MaxPageSize))
+
+	tsQuery := "websearch_to_tsquery(" + c.quoteTextSearchConfig(false) + ", $1)"
+	condition := c.QuoteIdentifier(c.searchColumn) + " @@ " + tsQuery + c.andScopeCondition()
+
+	sql := "SELECT * FROM " + c.QuotedTableName() + " WHERE " + condition +
+		" ORDER BY ts_rank(" + c.QuoteIdentifier(c.searchColumn) + ", " + tsQuery + ") DESC"
+	if skip >= 0 {
+		sql += " OFFSET " + strconv.FormatInt(skip, 10)
+	}
+	sql += " LIMIT " + strconv.FormatInt(take, 10)
+
+	qResult, qErr := c.ReadClient.Query(ctx, sql, query)
+	if qErr != nil {
+		return nil, qErr
+	}
+	defer qResult.Close()
+
+	items := make([]interface{}, 0)
+	skipped := 0
+	for qResult.Next() {
+		item, convErr := c.ConvertRowToPublic(correlationId, qResult)
+		if convErr != nil {
+			return nil, convErr
+		}
+		if item == nil {
+			skipped++
+			continue
+		}
+		items = append(items, item)
+	}
+	c.logSkippedRows(correlationId, skipped, len(items))
+	if err = qResult.Err(); err != nil {
+		return nil, err
+	}
+
+	c.Logger.Trace(correlationId, "Found %d items by text in %s", len(items), c.TableName)
+
+	var total int64 = 0
+	if paging.Total {
+		var count interface{}
+		sql = "SELECT COUNT(*) AS count FROM " + c.QuotedTableName() + " WHERE " + condition
+		err = c.ReadClient.QueryRow(ctx, sql, query).Scan(&count)
+		if err != nil {
+			return nil, err
+		}
+		total = cconv.LongConverter.ToLong(count)
+	}
+	return cdata.NewDataPage(&total, items), nil
+}
+
+// Quotes the configured text search configuration as a regconfig literal.
+// The trigger function requires a schema qualified name.
+func (c *PostgresPersistence) quoteTextSearchConfig(qualified bool) string {
+	config := c.textSearchConfig
+	if qualified && !strings.Contains(config, ".") {
+		config = "pg_catalog." + config
+	}
+	return c.QuoteLiteral(config)
+}
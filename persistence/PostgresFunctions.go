@@ -0,0 +1,70 @@
+package persistence
+
+// Calls a function that returns rows of the persistence table, like RETURNS SETOF <table>
+// or RETURNS TABLE (...) with matching columns, and converts the rows into data items.
+// A function name without schema refers to the schema of this persistence.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - name              a function name, optionally qualified with a schema like "schema.function"
+//   - params            function parameters
+// Returns a list of data items or error.
+func (c *PostgresPersistence) CallFunction(correlationId string, name string, params ...interface{}) (items []interface{}, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	query := "SELECT * FROM " + c.quoteTableReference(name) + "(" + c.GenerateParameters(params) + ")"
+
+	qResult, qErr := c.Client.Query(ctx, query, params...)
+	if qErr != nil {
+		return nil, qErr
+	}
+	defer qResult.Close()
+
+	items = make([]interface{}, 0)
+	skipped := 0
+	for qResult.Next() {
+		item, convErr := c.ConvertRowToPublic(correlationId, qResult)
+		if convErr != nil {
+			return nil, convErr
+		}
+		if item == nil {
+			skipped++
+			continue
+		}
+		items = append(items, item)
+	}
+	c.logSkippedRows(correlationId, skipped, len(items))
+
+	c.Logger.Trace(correlationId, "Retrieved %d from function %s", len(items), name)
+	return items, qResult.Err()
+}
+
+// Calls a stored procedure. Procedures may change data, so calls are rejected in dry run mode.
+// A procedure name without schema refers to the schema of this persistence.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - name              a procedure name, optionally qualified with a schema like "schema.procedure"
+//   - params            procedure parameters
+// Returns error or nil for success.
+func (c *PostgresPersistence) CallProcedure(correlationId string, name string, params ...interface{}) (err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkNotDryRun(correlationId); err != nil {
+		return err
+	}
+
+	query := "CALL " + c.quoteTableReference(name) + "(" + c.GenerateParameters(params) + ")"
+
+	_, err = c.Client.Exec(ctx, query, params...)
+	if err != nil {
+		return err
+	}
+
+	c.Logger.Trace(correlationId, "Called procedure %s", name)
+	return nil
+}
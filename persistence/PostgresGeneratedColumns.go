@@ -0,0 +1,48 @@
+package persistence
+
+import (
+	"strings"
+)
+
+// Adds a statement to add a stored generated column to the table on opening
+// and excludes the column from INSERT and UPDATE statements, since its values
+// are always computed by the database, e.g. to index a field extracted from JSONB.
+// When the table is created by EnsureTableFromPrototype, tag the prototype field
+// with postgres:"generated" and call this method after it.
+// Must be called in DefineSchema.
+//   - name          a name of the generated column
+//   - colType       a column type
+//   - expression    an expression that computes the column value, e.g. "data->>'email'"
+func (c *PostgresPersistence) EnsureGeneratedColumn(name string, colType string, expression string) {
+	definition := colType + " GENERATED ALWAYS AS (" + expression + ") STORED"
+	c.setGeneratedColumn(name, definition)
+	c.EnsureSchema("ALTER TABLE " + c.QuotedTableName() + " ADD COLUMN IF NOT EXISTS " +
+		c.QuoteIdentifier(name) + " " + definition)
+}
+
+// Checks if a column is generated and cannot be written.
+//   - name      a column name
+// Returns true if the column is generated.
+func (c *PostgresPersistence) IsGeneratedColumn(name string) bool {
+	_, ok := c.generatedColumns[name]
+	return ok
+}
+
+func (c *PostgresPersistence) setGeneratedColumn(name string, definition string) {
+	if c.generatedColumns == nil {
+		c.generatedColumns = make(map[string]string)
+	}
+	c.generatedColumns[name] = definition
+}
+
+// Checks if a column definition declares a generated column
+func isGeneratedDefinition(definition string) bool {
+	return strings.Contains(strings.ToUpper(definition), "GENERATED ALWAYS AS")
+}
+
+// Removes generated columns from statement values
+func (c *PostgresPersistence) excludeGeneratedColumns(items map[string]interface{}) {
+	for name := range c.generatedColumns {
+		delete(items, name)
+	}
+}
@@ -0,0 +1,238 @@
+package persistence
+
+import (
+	"time"
+
+	"github.com/jackc/pgproto3/v2"
+	"github.com/jackc/pgx/v4"
+	cconv "github.com/pip-services3-go/pip-services3-commons-go/convert"
+)
+
+// Operations recorded in the history table
+const (
+	HistoryInsert = "INSERT"
+	HistoryUpdate = "UPDATE"
+	HistoryDelete = "DELETE"
+)
+
+// Number of service columns appended to history rows
+const historyColumns = 2
+
+// Configuration of the history table
+type postgresHistoryOptions struct {
+	enabled bool
+	table   string
+	key     string
+}
+
+// Version of a data item kept in the history table
+type PostgresHistoryItem struct {
+	// A time when the version was written
+	Time time.Time `json:"time"`
+	// An operation that wrote the version: HistoryInsert, HistoryUpdate or HistoryDelete
+	Operation string `json:"operation"`
+	// The item after the operation, or before it for HistoryDelete
+	Item interface{} `json:"item"`
+}
+
+// Wraps query results to hide trailing history columns from conversion
+type historyRows struct {
+	pgx.Rows
+}
+
+func (r *historyRows) FieldDescriptions() []pgproto3.FieldDescription {
+	fields := r.Rows.FieldDescriptions()
+	return fields[:len(fields)-historyColumns]
+}
+
+func (r *historyRows) Values() ([]interface{}, error) {
+	values, err := r.Rows.Values()
+	if err != nil || len(values) < historyColumns {
+		return values, err
+	}
+	return values[:len(values)-historyColumns], nil
+}
+
+func (r *historyRows) RawValues() [][]byte {
+	values := r.Rows.RawValues()
+	if len(values) < historyColumns {
+		return values
+	}
+	return values[:len(values)-historyColumns]
+}
+
+// Gets the time and the operation of the history row
+func (r *historyRows) version() (time.Time, string) {
+	values, err := r.Rows.Values()
+	if err != nil || len(values) < historyColumns {
+		return time.Time{}, ""
+	}
+	versionTime, _ := values[len(values)-2].(time.Time)
+	operation, _ := values[len(values)-1].(string)
+	return versionTime, operation
+}
+
+// Gets the name of the history table
+func (c *PostgresPersistence) historyTableName() string {
+	if c.history.table != "" {
+		return c.history.table
+	}
+	return c.TableName + "_history"
+}
+
+// Gets the quoted name of the history table
+func (c *PostgresPersistence) quotedHistoryTableName() string {
+	if len(c.SchemaName) > 0 {
+		return c.QuoteIdentifier(c.SchemaName) + "." + c.QuoteIdentifier(c.historyTableName())
+	}
+	return c.QuoteIdentifier(c.historyTableName())
+}
+
+// Creates the history table and the trigger that writes versions of items into it,
+// when options.history is enabled. Versions are stored as JSON, so the table
+// does not have to follow changes of the table structure.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns error or nil no errors occured.
+func (c *PostgresPersistence) ensureHistory(correlationId string) error {
+	if !c.history.enabled || c.view {
+		return nil
+	}
+
+	history := c.quotedHistoryTableName()
+	function := c.triggerFunctionName("history")
+	trigger := c.QuoteIdentifier(c.TableName + "_history")
+	key := c.QuoteLiteral(c.history.key)
+
+	statements := []string{
+		"CREATE TABLE IF NOT EXISTS " + history + " (\"history_id\" BIGSERIAL PRIMARY KEY," +
+			" \"history_key\" TEXT NOT NULL, \"history_time\" TIMESTAMPTZ NOT NULL DEFAULT now()," +
+			" \"history_operation\" TEXT NOT NULL, \"item\" JSONB NOT NULL)",
+		"CREATE INDEX IF NOT EXISTS " + c.QuoteIdentifier(c.historyTableName()+"_key") + " ON " + history +
+			" (\"history_key\", \"history_time\")",
+		"CREATE OR REPLACE FUNCTION " + function + "() RETURNS trigger AS $$\nBEGIN\n" +
+			"IF TG_OP = 'DELETE' THEN\n" +
+			"INSERT INTO " + history + " (\"history_key\", \"history_operation\", \"item\")" +
+			" VALUES (to_jsonb(OLD)->>" + key + ", TG_OP, to_jsonb(OLD));\n" +
+			"ELSE\n" +
+			"INSERT INTO " + history + " (\"history_key\", \"history_operation\", \"item\")" +
+			" VALUES (to_jsonb(NEW)->>" + key + ", TG_OP, to_jsonb(NEW));\n" +
+			"END IF;\nRETURN NULL;\nEND\n$$ LANGUAGE plpgsql",
+		"DROP TRIGGER IF EXISTS " + trigger + " ON " + c.QuotedTableName(),
+		"CREATE TRIGGER " + trigger + " AFTER INSERT OR UPDATE OR DELETE ON " + c.QuotedTableName() +
+			" FOR EACH ROW EXECUTE PROCEDURE " + function + "()",
+	}
+	for _, statement := range statements {
+		if _, err := c.Client.Exec(c.schemaContext(), statement); err != nil {
+			return err
+		}
+	}
+
+	c.Logger.Debug(correlationId, "Ensured history table %s of %s", c.historyTableName(), c.TableName)
+	return nil
+}
+
+// Composes a projection that restores items from the history in the current table structure
+func (c *PostgresPersistence) historySelect() string {
+	return "(jsonb_populate_record(NULL::" + c.QuotedTableName() + ", \"item\")).*"
+}
+
+// Composes a tenant condition on history items with leading AND
+func (c *PostgresPersistence) andHistoryTenantCondition() string {
+	if c.tenancy != TenancyColumn || c.tenant == "" {
+		return ""
+	}
+	return " AND \"item\"->>" + c.QuoteLiteral(c.tenantColumn) + "=" + c.QuoteLiteral(c.tenant)
+}
+
+// Gets all versions of a data item from the history table, from the oldest to the newest.
+// Requires options.history to be enabled.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - id                an id of the item, the value of options.history_key column
+// Returns versions of the item or error.
+func (c *PostgresPersistence) GetHistoryById(correlationId string, id interface{}) (items []*PostgresHistoryItem, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	query := "SELECT " + c.historySelect() + ", \"history_time\", \"history_operation\"" +
+		" FROM " + c.quotedHistoryTableName() +
+		" WHERE \"history_key\"=$1" + c.andHistoryTenantCondition() +
+		" ORDER BY \"history_time\", \"history_id\""
+
+	qResult, qErr := c.ReadClient.Query(ctx, query, cconv.StringConverter.ToString(id))
+	if qErr != nil {
+		return nil, qErr
+	}
+	defer qResult.Close()
+
+	rows := &historyRows{Rows: qResult}
+	items = make([]*PostgresHistoryItem, 0)
+	for qResult.Next() {
+		item, convErr := c.ConvertRowToPublic(correlationId, rows)
+		if convErr != nil {
+			return nil, convErr
+		}
+		versionTime, operation := rows.version()
+		items = append(items, &PostgresHistoryItem{Time: versionTime, Operation: operation, Item: item})
+	}
+	if err = qResult.Err(); err != nil {
+		return nil, err
+	}
+
+	c.Logger.Trace(correlationId, "Retrieved %d versions of %s from %s", len(items), id, c.historyTableName())
+	return items, nil
+}
+
+// Gets data items as they were at a given time, restored from the history table.
+// Items deleted before that time are not returned. Requires options.history to be enabled.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - filter            (optional) a filter SQL condition on columns of the table
+//   - asOf              a time to get the items at
+// Returns the items or error.
+func (c *PostgresPersistence) GetAsOf(correlationId string, filter string, asOf time.Time) (items []interface{}, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	// The latest version of every item written before the time
+	versions := "SELECT DISTINCT ON (\"history_key\") \"item\", \"history_operation\"" +
+		" FROM " + c.quotedHistoryTableName() +
+		" WHERE \"history_time\"<=$1" + c.andHistoryTenantCondition() +
+		" ORDER BY \"history_key\", \"history_time\" DESC, \"history_id\" DESC"
+	query := "SELECT * FROM (SELECT " + c.historySelect() + " FROM (" + versions + ") AS versions" +
+		" WHERE \"history_operation\"<>'" + HistoryDelete + "') AS items"
+	if filter != "" {
+		query += " WHERE " + filter
+	}
+
+	qResult, qErr := c.ReadClient.Query(ctx, query, asOf)
+	if qErr != nil {
+		return nil, qErr
+	}
+	defer qResult.Close()
+
+	items = make([]interface{}, 0)
+	skipped := 0
+	for qResult.Next() {
+		item, convErr := c.ConvertRowToPublic(correlationId, qResult)
+		if convErr != nil {
+			return nil, convErr
+		}
+		if item == nil {
+			skipped++
+			continue
+		}
+		items = append(items, item)
+	}
+	c.logSkippedRows(correlationId, skipped, len(items))
+	if err = qResult.Err(); err != nil {
+		return nil, err
+	}
+
+	c.Logger.Trace(correlationId, "Retrieved %d items of %s as of %s", len(items), c.TableName, asOf.String())
+	return items, nil
+}
@@ -0,0 +1,155 @@
+package persistence
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// Adds statements to enable hstore extension and to add a hstore column
+// to the table on opening. Must be called in DefineSchema after the table definition.
+//   - name      a name of the hstore column
+func (c *PostgresPersistence) EnsureHstoreColumn(name string) {
+	c.EnsureSchema("CREATE EXTENSION IF NOT EXISTS hstore")
+	c.EnsureSchema("ALTER TABLE " + c.QuotedTableName() + " ADD COLUMN IF NOT EXISTS " +
+		c.QuoteIdentifier(name) + " hstore")
+}
+
+// Composes a filter that selects rows which hstore column contains a key.
+//   - column    a hstore column
+//   - key       a key to look for
+// Returns the composed filter.
+func (c *PostgresPersistence) ComposeHstoreKeyFilter(column string, key string) string {
+	return c.QuoteIdentifier(column) + " ? " + c.QuoteLiteral(key)
+}
+
+// Composes a filter that selects rows which hstore column has a key with the given value.
+//   - column    a hstore column
+//   - key       a key to compare
+//   - value     an expected value
+// Returns the composed filter.
+func (c *PostgresPersistence) ComposeHstoreValueFilter(column string, key string, value string) string {
+	return c.QuoteIdentifier(column) + " -> " + c.QuoteLiteral(key) + " = " + c.QuoteLiteral(value)
+}
+
+// Composes a filter that selects rows which hstore column contains all given pairs.
+// The filter uses GIN or GiST indexes on the column.
+//   - column    a hstore column
+//   - values    key-value pairs to look for
+// Returns the composed filter.
+func (c *PostgresPersistence) ComposeHstoreContainsFilter(column string, values map[string]string) string {
+	return c.QuoteIdentifier(column) + " @> " + c.QuoteLiteral(HstoreToString(values)) + "::hstore"
+}
+
+// Converts a map into hstore text representation like "a"=>"1", "b"=>"2".
+// Use it in ConvertFromPublic to write maps into hstore columns.
+//   - values    a map to convert
+// Returns hstore text value
+func HstoreToString(values map[string]string) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	builder := strings.Builder{}
+	for index, key := range keys {
+		if index > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(quoteHstoreString(key))
+		builder.WriteString("=>")
+		builder.WriteString(quoteHstoreString(values[key]))
+	}
+	return builder.String()
+}
+
+func quoteHstoreString(value string) string {
+	value = strings.ReplaceAll(value, "\\", "\\\\")
+	value = strings.ReplaceAll(value, "\"", "\\\"")
+	return "\"" + value + "\""
+}
+
+// Parses hstore text representation like "a"=>"1", "b"=>NULL into a map.
+// Keys with NULL values are omitted.
+// Use it in ConvertToPublic to read maps from hstore columns.
+//   - value     a hstore text value
+// Returns parsed map or error
+func ParseHstore(value string) (map[string]string, error) {
+	result := make(map[string]string)
+	pos := 0
+
+	skipSpaces := func() {
+		for pos < len(value) && (value[pos] == ' ' || value[pos] == '\t' || value[pos] == '\n') {
+			pos++
+		}
+	}
+
+	readString := func() (string, bool, error) {
+		skipSpaces()
+		if pos >= len(value) {
+			return "", false, errors.New("invalid hstore: unexpected end")
+		}
+		if value[pos] != '"' {
+			start := pos
+			for pos < len(value) && value[pos] != '=' && value[pos] != ',' && value[pos] != ' ' {
+				pos++
+			}
+			word := value[start:pos]
+			if strings.ToUpper(word) == "NULL" {
+				return "", true, nil
+			}
+			return word, false, nil
+		}
+
+		pos++
+		builder := strings.Builder{}
+		for pos < len(value) {
+			ch := value[pos]
+			if ch == '\\' && pos+1 < len(value) {
+				builder.WriteByte(value[pos+1])
+				pos += 2
+				continue
+			}
+			if ch == '"' {
+				pos++
+				return builder.String(), false, nil
+			}
+			builder.WriteByte(ch)
+			pos++
+		}
+		return "", false, errors.New("invalid hstore: unterminated string")
+	}
+
+	for {
+		skipSpaces()
+		if pos >= len(value) {
+			return result, nil
+		}
+
+		key, _, err := readString()
+		if err != nil {
+			return nil, err
+		}
+		skipSpaces()
+		if !strings.HasPrefix(value[pos:], "=>") {
+			return nil, errors.New("invalid hstore: expected =>")
+		}
+		pos += 2
+		val, isNull, err := readString()
+		if err != nil {
+			return nil, err
+		}
+		if !isNull {
+			result[key] = val
+		}
+
+		skipSpaces()
+		if pos < len(value) {
+			if value[pos] != ',' {
+				return nil, errors.New("invalid hstore: expected ,")
+			}
+			pos++
+		}
+	}
+}
@@ -0,0 +1,434 @@
+package persistence
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+)
+
+// Options of data imports
+type PostgresImportOptions struct {
+	// Update existing rows with conflicting keys instead of failing their import
+	Upsert bool
+	// Key columns that identify rows in upsert mode, "id" by default
+	KeyColumns []string
+	// A maximum number of rows loaded by one COPY statement, 1000 by default
+	BatchSize int
+}
+
+// Failure to import a record
+type PostgresImportError struct {
+	// An ordinal number of the record in the input starting from 1, CSV header is not counted
+	Record int `json:"record"`
+	// A cause of the failure
+	Err error `json:"error"`
+}
+
+func (e *PostgresImportError) Error() string {
+	return "record " + strconv.Itoa(e.Record) + ": " + e.Err.Error()
+}
+
+// Outcome of a data import
+type PostgresImportResult struct {
+	// A number of imported rows
+	Imported int64 `json:"imported"`
+	// Records that failed to be parsed, converted or loaded
+	Errors []*PostgresImportError `json:"errors"`
+}
+
+// Record converted into columns of the table
+type postgresImportRow struct {
+	record  int
+	columns []string
+	values  map[string]interface{}
+}
+
+// Escapes special characters of COPY text format
+var copyTextEscaper = strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\r", "\\r", "\t", "\\t")
+
+// Loads records from a reader into the table using COPY FROM, e.g. to restore data exported by ExportByFilter.
+// Records are decoded into the prototype and mapped through ConvertFromPublic like items passed to Create.
+// Records that cannot be parsed, converted or loaded are collected in the result while other records are imported.
+// When a batch fails, its rows are loaded one by one to find the failed ones.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - format            a data format: DataFormatJson or DataFormatCsv with a header row of field names
+//   - reader            a reader to load the data from
+//   - options           (optional) import options
+// Returns the import result or error if the import was aborted.
+func (c *PostgresPersistence) ImportFromReader(correlationId string, format string, reader io.Reader,
+	options *PostgresImportOptions) (result *PostgresImportResult, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkWritable(correlationId); err != nil {
+		return nil, err
+	}
+	if err = c.checkNotDryRun(correlationId); err != nil {
+		return nil, err
+	}
+
+	if options == nil {
+		options = &PostgresImportOptions{}
+	}
+	batchSize := options.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+
+	var next func() (map[string]interface{}, error)
+	switch format {
+	case DataFormatJson:
+		next = c.jsonRecordReader(reader)
+	case DataFormatCsv:
+		next = c.csvRecordReader(reader)
+	default:
+		return nil, cerr.NewBadRequestError(correlationId, "INVALID_FORMAT", "Data format "+format+" is not supported").
+			WithDetails("format", format)
+	}
+
+	conn, aErr := c.AcquireConnection(ctx, c.Client)
+	if aErr != nil {
+		return nil, aErr
+	}
+	defer conn.Release()
+
+	result = &PostgresImportResult{Errors: make([]*PostgresImportError, 0)}
+	batch := make([]*postgresImportRow, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		imported, failed, fErr := c.importBatch(ctx, conn, batch, options)
+		result.Imported += imported
+		result.Errors = append(result.Errors, failed...)
+		batch = batch[:0]
+		return fErr
+	}
+
+	for record := 1; ; record++ {
+		values, rErr := next()
+		if rErr == io.EOF {
+			break
+		}
+		if rErr != nil {
+			var parseErr *csv.ParseError
+			var syntaxErr *json.SyntaxError
+			if !errors.As(rErr, &parseErr) && !errors.As(rErr, &syntaxErr) && !errors.As(rErr, new(*json.UnmarshalTypeError)) {
+				return result, rErr
+			}
+			result.Errors = append(result.Errors, &PostgresImportError{Record: record, Err: rErr})
+			continue
+		}
+
+		row, cErr := c.convertImportRecord(correlationId, values)
+		if cErr != nil {
+			result.Errors = append(result.Errors, &PostgresImportError{Record: record, Err: cErr})
+			continue
+		}
+		row.record = record
+		batch = append(batch, row)
+		if len(batch) >= batchSize {
+			if err = flush(); err != nil {
+				return result, err
+			}
+		}
+	}
+	if err = flush(); err != nil {
+		return result, err
+	}
+
+	c.Logger.Debug(correlationId, "Imported %d items into %s, %d failed", result.Imported, c.TableName, len(result.Errors))
+	return result, nil
+}
+
+// Creates a reader of JSON Lines records
+func (c *PostgresPersistence) jsonRecordReader(reader io.Reader) func() (map[string]interface{}, error) {
+	buffered := bufio.NewReader(reader)
+	return func() (map[string]interface{}, error) {
+		for {
+			line, err := buffered.ReadBytes('\n')
+			if err != nil && err != io.EOF {
+				return nil, err
+			}
+			line = bytes.TrimSpace(line)
+			if len(line) == 0 {
+				if err == io.EOF {
+					return nil, io.EOF
+				}
+				continue
+			}
+
+			values := make(map[string]interface{})
+			decoder := json.NewDecoder(bytes.NewReader(line))
+			decoder.UseNumber()
+			if dErr := decoder.Decode(&values); dErr != nil {
+				return nil, dErr
+			}
+			return values, nil
+		}
+	}
+}
+
+// Creates a reader of CSV records with a header row of field names.
+// Empty values are imported as nulls.
+func (c *PostgresPersistence) csvRecordReader(reader io.Reader) func() (map[string]interface{}, error) {
+	records := csv.NewReader(reader)
+	var header []string
+	return func() (map[string]interface{}, error) {
+		if header == nil {
+			names, err := records.Read()
+			if err != nil {
+				return nil, err
+			}
+			header = names
+		}
+
+		fields, err := records.Read()
+		if err != nil {
+			return nil, err
+		}
+		values := make(map[string]interface{}, len(fields))
+		for index, field := range fields {
+			if field != "" {
+				values[header[index]] = c.parseCsvValue(header[index], field)
+			}
+		}
+		return values, nil
+	}
+}
+
+// Parses a CSV value of a prototype field that is not a string,
+// so it can be decoded into the prototype
+func (c *PostgresPersistence) parseCsvValue(name string, text string) interface{} {
+	index, ok := c.prototypeFields[name]
+	if !ok {
+		return text
+	}
+	fieldType := c.prototypeStruct().FieldByIndex(index).Type
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	if fieldType.Kind() == reflect.String || fieldType == reflect.TypeOf(time.Time{}) {
+		return text
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return text
+	}
+	return value
+}
+
+// Converts an imported record into columns of the table
+func (c *PostgresPersistence) convertImportRecord(correlationId string, values map[string]interface{}) (*postgresImportRow, error) {
+	item := c.decodeToPrototype(values)
+	if convErr, ok := item.(*DataConversionError); ok {
+		convErr.CorrelationId = correlationId
+		return nil, convErr
+	}
+
+	return c.convertImportItem(correlationId, item)
+}
+
+// Converts a data item into columns of the table like Create does
+func (c *PostgresPersistence) convertImportItem(correlationId string, item interface{}) (*postgresImportRow, error) {
+	row := c.injectTenant(c.Overrides.ConvertFromPublic(item))
+	columns := c.convertToMap(row)
+	if columns == nil {
+		return nil, NewDataConversionError(correlationId, "", item, nil)
+	}
+	c.omitDefaultColumns(row, columns)
+
+	names := make([]string, 0, len(columns))
+	for name := range columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return &postgresImportRow{columns: names, values: columns}, nil
+}
+
+// Loads a batch of rows. Rows with the same columns are loaded together,
+// so omitted columns get their defaults. Failed groups are retried row by row.
+// Returns a number of imported rows, failed rows and error if the import shall be aborted.
+func (c *PostgresPersistence) importBatch(ctx context.Context, conn *pgxpool.Conn, batch []*postgresImportRow,
+	options *PostgresImportOptions) (int64, []*PostgresImportError, error) {
+	groups := make(map[string][]*postgresImportRow)
+	keys := make([]string, 0)
+	for _, row := range batch {
+		key := strings.Join(row.columns, ",")
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], row)
+	}
+
+	var imported int64
+	failed := make([]*PostgresImportError, 0)
+	for _, key := range keys {
+		rows := groups[key]
+		count, err := c.copyImportRows(ctx, conn, rows, options)
+		if err == nil {
+			imported += count
+			continue
+		}
+		if ctx.Err() != nil {
+			return imported, failed, err
+		}
+		for _, row := range rows {
+			count, err = c.copyImportRows(ctx, conn, []*postgresImportRow{row}, options)
+			if err != nil {
+				if ctx.Err() != nil {
+					return imported, failed, err
+				}
+				failed = append(failed, &PostgresImportError{Record: row.record, Err: err})
+				continue
+			}
+			imported += count
+		}
+	}
+	return imported, failed, nil
+}
+
+// Loads rows with the same columns by COPY FROM. In upsert mode rows are copied
+// into a temporary table and then merged into the table.
+func (c *PostgresPersistence) copyImportRows(ctx context.Context, conn *pgxpool.Conn, rows []*postgresImportRow,
+	options *PostgresImportOptions) (count int64, err error) {
+	columnNames := rows[0].columns
+	columns := make([]string, len(columnNames))
+	for index, name := range columnNames {
+		columns[index] = c.QuoteIdentifier(name)
+	}
+	columnList := strings.Join(columns, ",")
+
+	var data bytes.Buffer
+	for _, row := range rows {
+		for index, name := range columnNames {
+			if index > 0 {
+				data.WriteByte('\t')
+			}
+			value, vErr := encodeCopyValue(row.values[name])
+			if vErr != nil {
+				return 0, vErr
+			}
+			data.WriteString(value)
+		}
+		data.WriteByte('\n')
+	}
+
+	if !options.Upsert {
+		tag, cErr := conn.Conn().PgConn().CopyFrom(ctx, &data, "COPY "+c.QuotedTableName()+" ("+columnList+") FROM STDIN")
+		if cErr != nil {
+			return 0, cErr
+		}
+		return tag.RowsAffected(), nil
+	}
+
+	tx, tErr := conn.Begin(ctx)
+	if tErr != nil {
+		return 0, tErr
+	}
+	defer tx.Rollback(ctx)
+
+	temp := c.QuoteIdentifier(c.TableName + "_import")
+	_, err = tx.Exec(ctx, "CREATE TEMP TABLE "+temp+" (LIKE "+c.QuotedTableName()+" INCLUDING DEFAULTS) ON COMMIT DROP")
+	if err != nil {
+		return 0, err
+	}
+	if _, err = tx.Conn().PgConn().CopyFrom(ctx, &data, "COPY "+temp+" ("+columnList+") FROM STDIN"); err != nil {
+		return 0, err
+	}
+
+	tag, eErr := tx.Exec(ctx, "INSERT INTO "+c.QuotedTableName()+" ("+columnList+") SELECT "+columnList+" FROM "+temp+
+		c.importConflictClause(columnNames, options.KeyColumns))
+	if eErr != nil {
+		return 0, eErr
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// Composes ON CONFLICT clause of upserts
+func (c *PostgresPersistence) importConflictClause(columns []string, keyColumns []string) string {
+	if len(keyColumns) == 0 {
+		keyColumns = []string{"id"}
+	}
+	isKey := make(map[string]bool, len(keyColumns))
+	keys := make([]string, len(keyColumns))
+	for index, key := range keyColumns {
+		isKey[key] = true
+		keys[index] = c.QuoteIdentifier(key)
+	}
+
+	sets := make([]string, 0, len(columns))
+	for _, column := range columns {
+		if !isKey[column] {
+			sets = append(sets, c.QuoteIdentifier(column)+"=EXCLUDED."+c.QuoteIdentifier(column))
+		}
+	}
+	if len(sets) == 0 {
+		return " ON CONFLICT (" + strings.Join(keys, ",") + ") DO NOTHING"
+	}
+	return " ON CONFLICT (" + strings.Join(keys, ",") + ") DO UPDATE SET " + strings.Join(sets, ",") +
+		c.upsertTenantCondition()
+}
+
+// Encodes a value in COPY text format
+func encodeCopyValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "\\N", nil
+	case string:
+		return copyTextEscaper.Replace(v), nil
+	case []byte:
+		return copyTextEscaper.Replace("\\x" + hex.EncodeToString(v)), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case json.Number:
+		return v.String(), nil
+	case driver.Valuer:
+		if reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil() {
+			return "\\N", nil
+		}
+		converted, err := v.Value()
+		if err != nil {
+			return "", err
+		}
+		return encodeCopyValue(converted)
+	}
+
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return fmt.Sprint(value), nil
+	}
+	buffer, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return copyTextEscaper.Replace(string(buffer)), nil
+}
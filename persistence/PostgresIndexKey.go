@@ -0,0 +1,83 @@
+package persistence
+
+import "strings"
+
+// Key of an index created by EnsureIndexKeys.
+type PostgresIndexKey struct {
+	// Column name, quoted in the statement
+	Column string
+	// SQL expression used as is when column is not set, e.g. lower("name") or ("data"->>'email')
+	Expression string
+	// True for descending order
+	Descending bool
+}
+
+// Creates an index key on a column. The column name is quoted.
+//   - column      a column name.
+//   - descending  true for descending order.
+// Returns the index key.
+func NewIndexColumn(column string, descending bool) *PostgresIndexKey {
+	return &PostgresIndexKey{Column: column, Descending: descending}
+}
+
+// Creates an index key on an SQL expression. The expression is used as it is,
+// so identifiers inside it must be quoted by the caller when needed.
+//   - expression  an SQL expression.
+//   - descending  true for descending order.
+// Returns the index key.
+func NewIndexExpression(expression string, descending bool) *PostgresIndexKey {
+	return &PostgresIndexKey{Expression: expression, Descending: descending}
+}
+
+// Adds index definition with ordered column and expression keys to create it on opening.
+// Supported options:
+//   - unique   "true" to create a unique index
+//   - type     index method clause, e.g. "USING gin"
+//   - where    a predicate for a partial index
+//
+//   - name     an index name
+//   - keys     index keys
+//   - options  index options
+func (c *PostgresPersistence) EnsureIndexKeys(name string, keys []*PostgresIndexKey, options map[string]string) {
+	fields := make([]string, 0, len(keys))
+	for _, key := range keys {
+		var field string
+		if key.Column != "" {
+			field = c.QuoteIdentifier(key.Column)
+		} else {
+			// Expressions must be parenthesized unless they are function calls
+			field = "(" + key.Expression + ")"
+		}
+		if key.Descending {
+			field += " DESC"
+		}
+		fields = append(fields, field)
+	}
+
+	c.EnsureSchema(c.composeIndexStatement(name, strings.Join(fields, ", "), options))
+}
+
+func (c *PostgresPersistence) composeIndexStatement(name string, fields string, options map[string]string) string {
+	builder := "CREATE"
+	if options == nil {
+		options = make(map[string]string, 0)
+	}
+
+	if options["unique"] != "" {
+		builder += " UNIQUE"
+	}
+
+	// Index is always created in the schema of its table, so its name cannot be qualified
+	builder += " INDEX IF NOT EXISTS " + c.QuoteIdentifier(name) + " ON " + c.QuotedTableName()
+
+	if options["type"] != "" {
+		builder += " " + options["type"]
+	}
+
+	builder += "(" + fields + ")"
+
+	if options["where"] != "" {
+		builder += " WHERE " + options["where"]
+	}
+	return builder
+}
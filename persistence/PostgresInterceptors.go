@@ -0,0 +1,213 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/jackc/pgx/v4"
+)
+
+// Executes a SQL statement and returns its result: pgx.Rows for queries, pgx.Row for single row queries,
+// pgconn.CommandTag for commands and int64 number of copied rows for COPY.
+type PostgresStatementFunc func(ctx context.Context, sql string, args []interface{}) (interface{}, error)
+
+// Middleware that wraps execution of SQL statements, for cross-cutting concerns like tracing,
+// rewriting or mirroring of statements. An interceptor calls next to continue the chain,
+// it can change the statement and its arguments, call next several times or return a result without calling it.
+//   - ctx               a context of the operation
+//   - correlationId     transaction id of the operation, empty for schema statements
+//   - sql               a SQL statement. COPY is passed as "COPY table (columns) FROM STDIN" without arguments
+//   - args              arguments of the statement
+//   - next              the next interceptor or the statement execution
+// Returns the result of next or a replacement of the same type.
+type PostgresInterceptor func(ctx context.Context, correlationId string, sql string, args []interface{},
+	next PostgresStatementFunc) (interface{}, error)
+
+type postgresCorrelationIdKey struct{}
+
+// Adds an interceptor that wraps every SQL execution of the persistence.
+// Interceptors are called in the order they were added and shall be added before Open.
+// Statements run on dedicated connections, like COPY in ExportByFilter, bypass interceptors.
+//   - interceptor   an interceptor to be added
+func (c *PostgresPersistence) AddInterceptor(interceptor PostgresInterceptor) {
+	c.interceptors = append(c.interceptors, interceptor)
+}
+
+// Adds a correlation id to a context of an operation, to pass it to interceptors
+func withCorrelationId(ctx context.Context, correlationId string) context.Context {
+	return context.WithValue(ctx, postgresCorrelationIdKey{}, correlationId)
+}
+
+// Gets a correlation id of an operation from its context
+func getCorrelationId(ctx context.Context) string {
+	correlationId, _ := ctx.Value(postgresCorrelationIdKey{}).(string)
+	return correlationId
+}
+
+// Gets a client wrapped by retries and interceptors, the current pool for clients of a connection
+func unwrapClient(client IPostgresClient) IPostgresClient {
+	if retrying, ok := client.(*retryingClient); ok {
+		client = retrying.IPostgresClient
+	}
+	if intercepted, ok := client.(*interceptedClient); ok {
+		client = intercepted.client
+	}
+	if poolClient, ok := client.(*postgresPoolClient); ok {
+		if pool := poolClient.pool(); pool != nil {
+			return pool
+		}
+	}
+	return client
+}
+
+// Client that runs statements through a chain of interceptors
+type interceptedClient struct {
+	client       IPostgresClient
+	interceptors []PostgresInterceptor
+}
+
+func newInterceptedClient(client IPostgresClient, interceptors []PostgresInterceptor) IPostgresClient {
+	if len(interceptors) == 0 || client == nil {
+		return client
+	}
+	return &interceptedClient{client: client, interceptors: interceptors}
+}
+
+// Runs a statement through the interceptors, the first added interceptor is the outermost one
+func (c *interceptedClient) execute(ctx context.Context, sql string, args []interface{},
+	statement PostgresStatementFunc) (interface{}, error) {
+	correlationId := getCorrelationId(ctx)
+	next := statement
+	for index := len(c.interceptors) - 1; index >= 0; index-- {
+		interceptor, inner := c.interceptors[index], next
+		next = func(ctx context.Context, sql string, args []interface{}) (interface{}, error) {
+			return interceptor(ctx, correlationId, sql, args, inner)
+		}
+	}
+	return next(ctx, sql, args)
+}
+
+func (c *interceptedClient) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return interceptQuery(c, c.client, ctx, sql, args)
+}
+
+func (c *interceptedClient) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return interceptQueryRow(c, c.client, ctx, sql, args)
+}
+
+func (c *interceptedClient) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	return interceptExec(c, c.client, ctx, sql, args)
+}
+
+func (c *interceptedClient) Begin(ctx context.Context) (pgx.Tx, error) {
+	tx, err := c.client.Begin(ctx)
+	if err != nil {
+		return tx, err
+	}
+	return &interceptedTx{Tx: tx, client: c}, nil
+}
+
+func (c *interceptedClient) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string,
+	rowSrc pgx.CopyFromSource) (int64, error) {
+	return interceptCopyFrom(c, c.client, ctx, tableName, columnNames, rowSrc)
+}
+
+// Transaction that runs statements through interceptors of its client
+type interceptedTx struct {
+	pgx.Tx
+	client *interceptedClient
+}
+
+func (c *interceptedTx) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return interceptQuery(c.client, c.Tx, ctx, sql, args)
+}
+
+func (c *interceptedTx) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return interceptQueryRow(c.client, c.Tx, ctx, sql, args)
+}
+
+func (c *interceptedTx) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	return interceptExec(c.client, c.Tx, ctx, sql, args)
+}
+
+func (c *interceptedTx) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string,
+	rowSrc pgx.CopyFromSource) (int64, error) {
+	return interceptCopyFrom(c.client, c.Tx, ctx, tableName, columnNames, rowSrc)
+}
+
+// Statements of clients and transactions
+type postgresStatementRunner interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+func interceptQuery(c *interceptedClient, runner postgresStatementRunner, ctx context.Context,
+	sql string, args []interface{}) (pgx.Rows, error) {
+	result, err := c.execute(ctx, sql, args, func(ctx context.Context, sql string, args []interface{}) (interface{}, error) {
+		return runner.Query(ctx, sql, args...)
+	})
+	// Callers close rows even on errors, so they are never nil
+	if rows, ok := result.(pgx.Rows); ok && rows != nil {
+		return rows, err
+	}
+	if err == nil {
+		err = errors.New("interceptor returned no rows for query")
+	}
+	return &errorRows{err: err}, err
+}
+
+func interceptQueryRow(c *interceptedClient, runner postgresStatementRunner, ctx context.Context,
+	sql string, args []interface{}) pgx.Row {
+	result, err := c.execute(ctx, sql, args, func(ctx context.Context, sql string, args []interface{}) (interface{}, error) {
+		return runner.QueryRow(ctx, sql, args...), nil
+	})
+	if row, ok := result.(pgx.Row); ok && row != nil && err == nil {
+		return row
+	}
+	if err == nil {
+		err = errors.New("interceptor returned no row for query")
+	}
+	return &errorRows{err: err}
+}
+
+func interceptExec(c *interceptedClient, runner postgresStatementRunner, ctx context.Context,
+	sql string, args []interface{}) (pgconn.CommandTag, error) {
+	result, err := c.execute(ctx, sql, args, func(ctx context.Context, sql string, args []interface{}) (interface{}, error) {
+		return runner.Exec(ctx, sql, args...)
+	})
+	tag, _ := result.(pgconn.CommandTag)
+	return tag, err
+}
+
+func interceptCopyFrom(c *interceptedClient, runner postgresStatementRunner, ctx context.Context,
+	tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	columns := make([]string, len(columnNames))
+	for index, column := range columnNames {
+		columns[index] = pgx.Identifier{column}.Sanitize()
+	}
+	sql := "COPY " + tableName.Sanitize() + " (" + strings.Join(columns, ",") + ") FROM STDIN"
+	result, err := c.execute(ctx, sql, nil, func(ctx context.Context, sql string, args []interface{}) (interface{}, error) {
+		return runner.CopyFrom(ctx, tableName, columnNames, rowSrc)
+	})
+	count, _ := result.(int64)
+	return count, err
+}
+
+// Rows of a statement that failed or was rejected by an interceptor
+type errorRows struct {
+	err error
+}
+
+func (c *errorRows) Close()                                         {}
+func (c *errorRows) Err() error                                     { return c.err }
+func (c *errorRows) CommandTag() pgconn.CommandTag                  { return nil }
+func (c *errorRows) FieldDescriptions() []pgproto3.FieldDescription { return nil }
+func (c *errorRows) Next() bool                                     { return false }
+func (c *errorRows) Scan(dest ...interface{}) error                 { return c.err }
+func (c *errorRows) Values() ([]interface{}, error)                 { return nil, c.err }
+func (c *errorRows) RawValues() [][]byte                            { return nil }
@@ -0,0 +1,126 @@
+package persistence
+
+import (
+	"strings"
+)
+
+// Join of a related table used to filter data items by columns of the related table
+type PostgresJoin struct {
+	// Join type: INNER, LEFT, RIGHT or FULL. INNER when not set
+	Type string
+	// A related table name, optionally qualified with a schema like "schema.table"
+	Table string
+	// (optional) An alias of the related table to reference it in ON clause and filter
+	Alias string
+	// A join condition that references the persistence table by its quoted name
+	On string
+}
+
+/*
+Filter that joins related tables to filter data items by their columns.
+It can be passed to GetPageByFilter, GetListByFilter, GetCountByFilter and GetOneRandom
+instead of a string filter. Only columns of the persistence table are selected,
+unless a custom projection is set.
+
+Joins of one-to-many relations return an item for each matched related row,
+use EXISTS conditions to filter such relations without duplicates.
+
+### Example ###
+
+    filter := persist.NewPostgresJoinFilter("c.\"country\"='US'",
+        persist.NewPostgresJoin("customers", "c", "c.\"id\"=\"orders\".\"customer_id\""))
+    page, err := c.GetPageByFilter(correlationId, filter, paging, nil, nil)
+*/
+type PostgresJoinFilter struct {
+	// Joins of related tables
+	Joins []*PostgresJoin
+	// (optional) A filter condition that may reference joined tables
+	Filter string
+}
+
+// Creates a new inner join.
+//   - table     a related table name, optionally qualified with a schema
+//   - alias     (optional) an alias of the related table
+//   - on        a join condition
+// Returns a new join.
+func NewPostgresJoin(table string, alias string, on string) *PostgresJoin {
+	return &PostgresJoin{Table: table, Alias: alias, On: on}
+}
+
+// Creates a new left outer join.
+//   - table     a related table name, optionally qualified with a schema
+//   - alias     (optional) an alias of the related table
+//   - on        a join condition
+// Returns a new join.
+func NewPostgresLeftJoin(table string, alias string, on string) *PostgresJoin {
+	return &PostgresJoin{Type: "LEFT", Table: table, Alias: alias, On: on}
+}
+
+// Creates a new filter with joins.
+//   - filter    (optional) a filter condition
+//   - joins     joins of related tables
+// Returns a new filter.
+func NewPostgresJoinFilter(filter string, joins ...*PostgresJoin) *PostgresJoinFilter {
+	return &PostgresJoinFilter{Filter: filter, Joins: joins}
+}
+
+// Composes FROM clause without the keyword, that includes joins of a filter
+func (c *PostgresPersistence) composeFrom(filter interface{}) string {
+	from := c.QuotedTableName()
+	flt, ok := filter.(*PostgresJoinFilter)
+	if !ok || flt == nil {
+		return from
+	}
+
+	for _, join := range flt.Joins {
+		joinType := strings.ToUpper(strings.TrimSpace(join.Type))
+		if joinType == "" {
+			joinType = "INNER"
+		}
+		from += " " + joinType + " JOIN " + c.quoteTableReference(join.Table)
+		if join.Alias != "" {
+			from += " " + c.QuoteIdentifier(join.Alias)
+		}
+		from += " ON " + join.On
+	}
+	return from
+}
+
+// Composes SELECT and FROM clauses for a filter and projection
+func (c *PostgresPersistence) composeSelect(filter interface{}, sel interface{}) string {
+	columns := "*"
+	if flt, ok := filter.(*PostgresJoinFilter); ok && flt != nil && len(flt.Joins) > 0 {
+		// Joined columns would override columns of the table with the same names
+		columns = c.QuotedTableName() + ".*"
+	}
+	if slct, ok := sel.(string); ok && slct != "" {
+		columns = slct
+	}
+	return "SELECT " + columns + " FROM " + c.composeFrom(filter)
+}
+
+// Composes WHERE clause for a string filter or a filter with joins.
+// In column tenancy mode the clause also limits rows to the current tenant.
+// Returns the clause with leading space or empty string.
+func (c *PostgresPersistence) composeWhere(filter interface{}) string {
+	condition := ""
+	switch flt := filter.(type) {
+	case string:
+		condition = flt
+	case *PostgresJoinFilter:
+		if flt != nil {
+			condition = flt.Filter
+		}
+	}
+
+	scope := c.scopeCondition()
+	switch {
+	case scope == "" && condition == "":
+		return ""
+	case scope == "":
+		return " WHERE " + condition
+	case condition == "":
+		return " WHERE " + scope
+	}
+	return " WHERE (" + condition + ") AND " + scope
+}
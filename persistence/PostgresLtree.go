@@ -0,0 +1,78 @@
+package persistence
+
+import (
+	"strings"
+)
+
+// Adds statements to enable ltree extension and to add a ltree column
+// to the table on opening. Must be called in DefineSchema after the table definition.
+//   - name      a name of the path column
+func (c *PostgresPersistence) EnsureLtreeColumn(name string) {
+	c.EnsureSchema("CREATE EXTENSION IF NOT EXISTS ltree")
+	c.EnsureSchema("ALTER TABLE " + c.QuotedTableName() + " ADD COLUMN IF NOT EXISTS " +
+		c.QuoteIdentifier(name) + " ltree")
+}
+
+// Adds a GiST index definition over a ltree column to create it on opening.
+// The index speeds up ancestor, descendant and lquery filters.
+//   - name      an index name
+//   - column    a ltree column to index
+func (c *PostgresPersistence) EnsureLtreeIndex(name string, column string) {
+	c.EnsureSchema("CREATE INDEX IF NOT EXISTS " + c.QuoteIdentifier(name) + " ON " + c.QuotedTableName() +
+		" USING gist (" + c.QuoteIdentifier(column) + ")")
+}
+
+// Composes a filter that selects rows which paths are ancestors of the given path.
+// The path itself is included.
+//   - column    a ltree column
+//   - path      a path like "top.science.astronomy"
+// Returns the composed filter.
+func (c *PostgresPersistence) ComposeAncestorFilter(column string, path string) string {
+	return c.QuoteIdentifier(column) + " @> " + c.QuoteLiteral(path) + "::ltree"
+}
+
+// Composes a filter that selects rows which paths are descendants of the given path.
+// The path itself is included.
+//   - column    a ltree column
+//   - path      a path like "top.science"
+// Returns the composed filter.
+func (c *PostgresPersistence) ComposeDescendantFilter(column string, path string) string {
+	return c.QuoteIdentifier(column) + " <@ " + c.QuoteLiteral(path) + "::ltree"
+}
+
+// Composes a filter that selects rows which paths match a lquery pattern like "*.astronomy.*".
+//   - column    a ltree column
+//   - pattern   a lquery pattern
+// Returns the composed filter.
+func (c *PostgresPersistence) ComposeLtreeMatchFilter(column string, pattern string) string {
+	return c.QuoteIdentifier(column) + " ~ " + c.QuoteLiteral(pattern) + "::lquery"
+}
+
+// Converts path labels into ltree text representation like "top.science.astronomy".
+// Characters not allowed in ltree labels are replaced with underscores.
+// Use it in ConvertFromPublic to write path fields into ltree columns.
+//   - labels    labels of the path from the root
+// Returns ltree text value
+func LabelsToLtree(labels []string) string {
+	result := make([]string, 0, len(labels))
+	for _, label := range labels {
+		result = append(result, strings.Map(func(r rune) rune {
+			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+				return r
+			}
+			return '_'
+		}, label))
+	}
+	return strings.Join(result, ".")
+}
+
+// Parses ltree text representation like "top.science.astronomy" into path labels.
+// Use it in ConvertToPublic to read path fields from ltree columns.
+//   - value     a ltree text value
+// Returns path labels
+func ParseLtree(value string) []string {
+	if value == "" {
+		return []string{}
+	}
+	return strings.Split(value, ".")
+}
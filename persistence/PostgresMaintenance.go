@@ -0,0 +1,89 @@
+package persistence
+
+import (
+	"time"
+)
+
+// Updates planner statistics of the table with ANALYZE.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns error or nil no errors occured.
+func (c *PostgresPersistence) Analyze(correlationId string) error {
+	return c.execMaintenance(correlationId, "ANALYZE "+c.QuotedTableName())
+}
+
+// Reclaims storage occupied by dead rows of the table with VACUUM.
+// Full vacuum rewrites the table to return space to the operating system,
+// but holds an exclusive lock of the table until it is completed.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+//   - full             true to run VACUUM FULL
+// Returns error or nil no errors occured.
+func (c *PostgresPersistence) Vacuum(correlationId string, full bool) error {
+	query := "VACUUM "
+	if full {
+		query += "FULL "
+	}
+	return c.execMaintenance(correlationId, query+c.QuotedTableName())
+}
+
+// Rebuilds all indexes of the table with REINDEX, e.g. to remove bloat of indexes in high-churn tables.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns error or nil no errors occured.
+func (c *PostgresPersistence) Reindex(correlationId string) error {
+	return c.execMaintenance(correlationId, "REINDEX TABLE "+c.QuotedTableName())
+}
+
+// Executes a maintenance statement of the table
+func (c *PostgresPersistence) execMaintenance(correlationId string, query string) (err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkWritable(correlationId); err != nil {
+		return err
+	}
+
+	if _, err = c.Client.Exec(ctx, query); err != nil {
+		return err
+	}
+
+	c.Logger.Debug(correlationId, "Executed %s", query)
+	return nil
+}
+
+// Starts periodic VACUUM and ANALYZE of the table if options.maintenance_period is set
+func (c *PostgresPersistence) startMaintenance(correlationId string) {
+	if c.maintainPeriod <= 0 || c.view {
+		return
+	}
+
+	stop := make(chan struct{})
+	c.maintainStop = stop
+	go func() {
+		ticker := time.NewTicker(c.maintainPeriod)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				err := c.Vacuum(correlationId, false)
+				if err == nil {
+					err = c.Analyze(correlationId)
+				}
+				if err != nil {
+					c.Logger.Error(correlationId, err, "Failed to maintain %s", c.TableName)
+				}
+			}
+		}
+	}()
+}
+
+// Stops periodic maintenance of the table
+func (c *PostgresPersistence) stopMaintenance() {
+	if c.maintainStop != nil {
+		close(c.maintainStop)
+		c.maintainStop = nil
+	}
+}
@@ -0,0 +1,311 @@
+package persistence
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+)
+
+// Versioned change of the database schema.
+type PostgresMigration struct {
+	// Version of the migration. Migrations are applied in ascending order of versions
+	Version int64
+	// Human readable description of the migration
+	Description string
+	// SQL script to apply the migration
+	Script string
+	// Function to apply the migration, used when script is empty
+	Func func(correlationId string, tx pgx.Tx) error
+	// SQL script to revert the migration
+	DownScript string
+	// Function to revert the migration, used when down script is empty
+	DownFunc func(correlationId string, tx pgx.Tx) error
+}
+
+// Checks if the migration can be reverted.
+// Returns true if the migration has a down script or function.
+func (c *PostgresMigration) IsReversible() bool {
+	return c.DownScript != "" || c.DownFunc != nil
+}
+
+// Registers a migration script to be applied at opening.
+// A migration with the same version replaces the previously registered one.
+//   - version       a migration version, must be positive.
+//   - description   a migration description.
+//   - script        an SQL script to apply.
+func (c *PostgresPersistence) EnsureMigration(version int64, description string, script string) {
+	c.addMigration(&PostgresMigration{Version: version, Description: description, Script: script})
+}
+
+// Registers a migration function to be applied at opening.
+// A migration with the same version replaces the previously registered one.
+//   - version       a migration version, must be positive.
+//   - description   a migration description.
+//   - migrate       a function that applies the migration within a transaction.
+func (c *PostgresPersistence) EnsureMigrationFunc(version int64, description string,
+	migrate func(correlationId string, tx pgx.Tx) error) {
+	c.addMigration(&PostgresMigration{Version: version, Description: description, Func: migrate})
+}
+
+// Registers a reversible migration with scripts to apply and revert it.
+// A migration with the same version replaces the previously registered one.
+//   - version       a migration version, must be positive.
+//   - description   a migration description.
+//   - script        an SQL script to apply.
+//   - downScript    an SQL script to revert.
+func (c *PostgresPersistence) EnsureReversibleMigration(version int64, description string, script string, downScript string) {
+	c.addMigration(&PostgresMigration{Version: version, Description: description, Script: script, DownScript: downScript})
+}
+
+// Registers a reversible migration with functions to apply and revert it.
+// A migration with the same version replaces the previously registered one.
+//   - version       a migration version, must be positive.
+//   - description   a migration description.
+//   - migrate       a function that applies the migration within a transaction.
+//   - revert        a function that reverts the migration within a transaction.
+func (c *PostgresPersistence) EnsureReversibleMigrationFunc(version int64, description string,
+	migrate func(correlationId string, tx pgx.Tx) error, revert func(correlationId string, tx pgx.Tx) error) {
+	c.addMigration(&PostgresMigration{Version: version, Description: description, Func: migrate, DownFunc: revert})
+}
+
+func (c *PostgresPersistence) addMigration(migration *PostgresMigration) {
+	for index, existing := range c.migrations {
+		if existing.Version == migration.Version {
+			c.migrations[index] = migration
+			return
+		}
+	}
+	c.migrations = append(c.migrations, migration)
+	sort.Slice(c.migrations, func(i, j int) bool {
+		return c.migrations[i].Version < c.migrations[j].Version
+	})
+}
+
+// Gets quoted name of the table that tracks applied migrations
+func (c *PostgresPersistence) QuotedMigrationTableName() string {
+	if c.SchemaName != "" {
+		return c.QuoteIdentifier(c.SchemaName) + "." + c.QuoteIdentifier(c.migrationsTable)
+	}
+	return c.QuoteIdentifier(c.migrationsTable)
+}
+
+// Gets the latest applied migration version of this persistence table.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns the applied version or 0 if no migrations were applied.
+func (c *PostgresPersistence) GetMigrationVersion(correlationId string) (version int64, err error) {
+	err = c.ensureMigrationTable()
+	if err != nil {
+		return 0, err
+	}
+
+	query := "SELECT COALESCE(MAX(\"version\"), 0) FROM " + c.QuotedMigrationTableName() + " WHERE \"table_name\"=$1"
+	err = c.Client.QueryRow(c.schemaContext(), query, c.TableName).Scan(&version)
+	return version, err
+}
+
+func (c *PostgresPersistence) ensureMigrationTable() error {
+	query := "CREATE TABLE IF NOT EXISTS " + c.QuotedMigrationTableName() +
+		" (\"table_name\" TEXT NOT NULL, \"version\" BIGINT NOT NULL, \"description\" TEXT," +
+		" \"applied_time\" TIMESTAMPTZ NOT NULL DEFAULT now(), PRIMARY KEY (\"table_name\", \"version\"))"
+	_, err := c.Client.Exec(c.schemaContext(), query)
+	return err
+}
+
+// Applies registered migrations that were not applied yet.
+// Every migration runs in its own transaction together with recording its version.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns error or nil no errors occured.
+func (c *PostgresPersistence) Migrate(correlationId string) error {
+	if len(c.migrations) == 0 {
+		return nil
+	}
+
+	version, err := c.GetMigrationVersion(correlationId)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range c.migrations {
+		if migration.Version <= version {
+			continue
+		}
+
+		err = c.applyMigration(correlationId, migration)
+		if err != nil {
+			return cerr.NewInternalError(correlationId, "MIGRATION_FAILED",
+				"Failed to apply migration "+migration.Description+" to "+c.TableName).
+				WithDetails("version", migration.Version).
+				WithCause(err)
+		}
+		c.Logger.Info(correlationId, "Applied migration %d (%s) to %s", migration.Version, migration.Description, c.TableName)
+	}
+	return nil
+}
+
+func (c *PostgresPersistence) applyMigration(correlationId string, migration *PostgresMigration) error {
+	tx, err := c.Client.Begin(c.schemaContext())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(c.schemaContext())
+
+	if migration.Script != "" {
+		_, err = tx.Exec(c.schemaContext(), migration.Script)
+	} else if migration.Func != nil {
+		err = migration.Func(correlationId, tx)
+	}
+	if err != nil {
+		return err
+	}
+
+	query := "INSERT INTO " + c.QuotedMigrationTableName() + " (\"table_name\", \"version\", \"description\") VALUES ($1, $2, $3)"
+	_, err = tx.Exec(c.schemaContext(), query, c.TableName, migration.Version, migration.Description)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(c.schemaContext())
+}
+
+// Reverts applied migrations with versions greater than the given one in descending order.
+// All reverted migrations must be registered and reversible, otherwise nothing is reverted.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+//   - version         a version to downgrade to, 0 to revert all migrations.
+// Returns error or nil no errors occured.
+func (c *PostgresPersistence) Downgrade(correlationId string, version int64) error {
+	return c.withSchemaLock(correlationId, func() error {
+		return c.downgrade(correlationId, version)
+	})
+}
+
+func (c *PostgresPersistence) downgrade(correlationId string, version int64) error {
+	err := c.ensureMigrationTable()
+	if err != nil {
+		return err
+	}
+
+	query := "SELECT \"version\" FROM " + c.QuotedMigrationTableName() +
+		" WHERE \"table_name\"=$1 AND \"version\">$2 ORDER BY \"version\" DESC"
+	qResult, err := c.Client.Query(c.schemaContext(), query, c.TableName, version)
+	if err != nil {
+		return err
+	}
+	applied := make([]int64, 0)
+	for qResult.Next() {
+		var v int64
+		if err = qResult.Scan(&v); err != nil {
+			break
+		}
+		applied = append(applied, v)
+	}
+	qResult.Close()
+	if err == nil {
+		err = qResult.Err()
+	}
+	if err != nil {
+		return err
+	}
+
+	// Check all migrations before reverting any of them
+	reverted := make([]*PostgresMigration, 0, len(applied))
+	for _, v := range applied {
+		var migration *PostgresMigration
+		for _, m := range c.migrations {
+			if m.Version == v {
+				migration = m
+				break
+			}
+		}
+		if migration == nil || !migration.IsReversible() {
+			return cerr.NewInvalidStateError(correlationId, "MIGRATION_NOT_REVERSIBLE",
+				"Migration "+strconv.FormatInt(v, 10)+" of "+c.TableName+" cannot be reverted").
+				WithDetails("version", v)
+		}
+		reverted = append(reverted, migration)
+	}
+
+	for _, migration := range reverted {
+		err = c.revertMigration(correlationId, migration)
+		if err != nil {
+			return cerr.NewInternalError(correlationId, "MIGRATION_FAILED",
+				"Failed to revert migration "+migration.Description+" of "+c.TableName).
+				WithDetails("version", migration.Version).
+				WithCause(err)
+		}
+		c.Logger.Info(correlationId, "Reverted migration %d (%s) of %s", migration.Version, migration.Description, c.TableName)
+	}
+	return nil
+}
+
+func (c *PostgresPersistence) revertMigration(correlationId string, migration *PostgresMigration) error {
+	tx, err := c.Client.Begin(c.schemaContext())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(c.schemaContext())
+
+	if migration.DownScript != "" {
+		_, err = tx.Exec(c.schemaContext(), migration.DownScript)
+	} else {
+		err = migration.DownFunc(correlationId, tx)
+	}
+	if err != nil {
+		return err
+	}
+
+	query := "DELETE FROM " + c.QuotedMigrationTableName() + " WHERE \"table_name\"=$1 AND \"version\"=$2"
+	_, err = tx.Exec(c.schemaContext(), query, c.TableName, migration.Version)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(c.schemaContext())
+}
+
+// Key of the advisory lock that serializes schema changes
+const schemaLockKey = "pip-services:schema"
+
+// Runs schema changes while holding a session advisory lock, so several instances
+// that start concurrently do not race on creating the same objects.
+// The lock is held on a dedicated connection while the changes use other pool connections,
+// so it is skipped with a warning when the pool has a single connection or the client is not a pool.
+func (c *PostgresPersistence) withSchemaLock(correlationId string, action func() error) error {
+	if !c.schemaLock {
+		return action()
+	}
+	pool, ok := unwrapClient(c.Client).(*pgxpool.Pool)
+	if !ok {
+		c.Logger.Warn(correlationId, "Schema of %s is changed without lock: the client is not a connection pool", c.TableName)
+		return action()
+	}
+	if pool.Config().MaxConns < 2 {
+		c.Logger.Warn(correlationId, "Schema of %s is changed without lock: the pool has a single connection", c.TableName)
+		return action()
+	}
+
+	conn, err := pool.Acquire(c.schemaContext())
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Exec(c.schemaContext(), "SELECT pg_advisory_lock(hashtext($1))", schemaLockKey)
+	if err != nil {
+		conn.Release()
+		return err
+	}
+	defer func() {
+		_, unlockErr := conn.Exec(c.schemaContext(), "SELECT pg_advisory_unlock(hashtext($1))", schemaLockKey)
+		if unlockErr != nil {
+			// The lock is held until the session ends, so the connection must not return to the pool
+			c.Logger.Error(correlationId, unlockErr, "Failed to release schema lock, closing its connection")
+			closeAcquiredConnection(conn)
+			return
+		}
+		conn.Release()
+	}()
+
+	return action()
+}
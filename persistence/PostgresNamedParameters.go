@@ -0,0 +1,227 @@
+package persistence
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rewrites :name style named parameters in a query into positional $n placeholders.
+// A parameter used several times gets the same position. Literals, quoted identifiers,
+// comments and type casts like ::text are kept as they are.
+//   - query     a SQL query with named parameters, e.g. "\"key\"=:key AND \"time\">:from"
+//   - params    values of the named parameters
+// Returns the query with positional parameters, values ordered by positions, or error for a missing value.
+func BindNamedParameters(query string, params map[string]interface{}) (string, []interface{}, error) {
+	positions := make(map[string]int)
+	values := make([]interface{}, 0, len(params))
+
+	result, err := replaceNamedParameters(query, func(name string) (string, error) {
+		position, ok := positions[name]
+		if !ok {
+			value, found := params[name]
+			if !found {
+				return "", fmt.Errorf("value of query parameter %s is not set", name)
+			}
+			values = append(values, value)
+			position = len(values)
+			positions[name] = position
+		}
+		return "$" + strconv.Itoa(position), nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return result, values, nil
+}
+
+// Composes a filter from a template with :name style named parameters.
+// Values are rendered as quoted SQL literals, so the filter can be passed
+// to GetPageByFilter, GetListByFilter and other methods that accept filters as strings.
+// Supported values are strings, numbers, booleans, times, nil and slices of them rendered as arrays.
+//   - template  a filter template, e.g. "\"key\"=:key AND \"id\" = ANY(:ids)"
+//   - params    values of the named parameters
+// Returns the composed filter or error for a missing or unsupported value.
+func (c *PostgresPersistence) ComposeNamedFilter(template string, params map[string]interface{}) (string, error) {
+	return replaceNamedParameters(template, func(name string) (string, error) {
+		value, found := params[name]
+		if !found {
+			return "", fmt.Errorf("value of filter parameter %s is not set", name)
+		}
+		return c.formatLiteral(value)
+	})
+}
+
+// Runs a query with :name style named parameters. See QueryRows.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - query             a SQL query with named parameters
+//   - params            values of the named parameters
+// Returns a list of data items or error.
+func (c *PostgresPersistence) QueryRowsNamed(correlationId string, query string, params map[string]interface{}) ([]interface{}, error) {
+	query, args, err := BindNamedParameters(query, params)
+	if err != nil {
+		return nil, err
+	}
+	return c.QueryRows(correlationId, query, args...)
+}
+
+// Runs a statement with :name style named parameters. See ExecNonQuery.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - query             a SQL statement with named parameters
+//   - params            values of the named parameters
+// Returns a number of affected rows or error.
+func (c *PostgresPersistence) ExecNonQueryNamed(correlationId string, query string, params map[string]interface{}) (int64, error) {
+	query, args, err := BindNamedParameters(query, params)
+	if err != nil {
+		return 0, err
+	}
+	return c.ExecNonQuery(correlationId, query, args...)
+}
+
+// Formats a parameter value as SQL literal
+func (c *PostgresPersistence) formatLiteral(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "NULL", nil
+	case string:
+		return c.QuoteLiteral(v), nil
+	case bool:
+		if v {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case time.Time:
+		return c.QuoteLiteral(v.Format(time.RFC3339Nano)), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("%d", v), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	}
+
+	items := reflect.ValueOf(value)
+	if items.Kind() == reflect.Slice || items.Kind() == reflect.Array {
+		literals := make([]string, 0, items.Len())
+		for index := 0; index < items.Len(); index++ {
+			literal, err := c.formatLiteral(items.Index(index).Interface())
+			if err != nil {
+				return "", err
+			}
+			literals = append(literals, literal)
+		}
+		return "ARRAY[" + strings.Join(literals, ",") + "]", nil
+	}
+	return "", fmt.Errorf("value of type %T cannot be used as filter parameter", value)
+}
+
+// Replaces :name parameters in a query outside of literals, quoted identifiers and comments
+func replaceNamedParameters(query string, replace func(name string) (string, error)) (string, error) {
+	builder := strings.Builder{}
+	length := len(query)
+
+	for index := 0; index < length; {
+		ch := query[index]
+		switch {
+		case ch == '\'':
+			// String literals, E'...' literals allow backslash escapes
+			escapes := index > 0 && (query[index-1] == 'E' || query[index-1] == 'e')
+			end := index + 1
+			for end < length {
+				if escapes && query[end] == '\\' {
+					end += 2
+					continue
+				}
+				if query[end] == '\'' {
+					break
+				}
+				end++
+			}
+			end = minInt(end+1, length)
+			builder.WriteString(query[index:end])
+			index = end
+		case ch == '"':
+			end := strings.IndexByte(query[index+1:], '"')
+			if end < 0 {
+				end = length
+			} else {
+				end += index + 2
+			}
+			builder.WriteString(query[index:end])
+			index = end
+		case ch == '-' && index+1 < length && query[index+1] == '-':
+			end := strings.IndexByte(query[index:], '\n')
+			if end < 0 {
+				end = length
+			} else {
+				end += index
+			}
+			builder.WriteString(query[index:end])
+			index = end
+		case ch == '/' && index+1 < length && query[index+1] == '*':
+			end := strings.Index(query[index+2:], "*/")
+			if end < 0 {
+				end = length
+			} else {
+				end += index + 4
+			}
+			builder.WriteString(query[index:end])
+			index = end
+		case ch == '$':
+			// Dollar quoted strings like $$...$$ or $tag$...$tag$
+			tagEnd := index + 1
+			for tagEnd < length && isNameChar(query[tagEnd]) && !(tagEnd == index+1 && isDigit(query[tagEnd])) {
+				tagEnd++
+			}
+			if tagEnd < length && query[tagEnd] == '$' {
+				tag := query[index : tagEnd+1]
+				end := strings.Index(query[tagEnd+1:], tag)
+				if end < 0 {
+					end = length
+				} else {
+					end += tagEnd + 1 + len(tag)
+				}
+				builder.WriteString(query[index:end])
+				index = end
+			} else {
+				builder.WriteByte(ch)
+				index++
+			}
+		case ch == ':' && index+1 < length && query[index+1] == ':':
+			builder.WriteString("::")
+			index += 2
+		case ch == ':' && index+1 < length && isNameChar(query[index+1]) && !isDigit(query[index+1]):
+			end := index + 1
+			for end < length && isNameChar(query[end]) {
+				end++
+			}
+			placeholder, err := replace(query[index+1 : end])
+			if err != nil {
+				return "", err
+			}
+			builder.WriteString(placeholder)
+			index = end
+		default:
+			builder.WriteByte(ch)
+			index++
+		}
+	}
+	return builder.String(), nil
+}
+
+func isNameChar(ch byte) bool {
+	return ch == '_' || isDigit(ch) || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
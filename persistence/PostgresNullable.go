@@ -0,0 +1,98 @@
+package persistence
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+)
+
+var (
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	valuerType  = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+)
+
+// Collects prototype fields of types like sql.NullString that convert themselves
+// to and from database values. JSON encoding turns such values into objects,
+// so they are read with Scan and written with Value instead.
+func getPrototypeSqlFields(proto reflect.Type) map[string][]int {
+	return getPrototypeFields(proto, func(fieldType reflect.Type) bool {
+		return fieldType.Kind() == reflect.Struct && fieldType != timeType &&
+			fieldType.Implements(valuerType) && reflect.PtrTo(fieldType).Implements(scannerType)
+	})
+}
+
+// Splits column values into values decoded with JSON and values
+// of sql and binary fields or columns with converters that are assigned directly
+func (c *PostgresPersistence) splitSqlValues(values interface{}) (interface{}, map[string]interface{}) {
+	columns, ok := values.(map[string]interface{})
+	if !ok || (len(c.sqlFields) == 0 && len(c.bytesFields) == 0 &&
+		len(c.columnConverters) == 0 && len(c.typeConverters) == 0) {
+		return values, nil
+	}
+
+	jsonValues := make(map[string]interface{}, len(columns))
+	sqlValues := make(map[string]interface{})
+	for name, value := range columns {
+		_, isSql := c.sqlFields[name]
+		_, isBytes := c.bytesFields[name]
+		if isSql || isBytes || c.getConverter(name) != nil {
+			sqlValues[name] = value
+		} else {
+			jsonValues[name] = value
+		}
+	}
+	return jsonValues, sqlValues
+}
+
+// Sets values of sql fields with their Scan methods.
+// NULL values are scanned as well, so such fields become invalid.
+func (c *PostgresPersistence) assignSqlValues(docPointer reflect.Value, sqlValues map[string]interface{}) *DataConversionError {
+	doc := docPointer.Elem()
+	if doc.Kind() != reflect.Struct {
+		return nil
+	}
+	for name, value := range sqlValues {
+		if _, ok := c.sqlFields[name]; !ok || c.getConverter(name) != nil {
+			continue
+		}
+		scanner := doc.FieldByIndex(c.sqlFields[name]).Addr().Interface().(sql.Scanner)
+		if err := scanner.Scan(value); err != nil {
+			return NewDataConversionError("", name, value, err)
+		}
+	}
+	return nil
+}
+
+// Replaces JSON encoded sql fields with their database values, nil for invalid ones
+func (c *PostgresPersistence) convertSqlValuesFromPublic(value interface{}, items map[string]interface{}) {
+	if len(c.sqlFields) == 0 || value == nil {
+		return
+	}
+
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	proto := c.Prototype
+	for proto.Kind() == reflect.Ptr {
+		proto = proto.Elem()
+	}
+	if v.Type() != proto {
+		return
+	}
+
+	for name, index := range c.sqlFields {
+		if _, ok := items[name]; !ok {
+			continue
+		}
+		dbValue, err := v.FieldByIndex(index).Interface().(driver.Valuer).Value()
+		if err != nil {
+			c.Logger.Error("PostgresPersistence", err, "Error data convertion")
+			dbValue = nil
+		}
+		items[name] = dbValue
+	}
+}
@@ -0,0 +1,133 @@
+package persistence
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgtype"
+)
+
+// Representations of NUMERIC values passed to the prototype decoding
+const (
+	// Exact JSON number. Suits float, integer and decimal fields that implement json.Unmarshaler
+	NumericFormatNumber = "number"
+	// JSON string. Suits string fields, *big.Rat and decimal fields that implement encoding.TextUnmarshaler
+	NumericFormatString = "string"
+)
+
+// Maximum number of significant digits that float64 keeps without loss
+const float64Digits = 15
+
+// Converts a NUMERIC value into an exact decimal string like "-123.4500".
+//   - value     a NUMERIC value returned by pgx
+// Returns the decimal string, "NaN" for not-a-number or empty string for NULL.
+func NumericToString(value pgtype.Numeric) string {
+	if value.Status != pgtype.Present {
+		return ""
+	}
+	if value.NaN {
+		return "NaN"
+	}
+	if value.Int == nil {
+		return "0"
+	}
+
+	digits := value.Int.String()
+	if value.Exp >= 0 {
+		if value.Int.Sign() == 0 {
+			return "0"
+		}
+		return digits + strings.Repeat("0", int(value.Exp))
+	}
+
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign = "-"
+		digits = digits[1:]
+	}
+	scale := int(-value.Exp)
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+	point := len(digits) - scale
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// Converts a NUMERIC value into an exact rational number.
+//   - value     a NUMERIC value returned by pgx
+// Returns the rational number, nil for NULL, or error for not-a-number.
+func NumericToRat(value pgtype.Numeric) (*big.Rat, error) {
+	if value.Status != pgtype.Present {
+		return nil, nil
+	}
+	if value.NaN {
+		return nil, errors.New("NaN cannot be converted to a rational number")
+	}
+
+	result, ok := new(big.Rat).SetString(NumericToString(value))
+	if !ok {
+		return nil, errors.New("invalid numeric value " + NumericToString(value))
+	}
+	return result, nil
+}
+
+// Converts a column value returned by pgx into a value
+// that can be decoded into the prototype without loss
+func (c *PostgresPersistence) convertValueToPublic(value interface{}) interface{} {
+	switch v := value.(type) {
+	case pgtype.Numeric:
+		if v.Status != pgtype.Present {
+			return nil
+		}
+		if c.numericFormat == NumericFormatString {
+			return NumericToString(v)
+		}
+		if v.NaN {
+			// NaN has no JSON number representation
+			return nil
+		}
+		return json.Number(NumericToString(v))
+	case time.Time:
+		return c.normalizeTime(v)
+	}
+	return value
+}
+
+// Converts numbers decoded from JSON into statement parameters.
+// Numbers that float64 keeps exactly are passed as before,
+// longer numbers are passed as strings to keep NUMERIC values exact.
+func convertNumberFromPublic(value interface{}, nested bool) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if countDigits(string(v)) <= float64Digits {
+			if number, err := v.Float64(); err == nil {
+				return number
+			}
+		}
+		// Nested numbers are written back into JSON as they are
+		if nested {
+			return v
+		}
+		return string(v)
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = convertNumberFromPublic(item, true)
+		}
+	case []interface{}:
+		for index, item := range v {
+			v[index] = convertNumberFromPublic(item, true)
+		}
+	}
+	return value
+}
+
+func countDigits(value string) int {
+	if index := strings.IndexAny(value, "eE"); index >= 0 {
+		value = value[:index]
+	}
+	value = strings.TrimLeft(value, "+-0.")
+	return len(strings.ReplaceAll(value, ".", ""))
+}
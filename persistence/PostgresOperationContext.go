@@ -0,0 +1,116 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+)
+
+// Starts a database operation. The operation context is derived from the context set by WithContext
+// and limited by options.operation_timeout, so queries that exceed it or belong to aborted requests
+// are cancelled by the driver on the server side.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - err               a pointer to the error returned by the operation
+// When the persistence is closing the operation is rejected: the error is set to InvalidStateError
+// and the operation shall return immediately.
+// Returns the operation context and a function to call when the operation is completed.
+// The function releases the context and turns errors of timed out or cancelled operations into InvocationError.
+func (c *PostgresPersistence) beginOperation(correlationId string, err *error) (context.Context, func()) {
+	if !c.operations.enter() {
+		*err = cerr.NewInvalidStateError(correlationId, "CLOSED",
+			"Persistence of "+c.TableName+" is closing or closed")
+		return context.Background(), func() {}
+	}
+
+	ctx, done := c.operationContext(correlationId, err)
+	return ctx, func() {
+		done()
+		c.operations.exit()
+	}
+}
+
+// Starts a database operation of a component built on the persistence, like a cache or a queue.
+// The operation is tracked as operations of the persistence methods, so Close waits for it to complete.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - err               a pointer to the error returned by the operation
+// Returns the operation context and a function to call when the operation is completed.
+func (c *PostgresPersistence) BeginOperation(correlationId string, err *error) (context.Context, func()) {
+	return c.beginOperation(correlationId, err)
+}
+
+// Creates a context of a database operation without tracking it.
+// It is used by steps of operations that are already tracked.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - err               a pointer to the error returned by the operation
+// Returns the operation context and a function to call when the operation is completed.
+func (c *PostgresPersistence) operationContext(correlationId string, err *error) (context.Context, func()) {
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx = withCorrelationId(ctx, correlationId)
+	switch c.tenancy {
+	case TenancySchema:
+		var tErr error
+		if ctx, tErr = c.tenantContext(correlationId, ctx); tErr != nil {
+			*err = tErr
+			return ctx, func() {}
+		}
+	case TenancyColumn:
+		if tErr := c.validateTenant(correlationId); tErr != nil {
+			*err = tErr
+			return ctx, func() {}
+		}
+	}
+	cancel := func() {}
+	if c.operationTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.operationTimeout)
+	}
+
+	return ctx, func() {
+		if *err != nil {
+			switch ctx.Err() {
+			case context.DeadlineExceeded:
+				*err = cerr.NewInvocationError(correlationId, "TIMEOUT",
+					"Operation on "+c.TableName+" timed out after "+c.operationTimeout.String()).
+					WithCause(*err)
+			case context.Canceled:
+				c.Logger.Debug(correlationId, "Operation on %s was cancelled by caller", c.TableName)
+				*err = cerr.NewInvocationError(correlationId, "OPERATION_CANCELED",
+					"Operation on "+c.TableName+" was cancelled").
+					WithCause(*err)
+			}
+		}
+		cancel()
+	}
+}
+
+// Checks if an error was caused by cancellation of the operation context
+func isCanceledError(err error) bool {
+	return errors.Is(err, context.Canceled)
+}
+
+// Creates a copy of the persistence that runs operations within a caller's context,
+// so queries of aborted requests are cancelled on the server side.
+// The copy shares the connection, configuration and dependencies with the original.
+// Session variables for row-level security can be passed with the context, see connect.WithSessionVariables,
+// as well as a tenant, see WithTenant.
+//   - ctx   a context of the caller
+// Returns the persistence copy bound to the context.
+func (c *PostgresPersistence) WithContext(ctx context.Context) *PostgresPersistence {
+	clone := *c
+	clone.ctx = ctx
+	if tenant := GetTenant(ctx); tenant != "" {
+		clone.tenant = tenant
+	}
+	return &clone
+}
+
+// Sets a maximum duration of database operations, 0 to disable.
+// The same can be configured by options.operation_timeout in milliseconds.
+//   - timeout   a maximum duration of operations
+func (c *PostgresPersistence) SetOperationTimeout(timeout time.Duration) {
+	c.operationTimeout = timeout
+}
@@ -0,0 +1,78 @@
+package persistence
+
+import (
+	"sync"
+	"time"
+)
+
+// Tracks operations in flight, so Close can wait for them before the pool is closed
+type postgresOperationTracker struct {
+	lock    sync.Mutex
+	closing bool
+	active  int
+	// Closed when the last operation in flight completes, created by drain
+	idle chan struct{}
+}
+
+// Registers a new operation
+// Returns false if the persistence is closing and the operation shall be rejected
+func (c *postgresOperationTracker) enter() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.closing {
+		return false
+	}
+	c.active++
+	return true
+}
+
+// Unregisters a completed operation
+func (c *postgresOperationTracker) exit() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.active--
+	if c.active == 0 && c.idle != nil {
+		close(c.idle)
+		c.idle = nil
+	}
+}
+
+// Starts accepting operations after the persistence is opened
+func (c *postgresOperationTracker) open() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.closing = false
+}
+
+// Stops accepting operations and waits for ones in flight to complete
+//   - timeout   a maximum time to wait, 0 to wait until all are completed
+// Returns false if the operations were not completed in time
+func (c *postgresOperationTracker) drain(timeout time.Duration) bool {
+	c.lock.Lock()
+	c.closing = true
+	if c.active == 0 {
+		c.lock.Unlock()
+		return true
+	}
+	if c.idle == nil {
+		c.idle = make(chan struct{})
+	}
+	idle := c.idle
+	c.lock.Unlock()
+
+	if timeout <= 0 {
+		<-idle
+		return true
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-idle:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
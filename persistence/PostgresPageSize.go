@@ -0,0 +1,36 @@
+package persistence
+
+import (
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+)
+
+// Checks paging parameters requested by a client.
+// Negative skip or take are rejected. A take above MaxPageSize is rejected
+// when options.strict_paging is set, otherwise it is clamped to MaxPageSize.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - paging            paging parameters to check
+// Returns BadRequestError for invalid paging or nil.
+func (c *PostgresPersistence) validatePaging(correlationId string, paging *cdata.PagingParams) error {
+	if paging == nil {
+		return nil
+	}
+
+	if paging.Skip != nil && *paging.Skip < 0 {
+		return cerr.NewBadRequestError(correlationId, "INVALID_PAGING",
+			"Paging skip cannot be negative").
+			WithDetails("skip", *paging.Skip)
+	}
+	if paging.Take != nil && *paging.Take < 0 {
+		return cerr.NewBadRequestError(correlationId, "INVALID_PAGING",
+			"Paging take cannot be negative").
+			WithDetails("take", *paging.Take)
+	}
+	if c.strictPaging && c.MaxPageSize > 0 && paging.Take != nil && *paging.Take > int64(c.MaxPageSize) {
+		return cerr.NewBadRequestError(correlationId, "PAGE_SIZE_TOO_LARGE",
+			"Requested page size exceeds the maximum page size").
+			WithDetails("take", *paging.Take).
+			WithDetails("max_page_size", c.MaxPageSize)
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package persistence
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+)
+
+// Intervals of time-range partitions
+const (
+	PartitionIntervalDay   = "day"
+	PartitionIntervalWeek  = "week"
+	PartitionIntervalMonth = "month"
+	PartitionIntervalYear  = "year"
+)
+
+// Actions with partitions that expired according to the retention policy
+const (
+	// Expired partitions are detached and remain in the database as standalone tables
+	PartitionExpireDetach = "detach"
+	// Expired partitions are dropped with their data
+	PartitionExpireDrop = "drop"
+)
+
+// Layout of partition start times in partition names, e.g. "events_p20260101"
+const partitionNameLayout = "20060102"
+
+// Configuration of time-range partitions maintained by the persistence
+type postgresPartitionOptions struct {
+	column      string
+	interval    string
+	premake     int
+	retention   int
+	expire      string
+	checkPeriod time.Duration
+}
+
+// Gets the start of an interval that contains a time
+func partitionStart(interval string, t time.Time) time.Time {
+	t = t.UTC()
+	switch interval {
+	case PartitionIntervalDay:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	case PartitionIntervalWeek:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		// Weeks start on Monday
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case PartitionIntervalYear:
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// Shifts the start of an interval by a number of intervals
+func partitionShift(interval string, start time.Time, count int) time.Time {
+	switch interval {
+	case PartitionIntervalDay:
+		return start.AddDate(0, 0, count)
+	case PartitionIntervalWeek:
+		return start.AddDate(0, 0, 7*count)
+	case PartitionIntervalYear:
+		return start.AddDate(count, 0, 0)
+	default:
+		return start.AddDate(0, count, 0)
+	}
+}
+
+// Gets a name of the partition that starts at a time
+func (c *PostgresPersistence) partitionName(start time.Time) string {
+	return c.TableName + "_p" + start.Format(partitionNameLayout)
+}
+
+// Quotes a partition name with the schema of the table
+func (c *PostgresPersistence) quotedPartitionName(name string) string {
+	if len(c.SchemaName) > 0 {
+		return c.QuoteIdentifier(c.SchemaName) + "." + c.QuoteIdentifier(name)
+	}
+	return c.QuoteIdentifier(name)
+}
+
+// Creates partitions for the current and upcoming intervals, configured by options.partition_premake.
+// The table shall be created in DefineSchema partitioned by range of options.partition_column, e.g.
+//     CREATE TABLE events (... "time" TIMESTAMPTZ NOT NULL ...) PARTITION BY RANGE ("time")
+// Partitions are named after the table with start dates, e.g. "events_p20260101".
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns error or nil no errors occured.
+func (c *PostgresPersistence) EnsurePartitions(correlationId string) error {
+	if c.partitions.column == "" {
+		return cerr.NewInvalidStateError(correlationId, "NO_PARTITION_COLUMN",
+			"Partition column is not configured for "+c.TableName)
+	}
+
+	start := partitionStart(c.partitions.interval, time.Now())
+	for index := 0; index <= c.partitions.premake; index++ {
+		from := partitionShift(c.partitions.interval, start, index)
+		to := partitionShift(c.partitions.interval, from, 1)
+		query := "CREATE TABLE IF NOT EXISTS " + c.quotedPartitionName(c.partitionName(from)) +
+			" PARTITION OF " + c.QuotedTableName() +
+			" FOR VALUES FROM (" + c.QuoteLiteral(from.Format(time.RFC3339)) + ")" +
+			" TO (" + c.QuoteLiteral(to.Format(time.RFC3339)) + ")"
+		if _, err := c.Client.Exec(context.Background(), query); err != nil {
+			return cerr.NewInvocationError(correlationId, "PARTITION_FAILED",
+				"Failed to create partition "+c.partitionName(from)).WithCause(err)
+		}
+	}
+	return nil
+}
+
+// Detaches or drops partitions that ended before the retention period, configured by options.partition_retention.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns names of expired partitions or error.
+func (c *PostgresPersistence) ExpirePartitions(correlationId string) ([]string, error) {
+	expired := make([]string, 0)
+	if c.partitions.retention <= 0 {
+		return expired, nil
+	}
+
+	schemaName := c.SchemaName
+	if schemaName == "" {
+		schemaName = "public"
+	}
+	query := "SELECT child.relname FROM pg_inherits" +
+		" JOIN pg_class parent ON pg_inherits.inhparent=parent.oid" +
+		" JOIN pg_class child ON pg_inherits.inhrelid=child.oid" +
+		" JOIN pg_namespace ns ON parent.relnamespace=ns.oid" +
+		" WHERE parent.relname=$1 AND ns.nspname=$2"
+	qResult, err := c.Client.Query(context.Background(), query, c.TableName, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0)
+	for qResult.Next() {
+		var name string
+		if err = qResult.Scan(&name); err != nil {
+			qResult.Close()
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	qResult.Close()
+	if err = qResult.Err(); err != nil {
+		return nil, err
+	}
+
+	// Partitions that end before the first retained interval are expired
+	cutoff := partitionShift(c.partitions.interval,
+		partitionStart(c.partitions.interval, time.Now()), -c.partitions.retention)
+	prefix := c.TableName + "_p"
+	for _, name := range names {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		start, pErr := time.Parse(partitionNameLayout, strings.TrimPrefix(name, prefix))
+		if pErr != nil || partitionShift(c.partitions.interval, start, 1).After(cutoff) {
+			continue
+		}
+
+		statement := "ALTER TABLE " + c.QuotedTableName() + " DETACH PARTITION " + c.quotedPartitionName(name)
+		if c.partitions.expire == PartitionExpireDrop {
+			statement = "DROP TABLE " + c.quotedPartitionName(name)
+		}
+		if _, err = c.Client.Exec(context.Background(), statement); err != nil {
+			return expired, cerr.NewInvocationError(correlationId, "PARTITION_FAILED",
+				"Failed to expire partition "+name).WithCause(err)
+		}
+		c.Logger.Info(correlationId, "Expired partition %s of %s", name, c.TableName)
+		expired = append(expired, name)
+	}
+	return expired, nil
+}
+
+// Creates upcoming partitions and expires old ones. It is called on open and periodically while opened.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns error or nil no errors occured.
+func (c *PostgresPersistence) MaintainPartitions(correlationId string) error {
+	if err := c.EnsurePartitions(correlationId); err != nil {
+		return err
+	}
+	_, err := c.ExpirePartitions(correlationId)
+	return err
+}
+
+// Starts periodic maintenance of partitions if the partition column is configured
+func (c *PostgresPersistence) startPartitionMaintenance(correlationId string) {
+	if c.partitions.column == "" {
+		return
+	}
+	period := c.partitions.checkPeriod
+	if period <= 0 {
+		period = time.Hour
+	}
+
+	stop := make(chan struct{})
+	c.partitionStop = stop
+	go func() {
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := c.MaintainPartitions(correlationId); err != nil {
+					c.Logger.Error(correlationId, err, "Failed to maintain partitions of %s", c.TableName)
+				}
+			}
+		}
+	}()
+}
+
+// Stops periodic maintenance of partitions
+func (c *PostgresPersistence) stopPartitionMaintenance() {
+	if c.partitionStop != nil {
+		close(c.partitionStop)
+		c.partitionStop = nil
+	}
+}
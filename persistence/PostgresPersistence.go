@@ -1,28 +1,33 @@
 package persistence
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"math/rand"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/jackc/pgx/v4"
-	"github.com/jackc/pgx/v4/pgxpool"
 	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
 	cconv "github.com/pip-services3-go/pip-services3-commons-go/convert"
 	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
 	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
 	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	ccount "github.com/pip-services3-go/pip-services3-components-go/count"
 	clog "github.com/pip-services3-go/pip-services3-components-go/log"
 	cmpersist "github.com/pip-services3-go/pip-services3-data-go/persistence"
 	conn "github.com/pip-services3-go/pip-services3-postgres-go/connect"
 )
 
+// Hooks that child persistence components override to define the table schema
+// and conversion of data items between public and database formats.
+// The child component passes itself to InheritPostgresPersistence to override them.
 type IPostgresPersistenceOverrides interface {
 	DefineSchema()
 	ConvertFromPublic(item interface{}) interface{}
@@ -30,6 +35,40 @@ type IPostgresPersistenceOverrides interface {
 	ConvertFromPublicPartial(item interface{}) interface{}
 }
 
+// Public surface of PostgresPersistence, so business logic can depend on
+// the interface and receive any implementation, e.g. MockPostgresPersistence in unit tests.
+type IPostgresPersistence interface {
+	cref.IReferenceable
+	cref.IUnreferenceable
+	cconf.IConfigurable
+
+	IsOpen() bool
+	Open(correlationId string) error
+	Close(correlationId string) error
+	Clear(correlationId string) error
+
+	GetPageByFilter(correlationId string, filter interface{}, paging *cdata.PagingParams,
+		sort interface{}, sel interface{}) (*cdata.DataPage, error)
+	GetListByFilter(correlationId string, filter interface{}, sort interface{}, sel interface{}) ([]interface{}, error)
+	GetCountByFilter(correlationId string, filter interface{}) (int64, error)
+	GetOneRandom(correlationId string, filter interface{}) (interface{}, error)
+	Create(correlationId string, item interface{}) (interface{}, error)
+	DeleteByFilter(correlationId string, filter string) error
+}
+
+// Public surface of IdentifiablePostgresPersistence with operations over items with unique ids.
+type IIdentifiablePostgresPersistence interface {
+	IPostgresPersistence
+
+	GetListByIds(correlationId string, ids []interface{}) ([]interface{}, error)
+	GetOneById(correlationId string, id interface{}) (interface{}, error)
+	Set(correlationId string, item interface{}) (interface{}, error)
+	Update(correlationId string, item interface{}) (interface{}, error)
+	UpdatePartially(correlationId string, id interface{}, data *cdata.AnyValueMap) (interface{}, error)
+	DeleteById(correlationId string, id interface{}) (interface{}, error)
+	DeleteByIds(correlationId string, ids []interface{}) error
+}
+
 /*
 Abstract persistence component that stores data in PostgreSQL using plain driver.
 
@@ -38,6 +77,10 @@ able to store data items of any type. Specific CRUD operations
 over the data items must be implemented in child classes by
 accessing c._db or c._collection properties.
 
+Nullable columns map to pointer fields or to types like sql.NullString
+that implement sql.Scanner and driver.Valuer. NULL values are read as nil pointers
+or invalid values, and nil pointers or invalid values are written as NULL.
+
 ### Configuration parameters ###
 
 - collection:                  (optional) PostgreSQL collection name
@@ -55,12 +98,81 @@ accessing c._db or c._collection properties.
    - connect_timeout:      (optional) number of milliseconds to wait before timing out when connecting a new client (default: 0)
    - idle_timeout:         (optional) number of milliseconds a client must sit idle in the pool and not be checked out (default: 10000)
    - max_pool_size:        (optional) maximum number of clients the pool should contain (default: 10)
+   - min_pool_size:        (optional) minimum number of clients established during open and kept in the pool (default: 0)
+   - auto_reconnect:       (optional) recreate the connection pool in background when the database becomes unavailable (default: true)
+   - max_lifetime:         (optional) number of milliseconds after which a client is closed and replaced, 0 to disable (default: 3600000)
+   - health_check_period:  (optional) number of milliseconds between checks of idle clients and their lifetime (default: 60000)
+   - statement_cache_mode: (optional) how statements are cached per connection: prepare, describe or disabled (default: prepare)
+   - statement_cache_capacity: (optional) maximum number of cached statements per connection (default: 512)
+   - application_name:     (optional) name of the client shown in pg_stat_activity (default: name of the context info)
+   - statement_timeout:    (optional) number of milliseconds after which queries are cancelled by the server, 0 to disable (default: 0)
+   - vector_distance:      (optional) pgvector distance used by SearchBySimilarity: l2, cosine or inner_product (default: cosine)
+   - id_generator:         (optional) generator for empty ids: default or uuid_v7 for time-ordered UUIDs (default: default)
+   - strict_conversion:    (optional) return DataConversionError for rows that fail to convert instead of skipping them (default: false)
+   - pool_class:           (optional) workload class of the connection pool used by the persistence (default: default)
+   - max_list_size:        (optional) safety limit for GetListByFilter calls without filter, 0 to disable (default: 0)
+   - migrations_table:     (optional) table that tracks applied migrations (default: schema_migrations)
+   - schema_lock:          (optional) serialize schema creation and migrations between instances with an advisory lock (default: true)
+   - auto_migrate:         (optional) add columns and indexes missing in the table according to the prototype struct (default: false)
+   - table_prefix:         (optional) prefix added to the table name, e.g. to namespace tables of an environment
+   - table_suffix:         (optional) suffix added to the table name
+   - text_search_config:   (optional) text search configuration used by full-text search (default: english)
+   - search_vector_mode:   (optional) how the search vector column is maintained: generated or trigger (default: generated)
+   - numeric_format:       (optional) how NUMERIC values are decoded into the prototype: number or string (default: number)
+   - time_zone:            (optional) time zone of stored and returned timestamps: preserve, utc or local (default: preserve)
+   - time_precision:       (optional) precision timestamps are truncated to: s, ms, us or ns (default: no truncation)
+   - column_naming:        (optional) how columns are named after fields: json or snake_case (default: json)
+   - count_strategy:       (optional) how page totals are calculated: exact, estimated, capped or none (default: exact)
+   - count_limit:          (optional) maximum total counted by capped strategy (default: 10000)
+   - strict_paging:        (optional) reject pages larger than max_page_size with BadRequestError instead of clamping them (default: false)
+   - returning:            (optional) comma separated columns returned by write methods, * for all or none (default: *)
+   - read_replicas:        (optional) send reads of Get methods to read replicas of the connection when they are configured (default: true)
+   - tenancy:              (optional) multi-tenancy mode: none, schema to keep data of each tenant in its own schema
+                           or column to keep data of tenants in shared tables with a tenant column (default: none)
+   - tenant_schema_prefix: (optional) prefix of tenant schema names (default: tenant_)
+   - tenant_column:        (optional) column with tenant ids in column tenancy mode (default: tenant_id)
+   - partition_column:     (optional) timestamp column of a range partitioned table, enables maintenance of partitions
+   - partition_interval:   (optional) interval of partitions: day, week, month or year (default: month)
+   - partition_premake:    (optional) number of upcoming partitions created in advance (default: 3)
+   - partition_retention:  (optional) number of past intervals to keep, 0 to keep all partitions (default: 0)
+   - partition_expire:     (optional) detach or drop expired partitions (default: detach)
+   - partition_check_period: (optional) number of milliseconds between maintenance of partitions (default: 3600000)
+   - expire_column:        (optional) time column to expire rows by, enables cleanup of expired rows
+   - expire_ttl:           (optional) number of milliseconds after the column time when rows expire,
+                           0 when the column holds expiration times (default: 0)
+   - expire_batch_size:    (optional) maximum number of rows deleted by one statement (default: 1000)
+   - expire_archive_table: (optional) table with the same columns to move expired rows into instead of deleting them
+   - expire_period:        (optional) number of milliseconds between cleanups of expired rows, 0 to disable (default: 60000)
+   - soft_delete_column:   (optional) timestamp column set by delete methods instead of removing rows,
+                           soft deleted items are hidden from other methods
+   - history:              (optional) keep versions of items in a history table written by a trigger (default: false)
+   - history_table:        (optional) name of the history table (default: <table>_history)
+   - history_key:          (optional) column that identifies items in the history (default: id)
+   - maintenance_period:   (optional) number of milliseconds between VACUUM and ANALYZE of the table, 0 to disable (default: 0)
+   - readonly:             (optional) reject operations that change data with InvalidStateError and skip creation of database objects,
+                           set default_transaction_read_only option of the connection to enforce it on the server as well (default: false)
+   - dry_run:              (optional) log statements of Create, Set, Update and Delete methods and return would-be results
+                           without executing them, other writes are rejected (default: false)
+   - retry_max_attempts:   (optional) maximum number of attempts of statements that fail on transient conflicts, 1 to disable retries (default: 1)
+   - retry_sql_states:     (optional) comma separated SQL states of retried errors (default: 40001,40P01)
+   - retry_backoff:        (optional) how delays between retries grow: constant, linear or exponential (default: exponential)
+   - retry_delay:          (optional) number of milliseconds before the first retry (default: 50)
+   - retry_max_delay:      (optional) maximum number of milliseconds between retries, 0 for no limit (default: 2000)
+   - retry_jitter:         (optional) randomize delays between retries (default: true)
+   - close_timeout:        (optional) number of milliseconds Close waits for operations in flight, 0 to wait until completed (default: 10000)
+   - operation_timeout:    (optional) number of milliseconds after which an operation is cancelled with InvocationError, 0 to disable (default: 0)
+- table_definition:            (optional) table definition that replaces the one from DefineSchema
+   - columns.<name>:            column type with constraints, e.g. "TEXT PRIMARY KEY"
+   - indexes.<name>:            comma separated index columns with optional DESC, e.g. "key, time DESC"
+   - unique_indexes.<name>:     comma separated unique index columns
 
 ### References ###
 
 - \*:logger:\*:\*:1.0           (optional) ILogger components to pass log messages
+- \*:counters:\*:\*:1.0         (optional) ICounters components to pass collected measurements
 - \*:discovery:\*:\*:1.0        (optional) IDiscovery services
 - \*:credential-store:\*:\*:1.0 (optional) Credential stores to resolve credentials
+- \*:context-info:\*:\*:1.0     (optional) Context info with the default application name
 
 ### Example ###
 
@@ -111,15 +223,86 @@ type PostgresPersistence struct {
 	opened           bool
 	localConnection  bool
 	schemaStatements []string
+	vectorColumn     string
+	vectorDistance   string
+	idGenerator      string
+	strictConversion bool
+	poolClass        string
+	maxListSize      int
+	migrations       []*PostgresMigration
+	migrationsTable  string
+	schemaLock       bool
+	autoMigrate      bool
+	tableDefinition  *postgresTableDefinition
+	baseTableName    string
+	tablePrefix      string
+	tableSuffix      string
+	searchColumn     string
+	textSearchConfig string
+	searchVectorMode string
+	enumTypes        []*postgresEnumType
+	numericFormat    string
+	timeZone         string
+	timePrecision    time.Duration
+	timeFields       map[string][]int
+	sqlFields        map[string][]int
+	bytesFields      map[string][]int
+	prototypeFields  map[string][]int
+	columnConverters map[string]*PostgresTypeConverter
+	typeConverters   map[reflect.Type]*PostgresTypeConverter
+	columnNaming     string
+	countStrategy    string
+	countLimit       int64
+	strictPaging     bool
+	returning        []string
+	readReplicas     bool
+	operationTimeout time.Duration
+	ctx              context.Context
+	closeTimeout     time.Duration
+	operations       *postgresOperationTracker
+	tenancy          string
+	tenantPrefix     string
+	tenantColumn     string
+	tenant           string
+	tenants          *sync.Map
+	advisoryLocks    *sync.Map
+	partitions       postgresPartitionOptions
+	partitionStop    chan struct{}
+	maintainPeriod   time.Duration
+	maintainStop     chan struct{}
+	expiration       postgresExpirationOptions
+	expireStop       chan struct{}
+	history          postgresHistoryOptions
+	softDeleteColumn string
+	deletedOnly      bool
+	seeds            []postgresSeed
+	readonly         bool
+	dryRun           bool
+	columnFields     map[string]string
+	generatedColumns map[string]string
+	defaultFields    map[string]bool
+	defaultColumns   map[string]bool
+	view             bool
+	relations        map[string]*PostgresRelation
+	customClient     IPostgresClient
+	interceptors     []PostgresInterceptor
+	retryPolicy      *PostgresRetryPolicy
+	retryOverrides   map[string]*PostgresRetryPolicy
+	configErr        error
+	schemaErr        error
 
 	//The dependency resolver.
 	DependencyResolver *cref.DependencyResolver
 	//The logger.
 	Logger *clog.CompositeLogger
+	//The performance counters.
+	Counters *ccount.CompositeCounters
 	//The PostgreSQL connection component.
 	Connection *conn.PostgresConnection
-	//The PostgreSQL connection pool object.
-	Client *pgxpool.Pool
+	//The PostgreSQL client that runs statements on the current pool of the connection, unless another client is set by SetClient.
+	Client IPostgresClient
+	//The PostgreSQL client to read data. It uses the pool of read replicas, if they are configured, or the pool of Client.
+	ReadClient IPostgresClient
 	//The PostgreSQL database name.
 	DatabaseName string
 	//The PostgreSQL database schema name. If not set use "public" by default
@@ -145,11 +328,93 @@ func InheritPostgresPersistence(overrides IPostgresPersistenceOverrides, proto r
 			"options.auto_reconnect", true,
 			"options.max_page_size", 100,
 			"options.debug", true,
+			"options.vector_distance", VectorDistanceCosine,
+			"options.id_generator", IdGeneratorDefault,
+			"options.strict_conversion", false,
+			"options.pool_class", conn.DefaultPoolClass,
+			"options.max_list_size", 0,
+			"options.migrations_table", "schema_migrations",
+			"options.schema_lock", true,
+			"options.auto_migrate", false,
+			"options.text_search_config", "english",
+			"options.search_vector_mode", SearchVectorGenerated,
+			"options.numeric_format", NumericFormatNumber,
+			"options.time_zone", TimeZonePreserve,
+			"options.column_naming", ColumnNamingJson,
+			"options.count_strategy", CountStrategyExact,
+			"options.count_limit", 10000,
+			"options.strict_paging", false,
+			"options.returning", ReturningAll,
+			"options.read_replicas", true,
+			"options.operation_timeout", 0,
+			"options.close_timeout", 10000,
+			"options.tenancy", TenancyNone,
+			"options.tenant_schema_prefix", "tenant_",
+			"options.tenant_column", "tenant_id",
+			"options.partition_interval", PartitionIntervalMonth,
+			"options.partition_premake", 3,
+			"options.partition_retention", 0,
+			"options.partition_expire", PartitionExpireDetach,
+			"options.partition_check_period", 3600000,
+			"options.history", false,
+			"options.history_key", "id",
+			"options.maintenance_period", 0,
+			"options.readonly", false,
+			"options.dry_run", false,
+			"options.expire_ttl", 0,
+			"options.expire_batch_size", 1000,
+			"options.expire_period", 60000,
+			"options.retry_max_attempts", 1,
+			"options.retry_backoff", RetryBackoffExponential,
+			"options.retry_delay", 50,
+			"options.retry_max_delay", 2000,
+			"options.retry_jitter", true,
 		),
 		schemaStatements: make([]string, 0),
 		Logger:           clog.NewCompositeLogger(),
+		Counters:         ccount.NewCompositeCounters(),
 		MaxPageSize:      100,
 		TableName:        tableName,
+		baseTableName:    tableName,
+		vectorDistance:   VectorDistanceCosine,
+		idGenerator:      IdGeneratorDefault,
+		poolClass:        conn.DefaultPoolClass,
+		maxListSize:      0,
+		migrationsTable:  "schema_migrations",
+		schemaLock:       true,
+		textSearchConfig: "english",
+		searchVectorMode: SearchVectorGenerated,
+		numericFormat:    NumericFormatNumber,
+		timeZone:         TimeZonePreserve,
+		columnNaming:     ColumnNamingJson,
+		countStrategy:    CountStrategyExact,
+		countLimit:       10000,
+		readReplicas:     true,
+		closeTimeout:     10 * time.Second,
+		operations:       &postgresOperationTracker{},
+		tenancy:          TenancyNone,
+		tenantPrefix:     "tenant_",
+		tenantColumn:     "tenant_id",
+		tenants:          &sync.Map{},
+		advisoryLocks:    &sync.Map{},
+		retryPolicy:      NewPostgresRetryPolicy(),
+		retryOverrides:   make(map[string]*PostgresRetryPolicy),
+		history:          postgresHistoryOptions{key: "id"},
+		timeFields:       getPrototypeTimeFields(proto),
+		sqlFields:        getPrototypeSqlFields(proto),
+		bytesFields:      getPrototypeBytesFields(proto),
+		prototypeFields:  getPrototypeFields(proto, func(reflect.Type) bool { return true }),
+		defaultFields:    getPrototypeDefaultFields(proto),
+		partitions: postgresPartitionOptions{
+			interval:    PartitionIntervalMonth,
+			premake:     3,
+			expire:      PartitionExpireDetach,
+			checkPeriod: time.Hour,
+		},
+		expiration: postgresExpirationOptions{
+			batchSize: 1000,
+			period:    time.Minute,
+		},
 	}
 
 	c.DependencyResolver = cref.NewDependencyResolver()
@@ -166,10 +431,77 @@ func (c *PostgresPersistence) Configure(config *cconf.ConfigParams) {
 
 	c.DependencyResolver.Configure(config)
 
-	c.TableName = config.GetAsStringWithDefault("collection", c.TableName)
-	c.TableName = config.GetAsStringWithDefault("table", c.TableName)
+	c.baseTableName = config.GetAsStringWithDefault("collection", c.baseTableName)
+	c.baseTableName = config.GetAsStringWithDefault("table", c.baseTableName)
+	c.tablePrefix = config.GetAsStringWithDefault("options.table_prefix", c.tablePrefix)
+	c.tableSuffix = config.GetAsStringWithDefault("options.table_suffix", c.tableSuffix)
+	c.TableName = c.tablePrefix + c.baseTableName + c.tableSuffix
 	c.MaxPageSize = config.GetAsIntegerWithDefault("options.max_page_size", c.MaxPageSize)
 	c.SchemaName = config.GetAsStringWithDefault("schema", c.SchemaName)
+	c.vectorDistance = config.GetAsStringWithDefault("options.vector_distance", c.vectorDistance)
+	c.idGenerator = config.GetAsStringWithDefault("options.id_generator", c.idGenerator)
+	c.strictConversion = config.GetAsBooleanWithDefault("options.strict_conversion", c.strictConversion)
+	c.poolClass = config.GetAsStringWithDefault("options.pool_class", c.poolClass)
+	c.maxListSize = config.GetAsIntegerWithDefault("options.max_list_size", c.maxListSize)
+	c.migrationsTable = config.GetAsStringWithDefault("options.migrations_table", c.migrationsTable)
+	c.schemaLock = config.GetAsBooleanWithDefault("options.schema_lock", c.schemaLock)
+	c.autoMigrate = config.GetAsBooleanWithDefault("options.auto_migrate", c.autoMigrate)
+	c.textSearchConfig = config.GetAsStringWithDefault("options.text_search_config", c.textSearchConfig)
+	c.searchVectorMode = config.GetAsStringWithDefault("options.search_vector_mode", c.searchVectorMode)
+	c.numericFormat = config.GetAsStringWithDefault("options.numeric_format", c.numericFormat)
+	c.timeZone = config.GetAsStringWithDefault("options.time_zone", c.timeZone)
+	if precision := config.GetAsString("options.time_precision"); precision != "" {
+		c.timePrecision = parseTimePrecision(precision)
+	}
+	c.columnNaming = config.GetAsStringWithDefault("options.column_naming", c.columnNaming)
+	c.columnFields = c.getColumnFields()
+	c.countStrategy = config.GetAsStringWithDefault("options.count_strategy", c.countStrategy)
+	c.countLimit = config.GetAsLongWithDefault("options.count_limit", c.countLimit)
+	c.strictPaging = config.GetAsBooleanWithDefault("options.strict_paging", c.strictPaging)
+	c.readReplicas = config.GetAsBooleanWithDefault("options.read_replicas", c.readReplicas)
+	c.operationTimeout = time.Duration(config.GetAsLongWithDefault("options.operation_timeout",
+		int64(c.operationTimeout/time.Millisecond))) * time.Millisecond
+	c.tenancy = config.GetAsStringWithDefault("options.tenancy", c.tenancy)
+	c.tenantPrefix = config.GetAsStringWithDefault("options.tenant_schema_prefix", c.tenantPrefix)
+	c.tenantColumn = config.GetAsStringWithDefault("options.tenant_column", c.tenantColumn)
+	if c.tenancy == TenancySchema {
+		// Tables are resolved by the search path of tenants
+		c.SchemaName = ""
+	}
+	c.partitions.column = config.GetAsStringWithDefault("options.partition_column", c.partitions.column)
+	c.partitions.interval = config.GetAsStringWithDefault("options.partition_interval", c.partitions.interval)
+	c.partitions.premake = config.GetAsIntegerWithDefault("options.partition_premake", c.partitions.premake)
+	c.partitions.retention = config.GetAsIntegerWithDefault("options.partition_retention", c.partitions.retention)
+	c.partitions.expire = config.GetAsStringWithDefault("options.partition_expire", c.partitions.expire)
+	c.partitions.checkPeriod = time.Duration(config.GetAsLongWithDefault("options.partition_check_period",
+		int64(c.partitions.checkPeriod/time.Millisecond))) * time.Millisecond
+	c.expiration.column = config.GetAsStringWithDefault("options.expire_column", c.expiration.column)
+	c.expiration.ttl = time.Duration(config.GetAsLongWithDefault("options.expire_ttl",
+		int64(c.expiration.ttl/time.Millisecond))) * time.Millisecond
+	c.expiration.batchSize = config.GetAsIntegerWithDefault("options.expire_batch_size", c.expiration.batchSize)
+	c.expiration.archiveTable = config.GetAsStringWithDefault("options.expire_archive_table", c.expiration.archiveTable)
+	c.expiration.period = time.Duration(config.GetAsLongWithDefault("options.expire_period",
+		int64(c.expiration.period/time.Millisecond))) * time.Millisecond
+	c.softDeleteColumn = config.GetAsStringWithDefault("options.soft_delete_column", c.softDeleteColumn)
+	c.history.enabled = config.GetAsBooleanWithDefault("options.history", c.history.enabled)
+	c.history.table = config.GetAsStringWithDefault("options.history_table", c.history.table)
+	c.history.key = config.GetAsStringWithDefault("options.history_key", c.history.key)
+	c.maintainPeriod = time.Duration(config.GetAsLongWithDefault("options.maintenance_period",
+		int64(c.maintainPeriod/time.Millisecond))) * time.Millisecond
+	c.readonly = config.GetAsBooleanWithDefault("options.readonly", c.readonly)
+	c.dryRun = config.GetAsBooleanWithDefault("options.dry_run", c.dryRun)
+	c.retryPolicy = NewPostgresRetryPolicyFromConfig(config)
+	c.closeTimeout = time.Duration(config.GetAsLongWithDefault("options.close_timeout",
+		int64(c.closeTimeout/time.Millisecond))) * time.Millisecond
+	if returning := config.GetAsString("options.returning"); returning != "" {
+		c.SetReturningColumns(strings.Split(returning, ",")...)
+	}
+	c.tableDefinition = newPostgresTableDefinition(config)
+
+	// Reload options of an open local connection
+	if c.opened && c.localConnection {
+		c.Connection.Configure(config)
+	}
 }
 
 // Sets references to dependent components.
@@ -177,6 +509,7 @@ func (c *PostgresPersistence) Configure(config *cconf.ConfigParams) {
 func (c *PostgresPersistence) SetReferences(references cref.IReferences) {
 	c.references = references
 	c.Logger.SetReferences(references)
+	c.Counters.SetReferences(references)
 
 	// Get connection
 	c.DependencyResolver.SetReferences(references)
@@ -209,37 +542,23 @@ func (c *PostgresPersistence) createConnection() *conn.PostgresConnection {
 	return connection
 }
 
-// Adds index definition to create it on opening
+// Adds index definition to create it on opening.
+// Keys are used in the statement as they are, so they can be SQL expressions.
+// Keys are sorted by name, use EnsureIndexKeys when the order of keys matters.
 //   - keys index keys (fields)
 //   - options index options
 func (c *PostgresPersistence) EnsureIndex(name string, keys map[string]string, options map[string]string) {
-	builder := "CREATE"
-	if options == nil {
-		options = make(map[string]string, 0)
-	}
-
-	if options["unique"] != "" {
-		builder += " UNIQUE"
-	}
-
-	//builder += " INDEX IF NOT EXISTS " + name + " ON " + c.QuotedTableName()
-	indexName := c.QuoteIdentifier(name)
-	if len(c.SchemaName) > 0 {
-		indexName = c.QuoteIdentifier(c.SchemaName) + "." + indexName
-	}
-
-	builder += " INDEX IF NOT EXISTS " + indexName + " ON " + c.QuotedTableName()
-
-	if options["type"] != "" {
-		builder += " " + options["type"]
+	names := make([]string, 0, len(keys))
+	for key := range keys {
+		names = append(names, key)
 	}
+	sort.Strings(names)
 
 	fields := ""
-	for key, _ := range keys {
+	for _, key := range names {
 		if fields != "" {
 			fields += ", "
 		}
-		//fields += c.QuoteIdentifier(key)
 		fields += key
 		asc := keys[key]
 		if asc != "1" {
@@ -247,7 +566,7 @@ func (c *PostgresPersistence) EnsureIndex(name string, keys map[string]string, o
 		}
 	}
 
-	builder += "(" + fields + ")"
+	builder := c.composeIndexStatement(name, fields, options)
 
 	c.EnsureSchema(builder)
 }
@@ -274,31 +593,52 @@ func (c *PostgresPersistence) EnsureSchema(schemaStatement string) {
 	c.schemaStatements = append(c.schemaStatements, schemaStatement)
 }
 
+// Creates the configured database schema if it does not exist.
+// Schema definition already includes this step, use this method
+// to create objects in the schema outside of the schema definition.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns error or nil no errors occured.
+func (c *PostgresPersistence) EnsureSchemaExists(correlationId string) error {
+	if len(c.SchemaName) == 0 {
+		return nil
+	}
+
+	_, err := c.Client.Exec(c.schemaContext(), "CREATE SCHEMA IF NOT EXISTS "+c.QuoteIdentifier(c.SchemaName))
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "Failed to create schema "+c.SchemaName).
+			WithCause(err)
+	}
+	return nil
+}
+
 // Clears all auto-created objects
 func (c *PostgresPersistence) ClearSchema() {
 	c.schemaStatements = []string{}
+	c.seeds = nil
+	c.enumTypes = nil
+	c.generatedColumns = nil
+	c.defaultColumns = nil
+	c.view = false
+	c.schemaErr = nil
 }
 
 // Converts object value from internal to func (c * PostgresPersistence) format.
 //   - value     an object in internal format to convert.
-// Returns converted object in func (c * PostgresPersistence) format.
+// Returns converted object in func (c * PostgresPersistence) format
+// or DataConversionError if the row cannot be converted.
 func (c *PostgresPersistence) ConvertToPublic(rows pgx.Rows) interface{} {
 	values, valErr := rows.Values()
 	if valErr != nil || values == nil {
-		return nil
+		return NewDataConversionError("", "", nil, valErr)
 	}
 	columns := rows.FieldDescriptions()
 
 	buf := make(map[string]interface{}, 0)
 
 	for index, column := range columns {
-		buf[(string)(column.Name)] = values[index]
+		buf[c.getFieldName(string(column.Name))] = c.convertValueToPublic(values[index])
 	}
-	docPointer := c.NewObjectByPrototype()
-	jsonBuf, _ := json.Marshal(buf)
-	json.Unmarshal(jsonBuf, docPointer.Interface())
-	return c.DereferenceObject(docPointer)
-
+	return c.decodeToPrototype(buf)
 }
 
 // Convert object value from func (c * PostgresPersistence) to internal format.
@@ -315,14 +655,44 @@ func (c *PostgresPersistence) ConvertFromPublicPartial(value interface{}) interf
 	return c.ConvertFromPublic(value)
 }
 
+// Sets a table name without prefix and suffix. Components that name their tables by own settings,
+// like message queues, call it before Configure, which composes TableName from it
+// unless the name is overridden by "table" or "collection" parameters.
+//   - tableName   a table name without prefix and suffix.
+func (c *PostgresPersistence) SetBaseTableName(tableName string) {
+	c.baseTableName = tableName
+	c.TableName = c.tablePrefix + c.baseTableName + c.tableSuffix
+}
+
+// Quotes an identifier like a table or column name to safely use it in SQL statements.
+// A value that is already one quoted identifier with escaped quotes inside is returned as it is.
+//   - value   an identifier to quote.
+// Returns the quoted identifier.
 func (c *PostgresPersistence) QuoteIdentifier(value string) string {
 	if value == "" {
 		return value
 	}
-	if value[0] == '\'' {
+	if value[0] == '\'' || isQuotedIdentifier(value) {
 		return value
 	}
-	return "\"" + value + "\""
+	return "\"" + strings.ReplaceAll(value, "\"", "\"\"") + "\""
+}
+
+// Checks if a value is a single quoted identifier like "my ""table""",
+// so values like "a" OR "b" are quoted as a whole
+func isQuotedIdentifier(value string) bool {
+	if len(value) < 3 || value[0] != '"' || value[len(value)-1] != '"' {
+		return false
+	}
+	inner := value[1 : len(value)-1]
+	return !strings.Contains(strings.ReplaceAll(inner, "\"\"", ""), "\"")
+}
+
+// Quotes a string literal to safely embed it into SQL filters and statements.
+//   - value   a string value to quote.
+// Returns the quoted literal.
+func (c *PostgresPersistence) QuoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
 }
 
 // Return quoted SchemaName with TableName ("schema"."table")
@@ -333,6 +703,17 @@ func (c *PostgresPersistence) QuotedTableName() string {
 	return c.QuoteIdentifier(c.TableName)
 }
 
+// Gets the connection pool dedicated to a workload class.
+// Use it to run specific calls, like batch jobs, in a separate pool.
+//   - poolClass     a workload class name configured in the connection
+// Returns a connection pool or the default client if the class is not configured
+func (c *PostgresPersistence) GetClientByClass(poolClass string) IPostgresClient {
+	if c.Connection == nil || !c.opened || c.customClient != nil {
+		return c.Client
+	}
+	return c.Connection.GetConnectionByClass(poolClass)
+}
+
 // Checks if the component is opened.
 // Returns true if the component has been opened and false otherwise.
 func (c *PostgresPersistence) IsOpen() bool {
@@ -346,6 +727,15 @@ func (c *PostgresPersistence) Open(correlationId string) (err error) {
 	if c.opened {
 		return nil
 	}
+	if c.configErr != nil {
+		return c.configErr
+	}
+
+	// A client set by SetClient is used without a connection
+	if c.customClient != nil {
+		c.setClients()
+		return c.openSchema(correlationId)
+	}
 
 	if c.Connection == nil {
 		c.Connection = c.createConnection()
@@ -369,24 +759,92 @@ func (c *PostgresPersistence) Open(correlationId string) (err error) {
 	if err != nil {
 		return err
 	}
-	c.Client = c.Connection.GetConnection()
+	c.setClients()
 	c.DatabaseName = c.Connection.GetDatabaseName()
+	return c.openSchema(correlationId)
+}
+
+// Defines and initializes the schema and starts background jobs, once clients are set
+func (c *PostgresPersistence) openSchema(correlationId string) (err error) {
+	c.operations.open()
 
 	// Define database schema
 	c.Overrides.DefineSchema()
+	c.defineConfiguredSchema()
+	if c.schemaErr != nil {
+		return c.schemaErr
+	}
 
-	// Recreate objects
-	err = c.CreateSchema(correlationId)
+	// Recreate objects and apply pending migrations.
+	// Schemas of tenants are initialized on their first operations.
+	// Read-only persistences use objects created by others.
+	if c.tenancy != TenancySchema && !c.readonly {
+		err = c.initializeSchema(correlationId)
+	}
+	if err == nil && c.partitions.column != "" && !c.readonly {
+		err = c.MaintainPartitions(correlationId)
+	}
 	if err != nil {
 		c.Client = nil
+		c.ReadClient = nil
 		err = cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "Connection to postgres failed").WithCause(err)
 	} else {
 		c.opened = true
+		if !c.readonly {
+			c.startPartitionMaintenance(correlationId)
+			c.startMaintenance(correlationId)
+			c.startExpiration(correlationId)
+		}
 		c.Logger.Debug(correlationId, "Connected to postgres database %s, collection %s", c.DatabaseName, c.QuotedTableName())
 	}
 
 	return err
+}
 
+// Sets clients from pools of the connection or the client set by SetClient.
+// It is called on open. Clients of the connection resolve its current pools per call,
+// so they are not replaced when the pools are recreated by reconnect.
+func (c *PostgresPersistence) setClients() {
+	if c.customClient != nil {
+		c.Client = newRetryingClient(newInterceptedClient(c.customClient, c.interceptors), c)
+		c.ReadClient = c.Client
+		return
+	}
+	client := &postgresPoolClient{connection: c.Connection, poolClass: c.poolClass}
+	c.Client = newRetryingClient(newInterceptedClient(client, c.interceptors), c)
+	c.ReadClient = c.Client
+	if c.readReplicas {
+		readClient := &postgresPoolClient{connection: c.Connection, poolClass: c.poolClass, replica: true}
+		c.ReadClient = newRetryingClient(newInterceptedClient(readClient, c.interceptors), c)
+	}
+}
+
+// Recreates database objects and applies pending migrations
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns error or nil no errors occured.
+func (c *PostgresPersistence) initializeSchema(correlationId string) error {
+	return c.withSchemaLock(correlationId, func() error {
+		err := c.ensureEnumTypes(correlationId)
+		if err == nil {
+			err = c.CreateSchema(correlationId)
+		}
+		if err == nil && c.autoMigrate {
+			err = c.AutoMigrate(correlationId)
+		}
+		if err == nil {
+			err = c.applyConfiguredSchema(correlationId)
+		}
+		if err == nil {
+			err = c.Migrate(correlationId)
+		}
+		if err == nil {
+			err = c.ensureHistory(correlationId)
+		}
+		if err == nil {
+			err = c.applySeedData(correlationId)
+		}
+		return err
+	})
 }
 
 // Closes component and frees used resources.
@@ -397,11 +855,21 @@ func (c *PostgresPersistence) Close(correlationId string) (err error) {
 		return nil
 	}
 
-	if c.Connection == nil {
+	if c.Connection == nil && c.customClient == nil {
 		return cerr.NewInvalidStateError(correlationId, "NO_CONNECTION", "Postgres connection is missing")
 	}
 
-	if c.localConnection {
+	c.stopPartitionMaintenance()
+	c.stopMaintenance()
+	c.stopExpiration()
+
+	// New operations are rejected while ones in flight complete
+	if !c.operations.drain(c.closeTimeout) {
+		c.Logger.Warn(correlationId, "Closing %s with operations in flight after %s", c.TableName, c.closeTimeout.String())
+	}
+	c.releaseAdvisoryLocks(correlationId)
+
+	if c.localConnection && c.customClient == nil {
 		err = c.Connection.Close(correlationId)
 	}
 	if err != nil {
@@ -409,21 +877,38 @@ func (c *PostgresPersistence) Close(correlationId string) (err error) {
 	}
 	c.opened = false
 	c.Client = nil
+	c.ReadClient = nil
 	return nil
 }
 
 // Clears component state.
 //   - correlationId 	(optional) transaction id to trace execution through call chain.
 //   - Returns 			error or nil no errors occured.
-func (c *PostgresPersistence) Clear(correlationId string) error {
+func (c *PostgresPersistence) Clear(correlationId string) (err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
 	// Return error if collection is not set
 	if c.TableName == "" {
 		return errors.New("Table name is not defined")
 	}
+	if err := c.checkWritable(correlationId); err != nil {
+		return err
+	}
+	if err := c.checkNotDryRun(correlationId); err != nil {
+		return err
+	}
 
+	// Only rows of the current tenant are cleared in column tenancy mode, including soft deleted ones
 	query := "DELETE FROM " + c.QuotedTableName()
+	if tenant := c.tenantCondition(); tenant != "" {
+		query += " WHERE " + tenant
+	}
 
-	qResult, err := c.Client.Query(context.TODO(), query)
+	qResult, err := c.Client.Query(ctx, query)
 	if err != nil {
 		err = cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "Connection to postgres failed").
 			WithCause(err)
@@ -438,22 +923,15 @@ func (c *PostgresPersistence) CreateSchema(correlationId string) (err error) {
 	}
 
 	// Check if table exist to determine weither to auto create objects
-	query := "SELECT to_regclass('" + c.QuotedTableName() + "')"
-	qResult, qErr := c.Client.Query(context.TODO(), query)
-	if qErr != nil {
-		return qErr
+	var exists bool
+	query := "SELECT to_regclass($1) IS NOT NULL"
+	err = c.Client.QueryRow(c.schemaContext(), query, c.QuotedTableName()).Scan(&exists)
+	if err != nil {
+		return err
 	}
-	defer qResult.Close()
 	// If table already exists then exit
-	if qResult != nil && qResult.Next() {
-		val, cErr := qResult.Values()
-		if cErr != nil {
-			return cErr
-		}
-
-		if len(val) > 0 && val[0] == c.TableName {
-			return nil
-		}
+	if exists {
+		return nil
 	}
 	c.Logger.Debug(correlationId, "Table "+c.QuotedTableName()+" does not exist. Creating database objects...")
 	wg := sync.WaitGroup{}
@@ -461,7 +939,7 @@ func (c *PostgresPersistence) CreateSchema(correlationId string) (err error) {
 	go func() {
 		defer wg.Done()
 		for _, dml := range c.schemaStatements {
-			qResult, err := c.Client.Query(context.TODO(), dml)
+			qResult, err := c.Client.Query(c.schemaContext(), dml)
 			if err != nil {
 				c.Logger.Error(correlationId, err, "Failed to autocreate database object")
 			}
@@ -471,7 +949,7 @@ func (c *PostgresPersistence) CreateSchema(correlationId string) (err error) {
 		}
 	}()
 	wg.Wait()
-	return qResult.Err()
+	return nil
 }
 
 // Generates a list of column names to use in SQL statements like: "column1,column2,column3"
@@ -582,17 +1060,34 @@ func (c *PostgresPersistence) convertToMap(values interface{}) map[string]interf
 		return nil
 	}
 	items := make(map[string]interface{}, 0)
-	mErr = json.Unmarshal(mRes, &items)
+	decoder := json.NewDecoder(bytes.NewReader(mRes))
+	decoder.UseNumber()
+	mErr = decoder.Decode(&items)
 	if mErr != nil {
 		c.Logger.Error("PostgresPersistence", mErr, "Error data convertion")
 		return nil
 	}
+	for key, item := range items {
+		items[key] = convertNumberFromPublic(item, false)
+	}
+	c.convertTimesFromPublic(items)
+	c.convertSqlValuesFromPublic(values, items)
+	c.convertBytesFromPublic(values, items)
+	if mErr = c.convertValuesFromPublic(values, items); mErr != nil {
+		c.Logger.Error("PostgresPersistence", mErr, "Error data convertion")
+		return nil
+	}
+	c.excludeRelationFields(items)
+	items = c.renameToColumns(items)
+	c.excludeGeneratedColumns(items)
 	return items
 }
 
 // Gets a page of data items retrieved by a given filter and sorted according to sort parameters.
 // This method shall be called by a func (c * PostgresPersistence) getPageByFilter method from child class that
 // receives FilterParams and converts them into a filter function.
+// The filter can be a SQL condition or *PostgresJoinFilter to filter by related tables.
+// Totals are calculated according to options.count_strategy.
 //   - correlationId     (optional) transaction id to trace execution through call chain.
 //   - filter            (optional) a filter JSON object
 //   - paging            (optional) paging parameters
@@ -602,26 +1097,46 @@ func (c *PostgresPersistence) convertToMap(values interface{}) map[string]interf
 func (c *PostgresPersistence) GetPageByFilter(correlationId string, filter interface{}, paging *cdata.PagingParams,
 	sort interface{}, sel interface{}) (page *cdata.DataPage, err error) {
 
-	query := "SELECT * FROM " + c.QuotedTableName()
-	if sel != nil {
-		if slct, ok := sel.(string); ok && slct != "" {
-			query = "SELECT " + slct + " FROM " + c.QuotedTableName()
-		}
+	result, err := c.GetPageByFilterWithPaging(correlationId, filter, &PostgresPagingParams{PagingParams: paging}, sort, sel)
+	if result == nil {
+		return nil, err
 	}
+	return result.DataPage, err
+}
+
+// Gets a page of data items like GetPageByFilter, with a strategy to calculate the total
+// set in paging parameters per request. The page also reports rows skipped by failed conversion.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - filter            (optional) a filter JSON object
+//   - paging            (optional) paging parameters with a count strategy
+//   - sort              (optional) sorting JSON object
+//   - select            (optional) projection JSON object
+//   - Returns           receives a data page with a number of skipped rows or error.
+func (c *PostgresPersistence) GetPageByFilterWithPaging(correlationId string, filter interface{}, paging *PostgresPagingParams,
+	sort interface{}, sel interface{}) (page *PostgresDataPage, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	query := c.composeSelect(filter, sel)
 
 	// Adjust max item count based on configurationpaging
 	if paging == nil {
-		paging = cdata.NewEmptyPagingParams()
+		paging = &PostgresPagingParams{}
+	}
+	if paging.PagingParams == nil {
+		paging.PagingParams = cdata.NewEmptyPagingParams()
+	}
+	if err = c.validatePaging(correlationId, paging.PagingParams); err != nil {
+		return nil, err
 	}
 	skip := paging.GetSkip(-1)
 	take := paging.GetTake((int64)(c.MaxPageSize))
 	pagingEnabled := paging.Total
 
-	if filter != nil {
-		if flt, ok := filter.(string); ok && flt != "" {
-			query += " WHERE " + flt
-		}
-	}
+	query += c.composeWhere(filter)
 
 	if sort != nil {
 		if srt, ok := sort.(string); ok && srt != "" {
@@ -634,7 +1149,7 @@ func (c *PostgresPersistence) GetPageByFilter(correlationId string, filter inter
 	}
 
 	query += " LIMIT " + strconv.FormatInt(take, 10)
-	qResult, qErr := c.Client.Query(context.TODO(), query)
+	qResult, qErr := c.ReadClient.Query(ctx, query)
 
 	if qErr != nil {
 		return nil, qErr
@@ -643,40 +1158,38 @@ func (c *PostgresPersistence) GetPageByFilter(correlationId string, filter inter
 	defer qResult.Close()
 
 	items := make([]interface{}, 0, 0)
+	skipped := 0
 	for qResult.Next() {
-		item := c.Overrides.ConvertToPublic(qResult)
+		item, convErr := c.ConvertRowToPublic(correlationId, qResult)
+		if convErr != nil {
+			return nil, convErr
+		}
+		if item == nil {
+			skipped++
+			continue
+		}
 		items = append(items, item)
 	}
+	c.logSkippedRows(correlationId, skipped, len(items))
 
 	if items != nil {
 		c.Logger.Trace(correlationId, "Retrieved %d from %s", len(items), c.TableName)
 	}
 
-	if pagingEnabled {
-		query := "SELECT COUNT(*) AS count FROM " + c.QuotedTableName()
-		if filter != nil {
-			if flt, ok := sel.(string); ok && flt != "" {
-				query += " WHERE " + flt
-			}
-		}
+	if err = qResult.Err(); err != nil {
+		return nil, err
+	}
 
-		qResult2, qErr2 := c.Client.Query(context.TODO(), query)
-		if qErr2 != nil {
-			return nil, qErr2
-		}
-		defer qResult2.Close()
-		var count int64 = 0
-		if qResult2.Next() {
-			rows, _ := qResult2.Values()
-			if len(rows) == 1 {
-				count = cconv.LongConverter.ToLong(rows[0])
-			}
+	if pagingEnabled {
+		total, cErr := c.countByStrategy(correlationId, filter, paging.CountStrategy, paging.CountLimit)
+		if cErr != nil {
+			return nil, cErr
 		}
-		page = cdata.NewDataPage(&count, items)
-		return page, qResult2.Err()
+		page = &PostgresDataPage{DataPage: cdata.NewDataPage(total, items), Skipped: skipped}
+		return page, nil
 	}
 	var total int64 = 0
-	page = cdata.NewDataPage(&total, items)
+	page = &PostgresDataPage{DataPage: cdata.NewDataPage(&total, items), Skipped: skipped}
 	return page, qResult.Err()
 }
 
@@ -687,16 +1200,15 @@ func (c *PostgresPersistence) GetPageByFilter(correlationId string, filter inter
 //   - filter            (optional) a filter JSON object
 //   - Returns           data page or error.
 func (c *PostgresPersistence) GetCountByFilter(correlationId string, filter interface{}) (count int64, err error) {
-
-	query := "SELECT COUNT(*) AS count FROM " + c.QuotedTableName()
-
-	if filter != nil {
-		if flt, ok := filter.(string); ok && flt != "" {
-			query += " WHERE " + flt
-		}
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
 	}
 
-	qResult, qErr := c.Client.Query(context.TODO(), query)
+	query := "SELECT COUNT(*) AS count FROM " + c.composeFrom(filter) + c.composeWhere(filter)
+
+	qResult, qErr := c.ReadClient.Query(ctx, query)
 	if qErr != nil {
 		return 0, qErr
 	}
@@ -718,6 +1230,7 @@ func (c *PostgresPersistence) GetCountByFilter(correlationId string, filter inte
 // Gets a list of data items retrieved by a given filter and sorted according to sort parameters.
 // This method shall be called by a func (c * PostgresPersistence) getListByFilter method from child class that
 // receives FilterParams and converts them into a filter function.
+// The filter can be a SQL condition or *PostgresJoinFilter to filter by related tables.
 //   - correlationId    (optional) transaction id to trace execution through call chain.
 //   - filter           (optional) a filter JSON object
 //   - paging           (optional) paging parameters
@@ -725,19 +1238,18 @@ func (c *PostgresPersistence) GetCountByFilter(correlationId string, filter inte
 //   - select           (optional) projection JSON object
 //   - Returns          data list or error.
 func (c *PostgresPersistence) GetListByFilter(correlationId string, filter interface{}, sort interface{}, sel interface{}) (items []interface{}, err error) {
-
-	query := "SELECT * FROM " + c.QuotedTableName()
-	if sel != nil {
-		if slct, ok := sel.(string); ok && slct != "" {
-			query = "SELECT " + slct + " FROM " + c.QuotedTableName()
-		}
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
 	}
 
-	if filter != nil {
-		if flt, ok := filter.(string); ok && flt != "" {
-			query += " WHERE " + flt
-		}
-	}
+	query := c.composeSelect(filter, sel)
+
+	where := c.composeWhere(filter)
+	query += where
+	// The tenant condition alone does not limit the list
+	unbounded := where == c.composeWhere("")
 
 	if sort != nil {
 		if srt, ok := sort.(string); ok && srt != "" {
@@ -745,17 +1257,38 @@ func (c *PostgresPersistence) GetListByFilter(correlationId string, filter inter
 		}
 	}
 
-	qResult, qErr := c.Client.Query(context.TODO(), query)
+	// Protect from accidental full table loads: request one extra row to detect truncation
+	limit := 0
+	if unbounded && c.maxListSize > 0 {
+		limit = c.maxListSize
+		query += " LIMIT " + strconv.Itoa(limit+1)
+	}
+
+	qResult, qErr := c.ReadClient.Query(ctx, query)
 
 	if qErr != nil {
 		return nil, qErr
 	}
 	defer qResult.Close()
 	items = make([]interface{}, 0, 1)
+	skipped := 0
 	for qResult.Next() {
-		item := c.Overrides.ConvertToPublic(qResult)
+		item, convErr := c.ConvertRowToPublic(correlationId, qResult)
+		if convErr != nil {
+			return nil, convErr
+		}
+		if item == nil {
+			skipped++
+			continue
+		}
 		items = append(items, item)
 	}
+	c.logSkippedRows(correlationId, skipped, len(items))
+
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+		c.Logger.Warn(correlationId, "Unfiltered list from %s was truncated to %d items. Use a filter or paging to retrieve all items", c.TableName, limit)
+	}
 
 	if items != nil {
 		c.Logger.Trace(correlationId, "Retrieved %d from %s", len(items), c.TableName)
@@ -770,27 +1303,21 @@ func (c *PostgresPersistence) GetListByFilter(correlationId string, filter inter
 //   - filter            (optional) a filter JSON object
 //   - Returns            random item or error.
 func (c *PostgresPersistence) GetOneRandom(correlationId string, filter interface{}) (item interface{}, err error) {
-
-	query := "SELECT COUNT(*) AS count FROM " + c.QuotedTableName()
-
-	if filter != nil {
-		if flt, ok := filter.(string); ok && flt != "" {
-			query += " WHERE " + flt
-		}
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
 	}
 
-	qResult, qErr := c.Client.Query(context.TODO(), query)
+	query := "SELECT COUNT(*) AS count FROM " + c.composeFrom(filter) + c.composeWhere(filter)
+
+	qResult, qErr := c.ReadClient.Query(ctx, query)
 	if qErr != nil {
 		return nil, qErr
 	}
 	defer qResult.Close()
 
-	query = "SELECT * FROM " + c.QuotedTableName()
-	if filter != nil {
-		if flt, ok := filter.(string); ok && flt != "" {
-			query += " WHERE " + flt
-		}
-	}
+	query = c.composeSelect(filter, nil) + c.composeWhere(filter)
 
 	var count int64 = 0
 	if !qResult.Next() {
@@ -813,7 +1340,7 @@ func (c *PostgresPersistence) GetOneRandom(correlationId string, filter interfac
 	rand.Seed(time.Now().UnixNano())
 	pos := rand.Int63n(int64(count))
 	query += " OFFSET " + strconv.FormatInt(pos, 10) + " LIMIT 1"
-	qResult2, qErr2 := c.Client.Query(context.TODO(), query)
+	qResult2, qErr2 := c.ReadClient.Query(ctx, query)
 	if qErr2 != nil {
 		return nil, qErr
 	}
@@ -822,7 +1349,10 @@ func (c *PostgresPersistence) GetOneRandom(correlationId string, filter interfac
 		c.Logger.Trace(correlationId, "Random item wasn't found from %s", c.TableName)
 		return nil, qResult2.Err()
 	}
-	item = c.Overrides.ConvertToPublic(qResult2)
+	item, err = c.ConvertRowToPublic(correlationId, qResult2)
+	if err != nil {
+		return nil, err
+	}
 	c.Logger.Trace(correlationId, "Retrieved random item from %s", c.TableName)
 	return item, nil
 
@@ -833,25 +1363,47 @@ func (c *PostgresPersistence) GetOneRandom(correlationId string, filter interfac
 //   - item              an item to be created.
 //   - Returns          (optional) callback function that receives created item or error.
 func (c *PostgresPersistence) Create(correlationId string, item interface{}) (result interface{}, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkWritable(correlationId); err != nil {
+		return nil, err
+	}
 
 	if item == nil {
 		return nil, nil
 	}
 
-	row := c.Overrides.ConvertFromPublic(item)
-	columns := c.GenerateColumns(row)
-	params := c.GenerateParameters(row)
-	values := c.GenerateValues(columns, row)
-	query := "INSERT INTO " + c.QuotedTableName() + " (" + columns + ") VALUES (" + params + ") RETURNING *"
-	qResult, qErr := c.Client.Query(context.TODO(), query, values...)
+	row := c.injectTenant(c.Overrides.ConvertFromPublic(item))
+	columns, params, values := c.generateInsert(row)
+	query := "INSERT INTO " + c.QuotedTableName() + " (" + columns + ") VALUES (" + params + ")" + c.returningClause("")
+	if c.dryRun {
+		c.logDryRun(correlationId, query, values)
+		return item, nil
+	}
+	qResult, qErr := c.Client.Query(ctx, query, values...)
 	if qErr != nil {
 		return nil, qErr
 	}
 	defer qResult.Close()
+	if c.returnsNothing() {
+		qResult.Close()
+		if err = qResult.Err(); err != nil {
+			return nil, err
+		}
+		c.Logger.Trace(correlationId, "Created in %s with id = %s", c.TableName, cmpersist.GetObjectId(item))
+		return item, nil
+	}
 	if !qResult.Next() {
 		return nil, qResult.Err()
 	}
-	item = c.Overrides.ConvertToPublic(qResult)
+	item, err = c.ConvertRowToPublic(correlationId, qResult)
+	if err != nil {
+		return nil, err
+	}
 	id := cmpersist.GetObjectId(item)
 	c.Logger.Trace(correlationId, "Created in %s with id = %s", c.TableName, id)
 	return item, nil
@@ -865,12 +1417,23 @@ func (c *PostgresPersistence) Create(correlationId string, item interface{}) (re
 //   - filter            (optional) a filter JSON object.
 //   - Returns           error or nil for success.
 func (c *PostgresPersistence) DeleteByFilter(correlationId string, filter string) (err error) {
-	query := "DELETE FROM " + c.QuotedTableName()
-	if filter != "" {
-		query += " WHERE " + filter
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkWritable(correlationId); err != nil {
+		return err
+	}
+
+	query := c.deleteStatement() + c.composeWhere(filter)
+	if c.dryRun {
+		c.logDryRun(correlationId, query, nil)
+		return nil
 	}
 
-	qResult, qErr := c.Client.Query(context.TODO(), query)
+	qResult, qErr := c.Client.Query(ctx, query)
 	defer qResult.Close()
 
 	if qErr != nil {
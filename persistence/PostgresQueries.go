@@ -0,0 +1,119 @@
+package persistence
+
+import (
+	"errors"
+
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+)
+
+// SQL states of constraint violations mapped into application errors
+const (
+	notNullViolationCode    = "23502"
+	foreignKeyViolationCode = "23503"
+	checkViolationCode      = "23514"
+)
+
+// Runs an arbitrary parameterized query and converts returned rows into data items
+// with the overriden ConvertToPublic method. Unlike direct use of the Client
+// the query is logged, measured by counters and its errors are mapped into application errors.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - query             a SQL query with parameters like $1, $2...
+//   - args              query parameters
+// Returns a list of data items or error.
+func (c *PostgresPersistence) QueryRows(correlationId string, query string, args ...interface{}) (items []interface{}, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	timing := c.Counters.BeginTiming(c.TableName + ".query_rows.exec_time")
+	defer timing.EndTiming()
+
+	qResult, qErr := c.Client.Query(ctx, query, args...)
+	if qErr != nil {
+		return nil, c.mapQueryError(correlationId, "query_rows", qErr)
+	}
+	defer qResult.Close()
+
+	items = make([]interface{}, 0)
+	skipped := 0
+	for qResult.Next() {
+		item, convErr := c.ConvertRowToPublic(correlationId, qResult)
+		if convErr != nil {
+			return nil, convErr
+		}
+		if item == nil {
+			skipped++
+			continue
+		}
+		items = append(items, item)
+	}
+	c.logSkippedRows(correlationId, skipped, len(items))
+
+	if qErr = qResult.Err(); qErr != nil {
+		return nil, c.mapQueryError(correlationId, "query_rows", qErr)
+	}
+
+	c.Logger.Trace(correlationId, "Retrieved %d from %s by query", len(items), c.TableName)
+	return items, nil
+}
+
+// Runs an arbitrary parameterized statement that returns no rows, like UPDATE or DELETE
+// without RETURNING clause. The statement is logged, measured by counters
+// and its errors are mapped into application errors. The statement is rejected in dry run mode.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - query             a SQL statement with parameters like $1, $2...
+//   - args              statement parameters
+// Returns a number of affected rows or error.
+func (c *PostgresPersistence) ExecNonQuery(correlationId string, query string, args ...interface{}) (count int64, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkReadOnly(correlationId); err != nil {
+		return 0, err
+	}
+	if err = c.checkNotDryRun(correlationId); err != nil {
+		return 0, err
+	}
+
+	timing := c.Counters.BeginTiming(c.TableName + ".exec_non_query.exec_time")
+	defer timing.EndTiming()
+
+	result, qErr := c.Client.Exec(ctx, query, args...)
+	if qErr != nil {
+		return 0, c.mapQueryError(correlationId, "exec_non_query", qErr)
+	}
+
+	count = result.RowsAffected()
+	c.Logger.Trace(correlationId, "Executed statement on %s, affected %d rows", c.TableName, count)
+	return count, nil
+}
+
+// Counts and logs a failed query and maps constraint violations
+// into ConflictError and BadRequestError. Other errors are returned as they are.
+func (c *PostgresPersistence) mapQueryError(correlationId string, operation string, err error) error {
+	// Cancelled requests are not failures of the database
+	if isCanceledError(err) {
+		return err
+	}
+
+	c.Counters.IncrementOne(c.TableName + "." + operation + ".errors")
+	c.Logger.Error(correlationId, err, "Failed to execute query on %s", c.TableName)
+
+	var pgErr interface{ SQLState() string }
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	switch pgErr.SQLState() {
+	case uniqueViolationCode:
+		return cerr.NewConflictError(correlationId, "DUPLICATE_KEY", err.Error()).WithCause(err)
+	case foreignKeyViolationCode, notNullViolationCode, checkViolationCode:
+		return cerr.NewBadRequestError(correlationId, "CONSTRAINT_VIOLATION", err.Error()).WithCause(err)
+	}
+	return err
+}
@@ -0,0 +1,485 @@
+package persistence
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Comparison operators allowed in query builder conditions
+var queryBuilderOperators = map[string]bool{
+	"=": true, "<>": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true,
+	"LIKE": true, "ILIKE": true, "NOT LIKE": true, "NOT ILIKE": true,
+}
+
+/*
+Builder of parameterized SELECT queries bound to the table and quoting rules of a persistence.
+Column names are quoted and values are passed as positional parameters,
+so child persistences do not need to concatenate SQL strings.
+
+Conditions are combined with AND. Invalid operators are reported by Build.
+Queries to the persistence table are limited to the current tenant and skip soft deleted rows
+like other persistence methods. Queries to other tables started with SelectFrom are not limited.
+Column names may be qualified with a table alias like "o.customer_id".
+
+Subqueries to other tables are started with SelectFrom and used in EXISTS and IN conditions.
+Their parameters are numbered together with parameters of the outer query.
+
+Window functions like row_number, rank, lag and lead are added to the select list
+with AddRowNumber, AddRank, AddLag and AddLead. Window orders are column names
+optionally followed by DESC, e.g. "create_time DESC". FirstPerGroup uses row_number to select
+the first row in each group, like the latest record per group.
+
+### Example ###
+
+    items, err := c.Select().
+        Where("key", "=", key).
+        WhereIn("status", "new", "active").
+        WhereExists(c.SelectFrom("permissions", "p").
+            WhereColumns("p.item_id", "=", c.TableName+".id").
+            Where("p.user_id", "=", userId)).
+        OrderBy("create_time", true).
+        Limit(10).
+        ToList(correlationId)
+*/
+type PostgresQueryBuilder struct {
+	persistence   *PostgresPersistence
+	from          string
+	columns       []string
+	windows       []string
+	conditions    []func(args *[]interface{}) string
+	orders        []string
+	limit         int64
+	offset        int64
+	firstPerGroup string
+	scoped        bool
+	err           error
+}
+
+// Starts a query to the persistence table.
+//   - columns   (optional) columns to select, all columns when not set
+//
+// Returns a new query builder.
+func (c *PostgresPersistence) Select(columns ...string) *PostgresQueryBuilder {
+	b := c.newQueryBuilder(c.QuotedTableName(), columns)
+	b.scoped = true
+	return b
+}
+
+// Starts a query to another table, usually a subquery for EXISTS and IN conditions.
+//   - table     a table name, optionally qualified with a schema like "schema.table"
+//   - alias     (optional) an alias of the table
+//   - columns   (optional) columns to select, all columns when not set
+//
+// Returns a new query builder.
+func (c *PostgresPersistence) SelectFrom(table string, alias string, columns ...string) *PostgresQueryBuilder {
+	from := c.quoteTableReference(table)
+	if alias != "" {
+		from += " " + c.QuoteIdentifier(alias)
+	}
+	return c.newQueryBuilder(from, columns)
+}
+
+func (c *PostgresPersistence) newQueryBuilder(from string, columns []string) *PostgresQueryBuilder {
+	b := &PostgresQueryBuilder{
+		persistence: c,
+		from:        from,
+		columns:     make([]string, 0, len(columns)),
+		windows:     make([]string, 0),
+		conditions:  make([]func(args *[]interface{}) string, 0),
+		orders:      make([]string, 0),
+		limit:       -1,
+		offset:      -1,
+	}
+	for _, column := range columns {
+		b.columns = append(b.columns, b.quoteColumn(column))
+	}
+	return b
+}
+
+// Adds a condition that compares a column with a value.
+//   - column    a column name
+//   - operator  a comparison operator: =, <>, <, <=, >, >=, LIKE, ILIKE, NOT LIKE or NOT ILIKE
+//   - value     a value to compare with
+//
+// Returns the builder.
+func (b *PostgresQueryBuilder) Where(column string, operator string, value interface{}) *PostgresQueryBuilder {
+	operator, ok := b.checkOperator(operator)
+	if !ok {
+		return b
+	}
+	column = b.quoteColumn(column)
+	b.addCondition(func(args *[]interface{}) string {
+		return column + " " + operator + " " + addQueryArg(args, value)
+	})
+	return b
+}
+
+// Adds a condition that compares two columns, e.g. a column of a subquery
+// with a column of the outer query.
+//   - left      a column name
+//   - operator  a comparison operator: =, <>, <, <=, >, >=, LIKE, ILIKE, NOT LIKE or NOT ILIKE
+//   - right     a column name to compare with
+//
+// Returns the builder.
+func (b *PostgresQueryBuilder) WhereColumns(left string, operator string, right string) *PostgresQueryBuilder {
+	operator, ok := b.checkOperator(operator)
+	if !ok {
+		return b
+	}
+	condition := b.quoteColumn(left) + " " + operator + " " + b.quoteColumn(right)
+	b.addCondition(func(args *[]interface{}) string {
+		return condition
+	})
+	return b
+}
+
+// Adds a condition that a column is equal to one of values.
+//   - column    a column name
+//   - values    allowed values
+//
+// Returns the builder.
+func (b *PostgresQueryBuilder) WhereIn(column string, values ...interface{}) *PostgresQueryBuilder {
+	column = b.quoteColumn(column)
+	b.addCondition(func(args *[]interface{}) string {
+		if len(values) == 0 {
+			return "FALSE"
+		}
+		params := make([]string, 0, len(values))
+		for _, value := range values {
+			params = append(params, addQueryArg(args, value))
+		}
+		return column + " IN (" + strings.Join(params, ",") + ")"
+	})
+	return b
+}
+
+// Adds a condition that a column is NULL.
+//   - column    a column name
+//
+// Returns the builder.
+func (b *PostgresQueryBuilder) WhereNull(column string) *PostgresQueryBuilder {
+	condition := b.quoteColumn(column) + " IS NULL"
+	b.addCondition(func(args *[]interface{}) string {
+		return condition
+	})
+	return b
+}
+
+// Adds a condition that a column is not NULL.
+//   - column    a column name
+//
+// Returns the builder.
+func (b *PostgresQueryBuilder) WhereNotNull(column string) *PostgresQueryBuilder {
+	condition := b.quoteColumn(column) + " IS NOT NULL"
+	b.addCondition(func(args *[]interface{}) string {
+		return condition
+	})
+	return b
+}
+
+// Adds a condition that a subquery returns at least one row.
+//   - subquery  a subquery started with SelectFrom
+//
+// Returns the builder.
+func (b *PostgresQueryBuilder) WhereExists(subquery *PostgresQueryBuilder) *PostgresQueryBuilder {
+	return b.addSubquery("EXISTS ", subquery)
+}
+
+// Adds a condition that a subquery returns no rows.
+//   - subquery  a subquery started with SelectFrom
+//
+// Returns the builder.
+func (b *PostgresQueryBuilder) WhereNotExists(subquery *PostgresQueryBuilder) *PostgresQueryBuilder {
+	return b.addSubquery("NOT EXISTS ", subquery)
+}
+
+// Adds a condition that a column is equal to one of values returned by a subquery.
+//   - column    a column name
+//   - subquery  a subquery started with SelectFrom that selects a single column
+//
+// Returns the builder.
+func (b *PostgresQueryBuilder) WhereInSubquery(column string, subquery *PostgresQueryBuilder) *PostgresQueryBuilder {
+	return b.addSubquery(b.quoteColumn(column)+" IN ", subquery)
+}
+
+// Adds a condition that a column is not equal to any of values returned by a subquery.
+//   - column    a column name
+//   - subquery  a subquery started with SelectFrom that selects a single column
+//
+// Returns the builder.
+func (b *PostgresQueryBuilder) WhereNotInSubquery(column string, subquery *PostgresQueryBuilder) *PostgresQueryBuilder {
+	return b.addSubquery(b.quoteColumn(column)+" NOT IN ", subquery)
+}
+
+// Adds row_number() window function to the select list, that numbers rows within partitions.
+//   - alias         a name of the result column
+//   - partitionBy   (optional) columns to partition rows by
+//   - orderBy       columns to order rows within partitions, e.g. "create_time DESC"
+//
+// Returns the builder.
+func (b *PostgresQueryBuilder) AddRowNumber(alias string, partitionBy []string, orderBy ...string) *PostgresQueryBuilder {
+	return b.addWindow("row_number()", alias, partitionBy, orderBy)
+}
+
+// Adds rank() window function to the select list, that ranks rows within partitions with gaps for ties.
+//   - alias         a name of the result column
+//   - partitionBy   (optional) columns to partition rows by
+//   - orderBy       columns to order rows within partitions, e.g. "score DESC"
+//
+// Returns the builder.
+func (b *PostgresQueryBuilder) AddRank(alias string, partitionBy []string, orderBy ...string) *PostgresQueryBuilder {
+	return b.addWindow("rank()", alias, partitionBy, orderBy)
+}
+
+// Adds lag() window function to the select list, that returns a column value of a previous row in partition.
+//   - column        a column which value to return
+//   - offset        a number of rows back, 1 for the previous row
+//   - alias         a name of the result column
+//   - partitionBy   (optional) columns to partition rows by
+//   - orderBy       columns to order rows within partitions
+//
+// Returns the builder.
+func (b *PostgresQueryBuilder) AddLag(column string, offset int, alias string, partitionBy []string,
+	orderBy ...string) *PostgresQueryBuilder {
+	return b.addWindow("lag("+b.quoteColumn(column)+", "+strconv.Itoa(offset)+")", alias, partitionBy, orderBy)
+}
+
+// Adds lead() window function to the select list, that returns a column value of a following row in partition.
+//   - column        a column which value to return
+//   - offset        a number of rows forward, 1 for the next row
+//   - alias         a name of the result column
+//   - partitionBy   (optional) columns to partition rows by
+//   - orderBy       columns to order rows within partitions
+//
+// Returns the builder.
+func (b *PostgresQueryBuilder) AddLead(column string, offset int, alias string, partitionBy []string,
+	orderBy ...string) *PostgresQueryBuilder {
+	return b.addWindow("lead("+b.quoteColumn(column)+", "+strconv.Itoa(offset)+")", alias, partitionBy, orderBy)
+}
+
+// Limits results to the first row in each group, like the latest record per group.
+// Rows are numbered after conditions are applied, while sorting, limit and offset
+// apply to the selected rows. Selected rows get an additional "row_number" column.
+//   - partitionBy   columns to group rows by
+//   - orderBy       columns to order rows within groups, e.g. "create_time DESC" for the latest row
+//
+// Returns the builder.
+func (b *PostgresQueryBuilder) FirstPerGroup(partitionBy []string, orderBy ...string) *PostgresQueryBuilder {
+	b.firstPerGroup = "row_number() " + b.composeWindow(partitionBy, orderBy) + " AS " + b.persistence.QuoteIdentifier("row_number")
+	return b
+}
+
+// Adds a sorting by a column. Sortings are applied in the order they are added.
+//   - column        a column name
+//   - descending    true to sort in descending order
+//
+// Returns the builder.
+func (b *PostgresQueryBuilder) OrderBy(column string, descending bool) *PostgresQueryBuilder {
+	order := b.quoteColumn(column)
+	if descending {
+		order += " DESC"
+	}
+	b.orders = append(b.orders, order)
+	return b
+}
+
+// Limits a number of returned rows.
+//   - limit     a maximum number of rows
+//
+// Returns the builder.
+func (b *PostgresQueryBuilder) Limit(limit int64) *PostgresQueryBuilder {
+	b.limit = limit
+	return b
+}
+
+// Skips a number of rows.
+//   - offset    a number of rows to skip
+//
+// Returns the builder.
+func (b *PostgresQueryBuilder) Offset(offset int64) *PostgresQueryBuilder {
+	b.offset = offset
+	return b
+}
+
+// Composes the WHERE condition without the keyword. The tenant and soft delete conditions
+// are not included, they are added by persistence methods that take the filter.
+// Returns the condition, empty if there are no conditions, and parameters.
+func (b *PostgresQueryBuilder) Filter() (string, []interface{}) {
+	args := make([]interface{}, 0)
+	return b.renderFilter(&args), args
+}
+
+// Composes the query.
+// Returns a SQL query with positional parameters, values of the parameters or error for invalid conditions.
+func (b *PostgresQueryBuilder) Build() (string, []interface{}, error) {
+	if b.err != nil {
+		return "", nil, b.err
+	}
+	args := make([]interface{}, 0)
+	return b.render(&args), args, nil
+}
+
+// Runs the query and converts returned rows into data items. See QueryRows.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//
+// Returns a list of data items or error.
+func (b *PostgresQueryBuilder) ToList(correlationId string) ([]interface{}, error) {
+	query, args, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	return b.persistence.QueryRows(correlationId, query, args...)
+}
+
+func (b *PostgresQueryBuilder) render(args *[]interface{}) string {
+	columns := "*"
+	if len(b.columns) > 0 {
+		columns = strings.Join(b.columns, ",")
+	}
+	selected := columns
+	if len(b.windows) > 0 {
+		selected += "," + strings.Join(b.windows, ",")
+	}
+
+	query := ""
+	if b.firstPerGroup != "" {
+		// Rows are numbered in a subquery, because window functions cannot be used in WHERE
+		query = "SELECT " + selected + "," + b.firstPerGroup + " FROM " + b.from
+		if filter := b.renderScopedFilter(args); filter != "" {
+			query += " WHERE " + filter
+		}
+		query = "SELECT * FROM (" + query + ") AS " + b.persistence.QuoteIdentifier("ranked") +
+			" WHERE " + b.persistence.QuoteIdentifier("row_number") + "=1"
+	} else {
+		query = "SELECT " + selected + " FROM " + b.from
+		if filter := b.renderScopedFilter(args); filter != "" {
+			query += " WHERE " + filter
+		}
+	}
+
+	if len(b.orders) > 0 {
+		query += " ORDER BY " + strings.Join(b.orders, ",")
+	}
+	if b.limit >= 0 {
+		query += " LIMIT " + strconv.FormatInt(b.limit, 10)
+	}
+	if b.offset >= 0 {
+		query += " OFFSET " + strconv.FormatInt(b.offset, 10)
+	}
+	return query
+}
+
+func (b *PostgresQueryBuilder) renderFilter(args *[]interface{}) string {
+	conditions := make([]string, 0, len(b.conditions))
+	for _, condition := range b.conditions {
+		conditions = append(conditions, condition(args))
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+// Adds the tenant and soft delete conditions of the persistence to queries of its table
+func (b *PostgresQueryBuilder) renderScopedFilter(args *[]interface{}) string {
+	filter := b.renderFilter(args)
+	if !b.scoped {
+		return filter
+	}
+	scope := b.persistence.scopeCondition()
+	if scope == "" {
+		return filter
+	}
+	if filter == "" {
+		return scope
+	}
+	return filter + " AND " + scope
+}
+
+func (b *PostgresQueryBuilder) addCondition(condition func(args *[]interface{}) string) {
+	b.conditions = append(b.conditions, condition)
+}
+
+func (b *PostgresQueryBuilder) addSubquery(prefix string, subquery *PostgresQueryBuilder) *PostgresQueryBuilder {
+	if subquery.err != nil {
+		b.setError(subquery.err)
+		return b
+	}
+	b.addCondition(func(args *[]interface{}) string {
+		return prefix + "(" + subquery.render(args) + ")"
+	})
+	return b
+}
+
+func (b *PostgresQueryBuilder) addWindow(function string, alias string, partitionBy []string,
+	orderBy []string) *PostgresQueryBuilder {
+	b.windows = append(b.windows, function+" "+b.composeWindow(partitionBy, orderBy)+
+		" AS "+b.persistence.QuoteIdentifier(alias))
+	return b
+}
+
+// Composes OVER clause of a window function
+func (b *PostgresQueryBuilder) composeWindow(partitionBy []string, orderBy []string) string {
+	clauses := make([]string, 0, 2)
+	if len(partitionBy) > 0 {
+		columns := make([]string, 0, len(partitionBy))
+		for _, column := range partitionBy {
+			columns = append(columns, b.quoteColumn(column))
+		}
+		clauses = append(clauses, "PARTITION BY "+strings.Join(columns, ","))
+	}
+	if len(orderBy) > 0 {
+		orders := make([]string, 0, len(orderBy))
+		for _, order := range orderBy {
+			orders = append(orders, b.quoteOrder(order))
+		}
+		clauses = append(clauses, "ORDER BY "+strings.Join(orders, ","))
+	}
+	return "OVER (" + strings.Join(clauses, " ") + ")"
+}
+
+// Quotes a column of a window order that may be followed by ASC or DESC
+func (b *PostgresQueryBuilder) quoteOrder(order string) string {
+	order = strings.TrimSpace(order)
+	upper := strings.ToUpper(order)
+	for _, direction := range []string{" DESC", " ASC"} {
+		if strings.HasSuffix(upper, direction) {
+			column := strings.TrimSpace(order[:len(order)-len(direction)])
+			return b.quoteColumn(column) + direction
+		}
+	}
+	return b.quoteColumn(order)
+}
+
+func (b *PostgresQueryBuilder) checkOperator(operator string) (string, bool) {
+	operator = strings.ToUpper(strings.TrimSpace(operator))
+	if !queryBuilderOperators[operator] {
+		b.setError(fmt.Errorf("operator %s is not supported", operator))
+		return operator, false
+	}
+	return operator, true
+}
+
+// Quotes a column name that may be qualified with a table alias
+func (b *PostgresQueryBuilder) quoteColumn(column string) string {
+	if strings.HasPrefix(column, "\"") || !strings.Contains(column, ".") {
+		return b.persistence.QuoteIdentifier(column)
+	}
+	parts := strings.Split(column, ".")
+	for index, part := range parts {
+		if part != "*" {
+			parts[index] = b.persistence.QuoteIdentifier(part)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+func (b *PostgresQueryBuilder) setError(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// Adds a parameter and returns its placeholder
+func addQueryArg(args *[]interface{}, value interface{}) string {
+	*args = append(*args, value)
+	return "$" + strconv.Itoa(len(*args))
+}
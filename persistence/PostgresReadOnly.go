@@ -0,0 +1,18 @@
+package persistence
+
+import (
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+)
+
+// Returns an error for operations that change data when the persistence is configured as read-only
+// by options.readonly, e.g. in reporting deployments or over read replicas.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+// Returns InvalidStateError in read-only mode or nil otherwise.
+func (c *PostgresPersistence) checkReadOnly(correlationId string) error {
+	if !c.readonly {
+		return nil
+	}
+	return cerr.NewInvalidStateError(correlationId, "READ_ONLY",
+		"Persistence of "+c.TableName+" is configured as read-only").
+		WithDetails("table", c.TableName)
+}
@@ -0,0 +1,257 @@
+package persistence
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/jackc/pgx/v4"
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+)
+
+// One-to-many relation between data items of the persistence and child items stored in another table
+type PostgresRelation struct {
+	// A json name of the prototype field that receives child items. The field must be a slice
+	Field string
+	// A child table name, optionally qualified with a schema like "schema.table"
+	Table string
+	// A column of the child table that references parent items
+	ForeignKey string
+	// A json name of the parent field referenced by child items ("id" by default)
+	ParentKey string
+	// (optional) A sorting of child items, e.g. "\"position\""
+	Sort string
+	// A type of child items
+	Prototype reflect.Type
+}
+
+// Defines a one-to-many relation to child items, that can be loaded together with data items
+// by LoadRelations, GetOneByIdWithRelations or GetPageByFilterWithRelations.
+// The relation field is excluded from writes, so child items are saved by their own persistence.
+// Relations shall be defined before the persistence is opened, e.g. in a constructor.
+//   - field         a json name of the prototype field that receives child items
+//   - table         a child table name
+//   - foreignKey    a column of the child table that references parent ids
+//   - proto         a type of child items
+// Returns the relation to set optional sorting or parent key.
+func (c *PostgresPersistence) DefineRelation(field string, table string, foreignKey string, proto reflect.Type) *PostgresRelation {
+	relation := &PostgresRelation{
+		Field:      field,
+		Table:      table,
+		ForeignKey: foreignKey,
+		ParentKey:  "id",
+		Prototype:  proto,
+	}
+	if c.relations == nil {
+		c.relations = make(map[string]*PostgresRelation)
+	}
+	c.relations[field] = relation
+	return relation
+}
+
+// Loads child items of relations into data items. Child items of all data items
+// are retrieved by a single query per relation to avoid a query per data item.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - items             data items to load child items into
+//   - relations         json names of relation fields to load, all relations when not set
+// Returns data items with loaded child items or error.
+func (c *PostgresPersistence) LoadRelations(correlationId string, items []interface{},
+	relations ...string) ([]interface{}, error) {
+
+	if len(items) == 0 {
+		return items, nil
+	}
+	if len(relations) == 0 {
+		for name := range c.relations {
+			relations = append(relations, name)
+		}
+	}
+
+	for _, name := range relations {
+		relation, ok := c.relations[name]
+		if !ok {
+			return nil, fmt.Errorf("relation %s is not defined in %s", name, c.TableName)
+		}
+		if err := c.loadRelation(correlationId, relation, items); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+// Gets a page of data items with child items of relations. See GetPageByFilter and LoadRelations.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - filter            (optional) a filter JSON object
+//   - paging            (optional) paging parameters
+//   - sort              (optional) sorting JSON object
+//   - relations         json names of relation fields to load, all relations when not set
+// Returns a data page or error.
+func (c *PostgresPersistence) GetPageByFilterWithRelations(correlationId string, filter interface{},
+	paging *cdata.PagingParams, sort interface{}, relations ...string) (page *cdata.DataPage, err error) {
+
+	page, err = c.GetPageByFilter(correlationId, filter, paging, sort, nil)
+	if err != nil || page == nil {
+		return page, err
+	}
+	page.Data, err = c.LoadRelations(correlationId, page.Data, relations...)
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// Gets a data item by its unique id with child items of relations. See GetOneById and LoadRelations.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - id                an id of data item to be retrieved.
+//   - relations         json names of relation fields to load, all relations when not set
+// Returns a data item or error.
+func (c *IdentifiablePostgresPersistence) GetOneByIdWithRelations(correlationId string, id interface{},
+	relations ...string) (item interface{}, err error) {
+
+	item, err = c.GetOneById(correlationId, id)
+	if err != nil || item == nil {
+		return item, err
+	}
+	items, err := c.LoadRelations(correlationId, []interface{}{item}, relations...)
+	if err != nil {
+		return nil, err
+	}
+	return items[0], nil
+}
+
+func (c *PostgresPersistence) loadRelation(correlationId string, relation *PostgresRelation, items []interface{}) (err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	keys := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		if key := c.getItemField(item, relation.ParentKey); key != nil {
+			keys = append(keys, key)
+		}
+	}
+
+	query := "SELECT * FROM " + c.quoteTableReference(relation.Table) +
+		" WHERE " + c.QuoteIdentifier(relation.ForeignKey) + "=ANY($1)"
+	if relation.Sort != "" {
+		query += " ORDER BY " + relation.Sort
+	}
+
+	qResult, qErr := c.ReadClient.Query(ctx, query, keys)
+	if qErr != nil {
+		return qErr
+	}
+	defer qResult.Close()
+
+	children := make(map[string][]interface{})
+	count := 0
+	for qResult.Next() {
+		key, child, err := c.convertRelatedRow(qResult, relation)
+		if err != nil {
+			return err
+		}
+		children[key] = append(children[key], child)
+		count++
+	}
+	if err := qResult.Err(); err != nil {
+		return err
+	}
+
+	for index, item := range items {
+		key := fmt.Sprint(c.getItemField(item, relation.ParentKey))
+		items[index] = c.setItemField(item, relation, children[key])
+	}
+
+	c.Logger.Trace(correlationId, "Loaded %d items of %s relation from %s", count, relation.Field, relation.Table)
+	return nil
+}
+
+// Converts a row of the child table into a child item and gets its reference to the parent
+func (c *PostgresPersistence) convertRelatedRow(rows pgx.Rows, relation *PostgresRelation) (string, interface{}, error) {
+	values, err := rows.Values()
+	if err != nil {
+		return "", nil, err
+	}
+
+	buf := make(map[string]interface{}, len(values))
+	var key interface{}
+	for index, column := range rows.FieldDescriptions() {
+		name := string(column.Name)
+		if name == relation.ForeignKey {
+			key = values[index]
+		}
+		buf[name] = c.convertValueToPublic(values[index])
+	}
+
+	jsonBuf, err := json.Marshal(buf)
+	if err != nil {
+		return "", nil, err
+	}
+	child := reflect.New(relation.Prototype)
+	if err = json.Unmarshal(jsonBuf, child.Interface()); err != nil {
+		return "", nil, NewDataConversionError("", relation.Field, buf, err)
+	}
+	return fmt.Sprint(key), child.Elem().Interface(), nil
+}
+
+// Gets a field value of a data item by its json name
+func (c *PostgresPersistence) getItemField(item interface{}, name string) interface{} {
+	if values, ok := item.(map[string]interface{}); ok {
+		return values[name]
+	}
+	value, ok := c.prototypeValue(item)
+	if !ok {
+		return nil
+	}
+	if index, found := c.prototypeFields[name]; found {
+		return value.FieldByIndex(index).Interface()
+	}
+	return nil
+}
+
+// Sets child items into the relation field of a data item
+// and returns the data item, because struct items are passed by value
+func (c *PostgresPersistence) setItemField(item interface{}, relation *PostgresRelation, children []interface{}) interface{} {
+	if values, ok := item.(map[string]interface{}); ok {
+		if children == nil {
+			children = make([]interface{}, 0)
+		}
+		values[relation.Field] = children
+		return values
+	}
+
+	index, found := c.prototypeFields[relation.Field]
+	value, ok := c.prototypeValue(item)
+	if !found || !ok {
+		return item
+	}
+
+	result := reflect.New(value.Type()).Elem()
+	result.Set(value)
+	field := result.FieldByIndex(index)
+	list := reflect.MakeSlice(field.Type(), 0, len(children))
+	for _, child := range children {
+		childValue := reflect.ValueOf(child)
+		if field.Type().Elem().Kind() == reflect.Ptr {
+			pointer := reflect.New(childValue.Type())
+			pointer.Elem().Set(childValue)
+			childValue = pointer
+		}
+		list = reflect.Append(list, childValue)
+	}
+	field.Set(list)
+
+	if reflect.TypeOf(item).Kind() == reflect.Ptr {
+		return result.Addr().Interface()
+	}
+	return result.Interface()
+}
+
+// Removes relation fields from statement values
+func (c *PostgresPersistence) excludeRelationFields(items map[string]interface{}) {
+	for name := range c.relations {
+		delete(items, name)
+	}
+}
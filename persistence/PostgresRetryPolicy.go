@@ -0,0 +1,303 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+)
+
+// Strategies to calculate delays between retries
+const (
+	// Wait the same delay before every retry
+	RetryBackoffConstant = "constant"
+	// Increase the delay by the initial delay with every retry
+	RetryBackoffLinear = "linear"
+	// Double the delay with every retry
+	RetryBackoffExponential = "exponential"
+)
+
+// SQL states of transient conflicts between concurrent transactions
+const (
+	serializationFailureCode = "40001"
+	deadlockDetectedCode     = "40P01"
+)
+
+// Policy to retry statements that failed on transient conflicts, like deadlocks and serialization failures.
+// The policy of a persistence is configured by retry options or set by SetRetryPolicy.
+type PostgresRetryPolicy struct {
+	// SQL states of errors to retry, serialization failures and deadlocks by default
+	SqlStates []string
+	// Maximum number of attempts including the first one, 1 to disable retries
+	MaxAttempts int
+	// Strategy to calculate delays: constant, linear or exponential
+	Backoff string
+	// Delay before the first retry
+	Delay time.Duration
+	// Maximum delay between retries, 0 for no limit
+	MaxDelay time.Duration
+	// Randomize delays between a half and the full delay, so conflicting clients do not retry at once
+	Jitter bool
+}
+
+// Creates a new retry policy with default parameters. Retries are disabled until MaxAttempts is set.
+// Returns a new retry policy.
+func NewPostgresRetryPolicy() *PostgresRetryPolicy {
+	return &PostgresRetryPolicy{
+		SqlStates:   []string{serializationFailureCode, deadlockDetectedCode},
+		MaxAttempts: 1,
+		Backoff:     RetryBackoffExponential,
+		Delay:       50 * time.Millisecond,
+		MaxDelay:    2 * time.Second,
+		Jitter:      true,
+	}
+}
+
+// Creates a new retry policy from retry options of a persistence configuration.
+//   - config    configuration parameters with options.retry_* parameters.
+// Returns a new retry policy.
+func NewPostgresRetryPolicyFromConfig(config *cconf.ConfigParams) *PostgresRetryPolicy {
+	c := NewPostgresRetryPolicy()
+	if states := config.GetAsString("options.retry_sql_states"); states != "" {
+		c.SqlStates = make([]string, 0)
+		for _, state := range strings.Split(states, ",") {
+			if state = strings.TrimSpace(state); state != "" {
+				c.SqlStates = append(c.SqlStates, state)
+			}
+		}
+	}
+	c.MaxAttempts = config.GetAsIntegerWithDefault("options.retry_max_attempts", c.MaxAttempts)
+	c.Backoff = config.GetAsStringWithDefault("options.retry_backoff", c.Backoff)
+	c.Delay = time.Duration(config.GetAsLongWithDefault("options.retry_delay",
+		int64(c.Delay/time.Millisecond))) * time.Millisecond
+	c.MaxDelay = time.Duration(config.GetAsLongWithDefault("options.retry_max_delay",
+		int64(c.MaxDelay/time.Millisecond))) * time.Millisecond
+	c.Jitter = config.GetAsBooleanWithDefault("options.retry_jitter", c.Jitter)
+	return c
+}
+
+// Checks if an error is a transient conflict that shall be retried.
+//   - err       an error of a statement
+// Returns true if the error has one of retried SQL states.
+func (c *PostgresRetryPolicy) IsRetryable(err error) bool {
+	var pgErr interface{ SQLState() string }
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	for _, state := range c.SqlStates {
+		if pgErr.SQLState() == state {
+			return true
+		}
+	}
+	return false
+}
+
+// Calculates a delay before a retry.
+//   - attempt   a number of the failed attempt, starting from 1
+// Returns the delay before the next attempt.
+func (c *PostgresRetryPolicy) GetDelay(attempt int) time.Duration {
+	delay := c.Delay
+	switch c.Backoff {
+	case RetryBackoffLinear:
+		delay = c.Delay * time.Duration(attempt)
+	case RetryBackoffExponential:
+		for index := 1; index < attempt && (c.MaxDelay <= 0 || delay < c.MaxDelay); index++ {
+			delay *= 2
+		}
+	}
+	if c.MaxDelay > 0 && delay > c.MaxDelay {
+		delay = c.MaxDelay
+	}
+	if c.Jitter && delay > 1 {
+		delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+	}
+	return delay
+}
+
+// Sets a retry policy that replaces the one configured by retry options.
+// Retries of statements are enabled on Open, so the policy shall be set before it.
+//   - policy    a retry policy
+func (c *PostgresPersistence) SetRetryPolicy(policy *PostgresRetryPolicy) {
+	if policy == nil {
+		policy = NewPostgresRetryPolicy()
+	}
+	c.retryPolicy = policy
+}
+
+// Gets the retry policy of the persistence.
+// Returns the retry policy.
+func (c *PostgresPersistence) GetRetryPolicy() *PostgresRetryPolicy {
+	return c.retryPolicy
+}
+
+// Sets a retry policy for an operation that replaces the default one.
+// Statements are retried by operations named after their first keyword: insert, update, delete, select...
+// Actions of RunWithRetry use the operation name passed to it.
+//   - operation     an operation name
+//   - policy        a retry policy or nil to use the default one
+func (c *PostgresPersistence) SetOperationRetryPolicy(operation string, policy *PostgresRetryPolicy) {
+	operation = strings.ToLower(operation)
+	if policy == nil {
+		delete(c.retryOverrides, operation)
+		return
+	}
+	c.retryOverrides[operation] = policy
+}
+
+// Gets a retry policy of an operation.
+//   - operation     an operation name
+// Returns the policy set for the operation or the default one.
+func (c *PostgresPersistence) GetOperationRetryPolicy(operation string) *PostgresRetryPolicy {
+	if policy, ok := c.retryOverrides[strings.ToLower(operation)]; ok {
+		return policy
+	}
+	return c.retryPolicy
+}
+
+// Runs an action and repeats it while it fails on transient conflicts according to the retry policy of the operation.
+// It is used for transactions, as statements within them are not retried one by one.
+// The action must be safe to repeat, e.g. begin and commit its own transaction.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - operation         an operation name to select the retry policy
+//   - action            an action to run. It shall return errors of the driver to be retried
+// Returns error of the last attempt or nil when the action succeeded.
+func (c *PostgresPersistence) RunWithRetry(correlationId string, operation string, action func() error) error {
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return c.retry(ctx, correlationId, operation, action)
+}
+
+// Runs an action with retries of the operation's policy
+func (c *PostgresPersistence) retry(ctx context.Context, correlationId string, operation string, action func() error) error {
+	policy := c.GetOperationRetryPolicy(operation)
+	for attempt := 1; ; attempt++ {
+		err := action()
+		if err == nil || attempt >= policy.MaxAttempts || !policy.IsRetryable(err) {
+			return err
+		}
+
+		delay := policy.GetDelay(attempt)
+		c.Counters.IncrementOne(c.TableName + "." + operation + ".retries")
+		c.Logger.Debug(correlationId, "Retrying %s on %s in %s after attempt %d failed: %s",
+			operation, c.TableName, delay.String(), attempt, err.Error())
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// Checks if statements shall be retried by the client
+func (c *PostgresPersistence) retriesEnabled() bool {
+	if c.retryPolicy.MaxAttempts > 1 {
+		return true
+	}
+	for _, policy := range c.retryOverrides {
+		if policy.MaxAttempts > 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// Gets an operation name of a statement by its first keyword
+func statementOperation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.ToLower(fields[0])
+}
+
+// Client that retries statements that are not run in transactions
+type retryingClient struct {
+	IPostgresClient
+	persistence *PostgresPersistence
+}
+
+func newRetryingClient(client IPostgresClient, persistence *PostgresPersistence) IPostgresClient {
+	if client == nil || !persistence.retriesEnabled() {
+		return client
+	}
+	return &retryingClient{IPostgresClient: client, persistence: persistence}
+}
+
+func (c *retryingClient) retry(ctx context.Context, sql string, action func() error) error {
+	return c.persistence.retry(ctx, getCorrelationId(ctx), statementOperation(sql), action)
+}
+
+// Retries queries that fail before their first row. Rows of statements that change data,
+// like INSERT with RETURNING clause, are sent after the statement completes, so it is retried as a whole.
+func (c *retryingClient) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	var rows pgx.Rows
+	err := c.retry(ctx, sql, func() error {
+		var qErr error
+		rows, qErr = c.IPostgresClient.Query(ctx, sql, args...)
+		if qErr != nil {
+			return qErr
+		}
+		if rows.Next() {
+			rows = &peekedRows{Rows: rows, peeked: true}
+			return nil
+		}
+		rows.Close()
+		return rows.Err()
+	})
+	if rows == nil {
+		rows = &errorRows{err: err}
+	}
+	return rows, err
+}
+
+func (c *retryingClient) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return &retryingRow{client: c, ctx: ctx, sql: sql, args: args}
+}
+
+func (c *retryingClient) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	var tag pgconn.CommandTag
+	err := c.retry(ctx, sql, func() error {
+		var eErr error
+		tag, eErr = c.IPostgresClient.Exec(ctx, sql, args...)
+		return eErr
+	})
+	return tag, err
+}
+
+// Row that runs its query on Scan and repeats it on transient conflicts
+type retryingRow struct {
+	client *retryingClient
+	ctx    context.Context
+	sql    string
+	args   []interface{}
+}
+
+func (c *retryingRow) Scan(dest ...interface{}) error {
+	return c.client.retry(c.ctx, c.sql, func() error {
+		return c.client.IPostgresClient.QueryRow(c.ctx, c.sql, c.args...).Scan(dest...)
+	})
+}
+
+// Rows with the first row read in advance
+type peekedRows struct {
+	pgx.Rows
+	peeked bool
+}
+
+func (c *peekedRows) Next() bool {
+	if c.peeked {
+		c.peeked = false
+		return true
+	}
+	return c.Rows.Next()
+}
@@ -0,0 +1,64 @@
+package persistence
+
+import (
+	"strings"
+)
+
+// Special values of returned columns
+const (
+	// Write statements return all columns
+	ReturningAll = "*"
+	// Write statements return no columns. Methods that locate items by id return only the id
+	// to tell whether the item was found, Create returns the item that was passed in
+	ReturningNone = "none"
+)
+
+// Sets columns returned by Create, Set, Update, UpdatePartially and DeleteById
+// instead of all columns, to reduce traffic for wide tables or hide write-only columns.
+// Returned items have only the returned fields set. The same can be configured by options.returning.
+//   - columns   column names, ReturningAll or ReturningNone. All columns when not set
+func (c *PostgresPersistence) SetReturningColumns(columns ...string) {
+	c.returning = nil
+	for _, column := range columns {
+		column = strings.TrimSpace(column)
+		if column == "" || column == ReturningAll {
+			c.returning = nil
+			return
+		}
+		c.returning = append(c.returning, column)
+	}
+}
+
+// Checks if write statements return no columns
+func (c *PostgresPersistence) returnsNothing() bool {
+	return len(c.returning) == 1 && c.returning[0] == ReturningNone
+}
+
+// Composes RETURNING clause with a leading space
+//   - keyColumn     a column returned instead of none to detect affected items, or empty
+// Returns the clause or empty string when nothing shall be returned.
+func (c *PostgresPersistence) returningClause(keyColumn string) string {
+	columns := c.returningColumns(keyColumn)
+	if columns == "" {
+		return ""
+	}
+	return " RETURNING " + columns
+}
+
+// Composes a list of returned columns
+func (c *PostgresPersistence) returningColumns(keyColumn string) string {
+	if len(c.returning) == 0 {
+		return "*"
+	}
+	if c.returnsNothing() {
+		if keyColumn == "" {
+			return ""
+		}
+		return c.QuoteIdentifier(keyColumn)
+	}
+	columns := make([]string, len(c.returning))
+	for index, column := range c.returning {
+		columns[index] = c.QuoteIdentifier(column)
+	}
+	return strings.Join(columns, ",")
+}
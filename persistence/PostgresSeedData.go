@@ -0,0 +1,116 @@
+package persistence
+
+import (
+	"io"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Seed data declared in the schema definition
+type postgresSeed struct {
+	items []interface{}
+	fsys  fs.FS
+	name  string
+}
+
+// Adds data items that are inserted on opening after the schema is created,
+// e.g. to populate lookup tables on the first deploy. Items that already exist
+// are skipped, so data changed by users is kept. In column tenancy mode
+// items shall set the tenant column.
+// Must be called in DefineSchema after the table definition.
+//   - items     data items to insert
+func (c *PostgresPersistence) EnsureSeedData(items ...interface{}) {
+	c.seeds = append(c.seeds, postgresSeed{items: items})
+}
+
+// Adds a file with data items that are inserted on opening like EnsureSeedData,
+// e.g. a file embedded into the service with embed.FS.
+// Files with .csv extension are read as CSV with a header row, others as JSON Lines.
+// Must be called in DefineSchema after the table definition.
+//   - fsys      a file system with the file
+//   - name      a name of the file
+func (c *PostgresPersistence) EnsureSeedFile(fsys fs.FS, name string) {
+	c.seeds = append(c.seeds, postgresSeed{fsys: fsys, name: name})
+}
+
+// Inserts declared seed data items that do not exist yet
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns error or nil no errors occured.
+func (c *PostgresPersistence) applySeedData(correlationId string) error {
+	if len(c.seeds) == 0 || c.view {
+		return nil
+	}
+
+	var count int64
+	for _, seed := range c.seeds {
+		rows, err := c.readSeedRows(correlationId, seed)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			columns := make([]string, len(row.columns))
+			params := make([]string, len(row.columns))
+			values := make([]interface{}, len(row.columns))
+			for index, name := range row.columns {
+				columns[index] = c.QuoteIdentifier(name)
+				params[index] = "$" + strconv.Itoa(index+1)
+				values[index] = row.values[name]
+			}
+			query := "INSERT INTO " + c.QuotedTableName() + " (" + strings.Join(columns, ",") + ")" +
+				" VALUES (" + strings.Join(params, ",") + ") ON CONFLICT DO NOTHING"
+			result, err := c.Client.Exec(c.schemaContext(), query, values...)
+			if err != nil {
+				return err
+			}
+			count += result.RowsAffected()
+		}
+	}
+
+	if count > 0 {
+		c.Logger.Debug(correlationId, "Inserted %d seed items into %s", count, c.TableName)
+	}
+	return nil
+}
+
+// Converts seed data items or records of a seed file into columns of the table
+func (c *PostgresPersistence) readSeedRows(correlationId string, seed postgresSeed) ([]*postgresImportRow, error) {
+	rows := make([]*postgresImportRow, 0)
+	if seed.fsys == nil {
+		for _, item := range seed.items {
+			row, err := c.convertImportItem(correlationId, item)
+			if err != nil {
+				return nil, err
+			}
+			rows = append(rows, row)
+		}
+		return rows, nil
+	}
+
+	file, err := seed.fsys.Open(seed.name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	next := c.jsonRecordReader(file)
+	if strings.EqualFold(path.Ext(seed.name), ".csv") {
+		next = c.csvRecordReader(file)
+	}
+	for {
+		values, err := next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		row, err := c.convertImportRecord(correlationId, values)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
@@ -0,0 +1,152 @@
+package persistence
+
+import (
+	"time"
+
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+)
+
+// Composes a condition that limits rows to the current tenant and hides soft deleted rows.
+// Copies made by GetDeletedPageByFilter select only soft deleted rows instead.
+// Returns the condition or empty string
+func (c *PostgresPersistence) scopeCondition() string {
+	tenant := c.tenantCondition()
+	if c.softDeleteColumn == "" {
+		return tenant
+	}
+
+	deleted := c.QuotedTableName() + "." + c.QuoteIdentifier(c.softDeleteColumn) + " IS NULL"
+	if c.deletedOnly {
+		deleted = c.QuotedTableName() + "." + c.QuoteIdentifier(c.softDeleteColumn) + " IS NOT NULL"
+	}
+	if tenant == "" {
+		return deleted
+	}
+	return tenant + " AND " + deleted
+}
+
+// Composes a scope condition to append to other conditions
+// Returns the condition with leading AND or empty string
+func (c *PostgresPersistence) andScopeCondition() string {
+	condition := c.scopeCondition()
+	if condition == "" {
+		return ""
+	}
+	return " AND " + condition
+}
+
+// Composes the beginning of a delete statement. With options.soft_delete_column
+// rows are marked with the deletion time instead of being removed.
+func (c *PostgresPersistence) deleteStatement() string {
+	if c.softDeleteColumn == "" {
+		return "DELETE FROM " + c.QuotedTableName()
+	}
+	return "UPDATE " + c.QuotedTableName() + " SET " + c.QuoteIdentifier(c.softDeleteColumn) + "=now()"
+}
+
+// Checks if soft delete is configured by options.soft_delete_column
+func (c *PostgresPersistence) checkSoftDelete(correlationId string) error {
+	if c.softDeleteColumn == "" {
+		return cerr.NewInvalidStateError(correlationId, "NO_SOFT_DELETE_COLUMN",
+			"Soft delete column is not configured for "+c.TableName)
+	}
+	return nil
+}
+
+// Gets a page of soft deleted data items retrieved by a given filter and sorted according to sort parameters.
+// Requires options.soft_delete_column to be set.
+//   - correlationId    (optional) transaction id to trace execution through call chain.
+//   - filter           (optional) a filter JSON object
+//   - paging           (optional) paging parameters
+//   - sort             (optional) sorting JSON object
+//   - select           (optional) projection JSON object
+// Returns a data page or error.
+func (c *PostgresPersistence) GetDeletedPageByFilter(correlationId string, filter interface{}, paging *cdata.PagingParams,
+	sort interface{}, sel interface{}) (*cdata.DataPage, error) {
+	if err := c.checkSoftDelete(correlationId); err != nil {
+		return nil, err
+	}
+
+	deleted := *c
+	deleted.deletedOnly = true
+	return deleted.GetPageByFilter(correlationId, filter, paging, sort, sel)
+}
+
+// Permanently removes items that were soft deleted before a given age, to enforce retention policies.
+// Requires options.soft_delete_column to be set.
+//   - correlationId    (optional) transaction id to trace execution through call chain.
+//   - age              a minimum time since deletion of purged items
+// Returns a number of purged items or error.
+func (c *PostgresPersistence) PurgeDeletedOlderThan(correlationId string, age time.Duration) (count int64, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkSoftDelete(correlationId); err != nil {
+		return 0, err
+	}
+	if err = c.checkWritable(correlationId); err != nil {
+		return 0, err
+	}
+	if err = c.checkNotDryRun(correlationId); err != nil {
+		return 0, err
+	}
+
+	query := "DELETE FROM " + c.QuotedTableName() +
+		" WHERE " + c.QuoteIdentifier(c.softDeleteColumn) + "<$1" + c.andTenantCondition()
+
+	result, qErr := c.Client.Exec(ctx, query, time.Now().Add(-age))
+	if qErr != nil {
+		return 0, qErr
+	}
+
+	count = result.RowsAffected()
+	c.Logger.Trace(correlationId, "Purged %d deleted items from %s", count, c.TableName)
+	return count, nil
+}
+
+// Restores a soft deleted data item by its unique id.
+// Requires options.soft_delete_column to be set.
+//   - correlationId    (optional) transaction id to trace execution through call chain.
+//   - id               an id of the item to be restored
+// Returns the restored item, nil if no deleted item was found, or error.
+func (c *IdentifiablePostgresPersistence) RestoreById(correlationId string, id interface{}) (result interface{}, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkSoftDelete(correlationId); err != nil {
+		return nil, err
+	}
+	if err = c.checkWritable(correlationId); err != nil {
+		return nil, err
+	}
+	if err = c.checkNotDryRun(correlationId); err != nil {
+		return nil, err
+	}
+
+	column := c.QuoteIdentifier(c.softDeleteColumn)
+	query := "UPDATE " + c.QuotedTableName() + " SET " + column + "=NULL" +
+		" WHERE \"id\"=$1 AND " + column + " IS NOT NULL" + c.andTenantCondition() + c.returningClause("id")
+
+	qResult, qErr := c.Client.Query(ctx, query, id)
+	if qErr != nil {
+		return nil, qErr
+	}
+	defer qResult.Close()
+	if !qResult.Next() {
+		return nil, qResult.Err()
+	}
+
+	result, err = c.ConvertRowToPublic(correlationId, qResult)
+	if err != nil {
+		return nil, err
+	}
+	c.Logger.Trace(correlationId, "Restored in %s with id = %s", c.TableName, id)
+	return result, nil
+}
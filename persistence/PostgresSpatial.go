@@ -0,0 +1,164 @@
+package persistence
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Spatial reference id of WGS 84 coordinates used by GPS
+const SridWgs84 = 4326
+
+// Geographic point with WGS 84 coordinates.
+type GeoPoint struct {
+	// Longitude in degrees
+	Lon float64 `json:"lon"`
+	// Latitude in degrees
+	Lat float64 `json:"lat"`
+}
+
+// Adds statements to enable PostGIS extension and to add a spatial column
+// to the table on opening. Must be called in DefineSchema after the table definition.
+//   - name      a name of the spatial column
+//   - colType   a column type: "geometry" or "geography" (default: "geography")
+//   - shape     a shape of stored values like "Point" or "Polygon", or empty for any shape
+func (c *PostgresPersistence) EnsureSpatialColumn(name string, colType string, shape string) {
+	if colType == "" {
+		colType = "geography"
+	}
+	if shape == "" {
+		shape = "Geometry"
+	}
+
+	c.EnsureSchema("CREATE EXTENSION IF NOT EXISTS postgis")
+	c.EnsureSchema("ALTER TABLE " + c.QuotedTableName() + " ADD COLUMN IF NOT EXISTS " +
+		c.QuoteIdentifier(name) + " " + colType + "(" + shape + ", " + strconv.Itoa(SridWgs84) + ")")
+}
+
+// Adds a GiST index definition over a spatial column to create it on opening.
+//   - name      an index name
+//   - column    a spatial column to index
+func (c *PostgresPersistence) EnsureSpatialIndex(name string, column string) {
+	c.EnsureSchema("CREATE INDEX IF NOT EXISTS " + c.QuoteIdentifier(name) + " ON " + c.QuotedTableName() +
+		" USING gist (" + c.QuoteIdentifier(column) + ")")
+}
+
+// Composes a filter that selects rows located within a distance from a point.
+// Distance is measured in meters on the spheroid. The filter uses spatial indexes on geography columns.
+// The result can be passed as a filter to GetPageByFilter, GetListByFilter and similar methods.
+//   - column    a spatial column
+//   - point     a center point
+//   - meters    a maximum distance in meters
+// Returns the composed filter.
+func (c *PostgresPersistence) ComposeWithinDistanceFilter(column string, point GeoPoint, meters float64) string {
+	return "ST_DWithin(" + c.QuoteIdentifier(column) + "::geography, " + composeGeoPoint(point) +
+		"::geography, " + formatGeoNumber(meters) + ")"
+}
+
+// Composes a filter that selects rows which bounding boxes intersect the given box.
+// The filter uses spatial indexes on geometry columns.
+//   - column    a spatial column
+//   - min       a south-west corner of the box
+//   - max       a north-east corner of the box
+// Returns the composed filter.
+func (c *PostgresPersistence) ComposeBoundingBoxFilter(column string, min GeoPoint, max GeoPoint) string {
+	return c.QuoteIdentifier(column) + "::geometry && ST_MakeEnvelope(" + formatGeoNumber(min.Lon) + ", " +
+		formatGeoNumber(min.Lat) + ", " + formatGeoNumber(max.Lon) + ", " + formatGeoNumber(max.Lat) + ", " +
+		strconv.Itoa(SridWgs84) + ")"
+}
+
+// Composes a sort that orders rows from the nearest to a point.
+// The <-> operator is accelerated by spatial indexes for top N queries.
+//   - column    a spatial column
+//   - point     a point to measure distance from
+// Returns the composed sort.
+func (c *PostgresPersistence) ComposeDistanceSort(column string, point GeoPoint) string {
+	return c.QuoteIdentifier(column) + " <-> " + composeGeoPoint(point)
+}
+
+func composeGeoPoint(point GeoPoint) string {
+	return "ST_SetSRID(ST_MakePoint(" + formatGeoNumber(point.Lon) + ", " + formatGeoNumber(point.Lat) + "), " +
+		strconv.Itoa(SridWgs84) + ")"
+}
+
+func formatGeoNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// Converts a point into extended WKT representation like "SRID=4326;POINT(30 10)".
+// Use it in ConvertFromPublic to write points into geometry and geography columns.
+//   - point     a point to convert
+// Returns EWKT value
+func GeoPointToString(point GeoPoint) string {
+	return "SRID=" + strconv.Itoa(SridWgs84) + ";POINT(" + formatGeoNumber(point.Lon) + " " +
+		formatGeoNumber(point.Lat) + ")"
+}
+
+// Parses a point returned by PostgreSQL. Both hex encoded EWKB, the default output
+// of geometry and geography columns, and (E)WKT representations are supported.
+// Use it in ConvertToPublic to read points from spatial columns.
+//   - value     a point value
+// Returns parsed point or error
+func ParseGeoPoint(value string) (GeoPoint, error) {
+	value = strings.TrimSpace(value)
+	if index := strings.Index(value, ";"); index >= 0 && strings.HasPrefix(strings.ToUpper(value), "SRID=") {
+		value = value[index+1:]
+	}
+
+	upper := strings.ToUpper(value)
+	if strings.HasPrefix(upper, "POINT") {
+		body := strings.TrimSpace(value[len("POINT"):])
+		body = strings.TrimSuffix(strings.TrimPrefix(body, "("), ")")
+		parts := strings.Fields(body)
+		if len(parts) < 2 {
+			return GeoPoint{}, errors.New("invalid point: " + value)
+		}
+		lon, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil {
+			return GeoPoint{}, err
+		}
+		lat, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return GeoPoint{}, err
+		}
+		return GeoPoint{Lon: lon, Lat: lat}, nil
+	}
+
+	data, err := hex.DecodeString(value)
+	if err != nil {
+		return GeoPoint{}, err
+	}
+	return parseEwkbPoint(data)
+}
+
+func parseEwkbPoint(data []byte) (GeoPoint, error) {
+	if len(data) < 5 {
+		return GeoPoint{}, errors.New("invalid EWKB: too short")
+	}
+
+	var order binary.ByteOrder = binary.BigEndian
+	if data[0] == 1 {
+		order = binary.LittleEndian
+	}
+
+	geomType := order.Uint32(data[1:5])
+	offset := 5
+	if geomType&0x20000000 != 0 {
+		// Skip SRID
+		offset += 4
+	}
+	if geomType&0xFFFF != 1 {
+		return GeoPoint{}, errors.New("invalid EWKB: geometry is not a point")
+	}
+	if len(data) < offset+16 {
+		return GeoPoint{}, errors.New("invalid EWKB: too short")
+	}
+
+	return GeoPoint{
+		Lon: math.Float64frombits(order.Uint64(data[offset : offset+8])),
+		Lat: math.Float64frombits(order.Uint64(data[offset+8 : offset+16])),
+	}, nil
+}
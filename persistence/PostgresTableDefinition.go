@@ -0,0 +1,126 @@
+package persistence
+
+import (
+	"sort"
+	"strings"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+)
+
+// Table definition described in configuration
+type postgresTableDefinition struct {
+	columns       map[string]string
+	indexes       map[string]string
+	uniqueIndexes map[string]string
+}
+
+func newPostgresTableDefinition(config *cconf.ConfigParams) *postgresTableDefinition {
+	definition := &postgresTableDefinition{
+		columns:       config.GetSection("table_definition.columns").Value(),
+		indexes:       config.GetSection("table_definition.indexes").Value(),
+		uniqueIndexes: config.GetSection("table_definition.unique_indexes").Value(),
+	}
+	if len(definition.columns) == 0 && len(definition.indexes) == 0 && len(definition.uniqueIndexes) == 0 {
+		return nil
+	}
+	return definition
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (c *PostgresPersistence) getConfiguredIndexStatements() []string {
+	statements := make([]string, 0)
+	for _, unique := range []bool{false, true} {
+		indexes := c.tableDefinition.indexes
+		builder := "CREATE INDEX IF NOT EXISTS "
+		if unique {
+			indexes = c.tableDefinition.uniqueIndexes
+			builder = "CREATE UNIQUE INDEX IF NOT EXISTS "
+		}
+
+		for _, name := range sortedKeys(indexes) {
+			fields := make([]string, 0)
+			for _, field := range strings.Split(indexes[name], ",") {
+				parts := strings.Fields(field)
+				if len(parts) == 0 {
+					continue
+				}
+				field = c.QuoteIdentifier(parts[0])
+				if len(parts) > 1 && strings.ToUpper(parts[1]) == "DESC" {
+					field += " DESC"
+				}
+				fields = append(fields, field)
+			}
+			statements = append(statements, builder+c.QuoteIdentifier(name)+
+				" ON "+c.QuotedTableName()+" ("+strings.Join(fields, ", ")+")")
+		}
+	}
+	return statements
+}
+
+// Replaces the table definition with the one described in configuration.
+// When columns are configured they replace the table created in DefineSchema,
+// configured indexes are added to the schema definition.
+func (c *PostgresPersistence) defineConfiguredSchema() {
+	if c.tableDefinition == nil {
+		return
+	}
+
+	if len(c.tableDefinition.columns) > 0 {
+		c.ClearSchema()
+		c.DefineSchema()
+
+		columns := make([]string, 0, len(c.tableDefinition.columns))
+		for _, name := range sortedKeys(c.tableDefinition.columns) {
+			definition := c.tableDefinition.columns[name]
+			if isGeneratedDefinition(definition) {
+				c.setGeneratedColumn(name, definition)
+			} else if isDefaultDefinition(definition) {
+				c.setColumnDefault(name)
+			}
+			columns = append(columns, c.QuoteIdentifier(name)+" "+definition)
+		}
+		c.EnsureSchema("CREATE TABLE IF NOT EXISTS " + c.QuotedTableName() + " (" + strings.Join(columns, ", ") + ")")
+	}
+
+	for _, statement := range c.getConfiguredIndexStatements() {
+		c.EnsureSchema(statement)
+	}
+}
+
+// Adds configured columns and indexes that are missing in an existing table.
+// Types of existing columns are not changed.
+func (c *PostgresPersistence) applyConfiguredSchema(correlationId string) error {
+	if c.tableDefinition == nil {
+		return nil
+	}
+
+	for _, name := range sortedKeys(c.tableDefinition.columns) {
+		// Constraints like PRIMARY KEY cannot be added with the column to a filled table
+		columnType := c.tableDefinition.columns[name]
+		if strings.Contains(strings.ToUpper(columnType), "PRIMARY KEY") {
+			continue
+		}
+		statement := "ALTER TABLE " + c.QuotedTableName() + " ADD COLUMN IF NOT EXISTS " +
+			c.QuoteIdentifier(name) + " " + columnType
+		if _, err := c.Client.Exec(c.schemaContext(), statement); err != nil {
+			return err
+		}
+	}
+
+	for _, statement := range c.getConfiguredIndexStatements() {
+		if _, err := c.Client.Exec(c.schemaContext(), statement); err != nil {
+			return err
+		}
+	}
+
+	c.Logger.Debug(correlationId, "Applied configured table definition to %s", c.TableName)
+	return nil
+}
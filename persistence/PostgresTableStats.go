@@ -0,0 +1,67 @@
+package persistence
+
+import (
+	"time"
+)
+
+// Storage statistics of a persistence table
+type PostgresTableStats struct {
+	// An estimated number of rows according to the last vacuum or analyze
+	RowEstimate int64 `json:"row_estimate"`
+	// A number of live rows tracked by the statistics collector
+	LiveTuples int64 `json:"live_tuples"`
+	// A number of dead rows that wait for vacuum
+	DeadTuples int64 `json:"dead_tuples"`
+	// A size of the table data in bytes, including TOAST
+	TableSize int64 `json:"table_size"`
+	// A size of all indexes of the table in bytes
+	IndexesSize int64 `json:"indexes_size"`
+	// A total size of the table with indexes in bytes
+	TotalSize int64 `json:"total_size"`
+	// A time of the last manual vacuum or nil
+	LastVacuum *time.Time `json:"last_vacuum"`
+	// A time of the last vacuum by the autovacuum daemon or nil
+	LastAutoVacuum *time.Time `json:"last_autovacuum"`
+	// A time of the last manual analyze or nil
+	LastAnalyze *time.Time `json:"last_analyze"`
+	// A time of the last analyze by the autovacuum daemon or nil
+	LastAutoAnalyze *time.Time `json:"last_autoanalyze"`
+}
+
+// Gets storage statistics of the table: row estimates, table and index sizes,
+// dead rows and times of the last vacuum and analyze, e.g. to expose them as telemetry.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+// Returns the statistics, nil if the table does not exist, or error.
+func (c *PostgresPersistence) GetTableStats(correlationId string) (stats *PostgresTableStats, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	query := "SELECT GREATEST(t.reltuples, 0)::bigint, COALESCE(s.n_live_tup, 0), COALESCE(s.n_dead_tup, 0)," +
+		" pg_table_size(t.oid), pg_indexes_size(t.oid), pg_total_relation_size(t.oid)," +
+		" s.last_vacuum, s.last_autovacuum, s.last_analyze, s.last_autoanalyze" +
+		" FROM pg_class t LEFT JOIN pg_stat_all_tables s ON s.relid=t.oid" +
+		" WHERE t.oid=to_regclass($1)"
+
+	qResult, qErr := c.ReadClient.Query(ctx, query, c.QuotedTableName())
+	if qErr != nil {
+		return nil, qErr
+	}
+	defer qResult.Close()
+
+	if !qResult.Next() {
+		return nil, qResult.Err()
+	}
+	stats = &PostgresTableStats{}
+	err = qResult.Scan(&stats.RowEstimate, &stats.LiveTuples, &stats.DeadTuples,
+		&stats.TableSize, &stats.IndexesSize, &stats.TotalSize,
+		&stats.LastVacuum, &stats.LastAutoVacuum, &stats.LastAnalyze, &stats.LastAutoAnalyze)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Logger.Trace(correlationId, "Retrieved stats of %s", c.TableName)
+	return stats, nil
+}
@@ -0,0 +1,213 @@
+package persistence
+
+import (
+	"context"
+	"sync"
+
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	conn "github.com/pip-services3-go/pip-services3-postgres-go/connect"
+)
+
+// Multi-tenancy modes
+const (
+	// Data of all tenants is kept together
+	TenancyNone = "none"
+	// Data of each tenant is kept in its own schema, that is created and migrated on the first use
+	TenancySchema = "schema"
+	// Data of all tenants is kept in shared tables with a tenant column,
+	// that is set on inserts and added to conditions of queries
+	TenancyColumn = "column"
+)
+
+type tenantKey struct{}
+
+// Creates a context with a tenant, so persistence components bound to the context
+// by WithContext keep data of the tenant according to options.tenancy.
+//   - ctx           a parent context
+//   - tenantId      a tenant id, letters, digits and underscores
+// Returns a context with the tenant.
+func WithTenant(ctx context.Context, tenantId string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantId)
+}
+
+// Gets a tenant of a context
+//   - ctx           a context
+// Returns the tenant id set by WithTenant or empty string.
+func GetTenant(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	tenantId, _ := ctx.Value(tenantKey{}).(string)
+	return tenantId
+}
+
+// Creates a copy of the persistence that keeps data of a tenant according to options.tenancy.
+// The copy shares the connection, configuration and dependencies with the original.
+//   - tenantId      a tenant id, letters, digits and underscores
+// Returns the persistence copy bound to the tenant.
+func (c *PostgresPersistence) ForTenant(tenantId string) *PostgresPersistence {
+	clone := *c
+	clone.tenant = tenantId
+	return &clone
+}
+
+// State of a tenant schema initialization
+type postgresTenantSchema struct {
+	once sync.Once
+	err  error
+}
+
+// Checks that the current tenant is set and safe to use in names and conditions
+// Returns BadRequestError when the tenant is not set or invalid
+func (c *PostgresPersistence) validateTenant(correlationId string) error {
+	if c.tenant == "" {
+		return cerr.NewBadRequestError(correlationId, "TENANT_REQUIRED",
+			"Tenant is required for operations on "+c.TableName)
+	}
+	for index := 0; index < len(c.tenant); index++ {
+		if !isNameChar(c.tenant[index]) {
+			return cerr.NewBadRequestError(correlationId, "INVALID_TENANT",
+				"Tenant "+c.tenant+" is invalid").WithDetails("tenant", c.tenant)
+		}
+	}
+	return nil
+}
+
+// Gets the schema of the current tenant
+// Returns the schema name or BadRequestError when the tenant is not set or invalid
+func (c *PostgresPersistence) tenantSchemaName(correlationId string) (string, error) {
+	if err := c.validateTenant(correlationId); err != nil {
+		return "", err
+	}
+	return c.tenantPrefix + c.tenant, nil
+}
+
+// Binds an operation context to the schema of the current tenant.
+// The schema is created and migrated on the first operation of the tenant.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - ctx               an operation context
+// Returns the context with the tenant search path or error.
+func (c *PostgresPersistence) tenantContext(correlationId string, ctx context.Context) (context.Context, error) {
+	schema, err := c.tenantSchemaName(correlationId)
+	if err != nil {
+		return ctx, err
+	}
+	ctx = conn.WithSearchPath(ctx, c.tenantSearchPath(schema))
+
+	// Tenants that failed to initialize are retried by next operations
+	value, _ := c.tenants.LoadOrStore(schema, &postgresTenantSchema{})
+	state := value.(*postgresTenantSchema)
+	state.once.Do(func() {
+		state.err = c.initializeTenant(correlationId, schema)
+	})
+	if state.err != nil {
+		c.tenants.Delete(schema)
+		return ctx, cerr.NewConnectionError(correlationId, "CONNECT_FAILED",
+			"Failed to initialize schema "+schema).WithCause(state.err)
+	}
+	return ctx, nil
+}
+
+// Creates a tenant schema with database objects and applies pending migrations
+func (c *PostgresPersistence) initializeTenant(correlationId string, schema string) error {
+	if c.readonly {
+		return nil
+	}
+	c.Logger.Debug(correlationId, "Initializing schema %s for %s", schema, c.TableName)
+
+	_, err := c.Client.Exec(context.Background(), "CREATE SCHEMA IF NOT EXISTS "+c.QuoteIdentifier(schema))
+	if err != nil {
+		return err
+	}
+	return c.initializeSchema(correlationId)
+}
+
+// Composes a search path of a tenant schema. Public schema remains available for extensions
+func (c *PostgresPersistence) tenantSearchPath(schema string) string {
+	return c.QuoteIdentifier(schema) + ", public"
+}
+
+// Gets a context for schema statements. For tenants it sets the search path to the tenant schema
+func (c *PostgresPersistence) schemaContext() context.Context {
+	if c.tenancy == TenancySchema && c.tenant != "" {
+		if schema, err := c.tenantSchemaName(""); err == nil {
+			return conn.WithSearchPath(context.Background(), c.tenantSearchPath(schema))
+		}
+	}
+	return context.TODO()
+}
+
+// Gets a name of the schema where database objects are located
+func (c *PostgresPersistence) currentSchemaName() string {
+	if c.tenancy == TenancySchema && c.tenant != "" {
+		if schema, err := c.tenantSchemaName(""); err == nil {
+			return schema
+		}
+	}
+	if c.SchemaName == "" {
+		return "public"
+	}
+	return c.SchemaName
+}
+
+// Composes a condition that limits rows to the current tenant in column tenancy mode
+// Returns the condition or empty string
+func (c *PostgresPersistence) tenantCondition() string {
+	if c.tenancy != TenancyColumn || c.tenant == "" {
+		return ""
+	}
+	return c.QuotedTableName() + "." + c.QuoteIdentifier(c.tenantColumn) + "=" + c.QuoteLiteral(c.tenant)
+}
+
+// Composes a tenant condition to append to other conditions
+// Returns the condition with leading AND or empty string
+func (c *PostgresPersistence) andTenantCondition() string {
+	condition := c.tenantCondition()
+	if condition == "" {
+		return ""
+	}
+	return " AND " + condition
+}
+
+// Sets the tenant column of a row in column tenancy mode, so items cannot be written to other tenants
+//   - row   a row converted from a data item
+// Returns the row with the tenant column
+func (c *PostgresPersistence) injectTenant(row interface{}) interface{} {
+	if c.tenancy != TenancyColumn || c.tenant == "" || row == nil {
+		return row
+	}
+	// Maps are copied as is to keep values of special types, e.g. JSON data
+	values, ok := row.(map[string]interface{})
+	if ok {
+		copied := make(map[string]interface{}, len(values)+1)
+		for key, value := range values {
+			copied[key] = value
+		}
+		values = copied
+	} else if values = c.convertToMap(row); values == nil {
+		return row
+	}
+	values[c.tenantColumn] = c.tenant
+	return values
+}
+
+// Composes a condition of upserts, so rows of other tenants with conflicting keys are not overwritten
+// Returns the condition with leading WHERE or empty string
+func (c *PostgresPersistence) upsertTenantCondition() string {
+	condition := c.tenantCondition()
+	if condition == "" {
+		return ""
+	}
+	return " WHERE " + condition
+}
+
+// Rejects column tenancy for persistences whose tables have no tenant column,
+// so ForTenant copies cannot read and write rows of all tenants. Open returns ConfigError when it is configured.
+func (c *PostgresPersistence) rejectColumnTenancy() {
+	c.configErr = nil
+	if c.tenancy == TenancyColumn {
+		c.configErr = cerr.NewConfigError("", "TENANCY_NOT_SUPPORTED",
+			"Column tenancy is not supported by "+c.TableName).
+			WithDetails("tenancy", c.tenancy)
+	}
+}
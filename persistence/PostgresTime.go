@@ -0,0 +1,147 @@
+package persistence
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Ways to return and store timestamp values
+const (
+	// Keep time zones as they are returned by the driver and set by callers
+	TimeZonePreserve = "preserve"
+	// Normalize all timestamps to UTC
+	TimeZoneUtc = "utc"
+	// Convert all timestamps to the local time zone of the process
+	TimeZoneLocal = "local"
+)
+
+// Parses truncation precision of timestamps like "s", "ms", "us" or "ns"
+func parseTimePrecision(value string) time.Duration {
+	switch strings.ToLower(value) {
+	case "s":
+		return time.Second
+	case "ms":
+		return time.Millisecond
+	case "us":
+		return time.Microsecond
+	default:
+		return 0
+	}
+}
+
+// Collects json names of prototype fields that hold timestamps
+// together with field indexes to set them directly
+func getPrototypeTimeFields(proto reflect.Type) map[string][]int {
+	return getPrototypeFields(proto, func(fieldType reflect.Type) bool {
+		return fieldType == timeType || fieldType == reflect.PtrTo(timeType)
+	})
+}
+
+// Collects json names and indexes of prototype fields which types match a condition.
+// Embedded structs are flattened the same way as JSON encoding does.
+func getPrototypeFields(proto reflect.Type, match func(fieldType reflect.Type) bool) map[string][]int {
+	fields := make(map[string][]int)
+	if proto == nil {
+		return fields
+	}
+	for proto.Kind() == reflect.Ptr {
+		proto = proto.Elem()
+	}
+	if proto.Kind() == reflect.Struct {
+		collectPrototypeFields(proto, []int{}, match, fields)
+	}
+	return fields
+}
+
+func collectPrototypeFields(proto reflect.Type, index []int, match func(fieldType reflect.Type) bool,
+	fields map[string][]int) {
+	for i := 0; i < proto.NumField(); i++ {
+		field := proto.Field(i)
+		jsonTag := field.Tag.Get("json")
+		if jsonTag == "-" {
+			continue
+		}
+		fieldIndex := append(append([]int{}, index...), i)
+
+		jsonName := strings.Split(jsonTag, ",")[0]
+		if field.Anonymous && jsonName == "" && field.Type.Kind() == reflect.Struct {
+			collectPrototypeFields(field.Type, fieldIndex, match, fields)
+			continue
+		}
+		if field.PkgPath != "" || !match(field.Type) {
+			continue
+		}
+
+		if jsonName == "" {
+			jsonName = field.Name
+		}
+		fields[jsonName] = fieldIndex
+	}
+}
+
+// Applies configured time zone and precision to a timestamp
+func (c *PostgresPersistence) normalizeTime(value time.Time) time.Time {
+	if c.timePrecision > 0 {
+		value = value.Truncate(c.timePrecision)
+	}
+	switch c.timeZone {
+	case TimeZoneUtc:
+		return value.UTC()
+	case TimeZoneLocal:
+		return value.Local()
+	default:
+		return value
+	}
+}
+
+// Sets timestamps into a decoded object as they are, because JSON encoding
+// replaces time zones with fixed offsets and map prototypes get strings instead of times
+func (c *PostgresPersistence) assignTimeValues(docPointer reflect.Value, values interface{}) {
+	columns, ok := values.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	doc := docPointer.Elem()
+	if doc.Kind() == reflect.Map && doc.Type().Key().Kind() == reflect.String &&
+		doc.Type().Elem().Kind() == reflect.Interface {
+		for name, value := range columns {
+			if t, ok := value.(time.Time); ok {
+				doc.SetMapIndex(reflect.ValueOf(name).Convert(doc.Type().Key()), reflect.ValueOf(t))
+			}
+		}
+		return
+	}
+
+	if doc.Kind() != reflect.Struct {
+		return
+	}
+	for name, index := range c.timeFields {
+		if t, ok := columns[name].(time.Time); ok {
+			field := doc.FieldByIndex(index)
+			if field.Kind() == reflect.Ptr {
+				field.Set(reflect.ValueOf(&t))
+			} else {
+				field.Set(reflect.ValueOf(t))
+			}
+		}
+	}
+}
+
+// Converts timestamps of prototype fields, encoded as JSON strings, back into times
+// with configured time zone and precision to pass them into statements
+func (c *PostgresPersistence) convertTimesFromPublic(items map[string]interface{}) {
+	if c.timeZone == TimeZonePreserve && c.timePrecision == 0 {
+		return
+	}
+	for name := range c.timeFields {
+		str, ok := items[name].(string)
+		if !ok {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339Nano, str); err == nil {
+			items[name] = c.normalizeTime(t)
+		}
+	}
+}
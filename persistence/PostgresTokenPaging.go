@@ -0,0 +1,217 @@
+package persistence
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+)
+
+// Page of data items with a continuation token to retrieve the next page
+type PostgresTokenPage struct {
+	// An opaque token to pass to the next call, empty when there are no more items
+	Token string `json:"token"`
+	// Data items of the page
+	Data []interface{} `json:"data"`
+	// A number of rows of the page skipped because they failed conversion
+	Skipped int `json:"skipped"`
+}
+
+// Position of the last item of a page encoded into a continuation token
+type postgresPageToken struct {
+	Keys   []string      `json:"k"`
+	Values []interface{} `json:"v"`
+}
+
+// Gets a page of data items retrieved by a given filter, that continues after the page
+// of a continuation token. Unlike skip based paging the token encodes values of sort keys
+// of the last returned item, so iteration is stable when rows are inserted or deleted mid-scan.
+// Sort keys must not be null and together must identify items uniquely, e.g. "create_time DESC", "id DESC".
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - filter            (optional) a filter JSON object
+//   - token             (optional) a continuation token of the previous page, empty for the first page
+//   - take              a number of items in a page (default: MaxPageSize)
+//   - keys              sort key columns optionally followed by ASC or DESC ("id" by default)
+// Returns a data page with a continuation token or error.
+func (c *PostgresPersistence) GetPageByToken(correlationId string, filter interface{}, token string, take int64,
+	keys ...string) (page *PostgresTokenPage, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.validatePaging(correlationId, cdata.NewPagingParams(nil, take, false)); err != nil {
+		return nil, err
+	}
+	if take <= 0 || take > int64(c.MaxPageSize) {
+		take = int64(c.MaxPageSize)
+	}
+	if len(keys) == 0 {
+		keys = []string{"id"}
+	}
+
+	columns := make([]string, len(keys))
+	descending := make([]bool, len(keys))
+	for index, key := range keys {
+		columns[index], descending[index] = parseSortKey(key)
+	}
+
+	query := c.composeSelect(filter, nil)
+	conditions := make([]string, 0, 2)
+	if where := c.composeWhere(filter); where != "" {
+		conditions = append(conditions, "("+strings.TrimPrefix(where, " WHERE ")+")")
+	}
+
+	var args []interface{}
+	if token != "" {
+		values, tErr := c.decodePageToken(correlationId, token, columns)
+		if tErr != nil {
+			return nil, tErr
+		}
+		conditions = append(conditions, "("+c.composeKeysetCondition(columns, descending)+")")
+		args = values
+	}
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	orders := make([]string, len(columns))
+	for index, column := range columns {
+		orders[index] = c.QuoteIdentifier(column)
+		if descending[index] {
+			orders[index] += " DESC"
+		}
+	}
+	// One extra item shows whether there is a next page
+	query += " ORDER BY " + strings.Join(orders, ",") + " LIMIT " + strconv.FormatInt(take+1, 10)
+
+	qResult, qErr := c.ReadClient.Query(ctx, query, args...)
+	if qErr != nil {
+		return nil, qErr
+	}
+	defer qResult.Close()
+
+	items := make([]interface{}, 0)
+	count := int64(0)
+	skipped := 0
+	for qResult.Next() {
+		count++
+		if count > take {
+			break
+		}
+		item, convErr := c.ConvertRowToPublic(correlationId, qResult)
+		if convErr != nil {
+			return nil, convErr
+		}
+		if item == nil {
+			skipped++
+			continue
+		}
+		items = append(items, item)
+	}
+	c.logSkippedRows(correlationId, skipped, len(items))
+
+	if err = qResult.Err(); err != nil {
+		return nil, err
+	}
+
+	page = &PostgresTokenPage{Data: items, Skipped: skipped}
+	if count > take && len(items) > 0 {
+		page.Token, err = c.encodePageToken(columns, items[len(items)-1])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.Logger.Trace(correlationId, "Retrieved %d from %s", len(items), c.TableName)
+	return page, nil
+}
+
+// Splits a sort key into a column and a descending flag
+func parseSortKey(key string) (string, bool) {
+	key = strings.TrimSpace(key)
+	upper := strings.ToUpper(key)
+	if strings.HasSuffix(upper, " DESC") {
+		return strings.TrimSpace(key[:len(key)-5]), true
+	}
+	if strings.HasSuffix(upper, " ASC") {
+		return strings.TrimSpace(key[:len(key)-4]), false
+	}
+	return key, false
+}
+
+// Composes a condition that selects items after a position.
+// Mixed sort directions do not allow row comparisons, so the condition is expanded:
+// (a > $1) OR (a = $1 AND b > $2) ...
+func (c *PostgresPersistence) composeKeysetCondition(columns []string, descending []bool) string {
+	alternatives := make([]string, len(columns))
+	for index := range columns {
+		parts := make([]string, 0, index+1)
+		for prev := 0; prev < index; prev++ {
+			parts = append(parts, c.QuoteIdentifier(columns[prev])+"=$"+strconv.Itoa(prev+1))
+		}
+		operator := ">"
+		if descending[index] {
+			operator = "<"
+		}
+		parts = append(parts, c.QuoteIdentifier(columns[index])+operator+"$"+strconv.Itoa(index+1))
+		alternatives[index] = "(" + strings.Join(parts, " AND ") + ")"
+	}
+	return strings.Join(alternatives, " OR ")
+}
+
+// Encodes values of sort keys of an item into a continuation token
+func (c *PostgresPersistence) encodePageToken(columns []string, item interface{}) (string, error) {
+	position := postgresPageToken{Keys: columns, Values: make([]interface{}, len(columns))}
+	for index, column := range columns {
+		position.Values[index] = c.getItemField(item, c.getFieldName(column))
+	}
+	buffer, err := json.Marshal(position)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buffer), nil
+}
+
+// Decodes values of sort keys from a continuation token.
+// Numbers are returned as strings, so the server parses them according to column types.
+func (c *PostgresPersistence) decodePageToken(correlationId string, token string, columns []string) ([]interface{}, error) {
+	invalid := cerr.NewBadRequestError(correlationId, "INVALID_TOKEN",
+		"Continuation token is invalid or was issued for another sort order")
+
+	buffer, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, invalid.WithCause(err)
+	}
+
+	var position postgresPageToken
+	decoder := json.NewDecoder(bytes.NewReader(buffer))
+	decoder.UseNumber()
+	if err = decoder.Decode(&position); err != nil {
+		return nil, invalid.WithCause(err)
+	}
+	if len(position.Keys) != len(columns) || len(position.Values) != len(columns) {
+		return nil, invalid
+	}
+
+	values := make([]interface{}, len(columns))
+	for index, column := range columns {
+		if position.Keys[index] != column {
+			return nil, invalid
+		}
+		switch value := position.Values[index].(type) {
+		case nil:
+			return nil, invalid
+		case json.Number:
+			values[index] = value.String()
+		default:
+			values[index] = value
+		}
+	}
+	return values, nil
+}
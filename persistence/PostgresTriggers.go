@@ -0,0 +1,49 @@
+package persistence
+
+import (
+	"strings"
+)
+
+// Adds statements to create a row level trigger with its PL/pgSQL function on opening.
+// The function is named <table>_<trigger>_fn and replaced on every bootstrap,
+// the trigger is recreated, so changes of the definition are applied to existing tables.
+// Must be called in DefineSchema after the table definition.
+//   - name          a trigger name
+//   - timing        when the trigger fires: BEFORE, AFTER or INSTEAD OF
+//   - event         events that fire the trigger, e.g. "INSERT OR UPDATE"
+//   - functionBody  a PL/pgSQL block or statements that are wrapped into BEGIN ... END,
+//     e.g. "NEW.\"update_time\" = now(); RETURN NEW;"
+func (c *PostgresPersistence) EnsureTrigger(name string, timing string, event string, functionBody string) {
+	function := c.triggerFunctionName(name)
+	trigger := c.QuoteIdentifier(name)
+
+	body := strings.TrimSpace(functionBody)
+	upperBody := strings.ToUpper(body)
+	if !strings.HasPrefix(upperBody, "BEGIN") && !strings.HasPrefix(upperBody, "DECLARE") {
+		body = "BEGIN\n" + body + "\nEND"
+	}
+
+	c.EnsureSchema("CREATE OR REPLACE FUNCTION " + function + "() RETURNS trigger AS $$\n" +
+		body + "\n$$ LANGUAGE plpgsql")
+	c.EnsureSchema("DROP TRIGGER IF EXISTS " + trigger + " ON " + c.QuotedTableName())
+	c.EnsureSchema("CREATE TRIGGER " + trigger + " " + strings.ToUpper(timing) + " " + strings.ToUpper(event) +
+		" ON " + c.QuotedTableName() + " FOR EACH ROW EXECUTE PROCEDURE " + function + "()")
+}
+
+// Adds statements to create a trigger that sets a timestamp column to the current time
+// on every insert and update, the common way to maintain update times.
+// Must be called in DefineSchema after the table definition.
+//   - column    a timestamp column to maintain
+func (c *PostgresPersistence) EnsureUpdateTimeTrigger(column string) {
+	c.EnsureTrigger(c.TableName+"_"+column+"_update", "BEFORE", "INSERT OR UPDATE",
+		"NEW."+c.QuoteIdentifier(column)+" = now(); RETURN NEW;")
+}
+
+// Composes a name of the trigger function qualified with the schema
+func (c *PostgresPersistence) triggerFunctionName(trigger string) string {
+	name := c.QuoteIdentifier(c.TableName + "_" + trigger + "_fn")
+	if len(c.SchemaName) > 0 {
+		return c.QuoteIdentifier(c.SchemaName) + "." + name
+	}
+	return name
+}
@@ -0,0 +1,47 @@
+package persistence
+
+import (
+	"strconv"
+)
+
+// Adds statements to enable pg_trgm extension and to create a trigram index
+// over a text column on opening. The index speeds up similarity filters
+// as well as LIKE and ILIKE searches.
+// Must be called in DefineSchema after the table definition.
+//   - name      an index name
+//   - column    a text column to index
+//   - method    an index method: "gin" or "gist" (default: "gin")
+func (c *PostgresPersistence) EnsureTrigramIndex(name string, column string, method string) {
+	if method == "" {
+		method = "gin"
+	}
+
+	c.EnsureSchema("CREATE EXTENSION IF NOT EXISTS pg_trgm")
+	c.EnsureSchema("CREATE INDEX IF NOT EXISTS " + c.QuoteIdentifier(name) + " ON " + c.QuotedTableName() +
+		" USING " + method + " (" + c.QuoteIdentifier(column) + " " + method + "_trgm_ops)")
+}
+
+// Composes a filter that selects rows with column values similar to the given text.
+// With zero threshold the filter uses the % operator that is accelerated by trigram indexes
+// and compares similarity with pg_trgm.similarity_threshold setting (0.3 by default).
+// The result can be passed as a filter to GetPageByFilter, GetListByFilter and similar methods.
+//   - column        a text column to compare
+//   - value         a text to look for
+//   - threshold     a minimum similarity between 0 and 1, or 0 to use the server setting
+// Returns the composed filter.
+func (c *PostgresPersistence) ComposeSimilarityFilter(column string, value string, threshold float64) string {
+	if threshold <= 0 {
+		return c.QuoteIdentifier(column) + " % " + c.QuoteLiteral(value)
+	}
+	return "similarity(" + c.QuoteIdentifier(column) + ", " + c.QuoteLiteral(value) + ") >= " +
+		strconv.FormatFloat(threshold, 'f', -1, 64)
+}
+
+// Composes a sort that orders rows from the most similar to the given text.
+// Use it together with ComposeSimilarityFilter for "did you mean" style lookups.
+//   - column        a text column to compare
+//   - value         a text to look for
+// Returns the composed sort.
+func (c *PostgresPersistence) ComposeSimilaritySort(column string, value string) string {
+	return "similarity(" + c.QuoteIdentifier(column) + ", " + c.QuoteLiteral(value) + ") DESC"
+}
@@ -0,0 +1,179 @@
+package persistence
+
+import (
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+)
+
+// Transaction prepared for two-phase commit that waits to be committed or rolled back.
+type PostgresPreparedTransaction struct {
+	// Global transaction id given to PrepareTransaction
+	Gid string
+	// Time the transaction was prepared
+	Prepared time.Time
+	// Name of the user that prepared the transaction
+	Owner string
+}
+
+// Runs an action in a transaction and prepares the transaction for two-phase commit.
+// Changes of the prepared transaction survive restarts of the server and become visible after CommitPrepared.
+// It is used to coordinate the database with another resource that supports two-phase commit.
+// The server must allow prepared transactions by max_prepared_transactions setting.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - gid               a unique global transaction id, at most 200 characters
+//   - action            an action that runs statements within the transaction
+// Returns error or nil when the transaction was prepared. On errors the transaction is rolled back.
+func (c *PostgresPersistence) PrepareTransaction(correlationId string, gid string,
+	action func(tx pgx.Tx) error) (err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkPreparedTransaction(correlationId, gid); err != nil {
+		return err
+	}
+
+	tx, err := c.Client.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err = action(tx); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(ctx, "PREPARE TRANSACTION "+c.QuoteLiteral(gid)); err != nil {
+		return err
+	}
+	// The session has left the transaction, it is only marked as closed
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	c.Logger.Trace(correlationId, "Prepared transaction %s", gid)
+	return nil
+}
+
+// Commits a transaction prepared by PrepareTransaction. It can be called from another session or process.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - gid               a global transaction id
+// Returns error or nil no errors occured.
+func (c *PostgresPersistence) CommitPrepared(correlationId string, gid string) error {
+	return c.finishPrepared(correlationId, gid, "COMMIT PREPARED ")
+}
+
+// Rolls back a transaction prepared by PrepareTransaction. It can be called from another session or process.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - gid               a global transaction id
+// Returns error or nil no errors occured.
+func (c *PostgresPersistence) RollbackPrepared(correlationId string, gid string) error {
+	return c.finishPrepared(correlationId, gid, "ROLLBACK PREPARED ")
+}
+
+// Gets transactions of the database that are prepared and not finished yet.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - prefix            (optional) a prefix of global transaction ids, e.g. a service name
+// Returns prepared transactions sorted by the time they were prepared or error.
+func (c *PostgresPersistence) GetPreparedTransactions(correlationId string,
+	prefix string) (items []*PostgresPreparedTransaction, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	query := "SELECT \"gid\", \"prepared\", \"owner\" FROM pg_prepared_xacts" +
+		" WHERE \"database\"=current_database() AND starts_with(\"gid\", $1) ORDER BY \"prepared\""
+	qResult, qErr := c.Client.Query(ctx, query, prefix)
+	if qErr != nil {
+		return nil, qErr
+	}
+	defer qResult.Close()
+
+	items = make([]*PostgresPreparedTransaction, 0)
+	for qResult.Next() {
+		item := &PostgresPreparedTransaction{}
+		if err = qResult.Scan(&item.Gid, &item.Prepared, &item.Owner); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, qResult.Err()
+}
+
+// Scans prepared transactions left by failed coordinators and finishes them.
+// It is called on startup of a coordinator to resolve transactions it has prepared before a crash.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - prefix            (optional) a prefix of global transaction ids owned by the coordinator
+//   - minAge            a minimum time since preparation, to skip transactions of commits in progress
+//   - decide            a function that returns true to commit a transaction or false to roll it back,
+//                       usually by the outcome recorded by the other resource
+// Returns a number of finished transactions or error.
+func (c *PostgresPersistence) RecoverPreparedTransactions(correlationId string, prefix string, minAge time.Duration,
+	decide func(item *PostgresPreparedTransaction) (bool, error)) (int, error) {
+	items, err := c.GetPreparedTransactions(correlationId, prefix)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, item := range items {
+		if time.Since(item.Prepared) < minAge {
+			continue
+		}
+		commit, dErr := decide(item)
+		if dErr != nil {
+			return count, dErr
+		}
+		if commit {
+			err = c.CommitPrepared(correlationId, item.Gid)
+		} else {
+			err = c.RollbackPrepared(correlationId, item.Gid)
+		}
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	if count > 0 {
+		c.Logger.Info(correlationId, "Recovered %d prepared transactions", count)
+	}
+	return count, nil
+}
+
+// Finishes a prepared transaction with COMMIT PREPARED or ROLLBACK PREPARED
+func (c *PostgresPersistence) finishPrepared(correlationId string, gid string, statement string) (err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkPreparedTransaction(correlationId, gid); err != nil {
+		return err
+	}
+
+	if _, err = c.Client.Exec(ctx, statement+c.QuoteLiteral(gid)); err != nil {
+		return err
+	}
+	c.Logger.Trace(correlationId, "Finished prepared transaction %s", gid)
+	return nil
+}
+
+// Checks if prepared transactions can be used
+func (c *PostgresPersistence) checkPreparedTransaction(correlationId string, gid string) error {
+	if gid == "" || len(gid) > 200 {
+		return cerr.NewBadRequestError(correlationId, "INVALID_GID",
+			"Global transaction id must have from 1 to 200 characters").
+			WithDetails("gid", gid)
+	}
+	if err := c.checkReadOnly(correlationId); err != nil {
+		return err
+	}
+	return c.checkNotDryRun(correlationId)
+}
@@ -0,0 +1,64 @@
+package persistence
+
+import (
+	"fmt"
+	"reflect"
+
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+)
+
+// Copies data items of a page into a typed slice, so callers do not type-assert
+// every element of []interface{}. The module targets Go versions without type parameters,
+// so the typed slice is passed by pointer, like a target of json.Unmarshal.
+// Items can be copied into a slice of the prototype type or a slice of pointers to it,
+// e.g. var items []MyData; total, err := persist.CopyPageData(page, &items)
+//   - page      a data page to copy items from
+//   - target    a pointer to a slice, e.g. *[]MyData or *[]*MyData
+// Returns the total of the page or error when items do not match the slice type.
+func CopyPageData(page *cdata.DataPage, target interface{}) (total *int64, err error) {
+	slice := reflect.ValueOf(target)
+	if slice.Kind() != reflect.Ptr || slice.Elem().Kind() != reflect.Slice {
+		return nil, fmt.Errorf("target must be a pointer to slice, got %T", target)
+	}
+	slice = slice.Elem()
+	elemType := slice.Type().Elem()
+
+	if page == nil {
+		slice.Set(reflect.MakeSlice(slice.Type(), 0, 0))
+		return nil, nil
+	}
+
+	result := reflect.MakeSlice(slice.Type(), 0, len(page.Data))
+	for index, item := range page.Data {
+		value, ok := convertPageItem(item, elemType)
+		if !ok {
+			return nil, fmt.Errorf("item %d of type %T cannot be copied into %s", index, item, elemType)
+		}
+		result = reflect.Append(result, value)
+	}
+	slice.Set(result)
+	return page.Total, nil
+}
+
+// Converts a page item to an element type taking or dereferencing pointers when needed
+func convertPageItem(item interface{}, elemType reflect.Type) (reflect.Value, bool) {
+	if item == nil {
+		if elemType.Kind() == reflect.Ptr || elemType.Kind() == reflect.Interface || elemType.Kind() == reflect.Map {
+			return reflect.Zero(elemType), true
+		}
+		return reflect.Value{}, false
+	}
+
+	value := reflect.ValueOf(item)
+	switch {
+	case value.Type().AssignableTo(elemType):
+		return value, true
+	case elemType.Kind() == reflect.Ptr && value.Type().AssignableTo(elemType.Elem()):
+		pointer := reflect.New(elemType.Elem())
+		pointer.Elem().Set(value)
+		return pointer, true
+	case value.Kind() == reflect.Ptr && !value.IsNil() && value.Elem().Type().AssignableTo(elemType):
+		return value.Elem(), true
+	}
+	return reflect.Value{}, false
+}
@@ -0,0 +1,194 @@
+package persistence
+
+import (
+	"strconv"
+	"strings"
+
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+)
+
+// Distances supported by pgvector similarity search
+const (
+	VectorDistanceL2           = "l2"
+	VectorDistanceCosine       = "cosine"
+	VectorDistanceInnerProduct = "inner_product"
+)
+
+// Adds statements to enable pgvector extension and to add an embedding column
+// to the table on opening. The column is used later by SearchBySimilarity.
+// Must be called in DefineSchema after the table definition.
+//   - name          a name of the vector column
+//   - dimensions    a number of dimensions in embeddings
+func (c *PostgresPersistence) EnsureVectorColumn(name string, dimensions int) {
+	c.EnsureSchema("CREATE EXTENSION IF NOT EXISTS vector")
+	c.EnsureSchema("ALTER TABLE " + c.QuotedTableName() + " ADD COLUMN IF NOT EXISTS " +
+		c.QuoteIdentifier(name) + " vector(" + strconv.Itoa(dimensions) + ")")
+	c.vectorColumn = name
+}
+
+// Adds a vector index definition over the column defined by EnsureVectorColumn
+// to create it on opening. The index operator class follows the configured distance.
+// It must be called after EnsureVectorColumn, otherwise Open returns ConfigError.
+//   - name      an index name
+//   - method    an index method: "hnsw" or "ivfflat" (default: "hnsw")
+//   - options   index storage parameters like "m", "ef_construction" for hnsw or "lists" for ivfflat
+func (c *PostgresPersistence) EnsureVectorIndex(name string, method string, options map[string]string) {
+	if c.vectorColumn == "" {
+		c.schemaErr = cerr.NewConfigError("", "NO_VECTOR_COLUMN",
+			"Vector index "+name+" is defined before the vector column. Call EnsureVectorColumn first").
+			WithDetails("index", name)
+		return
+	}
+	if method == "" {
+		method = "hnsw"
+	}
+
+	builder := "CREATE INDEX IF NOT EXISTS " + c.QuoteIdentifier(name) + " ON " + c.QuotedTableName() +
+		" USING " + method + " (" + c.QuoteIdentifier(c.vectorColumn) + " " + c.vectorOperatorClass() + ")"
+
+	params := ""
+	for key, value := range options {
+		if params != "" {
+			params += ", "
+		}
+		params += key + " = " + value
+	}
+	if params != "" {
+		builder += " WITH (" + params + ")"
+	}
+
+	c.EnsureSchema(builder)
+}
+
+// Gets a list of data items with embeddings most similar to the given one.
+// Items are ordered by distance configured in options.vector_distance.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - embedding         an embedding to compare with
+//   - topK              a maximum number of items to return, 0 for no limit
+//   - filter            (optional) a filter string with SQL conditions
+// Returns              a data list or error. BadRequestError is returned when topK is negative
+//                      or the filter is not a string.
+func (c *PostgresPersistence) SearchBySimilarity(correlationId string, embedding []float32, topK int,
+	filter interface{}) (items []interface{}, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if c.vectorColumn == "" {
+		return nil, cerr.NewInvalidStateError(correlationId, "NO_VECTOR_COLUMN",
+			"Vector column is not defined. Call EnsureVectorColumn in DefineSchema")
+	}
+	if topK < 0 {
+		return nil, cerr.NewBadRequestError(correlationId, "INVALID_TOP_K",
+			"Number of similar items cannot be negative").
+			WithDetails("top_k", topK)
+	}
+
+	condition := ""
+	if filter != nil {
+		flt, ok := filter.(string)
+		if !ok {
+			return nil, cerr.NewBadRequestError(correlationId, "INVALID_FILTER",
+				"Filter of similarity search must be a string").
+				WithDetails("filter", filter)
+		}
+		condition = flt
+	}
+
+	query := "SELECT * FROM " + c.QuotedTableName() + c.composeWhere(condition)
+
+	query += " ORDER BY " + c.QuoteIdentifier(c.vectorColumn) + " " + c.vectorDistanceOperator() + " $1::vector"
+
+	if topK > 0 {
+		query += " LIMIT " + strconv.Itoa(topK)
+	}
+
+	qResult, qErr := c.ReadClient.Query(ctx, query, VectorToString(embedding))
+	if qErr != nil {
+		return nil, qErr
+	}
+	defer qResult.Close()
+
+	items = make([]interface{}, 0, topK)
+	skipped := 0
+	for qResult.Next() {
+		item, convErr := c.ConvertRowToPublic(correlationId, qResult)
+		if convErr != nil {
+			return nil, convErr
+		}
+		if item == nil {
+			skipped++
+			continue
+		}
+		items = append(items, item)
+	}
+	c.logSkippedRows(correlationId, skipped, len(items))
+
+	c.Logger.Trace(correlationId, "Retrieved %d similar items from %s", len(items), c.TableName)
+	return items, qResult.Err()
+}
+
+func (c *PostgresPersistence) vectorDistanceOperator() string {
+	switch c.vectorDistance {
+	case VectorDistanceL2:
+		return "<->"
+	case VectorDistanceInnerProduct:
+		return "<#>"
+	default:
+		return "<=>"
+	}
+}
+
+func (c *PostgresPersistence) vectorOperatorClass() string {
+	switch c.vectorDistance {
+	case VectorDistanceL2:
+		return "vector_l2_ops"
+	case VectorDistanceInnerProduct:
+		return "vector_ip_ops"
+	default:
+		return "vector_cosine_ops"
+	}
+}
+
+// Converts an embedding into pgvector text representation like "[1,2,3]".
+// Use it in ConvertFromPublic to write embeddings into vector columns.
+//   - embedding     an embedding to convert
+// Returns pgvector text value
+func VectorToString(embedding []float32) string {
+	builder := strings.Builder{}
+	builder.WriteString("[")
+	for index, value := range embedding {
+		if index > 0 {
+			builder.WriteString(",")
+		}
+		builder.WriteString(strconv.FormatFloat(float64(value), 'f', -1, 32))
+	}
+	builder.WriteString("]")
+	return builder.String()
+}
+
+// Parses pgvector text representation like "[1,2,3]" into an embedding.
+// Use it in ConvertToPublic to read embeddings from vector columns.
+//   - value     a pgvector text value
+// Returns parsed embedding or error
+func ParseVector(value string) ([]float32, error) {
+	value = strings.TrimSpace(value)
+	value = strings.TrimPrefix(value, "[")
+	value = strings.TrimSuffix(value, "]")
+	if value == "" {
+		return []float32{}, nil
+	}
+
+	parts := strings.Split(value, ",")
+	result := make([]float32, len(parts))
+	for index, part := range parts {
+		number, err := strconv.ParseFloat(strings.TrimSpace(part), 32)
+		if err != nil {
+			return nil, err
+		}
+		result[index] = float32(number)
+	}
+	return result, nil
+}
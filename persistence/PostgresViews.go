@@ -0,0 +1,40 @@
+package persistence
+
+import (
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+)
+
+// Adds a statement to create a view on opening and defines the persistence over the view.
+// Views are useful for denormalized read models that span joins of several tables.
+// The persistence becomes read-only: operations that change data return UnsupportedError.
+// Must be called in DefineSchema after tables used by the view are defined.
+//   - definition    a SELECT query of the view
+func (c *PostgresPersistence) EnsureView(definition string) {
+	c.view = true
+	c.EnsureSchema("CREATE OR REPLACE VIEW " + c.QuotedTableName() + " AS " + definition)
+}
+
+// Checks if the persistence is defined over a view and rejects changes of data.
+// Returns true if the persistence is read-only and false otherwise.
+func (c *PostgresPersistence) IsReadOnly() bool {
+	return c.view || c.readonly
+}
+
+// Returns an error for operations that change data of read-only persistences
+func (c *PostgresPersistence) checkWritable(correlationId string) error {
+	if err := c.checkReadOnly(correlationId); err != nil {
+		return err
+	}
+	if !c.view {
+		return nil
+	}
+	return cerr.NewUnsupportedError(correlationId, "READ_ONLY", "View "+c.TableName+" is read-only").
+		WithDetails("view", c.TableName)
+}
+
+// Checks if data of the persistence can be changed by a component built on it, like a credential store.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+// Returns UnsupportedError for views, InvalidStateError for read-only persistences or nil otherwise.
+func (c *PostgresPersistence) CheckWritable(correlationId string) error {
+	return c.checkWritable(correlationId)
+}
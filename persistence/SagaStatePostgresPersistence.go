@@ -0,0 +1,344 @@
+package persistence
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+)
+
+const (
+	// Saga is executing its steps
+	SagaStatusRunning = "running"
+	// Saga is rolling back completed steps
+	SagaStatusCompensating = "compensating"
+	// Saga finished successfully
+	SagaStatusCompleted = "completed"
+	// Saga failed and can not be continued
+	SagaStatusFailed = "failed"
+)
+
+// State of a saga (workflow) instance.
+type SagaState struct {
+	// Unique id of the saga instance
+	Id string `json:"id"`
+	// Type of the saga that defines its steps
+	SagaType string `json:"saga_type"`
+	// Current status: running, compensating, completed or failed
+	Status string `json:"status"`
+	// Index of the current step
+	Step int `json:"step"`
+	// Saga data stored as JSON
+	Payload interface{} `json:"payload"`
+	// Time when the saga times out, nil if the saga has no timeout
+	TimeoutTime *time.Time `json:"timeout_time"`
+	// Owner that claimed the saga for processing
+	LockedBy *string `json:"locked_by"`
+	// Time until the claim is valid
+	LockedUntil *time.Time `json:"locked_until"`
+	// Time when the saga was started
+	CreateTime time.Time `json:"create_time"`
+	// Time of the last saga update
+	UpdateTime time.Time `json:"update_time"`
+}
+
+/*
+Persistence component that stores saga (workflow) instances in PostgreSQL
+so orchestrator services can coordinate multi-step transactions.
+
+Active sagas are claimed for processing with SELECT ... FOR UPDATE SKIP LOCKED,
+so multiple orchestrator instances can work on the same table. A claim is valid
+for a lock timeout; if the owner does not save progress or release the saga
+before it expires, the saga can be claimed by another owner.
+
+### Configuration parameters ###
+
+- table:                       (optional) PostgreSQL table name
+- schema:                      (optional) PostgreSQL schema, default "public"
+- connection(s):
+   - discovery_key:             (optional) a key to retrieve the connection from IDiscovery
+   - host:                      host name or IP address
+   - port:                      port number (default: 5432)
+   - uri:                       resource URI or connection string with all parameters in it
+- credential(s):
+   - store_key:                 (optional) a key to retrieve the credentials from ICredentialStore
+   - username:                  (optional) user name
+   - password:                  (optional) user password
+
+### References ###
+
+- \*:logger:\*:\*:1.0           (optional) ILogger components to pass log messages
+- \*:discovery:\*:\*:1.0        (optional) IDiscovery services
+- \*:credential-store:\*:\*:1.0 (optional) Credential stores to resolve credentials
+
+### Example ###
+
+    persistence := NewSagaStatePostgresPersistence("sagas")
+    ...
+    saga, err := persistence.Start("123", "order_checkout", payload, 10*time.Minute)
+
+    sagas, err := persistence.ClaimActive("123", "worker1", 30*time.Second, 10)
+    for _, saga := range sagas {
+        // Execute the next step
+        saga, err = persistence.SaveProgress("123", saga.Id, "worker1", saga.Step+1, SagaStatusRunning, saga.Payload)
+    }
+*/
+type SagaStatePostgresPersistence struct {
+	*IdentifiablePostgresPersistence
+}
+
+// Creates a new instance of the saga state persistence.
+//   - tableName    a table name for sagas.
+func NewSagaStatePostgresPersistence(tableName string) *SagaStatePostgresPersistence {
+	c := &SagaStatePostgresPersistence{}
+	c.IdentifiablePostgresPersistence = InheritIdentifiablePostgresPersistence(c, reflect.TypeOf(SagaState{}), tableName)
+	return c
+}
+
+// Configures component by passing configuration parameters.
+// Column tenancy is not supported, as the tables have no tenant column: Open returns ConfigError.
+//   - config    configuration parameters to be set.
+func (c *SagaStatePostgresPersistence) Configure(config *cconf.ConfigParams) {
+	c.IdentifiablePostgresPersistence.Configure(config)
+	c.rejectColumnTenancy()
+}
+
+// Defines a database schema for sagas
+func (c *SagaStatePostgresPersistence) DefineSchema() {
+	c.ClearSchema()
+	c.IdentifiablePostgresPersistence.DefineSchema()
+	c.EnsureSchema("CREATE TABLE IF NOT EXISTS " + c.QuotedTableName() +
+		" (\"id\" TEXT PRIMARY KEY, \"saga_type\" TEXT NOT NULL, \"status\" TEXT NOT NULL, \"step\" INTEGER NOT NULL DEFAULT 0," +
+		" \"payload\" JSONB, \"timeout_time\" TIMESTAMPTZ, \"locked_by\" TEXT, \"locked_until\" TIMESTAMPTZ," +
+		" \"create_time\" TIMESTAMPTZ NOT NULL DEFAULT now(), \"update_time\" TIMESTAMPTZ NOT NULL DEFAULT now())")
+	c.EnsureSchema("CREATE INDEX IF NOT EXISTS " + c.QuoteIdentifier(c.TableName+"_status") +
+		" ON " + c.QuotedTableName() + " (\"status\", \"update_time\")")
+}
+
+// Starts a new saga instance in running status.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - sagaType          a type of the saga.
+//   - payload           initial saga data.
+//   - timeout           a time for the saga to complete, 0 for no timeout.
+// Returns the started saga or error.
+func (c *SagaStatePostgresPersistence) Start(correlationId string, sagaType string,
+	payload interface{}, timeout time.Duration) (*SagaState, error) {
+
+	if err := c.checkWritable(correlationId); err != nil {
+		return nil, err
+	}
+	if err := c.checkNotDryRun(correlationId); err != nil {
+		return nil, err
+	}
+
+	var item interface{} = SagaState{SagaType: sagaType, Status: SagaStatusRunning, Payload: payload}
+	c.GenerateObjectId(&item)
+	saga := item.(SagaState)
+
+	buffer, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var timeoutTime *time.Time
+	if timeout > 0 {
+		t := time.Now().Add(timeout)
+		timeoutTime = &t
+	}
+
+	query := "INSERT INTO " + c.QuotedTableName() +
+		" (\"id\", \"saga_type\", \"status\", \"payload\", \"timeout_time\") VALUES ($1, $2, $3, $4, $5) RETURNING *"
+	result, err := c.querySaga(correlationId, query, saga.Id, sagaType, SagaStatusRunning, string(buffer), timeoutTime)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Logger.Trace(correlationId, "Started saga %s in %s with id = %s", sagaType, c.TableName, saga.Id)
+	return result, nil
+}
+
+// Claims running and compensating sagas that are not locked by other owners.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - owner             a unique name of the claiming orchestrator instance.
+//   - lockTimeout       a time the claim stays valid.
+//   - maxCount          a maximum number of sagas to claim.
+// Returns claimed sagas or error.
+func (c *SagaStatePostgresPersistence) ClaimActive(correlationId string, owner string,
+	lockTimeout time.Duration, maxCount int) ([]*SagaState, error) {
+
+	return c.claim(correlationId, "\"status\" IN ('"+SagaStatusRunning+"','"+SagaStatusCompensating+"')",
+		owner, lockTimeout, maxCount)
+}
+
+// Claims active sagas which timeout has expired so they can be compensated or failed.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - owner             a unique name of the claiming orchestrator instance.
+//   - lockTimeout       a time the claim stays valid.
+//   - maxCount          a maximum number of sagas to claim.
+// Returns claimed sagas or error.
+func (c *SagaStatePostgresPersistence) ClaimTimedOut(correlationId string, owner string,
+	lockTimeout time.Duration, maxCount int) ([]*SagaState, error) {
+
+	return c.claim(correlationId, "\"status\" IN ('"+SagaStatusRunning+"','"+SagaStatusCompensating+"')"+
+		" AND \"timeout_time\"<=now()", owner, lockTimeout, maxCount)
+}
+
+func (c *SagaStatePostgresPersistence) claim(correlationId string, filter string, owner string,
+	lockTimeout time.Duration, maxCount int) (result []*SagaState, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkWritable(correlationId); err != nil {
+		return nil, err
+	}
+	if err = c.checkNotDryRun(correlationId); err != nil {
+		return nil, err
+	}
+
+	if maxCount <= 0 {
+		maxCount = 1
+	}
+
+	query := "UPDATE " + c.QuotedTableName() +
+		" SET \"locked_by\"=$1, \"locked_until\"=now()+$2*interval '1 millisecond'" +
+		" WHERE \"id\" IN (SELECT \"id\" FROM " + c.QuotedTableName() +
+		" WHERE " + filter + " AND (\"locked_until\" IS NULL OR \"locked_until\"<now())" +
+		" ORDER BY \"update_time\" LIMIT " + strconv.Itoa(maxCount) + " FOR UPDATE SKIP LOCKED)" +
+		" RETURNING *"
+
+	qResult, qErr := c.Client.Query(ctx, query, owner, int64(lockTimeout/time.Millisecond))
+	if qErr != nil {
+		return nil, qErr
+	}
+	defer qResult.Close()
+
+	result = make([]*SagaState, 0)
+	for qResult.Next() {
+		item, convErr := c.ConvertRowToPublic(correlationId, qResult)
+		if convErr != nil {
+			return nil, convErr
+		}
+		if saga, ok := item.(SagaState); ok {
+			result = append(result, &saga)
+		}
+	}
+
+	if len(result) > 0 {
+		c.Logger.Trace(correlationId, "Claimed %d sagas from %s by %s", len(result), c.TableName, owner)
+	}
+	return result, qResult.Err()
+}
+
+// Saves progress of a claimed saga and releases the claim.
+// The saga must be claimed by the same owner, otherwise ConflictError is returned.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - id                an id of the saga.
+//   - owner             a name of the owner that claimed the saga.
+//   - step              a new step index.
+//   - status            a new saga status.
+//   - payload           updated saga data.
+// Returns the updated saga or error.
+func (c *SagaStatePostgresPersistence) SaveProgress(correlationId string, id string, owner string,
+	step int, status string, payload interface{}) (*SagaState, error) {
+
+	if err := c.checkWritable(correlationId); err != nil {
+		return nil, err
+	}
+	if err := c.checkNotDryRun(correlationId); err != nil {
+		return nil, err
+	}
+
+	buffer, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "UPDATE " + c.QuotedTableName() +
+		" SET \"step\"=$3, \"status\"=$4, \"payload\"=$5, \"locked_by\"=NULL, \"locked_until\"=NULL, \"update_time\"=now()" +
+		" WHERE \"id\"=$1 AND \"locked_by\"=$2 AND \"locked_until\">=now() RETURNING *"
+	result, err := c.querySaga(correlationId, query, id, owner, step, status, string(buffer))
+	if err == pgx.ErrNoRows {
+		return nil, c.lockConflict(correlationId, id, owner)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.Logger.Trace(correlationId, "Saved saga %s in %s at step %d with status %s", id, c.TableName, step, status)
+	return result, nil
+}
+
+// Releases the claim of a saga without changing its state.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - id                an id of the saga.
+//   - owner             a name of the owner that claimed the saga.
+// Returns error or nil for success.
+func (c *SagaStatePostgresPersistence) Release(correlationId string, id string, owner string) (err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkWritable(correlationId); err != nil {
+		return err
+	}
+	if err = c.checkNotDryRun(correlationId); err != nil {
+		return err
+	}
+
+	query := "UPDATE " + c.QuotedTableName() +
+		" SET \"locked_by\"=NULL, \"locked_until\"=NULL WHERE \"id\"=$1 AND \"locked_by\"=$2"
+	result, err := c.Client.Exec(ctx, query, id, owner)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return c.lockConflict(correlationId, id, owner)
+	}
+	return nil
+}
+
+func (c *SagaStatePostgresPersistence) lockConflict(correlationId string, id string, owner string) error {
+	return cerr.NewConflictError(correlationId, "SAGA_NOT_CLAIMED",
+		"Saga "+id+" is not claimed by "+owner).
+		WithDetails("id", id).
+		WithDetails("owner", owner)
+}
+
+func (c *SagaStatePostgresPersistence) querySaga(correlationId string, query string, args ...interface{}) (result *SagaState, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	qResult, qErr := c.Client.Query(ctx, query, args...)
+	if qErr != nil {
+		return nil, qErr
+	}
+	defer qResult.Close()
+
+	if !qResult.Next() {
+		if err := qResult.Err(); err != nil {
+			return nil, err
+		}
+		return nil, pgx.ErrNoRows
+	}
+	item, err := c.ConvertRowToPublic(correlationId, qResult)
+	if err != nil {
+		return nil, err
+	}
+	saga, ok := item.(SagaState)
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return &saga, nil
+}
@@ -0,0 +1,102 @@
+package persistence
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Id generators supported by identifiable persistence
+const (
+	IdGeneratorDefault = "default"
+	IdGeneratorUuidV7  = "uuid_v7"
+)
+
+var uuidV7Lock sync.Mutex
+var uuidV7LastTime int64
+var uuidV7Sequence uint16
+
+// Generates a new time-ordered UUID version 7 (RFC 9562).
+// The first 48 bits contain unix time in milliseconds, so ids generated later
+// are sorted after earlier ones and keep B-tree indexes compact on inserts.
+// Ids generated within the same millisecond are ordered by a counter.
+// Returns a UUID in canonical text form
+func NewUuidV7() string {
+	var buffer [16]byte
+	rand.Read(buffer[:])
+
+	uuidV7Lock.Lock()
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	if now <= uuidV7LastTime {
+		now = uuidV7LastTime
+		uuidV7Sequence++
+		if uuidV7Sequence > 0x0fff {
+			now++
+			uuidV7Sequence = 0
+		}
+	} else {
+		uuidV7Sequence = binary.BigEndian.Uint16(buffer[6:8]) & 0x07ff
+	}
+	uuidV7LastTime = now
+	sequence := uuidV7Sequence
+	uuidV7Lock.Unlock()
+
+	buffer[0] = byte(now >> 40)
+	buffer[1] = byte(now >> 32)
+	buffer[2] = byte(now >> 24)
+	buffer[3] = byte(now >> 16)
+	buffer[4] = byte(now >> 8)
+	buffer[5] = byte(now)
+	buffer[6] = 0x70 | byte(sequence>>8)
+	buffer[7] = byte(sequence)
+	buffer[8] = (buffer[8] & 0x3f) | 0x80
+
+	text := make([]byte, 36)
+	hex.Encode(text[0:8], buffer[0:4])
+	text[8] = '-'
+	hex.Encode(text[9:13], buffer[4:6])
+	text[13] = '-'
+	hex.Encode(text[14:18], buffer[6:8])
+	text[18] = '-'
+	hex.Encode(text[19:23], buffer[8:10])
+	text[23] = '-'
+	hex.Encode(text[24:], buffer[10:])
+	return string(text)
+}
+
+// Adds a statement to create uuid_generate_v7() SQL function on opening.
+// The function generates time-ordered UUIDs and can be used as a column default.
+// It requires PostgreSQL 13+ or pgcrypto extension for gen_random_uuid().
+func (c *PostgresPersistence) EnsureUuidV7Function() {
+	c.EnsureSchema("CREATE OR REPLACE FUNCTION " + c.uuidV7FunctionName() + "() RETURNS uuid AS $$\n" +
+		"DECLARE\n" +
+		"  uuid_bytes bytea;\n" +
+		"BEGIN\n" +
+		"  uuid_bytes = uuid_send(gen_random_uuid());\n" +
+		"  uuid_bytes = overlay(uuid_bytes placing substring(int8send(floor(extract(epoch FROM clock_timestamp()) * 1000)::bigint) FROM 3) FROM 1 FOR 6);\n" +
+		"  uuid_bytes = set_byte(uuid_bytes, 6, (b'0111' || get_byte(uuid_bytes, 6)::bit(4))::bit(8)::int);\n" +
+		"  RETURN encode(uuid_bytes, 'hex')::uuid;\n" +
+		"END\n" +
+		"$$ LANGUAGE plpgsql VOLATILE")
+}
+
+// Generates an id column definition with time-ordered UUID default
+// to be used in CREATE TABLE statements like: "id" UUID PRIMARY KEY DEFAULT uuid_generate_v7().
+// The function must be created by EnsureUuidV7Function before the table.
+//   - name  a name of the id column (default: "id")
+// Returns a column definition
+func (c *PostgresPersistence) UuidV7IdColumn(name string) string {
+	if name == "" {
+		name = "id"
+	}
+	return c.QuoteIdentifier(name) + " UUID PRIMARY KEY DEFAULT " + c.uuidV7FunctionName() + "()"
+}
+
+func (c *PostgresPersistence) uuidV7FunctionName() string {
+	if len(c.SchemaName) > 0 {
+		return c.QuoteIdentifier(c.SchemaName) + ".uuid_generate_v7"
+	}
+	return "uuid_generate_v7"
+}
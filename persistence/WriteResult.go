@@ -0,0 +1,237 @@
+package persistence
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/jackc/pgproto3/v2"
+	"github.com/jackc/pgx/v4"
+	cmpersist "github.com/pip-services3-go/pip-services3-data-go/persistence"
+)
+
+// PostgreSQL error code for unique constraint violation
+const uniqueViolationCode = "23505"
+
+// Name of the service column that reports if an upsert updated an existing row
+const conflictColumn = "__conflict"
+
+// Structured outcome of a write operation.
+type WriteResult struct {
+	// Number of rows affected by the operation
+	RowsAffected int64
+	// Item returned by the database after the operation, nil if no rows were affected
+	Item interface{}
+	// True if the write collided with an existing row:
+	// Create hit a unique constraint or Set replaced an existing item
+	Conflict bool
+	// Id assigned to the item by the persistence, nil if the item already had an id
+	GeneratedId interface{}
+}
+
+// Wraps query results to hide the trailing service column from conversion
+type conflictRows struct {
+	pgx.Rows
+}
+
+func (r *conflictRows) FieldDescriptions() []pgproto3.FieldDescription {
+	fields := r.Rows.FieldDescriptions()
+	return fields[:len(fields)-1]
+}
+
+func (r *conflictRows) Values() ([]interface{}, error) {
+	values, err := r.Rows.Values()
+	if err != nil || len(values) == 0 {
+		return values, err
+	}
+	return values[:len(values)-1], nil
+}
+
+func (r *conflictRows) RawValues() [][]byte {
+	values := r.Rows.RawValues()
+	if len(values) == 0 {
+		return values
+	}
+	return values[:len(values)-1]
+}
+
+func (r *conflictRows) conflict() bool {
+	values, err := r.Rows.Values()
+	if err != nil || len(values) == 0 {
+		return false
+	}
+	conflict, _ := values[len(values)-1].(bool)
+	return conflict
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr interface{ SQLState() string }
+	return errors.As(err, &pgErr) && pgErr.SQLState() == uniqueViolationCode
+}
+
+// Creates a data item and returns a structured result.
+// Unlike Create, a unique constraint violation is not an error
+// and is reported with Conflict flag.
+//   - correlation_id    (optional) transaction id to trace execution through call chain.
+//   - item              an item to be created.
+// Returns          result of the operation or error.
+func (c *IdentifiablePostgresPersistence) CreateWithResult(correlationId string, item interface{}) (result *WriteResult, err error) {
+	result = &WriteResult{}
+	if item == nil {
+		return result, nil
+	}
+
+	var newItem interface{}
+	newItem = cmpersist.CloneObject(item, c.Prototype)
+	if cmpersist.GetObjectId(newItem) == nil || cmpersist.GetObjectId(newItem) == "" {
+		c.GenerateObjectId(&newItem)
+		result.GeneratedId = cmpersist.GetObjectId(newItem)
+	}
+
+	result.Item, err = c.PostgresPersistence.Create(correlationId, newItem)
+	if isUniqueViolation(err) {
+		result.Item = nil
+		result.Conflict = true
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if result.Item != nil {
+		result.RowsAffected = 1
+	}
+	return result, nil
+}
+
+// Sets a data item and returns a structured result.
+// Conflict flag is set when an existing item was replaced.
+//   - correlation_id    (optional) transaction id to trace execution through call chain.
+//   - item              a item to be set.
+// Returns          result of the operation or error.
+func (c *IdentifiablePostgresPersistence) SetWithResult(correlationId string, item interface{}) (result *WriteResult, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkWritable(correlationId); err != nil {
+		return nil, err
+	}
+	if err = c.checkNotDryRun(correlationId); err != nil {
+		return nil, err
+	}
+	result = &WriteResult{}
+	if item == nil {
+		return result, nil
+	}
+
+	var newItem interface{}
+	newItem = cmpersist.CloneObject(item, c.Prototype)
+	if cmpersist.GetObjectId(newItem) == nil || cmpersist.GetObjectId(newItem) == "" {
+		c.GenerateObjectId(&newItem)
+		result.GeneratedId = cmpersist.GetObjectId(newItem)
+	}
+
+	row := c.injectTenant(c.Overrides.ConvertFromPublic(newItem))
+	params := c.GenerateParameters(row)
+	setParams, columns := c.GenerateSetParameters(row)
+	values := c.GenerateValues(columns, row)
+	id := cmpersist.GetObjectId(newItem)
+
+	// xmax is not zero for rows that were updated by ON CONFLICT clause
+	query := "INSERT INTO " + c.QuotedTableName() + " (" + columns + ")" +
+		" VALUES (" + params + ")" +
+		" ON CONFLICT " + c.conflictTarget(c.upsertConstraint) +
+		" DO UPDATE SET " + c.upsertSetParameters(setParams, c.upsertConstraint) + c.upsertTenantCondition() +
+		" RETURNING " + c.returningColumns("id") + ", (xmax <> 0) AS " + c.QuoteIdentifier(conflictColumn)
+
+	qResult, qErr := c.Client.Query(ctx, query, values...)
+	if qErr != nil {
+		return nil, qErr
+	}
+	defer qResult.Close()
+
+	if !qResult.Next() {
+		return result, qResult.Err()
+	}
+	rows := &conflictRows{Rows: qResult}
+	result.Item, err = c.ConvertRowToPublic(correlationId, rows)
+	if err != nil {
+		return nil, err
+	}
+	result.RowsAffected = 1
+	result.Conflict = rows.conflict()
+
+	c.Logger.Trace(correlationId, "Set in %s with id = %s", c.TableName, id)
+	return result, nil
+}
+
+// Updates a data item and returns a structured result.
+//   - correlation_id    (optional) transaction id to trace execution through call chain.
+//   - item              an item to be updated.
+// Returns          result of the operation or error.
+func (c *IdentifiablePostgresPersistence) UpdateWithResult(correlationId string, item interface{}) (result *WriteResult, err error) {
+	result = &WriteResult{}
+	if item == nil {
+		return result, nil
+	}
+
+	var newItem interface{}
+	newItem = cmpersist.CloneObject(item, c.Prototype)
+	id := cmpersist.GetObjectId(newItem)
+
+	row := c.injectTenant(c.Overrides.ConvertFromPublic(newItem))
+	params, col := c.GenerateSetParameters(row)
+	values := c.GenerateValues(col, row)
+	values = append(values, id)
+
+	query := "UPDATE " + c.QuotedTableName() +
+		" SET " + params + " WHERE \"id\"=$" + strconv.FormatInt((int64)(len(values)), 10) +
+		c.andScopeCondition() + c.returningClause("id")
+
+	return c.writeWithResult(correlationId, result, query, values...)
+}
+
+// Deletes a data item by it's unique id and returns a structured result.
+//   - correlation_id    (optional) transaction id to trace execution through call chain.
+//   - id                an id of the item to be deleted
+// Returns          result of the operation or error.
+func (c *IdentifiablePostgresPersistence) DeleteByIdWithResult(correlationId string, id interface{}) (result *WriteResult, err error) {
+	query := c.deleteStatement() + " WHERE \"id\"=$1" + c.andScopeCondition() + c.returningClause("id")
+	return c.writeWithResult(correlationId, &WriteResult{}, query, id)
+}
+
+func (c *IdentifiablePostgresPersistence) writeWithResult(correlationId string, result *WriteResult,
+	query string, values ...interface{}) (written *WriteResult, err error) {
+	ctx, done := c.beginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err := c.checkWritable(correlationId); err != nil {
+		return nil, err
+	}
+	if err := c.checkNotDryRun(correlationId); err != nil {
+		return nil, err
+	}
+
+	qResult, qErr := c.Client.Query(ctx, query, values...)
+	if qErr != nil {
+		return nil, qErr
+	}
+	defer qResult.Close()
+
+	if !qResult.Next() {
+		return result, qResult.Err()
+	}
+	item, err := c.ConvertRowToPublic(correlationId, qResult)
+	if err != nil {
+		return nil, err
+	}
+	result.Item = item
+	result.RowsAffected = 1
+
+	c.Logger.Trace(correlationId, "Written to %s with id = %s", c.TableName, cmpersist.GetObjectId(item))
+	return result, nil
+}
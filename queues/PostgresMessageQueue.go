@@ -0,0 +1,791 @@
+package queues
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cqueues "github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+)
+
+/*
+Message queue that stores messages in a PostgreSQL table and uses LISTEN/NOTIFY
+to wake up waiting receivers. Messages are consumed with SELECT ... FOR UPDATE SKIP LOCKED,
+so multiple consumers can safely read the same queue. Waiting receivers share one connection
+that listens for notifications, which is returned to the pool when nobody waits for messages.
+
+Received messages are locked for a visibility timeout. If a message is not completed
+before the timeout expires it becomes available again. Messages that were delivered
+more than max_deliveries times are moved to the dead letter state.
+
+### Configuration parameters ###
+
+- name:                        (optional) queue name, also used as a default table name
+- table:                       (optional) PostgreSQL table name
+- schema:                      (optional) PostgreSQL schema, default "public"
+- connection(s):
+   - discovery_key:             (optional) a key to retrieve the connection from IDiscovery
+   - host:                      host name or IP address
+   - port:                      port number (default: 5432)
+   - uri:                       resource URI or connection string with all parameters in it
+- credential(s):
+   - store_key:                 (optional) a key to retrieve the credentials from ICredentialStore
+   - username:                  (optional) user name
+   - password:                  (optional) user password
+- options:
+   - visibility_timeout:   (optional) number of milliseconds received messages stay locked (default: 30000)
+   - max_deliveries:       (optional) number of deliveries before a message is dead-lettered, 0 to disable (default: 5)
+   - wait_timeout:         (optional) number of milliseconds Listen waits for messages in one round (default: 5000)
+
+### References ###
+
+- \*:logger:\*:\*:1.0           (optional) ILogger components to pass log messages
+- \*:connection:postgres:\*:1.0 (optional) Shared PostgreSQL connection
+- \*:discovery:\*:\*:1.0        (optional) IDiscovery services
+- \*:credential-store:\*:\*:1.0 (optional) Credential stores to resolve credentials
+
+### Example ###
+
+    queue := queues.NewPostgresMessageQueue("myqueue")
+    queue.Configure(cconf.NewConfigParamsFromTuples(
+        "connection.host", "localhost",
+        "connection.port", 5432,
+        "connection.database", "test",
+    ))
+    err := queue.Open("123")
+    ...
+    queue.Send("123", cqueues.NewMessageEnvelope("", "mymessage", []byte("ABC")))
+    message, err := queue.Receive("123", 10 * time.Second)
+    if message != nil {
+        ...
+        queue.Complete(message)
+    }
+*/
+type PostgresMessageQueue struct {
+	*persist.PostgresPersistence
+
+	name              string
+	capabilities      *cqueues.MessagingCapabilities
+	visibilityTimeout time.Duration
+	maxDeliveries     int
+	waitTimeout       time.Duration
+
+	listenLock     sync.Mutex
+	listening      bool
+	waitCtx        context.Context
+	waitCancel     context.CancelFunc
+	waiters        int
+	listenerActive bool
+	notified       chan struct{}
+}
+
+// Creates a new instance of the message queue.
+//   - name  (optional) a queue name.
+func NewPostgresMessageQueue(name string) *PostgresMessageQueue {
+	if name == "" {
+		name = "messages"
+	}
+
+	c := &PostgresMessageQueue{
+		name:              name,
+		capabilities:      cqueues.NewMessagingCapabilities(true, true, true, true, true, true, true, true, true),
+		visibilityTimeout: 30000 * time.Millisecond,
+		maxDeliveries:     5,
+		waitTimeout:       5000 * time.Millisecond,
+		notified:          make(chan struct{}),
+	}
+	c.PostgresPersistence = persist.InheritPostgresPersistence(c, reflect.TypeOf(cqueues.MessageEnvelope{}), name)
+	return c
+}
+
+// Configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *PostgresMessageQueue) Configure(config *cconf.ConfigParams) {
+	c.name = config.GetAsStringWithDefault("name", c.name)
+	c.SetBaseTableName(c.name)
+	c.PostgresPersistence.Configure(config)
+
+	c.visibilityTimeout = time.Duration(config.GetAsLongWithDefault("options.visibility_timeout",
+		int64(c.visibilityTimeout/time.Millisecond))) * time.Millisecond
+	c.maxDeliveries = config.GetAsIntegerWithDefault("options.max_deliveries", c.maxDeliveries)
+	c.waitTimeout = time.Duration(config.GetAsLongWithDefault("options.wait_timeout",
+		int64(c.waitTimeout/time.Millisecond))) * time.Millisecond
+}
+
+// Defines a database schema for the queue table
+func (c *PostgresMessageQueue) DefineSchema() {
+	c.ClearSchema()
+	c.PostgresPersistence.DefineSchema()
+	c.EnsureSchema("CREATE TABLE IF NOT EXISTS " + c.QuotedTableName() + " (" +
+		"\"id\" TEXT PRIMARY KEY, " +
+		"\"correlation_id\" TEXT, " +
+		"\"message_type\" TEXT, " +
+		"\"message\" BYTEA, " +
+		"\"sent_time\" TIMESTAMPTZ NOT NULL DEFAULT now(), " +
+		"\"locked_until\" TIMESTAMPTZ, " +
+		"\"lock_token\" TEXT, " +
+		"\"deliveries\" INTEGER NOT NULL DEFAULT 0, " +
+		"\"dead_letter\" BOOLEAN NOT NULL DEFAULT FALSE)")
+	c.EnsureSchema("CREATE INDEX IF NOT EXISTS " + c.QuoteIdentifier(c.TableName+"_receive") +
+		" ON " + c.QuotedTableName() + " (\"dead_letter\", \"sent_time\")")
+}
+
+// Converts a queue table row into a message envelope.
+//   - rows  a current row
+// Returns a message envelope with a lock token as a reference.
+func (c *PostgresMessageQueue) ConvertToPublic(rows pgx.Rows) interface{} {
+	values, valErr := rows.Values()
+	if valErr != nil || values == nil {
+		return nil
+	}
+
+	message := &cqueues.MessageEnvelope{}
+	for index, column := range rows.FieldDescriptions() {
+		value := values[index]
+		switch string(column.Name) {
+		case "id":
+			message.MessageId, _ = value.(string)
+		case "correlation_id":
+			message.CorrelationId, _ = value.(string)
+		case "message_type":
+			message.MessageType, _ = value.(string)
+		case "message":
+			message.Message, _ = value.([]byte)
+		case "sent_time":
+			message.SentTime, _ = value.(time.Time)
+		case "lock_token":
+			if token, ok := value.(string); ok {
+				message.SetReference(token)
+			}
+		}
+	}
+	return message
+}
+
+// Gets the queue name
+func (c *PostgresMessageQueue) Name() string {
+	return c.name
+}
+
+// Gets the queue capabilities
+func (c *PostgresMessageQueue) Capabilities() *cqueues.MessagingCapabilities {
+	return c.capabilities
+}
+
+// Opens the component.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+//   - Returns 			error or nil no errors occured.
+func (c *PostgresMessageQueue) Open(correlationId string) error {
+	c.listenLock.Lock()
+	if c.waitCtx == nil || c.waitCtx.Err() != nil {
+		c.waitCtx, c.waitCancel = context.WithCancel(context.Background())
+	}
+	c.listenLock.Unlock()
+	return c.PostgresPersistence.Open(correlationId)
+}
+
+// Closes component and frees used resources.
+// Receivers waiting for messages are interrupted, so they release their connections.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+//   - Returns 			error or nil no errors occured.
+func (c *PostgresMessageQueue) Close(correlationId string) error {
+	c.EndListen(correlationId)
+	c.listenLock.Lock()
+	if c.waitCancel != nil {
+		c.waitCancel()
+	}
+	c.listenLock.Unlock()
+	return c.PostgresPersistence.Close(correlationId)
+}
+
+func (c *PostgresMessageQueue) checkOpen(correlationId string) error {
+	if !c.IsOpen() {
+		return cerr.NewInvalidStateError(correlationId, "NOT_OPENED", "The queue is not opened")
+	}
+	return nil
+}
+
+func (c *PostgresMessageQueue) channelName() string {
+	return c.QuoteIdentifier(c.TableName)
+}
+
+// Reads the current number of messages in the queue to be delivered.
+// Returns number of messages or error.
+func (c *PostgresMessageQueue) ReadMessageCount() (count int64, err error) {
+	if err = c.checkOpen(""); err != nil {
+		return 0, err
+	}
+	return c.GetCountByFilter("", "\"dead_letter\"=FALSE")
+}
+
+// Sends a message into the queue and notifies waiting receivers.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - envelope          a message envelop to be sent.
+// Returns error or nil for success.
+func (c *PostgresMessageQueue) Send(correlationId string, envelope *cqueues.MessageEnvelope) (err error) {
+	ctx, done := c.BeginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkOpen(correlationId); err != nil {
+		return err
+	}
+
+	if envelope.MessageId == "" {
+		envelope.MessageId = cdata.IdGenerator.NextLong()
+	}
+	if envelope.CorrelationId == "" {
+		envelope.CorrelationId = correlationId
+	}
+	envelope.SentTime = time.Now().UTC()
+
+	query := "INSERT INTO " + c.QuotedTableName() +
+		" (\"id\", \"correlation_id\", \"message_type\", \"message\", \"sent_time\") VALUES ($1,$2,$3,$4,$5)"
+	_, err = c.Client.Exec(ctx, query, envelope.MessageId, envelope.CorrelationId,
+		envelope.MessageType, envelope.Message, envelope.SentTime)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Client.Exec(ctx, "SELECT pg_notify($1, $2)", c.TableName, envelope.MessageId)
+	if err != nil {
+		return err
+	}
+
+	c.Logger.Debug(envelope.CorrelationId, "Sent message %s via %s", envelope.MessageId, c.name)
+	return nil
+}
+
+// Sends an object into the queue serialized as JSON.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - messageType       a message type
+//   - message           an object value to be sent
+// Returns error or nil for success.
+func (c *PostgresMessageQueue) SendAsObject(correlationId string, messageType string, message interface{}) error {
+	buffer, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	envelope := &cqueues.MessageEnvelope{
+		CorrelationId: correlationId,
+		MessageType:   messageType,
+		Message:       buffer,
+	}
+	return c.Send(correlationId, envelope)
+}
+
+// Peeks a single incoming message from the queue without removing it.
+// If there are no messages available in the queue it returns nil.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+// Returns a message or error.
+func (c *PostgresMessageQueue) Peek(correlationId string) (result *cqueues.MessageEnvelope, err error) {
+	messages, err := c.PeekBatch(correlationId, 1)
+	if err != nil || len(messages) == 0 {
+		return nil, err
+	}
+	return messages[0], nil
+}
+
+// Peeks multiple incoming messages from the queue without removing them.
+// If there are no messages available in the queue it returns an empty list.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - messageCount      a maximum number of messages to peek.
+// Returns a list of messages or error.
+func (c *PostgresMessageQueue) PeekBatch(correlationId string, messageCount int64) (result []*cqueues.MessageEnvelope, err error) {
+	ctx, done := c.BeginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkOpen(correlationId); err != nil {
+		return nil, err
+	}
+
+	query := "SELECT \"id\", \"correlation_id\", \"message_type\", \"message\", \"sent_time\" FROM " + c.QuotedTableName() +
+		" WHERE \"dead_letter\"=FALSE AND (\"locked_until\" IS NULL OR \"locked_until\"<now())" +
+		" ORDER BY \"sent_time\" LIMIT " + strconv.FormatInt(messageCount, 10)
+
+	qResult, qErr := c.Client.Query(ctx, query)
+	if qErr != nil {
+		return nil, qErr
+	}
+	defer qResult.Close()
+
+	result = make([]*cqueues.MessageEnvelope, 0, messageCount)
+	for qResult.Next() {
+		if message, ok := c.ConvertToPublic(qResult).(*cqueues.MessageEnvelope); ok {
+			result = append(result, message)
+		}
+	}
+
+	c.Logger.Trace(correlationId, "Peeked %d messages from %s", len(result), c.name)
+	return result, qResult.Err()
+}
+
+// Receives an incoming message and locks it for the visibility timeout.
+// The message must be completed, abandoned or moved to dead letter afterwards.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - waitTimeout       a timeout to wait for a message to come.
+// Returns a message or nil if nothing arrived within the timeout.
+func (c *PostgresMessageQueue) Receive(correlationId string, waitTimeout time.Duration) (result *cqueues.MessageEnvelope, err error) {
+	if err = c.checkOpen(correlationId); err != nil {
+		return nil, err
+	}
+
+	// Take the notification signal before checking the table, so messages sent in between are not missed
+	notified := c.startWaiting(correlationId)
+	defer c.stopWaiting()
+
+	waitCtx := c.waitContext()
+	deadline := time.Now().Add(waitTimeout)
+	for {
+		result, err = c.claimMessage(correlationId)
+		if err != nil || result != nil {
+			return result, err
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, nil
+		}
+
+		timer := time.NewTimer(remaining)
+		select {
+		case <-waitCtx.Done():
+			// The queue is closing
+			timer.Stop()
+			return nil, nil
+		case <-timer.C:
+			return nil, nil
+		case <-notified:
+			timer.Stop()
+		}
+		notified = c.notification()
+	}
+}
+
+// Registers a waiting receiver and starts the shared listener if it is not running.
+// Returns a channel that is closed on the next notification.
+func (c *PostgresMessageQueue) startWaiting(correlationId string) <-chan struct{} {
+	c.listenLock.Lock()
+	defer c.listenLock.Unlock()
+
+	c.waiters++
+	if !c.listenerActive {
+		c.listenerActive = true
+		go c.runListener(correlationId)
+	}
+	return c.notified
+}
+
+func (c *PostgresMessageQueue) stopWaiting() {
+	c.listenLock.Lock()
+	defer c.listenLock.Unlock()
+	c.waiters--
+}
+
+// Gets a channel that is closed on the next notification
+func (c *PostgresMessageQueue) notification() <-chan struct{} {
+	c.listenLock.Lock()
+	defer c.listenLock.Unlock()
+	return c.notified
+}
+
+// Wakes up all waiting receivers to check the table
+func (c *PostgresMessageQueue) notify() {
+	c.listenLock.Lock()
+	defer c.listenLock.Unlock()
+	close(c.notified)
+	c.notified = make(chan struct{})
+}
+
+// Stops the shared listener if nobody waits for messages.
+// Returns true if the listener was stopped.
+func (c *PostgresMessageQueue) stopIdleListener() bool {
+	c.listenLock.Lock()
+	defer c.listenLock.Unlock()
+	if c.waiters > 0 {
+		return false
+	}
+	c.listenerActive = false
+	return true
+}
+
+// Runs the shared listener until nobody waits for messages or the queue is closed.
+// Failed connections are reopened with a growing delay.
+func (c *PostgresMessageQueue) runListener(correlationId string) {
+	waitCtx := c.waitContext()
+	backoff := c.backoffPolicy()
+	for attempt := 1; ; attempt++ {
+		err := c.listenNotifications(correlationId, waitCtx)
+		if err == nil {
+			return
+		}
+		c.Logger.Error(correlationId, err, "Failed to listen notifications at %s", c.name)
+		if !c.sleep(waitCtx, backoff.GetDelay(attempt)) {
+			c.markListenerStopped()
+			return
+		}
+		if c.stopIdleListener() {
+			return
+		}
+	}
+}
+
+// Marks the listener stopped when the queue is closing
+func (c *PostgresMessageQueue) markListenerStopped() {
+	c.listenLock.Lock()
+	defer c.listenLock.Unlock()
+	c.listenerActive = false
+}
+
+// Listens for notifications on one connection and wakes up waiting receivers.
+// Returns nil when the listener stopped being idle or the queue is closed, or error of the connection.
+func (c *PostgresMessageQueue) listenNotifications(correlationId string, waitCtx context.Context) error {
+	conn, err := c.listen(correlationId)
+	if err != nil {
+		if waitCtx.Err() != nil {
+			c.markListenerStopped()
+			return nil
+		}
+		return err
+	}
+
+	// Receivers check the table again for messages sent before LISTEN
+	c.notify()
+
+	for {
+		ctx, cancel := context.WithTimeout(waitCtx, c.waitTimeout)
+		_, err = conn.Conn().WaitForNotification(ctx)
+		roundErr := ctx.Err()
+		cancel()
+
+		if waitCtx.Err() != nil {
+			// The queue is closing, the connection was interrupted in an unknown state
+			c.markListenerStopped()
+			c.closeListenConnection(conn)
+			return nil
+		}
+		if err == nil {
+			c.notify()
+			continue
+		}
+		if roundErr == nil {
+			c.closeListenConnection(conn)
+			return err
+		}
+		if c.stopIdleListener() {
+			c.releaseListenConnection(conn)
+			return nil
+		}
+	}
+}
+
+func (c *PostgresMessageQueue) listen(correlationId string) (conn *pgxpool.Conn, err error) {
+	ctx, done := c.BeginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	conn, err = c.AcquireConnection(ctx, c.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = conn.Exec(ctx, "LISTEN "+c.channelName())
+	if err != nil {
+		c.closeListenConnection(conn)
+		return nil, err
+	}
+	return conn, nil
+}
+
+// Stops listening and returns the connection to the pool
+func (c *PostgresMessageQueue) releaseListenConnection(conn *pgxpool.Conn) {
+	if _, err := conn.Exec(context.Background(), "UNLISTEN "+c.channelName()); err != nil {
+		c.closeListenConnection(conn)
+		return
+	}
+	conn.Release()
+}
+
+// Closes a connection that may still listen for notifications, so it is not reused by the pool
+func (c *PostgresMessageQueue) closeListenConnection(conn *pgxpool.Conn) {
+	conn.Conn().Close(context.Background())
+	conn.Release()
+}
+
+func (c *PostgresMessageQueue) claimMessage(correlationId string) (result *cqueues.MessageEnvelope, err error) {
+	ctx, done := c.BeginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	for {
+		token := cdata.IdGenerator.NextLong()
+		query := "UPDATE " + c.QuotedTableName() +
+			" SET \"locked_until\"=now()+$1*interval '1 millisecond', \"lock_token\"=$2, \"deliveries\"=\"deliveries\"+1" +
+			" WHERE \"id\"=(SELECT \"id\" FROM " + c.QuotedTableName() +
+			" WHERE \"dead_letter\"=FALSE AND (\"locked_until\" IS NULL OR \"locked_until\"<now())" +
+			" ORDER BY \"sent_time\" LIMIT 1 FOR UPDATE SKIP LOCKED)" +
+			" RETURNING \"id\", \"correlation_id\", \"message_type\", \"message\", \"sent_time\", \"lock_token\", \"deliveries\""
+
+		qResult, qErr := c.Client.Query(ctx, query, int64(c.visibilityTimeout/time.Millisecond), token)
+		if qErr != nil {
+			return nil, qErr
+		}
+
+		var message *cqueues.MessageEnvelope
+		deliveries := 0
+		if qResult.Next() {
+			message, _ = c.ConvertToPublic(qResult).(*cqueues.MessageEnvelope)
+			if values, err := qResult.Values(); err == nil {
+				if count, ok := values[len(values)-1].(int32); ok {
+					deliveries = int(count)
+				}
+			}
+		}
+		err = qResult.Err()
+		qResult.Close()
+
+		if err != nil || message == nil {
+			return nil, err
+		}
+
+		if c.maxDeliveries > 0 && deliveries > c.maxDeliveries {
+			c.Logger.Warn(message.CorrelationId, "Message %s exceeded %d deliveries in %s",
+				message.MessageId, c.maxDeliveries, c.name)
+			if err = c.MoveToDeadLetter(message); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		c.Logger.Debug(message.CorrelationId, "Received message %s via %s", message.MessageId, c.name)
+		return message, nil
+	}
+}
+
+func (c *PostgresMessageQueue) getLockToken(message *cqueues.MessageEnvelope) string {
+	token, _ := message.GetReference().(string)
+	return token
+}
+
+// Renews a lock on a message that makes it invisible from other receivers in the queue.
+//   - message       a message to extend its lock.
+//   - lockTimeout   a locking timeout.
+// Returns error or nil for success.
+func (c *PostgresMessageQueue) RenewLock(message *cqueues.MessageEnvelope, lockTimeout time.Duration) (err error) {
+	ctx, done := c.BeginOperation(message.CorrelationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkOpen(message.CorrelationId); err != nil {
+		return err
+	}
+
+	query := "UPDATE " + c.QuotedTableName() + " SET \"locked_until\"=now()+$1*interval '1 millisecond'" +
+		" WHERE \"id\"=$2 AND \"lock_token\"=$3"
+	_, err = c.Client.Exec(ctx, query, int64(lockTimeout/time.Millisecond),
+		message.MessageId, c.getLockToken(message))
+	if err == nil {
+		c.Logger.Trace(message.CorrelationId, "Renewed lock for message %s at %s", message.MessageId, c.name)
+	}
+	return err
+}
+
+// Permanently removes a message from the queue.
+// This method is usually used to remove the message after successful processing.
+//   - message   a message to remove.
+// Returns error or nil for success.
+func (c *PostgresMessageQueue) Complete(message *cqueues.MessageEnvelope) (err error) {
+	ctx, done := c.BeginOperation(message.CorrelationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkOpen(message.CorrelationId); err != nil {
+		return err
+	}
+
+	query := "DELETE FROM " + c.QuotedTableName() + " WHERE \"id\"=$1 AND \"lock_token\"=$2"
+	_, err = c.Client.Exec(ctx, query, message.MessageId, c.getLockToken(message))
+	if err == nil {
+		message.SetReference(nil)
+		c.Logger.Trace(message.CorrelationId, "Completed message %s at %s", message.MessageId, c.name)
+	}
+	return err
+}
+
+// Returns a message into the queue and makes it available for all subscribers to receive it again.
+// This method is usually used to return a message which could not be processed at the moment
+// to repeat the attempt.
+//   - message   a message to return.
+// Returns error or nil for success.
+func (c *PostgresMessageQueue) Abandon(message *cqueues.MessageEnvelope) (err error) {
+	ctx, done := c.BeginOperation(message.CorrelationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkOpen(message.CorrelationId); err != nil {
+		return err
+	}
+
+	query := "UPDATE " + c.QuotedTableName() + " SET \"locked_until\"=NULL, \"lock_token\"=NULL" +
+		" WHERE \"id\"=$1 AND \"lock_token\"=$2"
+	_, err = c.Client.Exec(ctx, query, message.MessageId, c.getLockToken(message))
+	if err != nil {
+		return err
+	}
+
+	message.SetReference(nil)
+	_, err = c.Client.Exec(ctx, "SELECT pg_notify($1, $2)", c.TableName, message.MessageId)
+	if err == nil {
+		c.Logger.Trace(message.CorrelationId, "Abandoned message %s at %s", message.MessageId, c.name)
+	}
+	return err
+}
+
+// Permanently removes a message from the queue and keeps it in the dead letter state.
+// This method is usually used to remove the message after unsuccessful processing.
+//   - message   a message to be removed.
+// Returns error or nil for success.
+func (c *PostgresMessageQueue) MoveToDeadLetter(message *cqueues.MessageEnvelope) (err error) {
+	ctx, done := c.BeginOperation(message.CorrelationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkOpen(message.CorrelationId); err != nil {
+		return err
+	}
+
+	query := "UPDATE " + c.QuotedTableName() + " SET \"dead_letter\"=TRUE, \"locked_until\"=NULL, \"lock_token\"=NULL" +
+		" WHERE \"id\"=$1"
+	_, err = c.Client.Exec(ctx, query, message.MessageId)
+	if err == nil {
+		message.SetReference(nil)
+		c.Logger.Trace(message.CorrelationId, "Moved to dead letter message %s at %s", message.MessageId, c.name)
+	}
+	return err
+}
+
+// Listens for incoming messages and blocks the current thread until queue is closed.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - receiver          a receiver to receive incoming messages.
+// Returns error or nil for success.
+func (c *PostgresMessageQueue) Listen(correlationId string, receiver cqueues.IMessageReceiver) error {
+	if err := c.checkOpen(correlationId); err != nil {
+		return err
+	}
+
+	c.listenLock.Lock()
+	c.listening = true
+	c.listenLock.Unlock()
+
+	c.Logger.Trace(correlationId, "Started listening messages at %s", c.name)
+
+	backoff := c.backoffPolicy()
+	failures := 0
+	for c.isListening() {
+		message, err := c.Receive(correlationId, c.waitTimeout)
+		if err != nil {
+			if !c.IsOpen() {
+				break
+			}
+			failures++
+			c.Logger.Error(correlationId, err, "Failed to receive the message")
+			// Back off, so a broken database is not polled in a tight loop
+			c.sleep(c.waitContext(), backoff.GetDelay(failures))
+			continue
+		}
+		failures = 0
+
+		if message != nil && c.isListening() {
+			if err = receiver.ReceiveMessage(message, c); err != nil {
+				c.Logger.Error(correlationId, err, "Failed to process the message")
+			}
+		}
+	}
+
+	c.Logger.Trace(correlationId, "Stopped listening messages at %s", c.name)
+	return nil
+}
+
+// Gets a context of waits for messages, which is cancelled when the queue is closed
+func (c *PostgresMessageQueue) waitContext() context.Context {
+	c.listenLock.Lock()
+	defer c.listenLock.Unlock()
+	if c.waitCtx == nil {
+		return context.Background()
+	}
+	return c.waitCtx
+}
+
+// Gets a policy of delays between attempts after failures, which grow up to the wait timeout
+func (c *PostgresMessageQueue) backoffPolicy() *persist.PostgresRetryPolicy {
+	policy := persist.NewPostgresRetryPolicy()
+	policy.Delay = 100 * time.Millisecond
+	policy.MaxDelay = c.waitTimeout
+	return policy
+}
+
+// Waits for a delay.
+// Returns false if the wait was interrupted because the queue is closing.
+func (c *PostgresMessageQueue) sleep(ctx context.Context, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (c *PostgresMessageQueue) isListening() bool {
+	c.listenLock.Lock()
+	defer c.listenLock.Unlock()
+	return c.listening
+}
+
+// Listens for incoming messages without blocking the current thread.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - receiver          a receiver to receive incoming messages.
+func (c *PostgresMessageQueue) BeginListen(correlationId string, receiver cqueues.IMessageReceiver) {
+	go func() {
+		err := c.Listen(correlationId, receiver)
+		if err != nil {
+			c.Logger.Error(correlationId, err, "Failed to listen messages")
+		}
+	}()
+}
+
+// Ends listening for incoming messages.
+// When this method is call Listen unblocks the thread and execution continues.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+func (c *PostgresMessageQueue) EndListen(correlationId string) {
+	c.listenLock.Lock()
+	defer c.listenLock.Unlock()
+	c.listening = false
+}
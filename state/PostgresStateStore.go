@@ -0,0 +1,298 @@
+package state
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+
+	"github.com/jackc/pgx/v4"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+)
+
+/*
+State store that keeps per-key state documents in a PostgreSQL JSONB table.
+It is compatible with IStateStore interface (Load, LoadBulk, Save, Delete)
+and additionally supports optimistic concurrency: every save increments a state version,
+and SaveWithVersion fails with ConflictError when the stored version was changed by someone else.
+
+### Configuration parameters ###
+
+- table:                       (optional) PostgreSQL table name (default: "state")
+- schema:                      (optional) PostgreSQL schema, default "public"
+- connection(s):
+   - discovery_key:             (optional) a key to retrieve the connection from IDiscovery
+   - host:                      host name or IP address
+   - port:                      port number (default: 5432)
+   - uri:                       resource URI or connection string with all parameters in it
+- credential(s):
+   - store_key:                 (optional) a key to retrieve the credentials from ICredentialStore
+   - username:                  (optional) user name
+   - password:                  (optional) user password
+
+### References ###
+
+- \*:logger:\*:\*:1.0           (optional) ILogger components to pass log messages
+- \*:connection:postgres:\*:1.0 (optional) Shared PostgreSQL connection
+- \*:discovery:\*:\*:1.0        (optional) IDiscovery services
+- \*:credential-store:\*:\*:1.0 (optional) Credential stores to resolve credentials
+
+### Example ###
+
+    store := NewPostgresStateStore()
+    store.Configure(cconf.NewConfigParamsFromTuples(
+        "connection.host", "localhost",
+        "connection.port", 5432,
+        "connection.database", "test",
+    ))
+    err := store.Open("123")
+    ...
+    state, err := store.LoadWithVersion("123", "workflow1")
+    state, err = store.SaveWithVersion("123", "workflow1", newValue, state.Version)
+    if err != nil {
+        // Somebody else changed the state, reload and retry
+    }
+*/
+type PostgresStateStore struct {
+	*persist.PostgresPersistence
+}
+
+// Creates a new instance of the state store component.
+func NewPostgresStateStore() *PostgresStateStore {
+	c := &PostgresStateStore{}
+	c.PostgresPersistence = persist.InheritPostgresPersistence(c, reflect.TypeOf(StateValue{}), "state")
+	return c
+}
+
+// Defines a database schema for the state table
+func (c *PostgresStateStore) DefineSchema() {
+	c.ClearSchema()
+	c.PostgresPersistence.DefineSchema()
+	c.EnsureSchema("CREATE TABLE IF NOT EXISTS " + c.QuotedTableName() +
+		" (\"key\" TEXT PRIMARY KEY, \"value\" JSONB, \"version\" BIGINT NOT NULL DEFAULT 1," +
+		" \"update_time\" TIMESTAMPTZ NOT NULL DEFAULT now())")
+}
+
+// Converts a state row into StateValue.
+//   - rows  a current row
+// Returns a converted state value
+func (c *PostgresStateStore) ConvertToPublic(rows pgx.Rows) interface{} {
+	values, valErr := rows.Values()
+	if valErr != nil || values == nil {
+		return persist.NewDataConversionError("", "", nil, valErr)
+	}
+
+	result := &StateValue{}
+	for index, column := range rows.FieldDescriptions() {
+		value := values[index]
+		switch string(column.Name) {
+		case "key":
+			result.Key, _ = value.(string)
+		case "value":
+			result.Value = value
+		case "version":
+			result.Version, _ = value.(int64)
+		}
+	}
+	return result
+}
+
+func (c *PostgresStateStore) checkOpen(correlationId string) error {
+	if !c.IsOpen() {
+		return cerr.NewInvalidStateError(correlationId, "NOT_OPENED", "The state store is not opened")
+	}
+	return nil
+}
+
+// Loads state from the store using its key.
+// If value is missing in the store it returns nil.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - key               a unique state key.
+// Returns the state value or error.
+func (c *PostgresStateStore) Load(correlationId string, key string) (interface{}, error) {
+	state, err := c.LoadWithVersion(correlationId, key)
+	if err != nil || state == nil {
+		return nil, err
+	}
+	return state.Value, nil
+}
+
+// Loads state from the store together with its version.
+// If value is missing in the store it returns nil.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - key               a unique state key.
+// Returns the state value with version or error.
+func (c *PostgresStateStore) LoadWithVersion(correlationId string, key string) (*StateValue, error) {
+	states, err := c.LoadBulk(correlationId, []string{key})
+	if err != nil || len(states) == 0 {
+		return nil, err
+	}
+	return states[0], nil
+}
+
+// Loads an array of states from the store using their keys.
+// Missing states are not included into the result.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - keys              unique state keys.
+// Returns an array with state values and their versions or error.
+func (c *PostgresStateStore) LoadBulk(correlationId string, keys []string) (result []*StateValue, err error) {
+	ctx, done := c.BeginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkOpen(correlationId); err != nil {
+		return nil, err
+	}
+
+	params := ""
+	values := make([]interface{}, len(keys))
+	for index, key := range keys {
+		if params != "" {
+			params += ","
+		}
+		params += "$" + strconv.Itoa(index+1)
+		values[index] = key
+	}
+	if len(keys) == 0 {
+		return []*StateValue{}, nil
+	}
+
+	query := "SELECT \"key\", \"value\", \"version\" FROM " + c.QuotedTableName() + " WHERE \"key\" IN (" + params + ")"
+	qResult, qErr := c.Client.Query(ctx, query, values...)
+	if qErr != nil {
+		return nil, qErr
+	}
+	defer qResult.Close()
+
+	result = make([]*StateValue, 0, len(keys))
+	for qResult.Next() {
+		if state, ok := c.ConvertToPublic(qResult).(*StateValue); ok {
+			result = append(result, state)
+		}
+	}
+
+	c.Logger.Trace(correlationId, "Loaded %d of %d states from %s", len(result), len(keys), c.TableName)
+	return result, qResult.Err()
+}
+
+// Saves state into the store regardless of its current version.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - key               a unique state key.
+//   - value             a state value to save.
+// Returns the saved value or error.
+func (c *PostgresStateStore) Save(correlationId string, key string, value interface{}) (saved interface{}, err error) {
+	ctx, done := c.BeginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkOpen(correlationId); err != nil {
+		return nil, err
+	}
+
+	buffer, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "INSERT INTO " + c.QuotedTableName() + " (\"key\", \"value\") VALUES ($1, $2)" +
+		" ON CONFLICT (\"key\") DO UPDATE SET \"value\"=EXCLUDED.\"value\"," +
+		" \"version\"=" + c.QuotedTableName() + ".\"version\"+1, \"update_time\"=now()"
+	_, err = c.Client.Exec(ctx, query, key, string(buffer))
+	if err != nil {
+		return nil, err
+	}
+
+	c.Logger.Trace(correlationId, "Saved state %s in %s", key, c.TableName)
+	return value, nil
+}
+
+// Saves state into the store only if its stored version matches the expected one.
+// Use 0 as expected version to save a new state that must not exist yet.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - key               a unique state key.
+//   - value             a state value to save.
+//   - expectedVersion   a version the stored state is expected to have.
+// Returns the saved state with its new version or ConflictError if versions do not match.
+func (c *PostgresStateStore) SaveWithVersion(correlationId string, key string, value interface{},
+	expectedVersion int64) (saved *StateValue, err error) {
+	ctx, done := c.BeginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkOpen(correlationId); err != nil {
+		return nil, err
+	}
+
+	buffer, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var query string
+	var args []interface{}
+	if expectedVersion == 0 {
+		query = "INSERT INTO " + c.QuotedTableName() + " (\"key\", \"value\") VALUES ($1, $2)" +
+			" ON CONFLICT (\"key\") DO NOTHING RETURNING \"version\""
+		args = []interface{}{key, string(buffer)}
+	} else {
+		query = "UPDATE " + c.QuotedTableName() +
+			" SET \"value\"=$2, \"version\"=\"version\"+1, \"update_time\"=now()" +
+			" WHERE \"key\"=$1 AND \"version\"=$3 RETURNING \"version\""
+		args = []interface{}{key, string(buffer), expectedVersion}
+	}
+
+	var version int64
+	err = c.Client.QueryRow(ctx, query, args...).Scan(&version)
+	if err == pgx.ErrNoRows {
+		return nil, cerr.NewConflictError(correlationId, "VERSION_CONFLICT",
+			"State "+key+" was changed by another process").
+			WithDetails("key", key).
+			WithDetails("version", expectedVersion)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.Logger.Trace(correlationId, "Saved state %s in %s with version %d", key, c.TableName, version)
+	return &StateValue{Key: key, Value: value, Version: version}, nil
+}
+
+// Deletes a state from the store by its key.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - key               a unique state key.
+// Returns the deleted value or error.
+func (c *PostgresStateStore) Delete(correlationId string, key string) (deleted interface{}, err error) {
+	ctx, done := c.BeginOperation(correlationId, &err)
+	defer done()
+	if err != nil {
+		return
+	}
+
+	if err = c.checkOpen(correlationId); err != nil {
+		return nil, err
+	}
+
+	query := "DELETE FROM " + c.QuotedTableName() + " WHERE \"key\"=$1 RETURNING \"key\", \"value\", \"version\""
+	qResult, qErr := c.Client.Query(ctx, query, key)
+	if qErr != nil {
+		return nil, qErr
+	}
+	defer qResult.Close()
+
+	if !qResult.Next() {
+		return nil, qResult.Err()
+	}
+	state, _ := c.ConvertToPublic(qResult).(*StateValue)
+	if state == nil {
+		return nil, qResult.Err()
+	}
+
+	c.Logger.Trace(correlationId, "Deleted state %s from %s", key, c.TableName)
+	return state.Value, nil
+}
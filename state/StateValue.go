@@ -0,0 +1,11 @@
+package state
+
+// A data object that holds a retrieved state value with its key and version.
+type StateValue struct {
+	// A unique state key
+	Key string `json:"key"`
+	// A stored state value
+	Value interface{} `json:"value"`
+	// A version of the state used for optimistic concurrency
+	Version int64 `json:"version"`
+}
@@ -0,0 +1,69 @@
+package test_auth
+
+import (
+	"testing"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cauth "github.com/pip-services3-go/pip-services3-components-go/auth"
+	auth "github.com/pip-services3-go/pip-services3-postgres-go/auth"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresCredentialStore(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+	dbConfig := db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.encryption_key", "test key",
+	))
+
+	store := auth.NewPostgresCredentialStore()
+	store.Configure(dbConfig)
+	err := store.Open("")
+	if err != nil {
+		t.Error("Error opened credential store", err)
+		return
+	}
+	defer store.Close("")
+
+	err = store.Clear("")
+	assert.Nil(t, err)
+
+	err = store.Store("", "key1", cauth.NewCredentialParamsFromTuples(
+		"username", "user1",
+		"password", "pass1",
+	))
+	assert.Nil(t, err)
+
+	credential, err := store.Lookup("", "key1")
+	assert.Nil(t, err)
+	assert.NotNil(t, credential)
+	assert.Equal(t, "user1", credential.Username())
+	assert.Equal(t, "pass1", credential.Password())
+
+	credential, err = store.Lookup("", "key2")
+	assert.Nil(t, err)
+	assert.Nil(t, credential)
+
+	err = store.Store("", "key1", nil)
+	assert.Nil(t, err)
+
+	credential, err = store.Lookup("", "key1")
+	assert.Nil(t, err)
+	assert.Nil(t, credential)
+
+	// Credentials are not changed in dry run mode
+	dryRunStore := auth.NewPostgresCredentialStore()
+	dryRunStore.Configure(dbConfig.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.dry_run", true,
+	)))
+	err = dryRunStore.Open("")
+	assert.Nil(t, err)
+	defer dryRunStore.Close("")
+
+	err = dryRunStore.Store("", "key1", cauth.NewCredentialParamsFromTuples("username", "user1"))
+	assert.NotNil(t, err)
+
+	credential, err = store.Lookup("", "key1")
+	assert.Nil(t, err)
+	assert.Nil(t, credential)
+}
@@ -0,0 +1,40 @@
+package test_benchmark
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	bench "github.com/pip-services3-go/pip-services3-postgres-go/benchmark"
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresLoadRunner(t *testing.T) {
+	runner := bench.NewPostgresLoadRunner()
+	runner.Configure(cconf.NewConfigParamsFromTuples(
+		"options.concurrency", 4,
+		"options.iterations", 200,
+	))
+
+	persistence := persist.NewMockPostgresPersistence(reflect.TypeOf(tf.Dummy{}))
+	stats, err := runner.RunCrud("", persistence, func(iteration int64) interface{} {
+		return tf.Dummy{Key: "Key " + strconv.FormatInt(iteration, 10), Content: "Content"}
+	})
+	assert.Nil(t, err)
+	assert.Len(t, stats, 3)
+
+	for _, s := range stats {
+		assert.Equal(t, int64(200), s.Operations)
+		assert.Equal(t, int64(0), s.Errors)
+		assert.True(t, s.MinLatency <= s.P50Latency)
+		assert.True(t, s.P50Latency <= s.P99Latency)
+		assert.True(t, s.P99Latency <= s.MaxLatency)
+	}
+
+	count, err := persistence.GetCountByFilter("", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(200), count)
+}
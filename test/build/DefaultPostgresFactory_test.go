@@ -0,0 +1,55 @@
+package test_build
+
+import (
+	"testing"
+
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	auth "github.com/pip-services3-go/pip-services3-postgres-go/auth"
+	build "github.com/pip-services3-go/pip-services3-postgres-go/build"
+	cache "github.com/pip-services3-go/pip-services3-postgres-go/cache"
+	conn "github.com/pip-services3-go/pip-services3-postgres-go/connect"
+	count "github.com/pip-services3-go/pip-services3-postgres-go/count"
+	lock "github.com/pip-services3-go/pip-services3-postgres-go/lock"
+	queues "github.com/pip-services3-go/pip-services3-postgres-go/queues"
+	state "github.com/pip-services3-go/pip-services3-postgres-go/state"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultPostgresFactory(t *testing.T) {
+	factory := build.NewDefaultPostgresFactory()
+
+	component, err := factory.Create(cref.NewDescriptor("pip-services", "connection", "postgres", "default", "1.0"))
+	assert.Nil(t, err)
+	assert.IsType(t, &conn.PostgresConnection{}, component)
+
+	component, err = factory.Create(cref.NewDescriptor("pip-services", "message-queue", "postgres", "queue1", "1.0"))
+	assert.Nil(t, err)
+	assert.IsType(t, &queues.PostgresMessageQueue{}, component)
+	assert.Equal(t, "queue1", component.(*queues.PostgresMessageQueue).Name())
+
+	component, err = factory.Create(cref.NewDescriptor("pip-services", "lock", "postgres", "default", "1.0"))
+	assert.Nil(t, err)
+	assert.IsType(t, &lock.PostgresLock{}, component)
+
+	component, err = factory.Create(cref.NewDescriptor("pip-services", "cache", "postgres", "default", "1.0"))
+	assert.Nil(t, err)
+	assert.IsType(t, &cache.PostgresCache{}, component)
+
+	component, err = factory.Create(cref.NewDescriptor("pip-services", "state-store", "postgres", "default", "1.0"))
+	assert.Nil(t, err)
+	assert.IsType(t, &state.PostgresStateStore{}, component)
+
+	component, err = factory.Create(cref.NewDescriptor("pip-services", "counters", "postgres", "default", "1.0"))
+	assert.Nil(t, err)
+	assert.IsType(t, &count.PostgresCounters{}, component)
+
+	component, err = factory.Create(cref.NewDescriptor("pip-services", "credential-store", "postgres", "default", "1.0"))
+	assert.Nil(t, err)
+	assert.IsType(t, &auth.PostgresCredentialStore{}, component)
+
+	component, err = factory.Create(cref.NewDescriptor("pip-services", "discovery", "postgres", "default", "1.0"))
+	assert.Nil(t, err)
+	assert.IsType(t, &conn.PostgresDiscovery{}, component)
+
+	assert.Nil(t, factory.CanCreate(cref.NewDescriptor("pip-services", "connection", "mongodb", "default", "1.0")))
+}
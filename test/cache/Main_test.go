@@ -0,0 +1,12 @@
+package test_cache
+
+import (
+	"os"
+	"testing"
+
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(tf.RunPostgresTests(m))
+}
@@ -0,0 +1,64 @@
+package test_cache
+
+import (
+	"testing"
+	"time"
+
+	cache "github.com/pip-services3-go/pip-services3-postgres-go/cache"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresCache(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	postgresCache := cache.NewPostgresCache()
+	postgresCache.Configure(db.Config)
+	err := postgresCache.Open("")
+	if err != nil {
+		t.Error("Error opened cache", err)
+		return
+	}
+	defer postgresCache.Close("")
+
+	err = postgresCache.Clear("")
+	assert.Nil(t, err)
+
+	t.Run("StoreAndRetrieve", func(t *testing.T) {
+		_, err := postgresCache.Store("", "key1", "value1", 5000)
+		assert.Nil(t, err)
+		_, err = postgresCache.Store("", "key2", map[string]interface{}{"name": "value2"}, 5000)
+		assert.Nil(t, err)
+
+		value, err := postgresCache.Retrieve("", "key1")
+		assert.Nil(t, err)
+		assert.Equal(t, "value1", value)
+
+		var result map[string]interface{}
+		ok, err := postgresCache.RetrieveAs("", "key2", &result)
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, "value2", result["name"])
+
+		err = postgresCache.Remove("", "key1")
+		assert.Nil(t, err)
+
+		value, err = postgresCache.Retrieve("", "key1")
+		assert.Nil(t, err)
+		assert.Nil(t, value)
+	})
+
+	t.Run("Expiration", func(t *testing.T) {
+		_, err := postgresCache.Store("", "key3", "value3", 100)
+		assert.Nil(t, err)
+
+		time.Sleep(300 * time.Millisecond)
+
+		value, err := postgresCache.Retrieve("", "key3")
+		assert.Nil(t, err)
+		assert.Nil(t, value)
+
+		err = postgresCache.RemoveExpired("")
+		assert.Nil(t, err)
+	})
+}
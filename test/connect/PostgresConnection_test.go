@@ -1,52 +1,23 @@
 package test_connect
 
 import (
-	"os"
+	"context"
 	"testing"
+	"time"
 
 	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
 	conn "github.com/pip-services3-go/pip-services3-postgres-go/connect"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestPostgresConnection(t *testing.T) {
 	var connection *conn.PostgresConnection
 
-	postgresUri := os.Getenv("POSTGRES_URI")
-	postgresHost := os.Getenv("POSTGRES_HOST")
-	if postgresHost == "" {
-		postgresHost = "localhost"
-	}
-	postgresPort := os.Getenv("POSTGRES_PORT")
-	if postgresPort == "" {
-		postgresPort = "5432"
-	}
-	postgresDatabase := os.Getenv("POSTGRES_DB")
-	if postgresDatabase == "" {
-		postgresDatabase = "test"
-	}
-	postgresUser := os.Getenv("POSTGRES_USER")
-	if postgresUser == "" {
-		postgresUser = "postgres"
-	}
-	postgresPassword := os.Getenv("POSTGRES_PASSWORD")
-	if postgresPassword == "" {
-		postgresPassword = "postgres#"
-	}
-
-	if postgresUri == "" && postgresHost == "" {
-		panic("Connection params losse")
-	}
-
-	dbConfig := cconf.NewConfigParamsFromTuples(
-		"connection.uri", postgresUri,
-		"connection.host", postgresHost,
-		"connection.port", postgresPort,
-		"connection.database", postgresDatabase,
-		"credential.username", postgresUser,
-		"credential.password", postgresPassword,
+	db := tf.StartPostgresTestDatabase(t)
+	dbConfig := db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
 		"options.max_pool_size", 10,
-	)
+	))
 
 	connection = conn.NewPostgresConnection()
 	connection.Configure(dbConfig)
@@ -58,3 +29,230 @@ func TestPostgresConnection(t *testing.T) {
 	assert.NotNil(t, connection.GetDatabaseName())
 	assert.NotEqual(t, "", connection.GetDatabaseName())
 }
+
+func TestPostgresConnectionPoolClasses(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+	dbConfig := db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.max_pool_size", 10,
+		"pools.batch", 20,
+	))
+
+	connection := conn.NewPostgresConnection()
+	connection.Configure(dbConfig)
+	err := connection.Open("")
+	assert.Nil(t, err)
+	defer connection.Close("")
+
+	assert.Len(t, connection.GetPoolClasses(), 2)
+	assert.Equal(t, int32(8), connection.GetConnection().Config().MaxConns)
+	assert.Equal(t, int32(2), connection.GetConnectionByClass("batch").Config().MaxConns)
+	assert.Equal(t, connection.GetConnection(), connection.GetConnectionByClass("unknown"))
+}
+
+func TestPostgresConnectionStatementCache(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+	dbConfig := db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.statement_cache_mode", "describe",
+		"options.statement_cache_capacity", 100,
+	))
+
+	connection := conn.NewPostgresConnection()
+	connection.Configure(dbConfig)
+	connection.PrepareStatement("test_sum", "SELECT $1::int + $2::int")
+	err := connection.Open("")
+	assert.Nil(t, err)
+	defer connection.Close("")
+
+	var sum int
+	err = connection.GetConnection().QueryRow(context.Background(), "test_sum", 1, 2).Scan(&sum)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, sum)
+}
+
+func TestPostgresConnectionStatementTimeout(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+	dbConfig := db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.statement_timeout", 100,
+	))
+
+	connection := conn.NewPostgresConnection()
+	connection.Configure(dbConfig)
+	err := connection.Open("")
+	assert.Nil(t, err)
+	defer connection.Close("")
+
+	var timeout string
+	err = connection.GetConnection().QueryRow(context.Background(), "SHOW statement_timeout").Scan(&timeout)
+	assert.Nil(t, err)
+	assert.Equal(t, "100ms", timeout)
+
+	_, err = connection.GetConnection().Exec(context.Background(), "SELECT pg_sleep(1)")
+	assert.NotNil(t, err)
+}
+
+func TestPostgresConnectionRecycling(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+	dbConfig := db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.idle_timeout", 5000,
+		"options.max_lifetime", 60000,
+		"options.health_check_period", 1000,
+	))
+
+	connection := conn.NewPostgresConnection()
+	connection.Configure(dbConfig)
+	err := connection.Open("")
+	assert.Nil(t, err)
+	defer connection.Close("")
+
+	config := connection.GetConnection().Config()
+	assert.Equal(t, 5*time.Second, config.MaxConnIdleTime)
+	assert.Equal(t, time.Minute, config.MaxConnLifetime)
+	assert.Equal(t, time.Second, config.HealthCheckPeriod)
+}
+
+func TestPostgresConnectionWarmUp(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+	dbConfig := db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.max_pool_size", 5,
+		"options.min_pool_size", 3,
+	))
+
+	connection := conn.NewPostgresConnection()
+	connection.Configure(dbConfig)
+	err := connection.Open("")
+	assert.Nil(t, err)
+	defer connection.Close("")
+
+	// Connections are established before the first query
+	stat := connection.GetConnection().Stat()
+	assert.Equal(t, int32(3), stat.TotalConns())
+	assert.Equal(t, int32(5), stat.MaxConns())
+}
+
+func TestPostgresConnectionReconnect(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+	dbConfig := db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.max_pool_size", 1,
+		"options.health_check_period", 100,
+		"options.reconnect_delay", 10,
+	))
+
+	connection := conn.NewPostgresConnection()
+	connection.Configure(dbConfig)
+	err := connection.Open("")
+	assert.Nil(t, err)
+	defer connection.Close("")
+
+	reconnected := make(chan bool, 1)
+	connection.AddReconnectListener(func() {
+		reconnected <- true
+	})
+	pool := connection.GetConnection()
+	_, err = pool.Exec(context.Background(), "SELECT 1")
+	assert.Nil(t, err)
+
+	// Kill sessions of the pool from another connection to simulate a server restart
+	killer := conn.NewPostgresConnection()
+	killer.Configure(dbConfig)
+	err = killer.Open("")
+	assert.Nil(t, err)
+	defer killer.Close("")
+
+	_, err = killer.GetConnection().Exec(context.Background(),
+		"SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE datname=current_database() AND pid<>pg_backend_pid()")
+	assert.Nil(t, err)
+
+	select {
+	case <-reconnected:
+	case <-time.After(5 * time.Second):
+		t.Error("Connection was not restored")
+		return
+	}
+	assert.NotEqual(t, pool, connection.GetConnection())
+
+	_, err = connection.GetConnection().Exec(context.Background(), "SELECT 1")
+	assert.Nil(t, err)
+}
+
+func TestPostgresConnectionReconfigure(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+	dbConfig := db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.max_pool_size", 2,
+	))
+
+	connection := conn.NewPostgresConnection()
+	connection.Configure(dbConfig)
+	err := connection.Open("")
+	assert.Nil(t, err)
+	defer connection.Close("")
+
+	pool := connection.GetConnection()
+
+	// Unchanged options keep the pool
+	connection.Configure(cconf.NewConfigParamsFromTuples("options.max_pool_size", 2))
+	assert.Equal(t, pool, connection.GetConnection())
+
+	// Changed pool options recreate the pool
+	connection.Configure(cconf.NewConfigParamsFromTuples("options.max_pool_size", 4))
+	assert.NotEqual(t, pool, connection.GetConnection())
+	assert.Equal(t, int32(4), connection.GetConnection().Stat().MaxConns())
+
+	// Changed pool shares recreate the pools, shares missing in the new configuration are removed
+	connection.Configure(cconf.NewConfigParamsFromTuples("options.max_pool_size", 4, "pools.batch", 50))
+	assert.Len(t, connection.GetPoolClasses(), 2)
+	assert.Equal(t, int32(2), connection.GetConnectionByClass("batch").Stat().MaxConns())
+
+	connection.Configure(cconf.NewConfigParamsFromTuples("options.max_pool_size", 4))
+	assert.Len(t, connection.GetPoolClasses(), 1)
+	assert.Equal(t, connection.GetConnection(), connection.GetConnectionByClass("batch"))
+
+	_, err = connection.GetConnection().Exec(context.Background(), "SELECT 1")
+	assert.Nil(t, err)
+}
+
+func TestPostgresConnectionApplicationName(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+	dbConfig := db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.application_name", "dummy-service",
+	))
+
+	connection := conn.NewPostgresConnection()
+	connection.Configure(dbConfig)
+	err := connection.Open("")
+	assert.Nil(t, err)
+	defer connection.Close("")
+
+	// Sessions are attributed to the application in pg_stat_activity
+	var appName string
+	err = connection.GetConnection().QueryRow(context.Background(),
+		"SELECT application_name FROM pg_stat_activity WHERE pid=pg_backend_pid()").Scan(&appName)
+	assert.Nil(t, err)
+	assert.Equal(t, "dummy-service", appName)
+}
+
+func TestPostgresConnectionSessionVariables(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+	dbConfig := db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.max_pool_size", 1,
+	))
+
+	connection := conn.NewPostgresConnection()
+	connection.Configure(dbConfig)
+	err := connection.Open("")
+	assert.Nil(t, err)
+	defer connection.Close("")
+
+	// Variables are set for queries within the context
+	ctx := conn.WithSessionVariables(context.Background(), map[string]string{"app.tenant_id": "42"})
+	var tenantId string
+	err = connection.GetConnection().QueryRow(ctx, "SELECT current_setting('app.tenant_id')").Scan(&tenantId)
+	assert.Nil(t, err)
+	assert.Equal(t, "42", tenantId)
+
+	// Variables are cleared when the connection is returned to the pool
+	time.Sleep(100 * time.Millisecond)
+	err = connection.GetConnection().QueryRow(context.Background(),
+		"SELECT COALESCE(current_setting('app.tenant_id', true), '')").Scan(&tenantId)
+	assert.Nil(t, err)
+	assert.Equal(t, "", tenantId)
+}
@@ -0,0 +1,47 @@
+package test_connect
+
+import (
+	"testing"
+
+	ccon "github.com/pip-services3-go/pip-services3-components-go/connect"
+	conn "github.com/pip-services3-go/pip-services3-postgres-go/connect"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresDiscovery(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	discovery := conn.NewPostgresDiscovery()
+	discovery.Configure(db.Config)
+	err := discovery.Open("")
+	if err != nil {
+		t.Error("Error opened discovery", err)
+		return
+	}
+	defer discovery.Close("")
+
+	connection := ccon.NewConnectionParamsFromTuples(
+		"host", "10.1.1.100",
+		"port", 8080,
+	)
+	_, err = discovery.Register("", "service1", connection)
+	assert.Nil(t, err)
+
+	result, err := discovery.ResolveOne("", "service1")
+	assert.Nil(t, err)
+	assert.NotNil(t, result)
+	assert.Equal(t, "10.1.1.100", result.Host())
+	assert.Equal(t, 8080, result.Port())
+
+	results, err := discovery.ResolveAll("", "service2")
+	assert.Nil(t, err)
+	assert.Len(t, results, 0)
+
+	err = discovery.Unregister("", "service1", connection)
+	assert.Nil(t, err)
+
+	result, err = discovery.ResolveOne("", "service1")
+	assert.Nil(t, err)
+	assert.Nil(t, result)
+}
@@ -0,0 +1,33 @@
+package test_count
+
+import (
+	"testing"
+
+	count "github.com/pip-services3-go/pip-services3-postgres-go/count"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresCounters(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	counters := count.NewPostgresCounters()
+	counters.Configure(db.Config)
+	err := counters.Open("")
+	if err != nil {
+		t.Error("Error opened counters", err)
+		return
+	}
+	defer counters.Close("")
+
+	counters.Increment("test.calls", 1)
+	counters.Increment("test.calls", 2)
+	counters.Last("test.last", 123)
+
+	err = counters.Dump()
+	assert.Nil(t, err)
+
+	// Nothing to save after the dump
+	err = counters.Dump()
+	assert.Nil(t, err)
+}
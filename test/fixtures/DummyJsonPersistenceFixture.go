@@ -0,0 +1,79 @@
+package test
+
+import (
+	"testing"
+
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	"github.com/stretchr/testify/assert"
+)
+
+// Fixture for persistences that store dummies as JSON documents.
+// It complements DummyPersistenceFixture with checks of values escaped in documents
+// and of changes applied to documents as a whole or in part.
+type DummyJsonPersistenceFixture struct {
+	persistence IDummyPersistence
+}
+
+func NewDummyJsonPersistenceFixture(persistence IDummyPersistence) *DummyJsonPersistenceFixture {
+	c := DummyJsonPersistenceFixture{}
+	c.persistence = persistence
+	return &c
+}
+
+func (c *DummyJsonPersistenceFixture) TestJsonOperations(t *testing.T) {
+	// Values with characters escaped in JSON are read without changes
+	contents := []string{
+		"Quoted \"content\"",
+		"Back\\slash",
+		"Line\nbreak\ttab",
+		"Unicode ü✓",
+		"{\"nested\": [1, 2]}",
+		"",
+	}
+	for index, content := range contents {
+		created, err := c.persistence.Create("", Dummy{Key: "Key \"" + string(rune('A'+index)) + "\"", Content: content})
+		if err != nil {
+			t.Errorf("Create method error %v", err)
+		}
+
+		result, err := c.persistence.GetOneById("", created.Id)
+		if err != nil {
+			t.Errorf("GetOneById method error %v", err)
+		}
+		assert.Equal(t, content, result.Content)
+	}
+
+	// Fields of documents can be filtered by escaped values
+	filter := cdata.NewFilterParamsFromTuples("Key", "Key \"B\"")
+	page, err := c.persistence.GetPageByFilter("", filter, cdata.NewPagingParams(0, 10, false))
+	if err != nil {
+		t.Errorf("GetPageByFilter method error %v", err)
+	}
+	if assert.NotNil(t, page) && assert.Len(t, page.Data, 1) {
+		assert.Equal(t, "Back\\slash", page.Data[0].Content)
+	}
+
+	// Partial updates change only given fields of the document
+	dummy := page.Data[0]
+	result, err := c.persistence.UpdatePartially("", dummy.Id,
+		cdata.NewAnyValueMapFromTuples("content", "Partially \"updated\""))
+	if err != nil {
+		t.Errorf("UpdatePartially method error %v", err)
+	}
+	assert.Equal(t, dummy.Id, result.Id)
+	assert.Equal(t, dummy.Key, result.Key)
+	assert.Equal(t, "Partially \"updated\"", result.Content)
+
+	// Updates replace the whole document
+	result, err = c.persistence.Update("", Dummy{Id: dummy.Id, Key: "Key \"Z\""})
+	if err != nil {
+		t.Errorf("Update method error %v", err)
+	}
+	assert.Equal(t, Dummy{Id: dummy.Id, Key: "Key \"Z\""}, result)
+
+	result, err = c.persistence.GetOneById("", dummy.Id)
+	if err != nil {
+		t.Errorf("GetOneById method error %v", err)
+	}
+	assert.Equal(t, Dummy{Id: dummy.Id, Key: "Key \"Z\""}, result)
+}
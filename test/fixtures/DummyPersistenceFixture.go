@@ -1,6 +1,7 @@
 package test
 
 import (
+	"strconv"
 	"testing"
 
 	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
@@ -228,3 +229,137 @@ func (c *DummyPersistenceFixture) TestRandomOperation(t *testing.T) {
 	}
 	assert.NotNil(t, result)
 }
+
+func (c *DummyPersistenceFixture) createDummies(t *testing.T, count int) {
+	for i := 0; i < count; i++ {
+		_, err := c.persistence.Create("", Dummy{Key: "Key " + strconv.Itoa(i), Content: "Content " + strconv.Itoa(i)})
+		if err != nil {
+			t.Errorf("Create method error %v", err)
+		}
+	}
+}
+
+func (c *DummyPersistenceFixture) TestPagingOperations(t *testing.T) {
+	c.createDummies(t, 5)
+
+	// Get a page in the middle with total
+	page, err := c.persistence.GetPageByFilter("", cdata.NewEmptyFilterParams(), cdata.NewPagingParams(1, 2, true))
+	if err != nil {
+		t.Errorf("GetPageByFilter method error %v", err)
+	}
+	assert.NotNil(t, page)
+	assert.Len(t, page.Data, 2)
+	assert.NotNil(t, page.Total)
+	if page.Total != nil {
+		assert.Equal(t, int64(5), *page.Total)
+	}
+
+	// Get the last incomplete page
+	page, err = c.persistence.GetPageByFilter("", cdata.NewEmptyFilterParams(), cdata.NewPagingParams(4, 2, false))
+	if err != nil {
+		t.Errorf("GetPageByFilter method error %v", err)
+	}
+	assert.NotNil(t, page)
+	assert.Len(t, page.Data, 1)
+
+	// Get a page past the end
+	page, err = c.persistence.GetPageByFilter("", cdata.NewEmptyFilterParams(), cdata.NewPagingParams(10, 2, false))
+	if err != nil {
+		t.Errorf("GetPageByFilter method error %v", err)
+	}
+	assert.NotNil(t, page)
+	assert.Len(t, page.Data, 0)
+
+	// Filter items by key
+	filter := cdata.NewFilterParamsFromTuples("Key", "Key 3")
+	page, err = c.persistence.GetPageByFilter("", filter, cdata.NewPagingParams(0, 5, true))
+	if err != nil {
+		t.Errorf("GetPageByFilter method error %v", err)
+	}
+	assert.NotNil(t, page)
+	assert.Len(t, page.Data, 1)
+	if len(page.Data) == 1 {
+		assert.Equal(t, "Key 3", page.Data[0].Key)
+	}
+
+	count, err := c.persistence.GetCountByFilter("", filter)
+	if err != nil {
+		t.Errorf("GetCountByFilter method error %v", err)
+	}
+	assert.Equal(t, int64(1), count)
+}
+
+func (c *DummyPersistenceFixture) TestSortingOperations(t *testing.T) {
+	persistence, ok := c.persistence.(IDummySortedPersistence)
+	if !ok {
+		t.Skip("Persistence does not support sorting")
+	}
+	c.createDummies(t, 5)
+
+	page, err := persistence.GetSortedPageByFilter("", cdata.NewEmptyFilterParams(), cdata.NewPagingParams(0, 5, false),
+		cdata.NewSortParams(cdata.NewSortField("key", false)))
+	if err != nil {
+		t.Errorf("GetSortedPageByFilter method error %v", err)
+	}
+	assert.NotNil(t, page)
+	assert.Len(t, page.Data, 5)
+	for i := 1; i < len(page.Data); i++ {
+		assert.True(t, page.Data[i-1].Key > page.Data[i].Key)
+	}
+
+	page, err = persistence.GetSortedPageByFilter("", cdata.NewEmptyFilterParams(), cdata.NewPagingParams(0, 2, false),
+		cdata.NewSortParams(cdata.NewSortField("key", true)))
+	if err != nil {
+		t.Errorf("GetSortedPageByFilter method error %v", err)
+	}
+	assert.NotNil(t, page)
+	assert.Len(t, page.Data, 2)
+	if len(page.Data) == 2 {
+		assert.Equal(t, "Key 0", page.Data[0].Key)
+		assert.Equal(t, "Key 1", page.Data[1].Key)
+	}
+}
+
+// Checks the CRUD contract beyond the main flow: generated and duplicate ids,
+// and operations on missing items that shall return empty results without errors
+func (c *DummyPersistenceFixture) TestCrudContract(t *testing.T) {
+	// Ids are generated for items without them
+	created, err := c.persistence.Create("", Dummy{Key: "Key 1", Content: "Content 1"})
+	if err != nil {
+		t.Errorf("Create method error %v", err)
+	}
+	assert.NotEqual(t, "", created.Id)
+
+	// Items with existing ids are rejected
+	_, err = c.persistence.Create("", Dummy{Id: created.Id, Key: "Key 2", Content: "Content 2"})
+	assert.NotNil(t, err)
+
+	// Missing items are read as empty
+	result, err := c.persistence.GetOneById("", "missing")
+	assert.Nil(t, err)
+	assert.Equal(t, Dummy{}, result)
+
+	items, err := c.persistence.GetListByIds("", []string{"missing"})
+	assert.Nil(t, err)
+	assert.Len(t, items, 0)
+
+	// Changes of missing items return empty results and do not create them
+	result, err = c.persistence.Update("", Dummy{Id: "missing", Key: "Key 3", Content: "Content 3"})
+	assert.Nil(t, err)
+	assert.Equal(t, Dummy{}, result)
+
+	result, err = c.persistence.UpdatePartially("", "missing", cdata.NewAnyValueMapFromTuples("content", "Content 3"))
+	assert.Nil(t, err)
+	assert.Equal(t, Dummy{}, result)
+
+	result, err = c.persistence.DeleteById("", "missing")
+	assert.Nil(t, err)
+	assert.Equal(t, Dummy{}, result)
+
+	err = c.persistence.DeleteByIds("", []string{"missing"})
+	assert.Nil(t, err)
+
+	count, err := c.persistence.GetCountByFilter("", cdata.NewEmptyFilterParams())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+}
@@ -0,0 +1,10 @@
+package test
+
+import cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+
+// Persistence of dummies that supports sorting of pages, validated by DummyPersistenceFixture.TestSortingOperations
+type IDummySortedPersistence interface {
+	IDummyPersistence
+	GetSortedPageByFilter(correlationId string, filter *cdata.FilterParams, paging *cdata.PagingParams,
+		sort *cdata.SortParams) (page *DummyPage, err error)
+}
@@ -0,0 +1,218 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+)
+
+// Default image of throwaway database containers, overridden by POSTGRES_TEST_IMAGE
+const defaultTestImage = "postgres:14"
+
+// Throwaway database container shared by all tests of a test binary
+var testContainer struct {
+	once sync.Once
+	lock sync.Mutex
+	id   string
+	port string
+	err  error
+}
+
+// Database for integration tests of persistence components.
+// Every test run gets an isolated schema that is dropped on cleanup.
+type PostgresTestDatabase struct {
+	// Configuration of persistence components with connection, credentials and the schema of the run
+	Config *cconf.ConfigParams
+	// Name of the isolated schema
+	Schema string
+
+	pool *pgxpool.Pool
+}
+
+// Starts a database for integration tests. When POSTGRES_URI or POSTGRES_HOST is set,
+// the database configured by POSTGRES_* environment variables is used, otherwise
+// a throwaway Postgres container is started with docker. The container is started once
+// and shared by all tests of the test binary, it is removed by RunPostgresTests.
+// The test is skipped if neither is available. The schema is removed when the test completes.
+//   - t     a test or benchmark that uses the database
+//
+// Returns the started database.
+func StartPostgresTestDatabase(t testing.TB) *PostgresTestDatabase {
+	t.Helper()
+	c := &PostgresTestDatabase{}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Logf("Failed to clean up test database: %v", err)
+		}
+	})
+
+	uri := os.Getenv("POSTGRES_URI")
+	host := os.Getenv("POSTGRES_HOST")
+	port := getEnvWithDefault("POSTGRES_PORT", "5432")
+	database := getEnvWithDefault("POSTGRES_DB", "test")
+	user := getEnvWithDefault("POSTGRES_USER", "postgres")
+	password := getEnvWithDefault("POSTGRES_PASSWORD", "postgres#")
+
+	if uri == "" && host == "" {
+		var err error
+		host = "127.0.0.1"
+		if port, err = startTestContainer(database, user, password); err != nil {
+			t.Skipf("Database is not configured and container cannot be started: %v", err)
+		}
+	}
+
+	c.Schema = "test_" + strconv.FormatInt(time.Now().UnixNano(), 36) + "_" + strconv.Itoa(rand.Intn(1000))
+	c.Config = cconf.NewConfigParamsFromTuples(
+		"connection.uri", uri,
+		"connection.host", host,
+		"connection.port", port,
+		"connection.database", database,
+		"credential.username", user,
+		"credential.password", password,
+		"schema", c.Schema,
+	)
+
+	connString := uri
+	if connString == "" {
+		connString = "host=" + host + " port=" + port + " dbname=" + database +
+			" user=" + user + " password='" + strings.NewReplacer("\\", "\\\\", "'", "\\'").Replace(password) + "'"
+	}
+	if err := c.connect(connString); err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	if _, err := c.pool.Exec(context.Background(), "CREATE SCHEMA \""+c.Schema+"\""); err != nil {
+		t.Fatalf("Failed to create test schema: %v", err)
+	}
+	return c
+}
+
+// Starts the shared database container on the first call and returns the port it is published on
+func startTestContainer(database string, user string, password string) (string, error) {
+	testContainer.once.Do(func() {
+		testContainer.port, testContainer.err = runTestContainer(database, user, password)
+	})
+	return testContainer.port, testContainer.err
+}
+
+// Starts a throwaway database container and returns the port it is published on.
+// Prepared transactions are enabled like in docker-compose files for two-phase commit tests.
+func runTestContainer(database string, user string, password string) (string, error) {
+	image := getEnvWithDefault("POSTGRES_TEST_IMAGE", defaultTestImage)
+	output, err := exec.Command("docker", "run", "-d", "--rm",
+		"-e", "POSTGRES_DB="+database, "-e", "POSTGRES_USER="+user, "-e", "POSTGRES_PASSWORD="+password,
+		"-p", "127.0.0.1::5432", image, "postgres", "-c", "max_prepared_transactions=10").Output()
+	if err != nil {
+		return "", err
+	}
+	testContainer.lock.Lock()
+	testContainer.id = strings.TrimSpace(string(output))
+	id := testContainer.id
+	testContainer.lock.Unlock()
+
+	output, err = exec.Command("docker", "port", id, "5432/tcp").Output()
+	if err != nil {
+		return "", err
+	}
+	// Output is like "127.0.0.1:49153"
+	address := strings.TrimSpace(strings.Split(string(output), "\n")[0])
+	return address[strings.LastIndex(address, ":")+1:], nil
+}
+
+// Removes the shared database container if it was started
+// Returns error or nil no errors occured.
+func StopPostgresTestContainer() error {
+	testContainer.lock.Lock()
+	id := testContainer.id
+	testContainer.id = ""
+	testContainer.lock.Unlock()
+
+	if id == "" {
+		return nil
+	}
+	return exec.Command("docker", "rm", "-f", id).Run()
+}
+
+// Runs tests of a package and removes the database container shared by the tests.
+// Packages that use StartPostgresTestDatabase call it from TestMain:
+//
+//     func TestMain(m *testing.M) {
+//         os.Exit(tf.RunPostgresTests(m))
+//     }
+//
+//   - m     tests of the package
+//
+// Returns the exit code of the tests.
+func RunPostgresTests(m *testing.M) int {
+	code := m.Run()
+	if err := StopPostgresTestContainer(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to remove test database container: %v\n", err)
+	}
+	return code
+}
+
+// Connects to the database waiting until it accepts connections
+func (c *PostgresTestDatabase) connect(connString string) error {
+	deadline := time.Now().Add(time.Minute)
+	for {
+		pool, err := pgxpool.Connect(context.Background(), connString)
+		if err == nil {
+			if err = pool.Ping(context.Background()); err == nil {
+				c.pool = pool
+				return nil
+			}
+			pool.Close()
+		}
+		if time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// Drops the schema of the run. The shared container is kept for other tests.
+// Returns error or nil no errors occured.
+func (c *PostgresTestDatabase) Close() error {
+	var err error
+	if c.pool != nil {
+		// Tests may terminate sessions of the database, so the drop is repeated on a new connection
+		for attempt := 0; attempt < 2; attempt++ {
+			if _, err = c.pool.Exec(context.Background(), "DROP SCHEMA IF EXISTS \""+c.Schema+"\" CASCADE"); err == nil {
+				break
+			}
+		}
+		c.pool.Close()
+		c.pool = nil
+	}
+	return err
+}
+
+// Composes a quoted name of a database object in a schema, so tests create side tables
+// and functions next to the table of a persistence.
+//   - schema    a schema name, e.g. Schema of the test database or SchemaName of a persistence
+//   - name      a name of the object
+//
+// Returns the quoted name like "schema"."name", or "name" when the schema is empty.
+func QuotedName(schema string, name string) string {
+	quoted := "\"" + strings.ReplaceAll(name, "\"", "\"\"") + "\""
+	if schema == "" {
+		return quoted
+	}
+	return "\"" + strings.ReplaceAll(schema, "\"", "\"\"") + "\"." + quoted
+}
+
+func getEnvWithDefault(name string, defaultValue string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,112 @@
+package test_lock
+
+import (
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	lock "github.com/pip-services3-go/pip-services3-postgres-go/lock"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresLock(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+	dbConfig := db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.max_pool_size", 5,
+	))
+
+	lock1 := lock.NewPostgresLock()
+	lock1.Configure(dbConfig)
+	err := lock1.Open("")
+	if err != nil {
+		t.Error("Error opened lock", err)
+		return
+	}
+	defer lock1.Close("")
+
+	lock2 := lock.NewPostgresLock()
+	lock2.Configure(dbConfig)
+	err = lock2.Open("")
+	if err != nil {
+		t.Error("Error opened lock", err)
+		return
+	}
+	defer lock2.Close("")
+
+	t.Run("TryAcquireLock", func(t *testing.T) {
+		ok, err := lock1.TryAcquireLock("", "test_lock", 10000)
+		assert.Nil(t, err)
+		assert.True(t, ok)
+
+		ok, err = lock2.TryAcquireLock("", "test_lock", 10000)
+		assert.Nil(t, err)
+		assert.False(t, ok)
+
+		err = lock1.ReleaseLock("", "test_lock")
+		assert.Nil(t, err)
+
+		ok, err = lock2.TryAcquireLock("", "test_lock", 10000)
+		assert.Nil(t, err)
+		assert.True(t, ok)
+
+		err = lock2.ReleaseLock("", "test_lock")
+		assert.Nil(t, err)
+	})
+
+	t.Run("AcquireLock", func(t *testing.T) {
+		err := lock1.AcquireLock("", "test_lock", 300, 1000)
+		assert.Nil(t, err)
+
+		err = lock2.AcquireLock("", "test_lock", 300, 100)
+		assert.NotNil(t, err)
+
+		// Lock expires by ttl
+		err = lock2.AcquireLock("", "test_lock", 300, 2000)
+		assert.Nil(t, err)
+
+		err = lock2.ReleaseLock("", "test_lock")
+		assert.Nil(t, err)
+	})
+
+	t.Run("LockExpiresByTtl", func(t *testing.T) {
+		ok, err := lock1.TryAcquireLock("", "test_lock", 100)
+		assert.Nil(t, err)
+		assert.True(t, ok)
+
+		time.Sleep(300 * time.Millisecond)
+
+		ok, err = lock2.TryAcquireLock("", "test_lock", 100)
+		assert.Nil(t, err)
+		assert.True(t, ok)
+
+		lock2.ReleaseLock("", "test_lock")
+	})
+	t.Run("AcquireLockWithBusyPool", func(t *testing.T) {
+		pooled := lock.NewPostgresLock()
+		pooled.Configure(db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+			"options.max_pool_size", 2,
+		)))
+		err := pooled.Open("")
+		if err != nil {
+			t.Error("Error opened lock", err)
+			return
+		}
+		defer pooled.Close("")
+
+		// Held locks keep all connections of the pool
+		assert.Nil(t, pooled.AcquireLock("", "busy_lock_1", 10000, 1000))
+		assert.Nil(t, pooled.AcquireLock("", "busy_lock_2", 10000, 1000))
+
+		start := time.Now()
+		err = pooled.AcquireLock("", "busy_lock_3", 10000, 300)
+		assert.True(t, time.Since(start) < 2*time.Second)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "LOCK_TIMEOUT", err.(*cerr.ApplicationError).Code)
+		}
+
+		assert.Nil(t, pooled.ReleaseLock("", "busy_lock_1"))
+		assert.Nil(t, pooled.AcquireLock("", "busy_lock_3", 10000, 1000))
+	})
+}
@@ -0,0 +1,49 @@
+package test
+
+import (
+	"bytes"
+	"testing"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlobPostgresPersistence(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+	dbConfig := db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.chunk_size", 4096,
+	))
+
+	persistence := persist.NewBlobPostgresPersistence("blobs")
+	persistence.Configure(dbConfig)
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	err = persistence.Clear("")
+	assert.Nil(t, err)
+
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+	info, err := persistence.Upload("", &persist.BlobInfo{Name: "test.txt", ContentType: "text/plain"},
+		bytes.NewReader(content))
+	assert.Nil(t, err)
+	assert.NotEqual(t, "", info.Id)
+	assert.Equal(t, int64(len(content)), info.Size)
+	assert.True(t, info.Completed)
+
+	var buffer bytes.Buffer
+	err = persistence.Download("", info.Id, &buffer)
+	assert.Nil(t, err)
+	assert.Equal(t, content, buffer.Bytes())
+
+	_, err = persistence.DeleteById("", info.Id)
+	assert.Nil(t, err)
+
+	err = persistence.Download("", info.Id, &buffer)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,94 @@
+package test
+
+import (
+	"reflect"
+	"testing"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+// Persistence of dummies with an integer content column, so rows with content fail conversion
+type DummyConversionPostgresPersistence struct {
+	persist.IdentifiablePostgresPersistence
+}
+
+func NewDummyConversionPostgresPersistence() *DummyConversionPostgresPersistence {
+	c := &DummyConversionPostgresPersistence{}
+	c.IdentifiablePostgresPersistence = *persist.InheritIdentifiablePostgresPersistence(c,
+		reflect.TypeOf(tf.Dummy{}), "conversion_dummies")
+	return c
+}
+
+func (c *DummyConversionPostgresPersistence) DefineSchema() {
+	c.ClearSchema()
+	c.IdentifiablePostgresPersistence.DefineSchema()
+	c.EnsureSchema("CREATE TABLE " + c.QuotedTableName() + " (\"id\" TEXT PRIMARY KEY, \"key\" TEXT, \"content\" INTEGER)")
+}
+
+func TestDataConversionError(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := NewDummyConversionPostgresPersistence()
+	persistence.Configure(db.Config)
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	strict := NewDummyConversionPostgresPersistence()
+	strict.Configure(db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.strict_conversion", true,
+	)))
+	err = strict.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer strict.Close("")
+
+	_, err = persistence.ExecNonQuery("", "INSERT INTO "+persistence.QuotedTableName()+
+		" (\"id\", \"key\", \"content\") VALUES ('1', 'Key 1', NULL), ('2', 'Key 2', 5), ('3', 'Key 3', NULL)")
+	assert.Nil(t, err)
+
+	// Rows that fail conversion are skipped and counted in pages
+	page, err := persistence.GetPageByFilterWithPaging("", "", persist.NewPostgresPagingParams(0, 10, false, ""), "\"id\"", nil)
+	assert.Nil(t, err)
+	assert.Len(t, page.Data, 2)
+	assert.Equal(t, 1, page.Skipped)
+
+	tokenPage, err := persistence.GetPageByToken("", "", "", 10)
+	assert.Nil(t, err)
+	assert.Len(t, tokenPage.Data, 2)
+	assert.Equal(t, 1, tokenPage.Skipped)
+
+	// The iterator passes over skipped rows
+	iterator := persistence.NewPageIterator("", nil, "\"id\"", 1)
+	defer iterator.Close()
+	count := 0
+	for iterator.Next() {
+		count += len(iterator.Page())
+	}
+	assert.Nil(t, iterator.Err())
+	assert.Equal(t, 2, count)
+
+	// Strict mode fails with an error naming the column and the value
+	_, err = strict.GetPageByFilter("", "", cdata.NewPagingParams(0, 10, false), "\"id\"", nil)
+	assert.NotNil(t, err)
+	convErr, ok := err.(*persist.DataConversionError)
+	if assert.True(t, ok) {
+		assert.Equal(t, "content", convErr.Column)
+		assert.EqualValues(t, 5, convErr.Value)
+		assert.Equal(t, "DATA_CONVERSION_FAILED", convErr.Code)
+	}
+
+	// Rows that convert are read in strict mode as well
+	items, err := strict.GetListByFilter("", "\"content\" IS NULL", "\"id\"", nil)
+	assert.Nil(t, err)
+	assert.Len(t, items, 2)
+}
@@ -27,6 +27,11 @@ func (c *DummyJsonPostgresPersistence) DefineSchema() {
 }
 
 func (c *DummyJsonPostgresPersistence) GetPageByFilter(correlationId string, filter *cdata.FilterParams, paging *cdata.PagingParams) (page *tf.DummyPage, err error) {
+	return c.GetSortedPageByFilter(correlationId, filter, paging, nil)
+}
+
+func (c *DummyJsonPostgresPersistence) GetSortedPageByFilter(correlationId string, filter *cdata.FilterParams, paging *cdata.PagingParams,
+	sort *cdata.SortParams) (page *tf.DummyPage, err error) {
 
 	if &filter == nil {
 		filter = cdata.NewEmptyFilterParams()
@@ -35,19 +40,36 @@ func (c *DummyJsonPostgresPersistence) GetPageByFilter(correlationId string, fil
 	key := filter.GetAsNullableString("Key")
 	filterObj := ""
 	if key != nil && *key != "" {
-		filterObj += "data->key='" + *key + "'"
+		filterObj += "data->>'key'='" + *key + "'"
+	}
+	sorting := ""
+	if sort != nil {
+		for _, field := range *sort {
+			if sorting != "" {
+				sorting += ","
+			}
+			sorting += "data->>" + c.QuoteLiteral(field.Name)
+			if !field.Ascending {
+				sorting += " DESC"
+			}
+		}
 	}
 
 	tempPage, err := c.IdentifiablePostgresPersistence.GetPageByFilter(correlationId,
 		filterObj, paging,
-		nil, nil)
+		sorting, nil)
+	if err != nil {
+		return nil, err
+	}
 	// Convert to DummyPage
 	dataLen := int64(len(tempPage.Data)) // For full release tempPage and delete this by GC
 	data := make([]tf.Dummy, dataLen)
 	for i, v := range tempPage.Data {
 		data[i] = v.(tf.Dummy)
 	}
-	page = tf.NewDummyPage(&dataLen, data)
+	// The total is taken from the persistence, as it counts all matching items and not only
+	// the items of the page. The paging fixture checks it against the number of created items.
+	page = tf.NewDummyPage(tempPage.Total, data)
 	return page, err
 }
 
@@ -61,7 +83,7 @@ func (c *DummyJsonPostgresPersistence) GetCountByFilter(correlationId string, fi
 	filterObj := ""
 
 	if key != nil && *key != "" {
-		filterObj += "data->key='" + *key + "'"
+		filterObj += "data->>'key'='" + *key + "'"
 	}
 
 	return c.IdentifiablePostgresPersistence.GetCountByFilter(correlationId, filterObj)
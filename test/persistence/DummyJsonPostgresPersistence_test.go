@@ -1,10 +1,8 @@
 package test
 
 import (
-	"os"
 	"testing"
 
-	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
 	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
 )
 
@@ -13,54 +11,50 @@ func TestDummyJsonPostgresPersistence(t *testing.T) {
 	var persistence *DummyJsonPostgresPersistence
 	var fixture tf.DummyPersistenceFixture
 
-	postgresUri := os.Getenv("POSTGRES_URI")
-	postgresHost := os.Getenv("POSTGRES_HOST")
-	if postgresHost == "" {
-		postgresHost = "localhost"
-	}
+	db := tf.StartPostgresTestDatabase(t)
 
-	postgresPort := os.Getenv("POSTGRES_PORT")
-	if postgresPort == "" {
-		postgresPort = "5432"
-	}
+	persistence = NewDummyJsonPostgresPersistence()
+	fixture = *tf.NewDummyPersistenceFixture(persistence)
+	persistence.Configure(db.Config)
 
-	postgresDatabase := os.Getenv("POSTGRES_DB")
-	if postgresDatabase == "" {
-		postgresDatabase = "test"
+	opnErr := persistence.Open("")
+	if opnErr != nil {
+		t.Error("Error opened persistence", opnErr)
+		return
 	}
+	defer persistence.Close("")
 
-	postgresUser := os.Getenv("POSTGRES_USER")
-	if postgresUser == "" {
-		postgresUser = "postgres"
-	}
-	postgresPassword := os.Getenv("POSTGRES_PASSWORD")
-	if postgresPassword == "" {
-		postgresPassword = "postgres#"
+	opnErr = persistence.Clear("")
+	if opnErr != nil {
+		t.Error("Error cleaned persistence", opnErr)
+		return
 	}
 
-	if postgresUri == "" && postgresHost == "" {
-		panic("Connection params not set")
+	t.Run("DummyPostgresConnection:CRUD", fixture.TestCrudOperations)
+
+	opnErr = persistence.Clear("")
+	if opnErr != nil {
+		t.Error("Error cleaned persistence", opnErr)
+		return
 	}
 
-	dbConfig := cconf.NewConfigParamsFromTuples(
-		"connection.uri", postgresUri,
-		"connection.host", postgresHost,
-		"connection.port", postgresPort,
-		"connection.database", postgresDatabase,
-		"credential.username", postgresUser,
-		"credential.password", postgresPassword,
-	)
+	t.Run("DummyPostgresConnection:CrudContract", fixture.TestCrudContract)
 
-	persistence = NewDummyJsonPostgresPersistence()
-	fixture = *tf.NewDummyPersistenceFixture(persistence)
-	persistence.Configure(dbConfig)
+	opnErr = persistence.Clear("")
+	if opnErr != nil {
+		t.Error("Error cleaned persistence", opnErr)
+		return
+	}
 
-	opnErr := persistence.Open("")
+	t.Run("DummyPostgresConnection:Batch", fixture.TestBatchOperations)
+
+	opnErr = persistence.Clear("")
 	if opnErr != nil {
-		t.Error("Error opened persistence", opnErr)
+		t.Error("Error cleaned persistence", opnErr)
 		return
 	}
-	defer persistence.Close("")
+
+	t.Run("DummyPostgresConnection:Paging", fixture.TestPagingOperations)
 
 	opnErr = persistence.Clear("")
 	if opnErr != nil {
@@ -68,7 +62,7 @@ func TestDummyJsonPostgresPersistence(t *testing.T) {
 		return
 	}
 
-	t.Run("DummyPostgresConnection:CRUD", fixture.TestCrudOperations)
+	t.Run("DummyPostgresConnection:Sorting", fixture.TestSortingOperations)
 
 	opnErr = persistence.Clear("")
 	if opnErr != nil {
@@ -76,6 +70,6 @@ func TestDummyJsonPostgresPersistence(t *testing.T) {
 		return
 	}
 
-	t.Run("DummyPostgresConnection:Batch", fixture.TestBatchOperations)
+	t.Run("DummyPostgresConnection:Json", tf.NewDummyJsonPersistenceFixture(persistence).TestJsonOperations)
 
 }
@@ -1,10 +1,8 @@
 package test
 
 import (
-	"os"
 	"testing"
 
-	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
 	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
 )
 
@@ -13,46 +11,10 @@ func TestDummyMapPostgresPersistence(t *testing.T) {
 	var persistence *DummyMapPostgresPersistence
 	var fixture tf.DummyMapPersistenceFixture
 
-	postgresUri := os.Getenv("POSTGRES_URI")
-	postgresHost := os.Getenv("POSTGRES_HOST")
-	if postgresHost == "" {
-		postgresHost = "localhost"
-	}
-
-	postgresPort := os.Getenv("POSTGRES_PORT")
-	if postgresPort == "" {
-		postgresPort = "5432"
-	}
-
-	postgresDatabase := os.Getenv("POSTGRES_DB")
-	if postgresDatabase == "" {
-		postgresDatabase = "test"
-	}
-
-	postgresUser := os.Getenv("POSTGRES_USER")
-	if postgresUser == "" {
-		postgresUser = "postgres"
-	}
-	postgresPassword := os.Getenv("POSTGRES_PASSWORD")
-	if postgresPassword == "" {
-		postgresPassword = "postgres#"
-	}
-
-	if postgresUri == "" && postgresHost == "" {
-		panic("Connection params not set")
-	}
-
-	dbConfig := cconf.NewConfigParamsFromTuples(
-		"connection.uri", postgresUri,
-		"connection.host", postgresHost,
-		"connection.port", postgresPort,
-		"connection.database", postgresDatabase,
-		"credential.username", postgresUser,
-		"credential.password", postgresPassword,
-	)
+	db := tf.StartPostgresTestDatabase(t)
 
 	persistence = NewDummyMapPostgresPersistence()
-	persistence.Configure(dbConfig)
+	persistence.Configure(db.Config)
 
 	fixture = *tf.NewDummyMapPersistenceFixture(persistence)
 
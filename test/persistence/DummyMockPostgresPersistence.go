@@ -0,0 +1,170 @@
+package test
+
+import (
+	"reflect"
+
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+)
+
+type DummyMockPostgresPersistence struct {
+	*persist.MockPostgresPersistence
+}
+
+func NewDummyMockPostgresPersistence() *DummyMockPostgresPersistence {
+	c := &DummyMockPostgresPersistence{
+		MockPostgresPersistence: persist.NewMockPostgresPersistence(reflect.TypeOf(tf.Dummy{})),
+	}
+	// Evaluates key conditions composed by GetSortedPageByFilter and GetCountByFilter
+	c.FilterMatcher = func(filter string, item interface{}) bool {
+		return filter == "key='"+item.(tf.Dummy).Key+"'"
+	}
+	return c
+}
+
+func (c *DummyMockPostgresPersistence) Create(correlationId string, item tf.Dummy) (result tf.Dummy, err error) {
+	value, err := c.MockPostgresPersistence.Create(correlationId, item)
+
+	if value != nil {
+		val, _ := value.(tf.Dummy)
+		result = val
+	}
+	return result, err
+}
+
+func (c *DummyMockPostgresPersistence) GetListByIds(correlationId string, ids []string) (items []tf.Dummy, err error) {
+	convIds := make([]interface{}, len(ids))
+	for i, v := range ids {
+		convIds[i] = v
+	}
+	result, err := c.MockPostgresPersistence.GetListByIds(correlationId, convIds)
+	items = make([]tf.Dummy, len(result))
+	for i, v := range result {
+		val, _ := v.(tf.Dummy)
+		items[i] = val
+	}
+	return items, err
+}
+
+func (c *DummyMockPostgresPersistence) GetOneById(correlationId string, id string) (item tf.Dummy, err error) {
+	result, err := c.MockPostgresPersistence.GetOneById(correlationId, id)
+	if result != nil {
+		val, _ := result.(tf.Dummy)
+		item = val
+	}
+	return item, err
+}
+
+func (c *DummyMockPostgresPersistence) GetOneRandom(correlationId string) (item tf.Dummy, err error) {
+	result, err := c.MockPostgresPersistence.GetOneRandom(correlationId, nil)
+	if result != nil {
+		val, _ := result.(tf.Dummy)
+		item = val
+	}
+	return item, err
+}
+
+func (c *DummyMockPostgresPersistence) Update(correlationId string, item tf.Dummy) (result tf.Dummy, err error) {
+	value, err := c.MockPostgresPersistence.Update(correlationId, item)
+	if value != nil {
+		val, _ := value.(tf.Dummy)
+		result = val
+	}
+	return result, err
+}
+
+func (c *DummyMockPostgresPersistence) Set(correlationId string, item tf.Dummy) (result tf.Dummy, err error) {
+	value, err := c.MockPostgresPersistence.Set(correlationId, item)
+	if value != nil {
+		val, _ := value.(tf.Dummy)
+		result = val
+	}
+	return result, err
+}
+
+func (c *DummyMockPostgresPersistence) UpdatePartially(correlationId string, id string, data *cdata.AnyValueMap) (item tf.Dummy, err error) {
+	result, err := c.MockPostgresPersistence.UpdatePartially(correlationId, id, data)
+
+	if result != nil {
+		val, _ := result.(tf.Dummy)
+		item = val
+	}
+	return item, err
+}
+
+func (c *DummyMockPostgresPersistence) DeleteById(correlationId string, id string) (item tf.Dummy, err error) {
+	result, err := c.MockPostgresPersistence.DeleteById(correlationId, id)
+	if result != nil {
+		val, _ := result.(tf.Dummy)
+		item = val
+	}
+	return item, err
+}
+
+func (c *DummyMockPostgresPersistence) DeleteByIds(correlationId string, ids []string) (err error) {
+	convIds := make([]interface{}, len(ids))
+	for i, v := range ids {
+		convIds[i] = v
+	}
+	return c.MockPostgresPersistence.DeleteByIds(correlationId, convIds)
+}
+
+func (c *DummyMockPostgresPersistence) GetPageByFilter(correlationId string, filter *cdata.FilterParams, paging *cdata.PagingParams) (page *tf.DummyPage, err error) {
+	return c.GetSortedPageByFilter(correlationId, filter, paging, nil)
+}
+
+func (c *DummyMockPostgresPersistence) GetSortedPageByFilter(correlationId string, filter *cdata.FilterParams, paging *cdata.PagingParams,
+	sort *cdata.SortParams) (page *tf.DummyPage, err error) {
+
+	if &filter == nil {
+		filter = cdata.NewEmptyFilterParams()
+	}
+
+	key := filter.GetAsNullableString("Key")
+	filterObj := ""
+	if key != nil && *key != "" {
+		filterObj += "key='" + *key + "'"
+	}
+	sorting := ""
+	if sort != nil {
+		for _, field := range *sort {
+			if sorting != "" {
+				sorting += ","
+			}
+			sorting += field.Name
+			if !field.Ascending {
+				sorting += " DESC"
+			}
+		}
+	}
+
+	tempPage, err := c.MockPostgresPersistence.GetPageByFilter(correlationId,
+		filterObj, paging,
+		sorting, nil)
+	if err != nil {
+		return nil, err
+	}
+	// Convert to DummyPage
+	dataLen := int64(len(tempPage.Data)) // For full release tempPage and delete this by GC
+	data := make([]tf.Dummy, dataLen)
+	for i, v := range tempPage.Data {
+		data[i] = v.(tf.Dummy)
+	}
+	page = tf.NewDummyPage(tempPage.Total, data)
+	return page, err
+}
+
+func (c *DummyMockPostgresPersistence) GetCountByFilter(correlationId string, filter *cdata.FilterParams) (count int64, err error) {
+
+	if &filter == nil {
+		filter = cdata.NewEmptyFilterParams()
+	}
+
+	key := filter.GetAsNullableString("Key")
+	filterObj := ""
+	if key != nil && *key != "" {
+		filterObj += "key='" + *key + "'"
+	}
+	return c.MockPostgresPersistence.GetCountByFilter(correlationId, filterObj)
+}
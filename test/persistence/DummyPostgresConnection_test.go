@@ -1,10 +1,8 @@
 package test
 
 import (
-	"os"
 	"testing"
 
-	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
 	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
 	conn "github.com/pip-services3-go/pip-services3-postgres-go/connect"
 	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
@@ -17,46 +15,10 @@ func TestDummyPostgresConnection(t *testing.T) {
 	var fixture tf.DummyPersistenceFixture
 	var connection *conn.PostgresConnection
 
-	postgresUri := os.Getenv("POSTGRES_URI")
-	postgresHost := os.Getenv("POSTGRES_HOST")
-	if postgresHost == "" {
-		postgresHost = "localhost"
-	}
-
-	postgresPort := os.Getenv("POSTGRES_PORT")
-	if postgresPort == "" {
-		postgresPort = "5432"
-	}
-
-	postgresDatabase := os.Getenv("POSTGRES_DB")
-	if postgresDatabase == "" {
-		postgresDatabase = "test"
-	}
-
-	postgresUser := os.Getenv("POSTGRES_USER")
-	if postgresUser == "" {
-		postgresUser = "postgres"
-	}
-	postgresPassword := os.Getenv("POSTGRES_PASSWORD")
-	if postgresPassword == "" {
-		postgresPassword = "postgres#"
-	}
-
-	if postgresUri == "" && postgresHost == "" {
-		panic("Connection params not set")
-	}
-
-	dbConfig := cconf.NewConfigParamsFromTuples(
-		"connection.uri", postgresUri,
-		"connection.host", postgresHost,
-		"connection.port", postgresPort,
-		"connection.database", postgresDatabase,
-		"credential.username", postgresUser,
-		"credential.password", postgresPassword,
-	)
+	db := tf.StartPostgresTestDatabase(t)
 
 	connection = conn.NewPostgresConnection()
-	connection.Configure(dbConfig)
+	connection.Configure(db.Config)
 
 	persistence = NewDummyPostgresPersistence()
 	descr := cref.NewDescriptor("pip-services", "connection", "postgres", "default", "1.0")
@@ -115,6 +115,11 @@ func (c *DummyPostgresPersistence) DeleteByIds(correlationId string, ids []strin
 }
 
 func (c *DummyPostgresPersistence) GetPageByFilter(correlationId string, filter *cdata.FilterParams, paging *cdata.PagingParams) (page *tf.DummyPage, err error) {
+	return c.GetSortedPageByFilter(correlationId, filter, paging, nil)
+}
+
+func (c *DummyPostgresPersistence) GetSortedPageByFilter(correlationId string, filter *cdata.FilterParams, paging *cdata.PagingParams,
+	sort *cdata.SortParams) (page *tf.DummyPage, err error) {
 
 	if &filter == nil {
 		filter = cdata.NewEmptyFilterParams()
@@ -126,17 +131,33 @@ func (c *DummyPostgresPersistence) GetPageByFilter(correlationId string, filter
 		filterObj += "key='" + *key + "'"
 	}
 	sorting := ""
+	if sort != nil {
+		for _, field := range *sort {
+			if sorting != "" {
+				sorting += ","
+			}
+			sorting += c.QuoteIdentifier(field.Name)
+			if !field.Ascending {
+				sorting += " DESC"
+			}
+		}
+	}
 
 	tempPage, err := c.IdentifiablePostgresPersistence.GetPageByFilter(correlationId,
 		filterObj, paging,
 		sorting, nil)
+	if err != nil {
+		return nil, err
+	}
 	// Convert to DummyPage
 	dataLen := int64(len(tempPage.Data)) // For full release tempPage and delete this by GC
 	data := make([]tf.Dummy, dataLen)
 	for i, v := range tempPage.Data {
 		data[i] = v.(tf.Dummy)
 	}
-	page = tf.NewDummyPage(&dataLen, data)
+	// The total is taken from the persistence, as it counts all matching items and not only
+	// the items of the page. The paging fixture checks it against the number of created items.
+	page = tf.NewDummyPage(tempPage.Total, data)
 	return page, err
 }
 
@@ -1,11 +1,11 @@
 package test
 
 import (
-	"os"
+	"strconv"
 	"testing"
 
-	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
 	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestDummyPostgresPersistence(t *testing.T) {
@@ -13,55 +13,58 @@ func TestDummyPostgresPersistence(t *testing.T) {
 	var persistence *DummyPostgresPersistence
 	var fixture tf.DummyPersistenceFixture
 
-	postgresUri := os.Getenv("POSTGRES_URI")
-	postgresHost := os.Getenv("POSTGRES_HOST")
-	if postgresHost == "" {
-		postgresHost = "localhost"
-	}
+	db := tf.StartPostgresTestDatabase(t)
 
-	postgresPort := os.Getenv("POSTGRES_PORT")
-	if postgresPort == "" {
-		postgresPort = "5432"
-	}
+	persistence = NewDummyPostgresPersistence()
+	fixture = *tf.NewDummyPersistenceFixture(persistence)
+	persistence.Configure(db.Config)
 
-	postgresDatabase := os.Getenv("POSTGRES_DB")
-	if postgresDatabase == "" {
-		postgresDatabase = "test"
+	opnErr := persistence.Open("")
+	if opnErr != nil {
+		t.Error("Error opened persistence", opnErr)
+		return
 	}
+	defer persistence.Close("")
 
-	postgresUser := os.Getenv("POSTGRES_USER")
-	if postgresUser == "" {
-		postgresUser = "postgres"
+	opnErr = persistence.Clear("")
+	if opnErr != nil {
+		t.Error("Error cleaned persistence", opnErr)
+		return
 	}
-	postgresPassword := os.Getenv("POSTGRES_PASSWORD")
-	if postgresPassword == "" {
-		postgresPassword = "postgres#"
+
+	t.Run("DummyPostgresPersistence:CRUD", fixture.TestCrudOperations)
+
+	opnErr = persistence.Clear("")
+	if opnErr != nil {
+		t.Error("Error cleaned persistence", opnErr)
+		return
 	}
 
-	if postgresUri == "" && postgresHost == "" {
-		panic("Connection params not set")
+	t.Run("DummyPostgresPersistence:CrudContract", fixture.TestCrudContract)
+
+	opnErr = persistence.Clear("")
+	if opnErr != nil {
+		t.Error("Error cleaned persistence", opnErr)
+		return
 	}
 
-	dbConfig := cconf.NewConfigParamsFromTuples(
-		"connection.uri", postgresUri,
-		"connection.host", postgresHost,
-		"connection.port", postgresPort,
-		"connection.database", postgresDatabase,
-		"credential.username", postgresUser,
-		"credential.password", postgresPassword,
-		"schema", "test_schema",
-	)
+	t.Run("DummyPostgresPersistence:Batch", fixture.TestBatchOperations)
 
-	persistence = NewDummyPostgresPersistence()
-	fixture = *tf.NewDummyPersistenceFixture(persistence)
-	persistence.Configure(dbConfig)
+	opnErr = persistence.Clear("")
+	if opnErr != nil {
+		t.Error("Error cleaned persistence", opnErr)
+		return
+	}
 
-	opnErr := persistence.Open("")
+	t.Run("DummyPostgresPersistence:Random", fixture.TestRandomOperation)
+
+	opnErr = persistence.Clear("")
 	if opnErr != nil {
-		t.Error("Error opened persistence", opnErr)
+		t.Error("Error cleaned persistence", opnErr)
 		return
 	}
-	defer persistence.Close("")
+
+	t.Run("DummyPostgresPersistence:Paging", fixture.TestPagingOperations)
 
 	opnErr = persistence.Clear("")
 	if opnErr != nil {
@@ -69,7 +72,7 @@ func TestDummyPostgresPersistence(t *testing.T) {
 		return
 	}
 
-	t.Run("DummyPostgresPersistence:CRUD", fixture.TestCrudOperations)
+	t.Run("DummyPostgresPersistence:Sorting", fixture.TestSortingOperations)
 
 	opnErr = persistence.Clear("")
 	if opnErr != nil {
@@ -77,7 +80,43 @@ func TestDummyPostgresPersistence(t *testing.T) {
 		return
 	}
 
-	t.Run("DummyPostgresPersistence:Batch", fixture.TestBatchOperations)
+	t.Run("DummyPostgresPersistence:PageIterator", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			_, err := persistence.Create("", tf.Dummy{Key: "Key " + strconv.Itoa(i), Content: "Content"})
+			assert.Nil(t, err)
+		}
+
+		iterator := persistence.NewPageIterator("", nil, "\"key\"", 2)
+		defer iterator.Close()
+
+		pages := 0
+		count := 0
+		for iterator.Next() {
+			pages++
+			count += len(iterator.Page())
+		}
+		assert.Nil(t, iterator.Err())
+		assert.Equal(t, 3, pages)
+		assert.Equal(t, 5, count)
+
+		// Page size is limited by MaxPageSize, so no items are skipped between pages
+		maxPageSize := persistence.MaxPageSize
+		persistence.MaxPageSize = 2
+		defer func() { persistence.MaxPageSize = maxPageSize }()
+
+		large := persistence.NewPageIterator("", nil, "\"key\"", 1000)
+		defer large.Close()
+
+		pages = 0
+		count = 0
+		for large.Next() {
+			pages++
+			count += len(large.Page())
+		}
+		assert.Nil(t, large.Err())
+		assert.Equal(t, 3, pages)
+		assert.Equal(t, 5, count)
+	})
 
 	opnErr = persistence.Clear("")
 	if opnErr != nil {
@@ -85,5 +124,33 @@ func TestDummyPostgresPersistence(t *testing.T) {
 		return
 	}
 
-	t.Run("DummyPostgresPersistence:Random", fixture.TestRandomOperation)
+	t.Run("DummyPostgresPersistence:WriteResult", func(t *testing.T) {
+		created, err := persistence.CreateWithResult("", tf.Dummy{Key: "Key 1", Content: "Content 1"})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), created.RowsAffected)
+		assert.False(t, created.Conflict)
+		assert.NotNil(t, created.GeneratedId)
+
+		duplicate, err := persistence.CreateWithResult("", tf.Dummy{Key: "Key 1", Content: "Content 2"})
+		assert.Nil(t, err)
+		assert.True(t, duplicate.Conflict)
+		assert.Nil(t, duplicate.Item)
+
+		dummy := created.Item.(tf.Dummy)
+		dummy.Content = "Updated Content"
+		set, err := persistence.SetWithResult("", dummy)
+		assert.Nil(t, err)
+		assert.True(t, set.Conflict)
+		assert.Nil(t, set.GeneratedId)
+		assert.Equal(t, "Updated Content", set.Item.(tf.Dummy).Content)
+
+		deleted, err := persistence.DeleteByIdWithResult("", dummy.Id)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), deleted.RowsAffected)
+
+		deleted, err = persistence.DeleteByIdWithResult("", dummy.Id)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(0), deleted.RowsAffected)
+		assert.Nil(t, deleted.Item)
+	})
 }
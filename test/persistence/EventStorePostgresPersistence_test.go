@@ -0,0 +1,124 @@
+package test
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventStorePostgresPersistence(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := persist.NewEventStorePostgresPersistence("events")
+	persistence.Configure(db.Config)
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	err = persistence.Clear("")
+	assert.Nil(t, err)
+
+	t.Run("AppendAndRead", func(t *testing.T) {
+		events, err := persistence.AppendEvents("", "order1", 0, []*persist.EventRecord{
+			{EventType: "OrderCreated", Data: map[string]interface{}{"total": 100}},
+			{EventType: "OrderPaid", Data: map[string]interface{}{"amount": 100}},
+		})
+		assert.Nil(t, err)
+		assert.Len(t, events, 2)
+		assert.Equal(t, int64(2), events[1].Version)
+		assert.True(t, events[1].Sequence > events[0].Sequence)
+
+		// Stale expected version must be rejected
+		_, err = persistence.AppendEvents("", "order1", 1, []*persist.EventRecord{
+			{EventType: "OrderShipped"},
+		})
+		assert.NotNil(t, err)
+
+		_, err = persistence.AppendEvents("", "order2", persist.ExpectedVersionAny, []*persist.EventRecord{
+			{EventType: "OrderCreated", Data: map[string]interface{}{"total": 50}},
+		})
+		assert.Nil(t, err)
+
+		version, err := persistence.GetAggregateVersion("", "order1")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(2), version)
+
+		events, err = persistence.ReadAggregateEvents("", "order1", 1, 0)
+		assert.Nil(t, err)
+		assert.Len(t, events, 1)
+		assert.Equal(t, "OrderPaid", events[0].EventType)
+
+		count := 0
+		_, err = persistence.StreamAllEvents("", 0, 2, func(event *persist.EventRecord) error {
+			count++
+			return nil
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 3, count)
+	})
+
+	t.Run("Snapshots", func(t *testing.T) {
+		snapshot, err := persistence.LoadSnapshot("", "order1")
+		assert.Nil(t, err)
+		assert.Nil(t, snapshot)
+
+		err = persistence.SaveSnapshot("", "order1", 2, map[string]interface{}{"status": "paid"})
+		assert.Nil(t, err)
+
+		snapshot, err = persistence.LoadSnapshot("", "order1")
+		assert.Nil(t, err)
+		assert.NotNil(t, snapshot)
+		assert.Equal(t, int64(2), snapshot.Version)
+	})
+
+	t.Run("ConcurrentAppends", func(t *testing.T) {
+		lastSequence, err := persistence.StreamAllEvents("", 0, 100, func(event *persist.EventRecord) error {
+			return nil
+		})
+		assert.Nil(t, err)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(aggregateId string) {
+				defer wg.Done()
+				for version := int64(0); version < 10; version++ {
+					_, err := persistence.AppendEvents("", aggregateId, version, []*persist.EventRecord{
+						{EventType: "ItemAdded"},
+					})
+					assert.Nil(t, err)
+				}
+			}("cart" + strconv.Itoa(i))
+		}
+		appended := make(chan bool)
+		go func() {
+			wg.Wait()
+			close(appended)
+		}()
+
+		// A reader that continues from the last read sequence sees every event exactly once
+		read := make(map[int64]bool)
+		collect := func(event *persist.EventRecord) error {
+			assert.False(t, read[event.Sequence])
+			read[event.Sequence] = true
+			return nil
+		}
+		for done := false; !done; {
+			select {
+			case <-appended:
+				done = true
+			default:
+			}
+			lastSequence, err = persistence.StreamAllEvents("", lastSequence, 10, collect)
+			assert.Nil(t, err)
+		}
+		assert.Len(t, read, 100)
+	})
+}
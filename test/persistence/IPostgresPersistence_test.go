@@ -0,0 +1,23 @@
+package test
+
+import (
+	"reflect"
+	"testing"
+
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIPostgresPersistence(t *testing.T) {
+	proto := reflect.TypeOf(tf.Dummy{})
+
+	assert.Implements(t, (*persist.IPostgresPersistence)(nil),
+		persist.InheritPostgresPersistence(nil, proto, "dummies"))
+	assert.Implements(t, (*persist.IIdentifiablePostgresPersistence)(nil),
+		&NewDummyPostgresPersistence().IdentifiablePostgresPersistence)
+	assert.Implements(t, (*persist.IIdentifiablePostgresPersistence)(nil),
+		&NewDummyJsonPostgresPersistence().IdentifiableJsonPostgresPersistence)
+	assert.Implements(t, (*persist.IIdentifiablePostgresPersistence)(nil),
+		persist.NewMockPostgresPersistence(proto))
+}
@@ -0,0 +1,102 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobQueuePostgresPersistence(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := persist.NewJobQueuePostgresPersistence("jobs")
+	persistence.Configure(db.Config)
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	err = persistence.Clear("")
+	assert.Nil(t, err)
+
+	t.Run("OneTimeJob", func(t *testing.T) {
+		job, err := persistence.Enqueue("", "email", map[string]interface{}{"to": "a@b.com"}, time.Now(), 2)
+		assert.Nil(t, err)
+		assert.Equal(t, persist.JobStatusPending, job.Status)
+
+		_, err = persistence.Enqueue("", "email", nil, time.Now().Add(time.Hour), 1)
+		assert.Nil(t, err)
+
+		jobs, err := persistence.ClaimDue("", "worker1", 10*time.Second, 10)
+		assert.Nil(t, err)
+		assert.Len(t, jobs, 1)
+		assert.Equal(t, 1, jobs[0].Attempts)
+
+		err = persistence.Heartbeat("", job.Id, "worker1", 10*time.Second)
+		assert.Nil(t, err)
+
+		err = persistence.Heartbeat("", job.Id, "worker2", 10*time.Second)
+		assert.NotNil(t, err)
+
+		err = persistence.Fail("", job.Id, "worker1", "Timeout", 0)
+		assert.Nil(t, err)
+
+		jobs, err = persistence.ClaimDue("", "worker1", 10*time.Second, 10)
+		assert.Nil(t, err)
+		assert.Len(t, jobs, 1)
+		assert.Equal(t, 2, jobs[0].Attempts)
+
+		err = persistence.Complete("", job.Id, "worker1")
+		assert.Nil(t, err)
+
+		jobs, err = persistence.ClaimDue("", "worker1", 10*time.Second, 10)
+		assert.Nil(t, err)
+		assert.Len(t, jobs, 0)
+	})
+
+	t.Run("RecurringJob", func(t *testing.T) {
+		job, err := persistence.EnqueueRecurring("", "report", nil, time.Now(), time.Hour, 1)
+		assert.Nil(t, err)
+
+		jobs, err := persistence.ClaimDue("", "worker1", 10*time.Second, 10)
+		assert.Nil(t, err)
+		assert.Len(t, jobs, 1)
+
+		err = persistence.Complete("", job.Id, "worker1")
+		assert.Nil(t, err)
+
+		// Next run is scheduled in an hour
+		jobs, err = persistence.ClaimDue("", "worker1", 10*time.Second, 10)
+		assert.Nil(t, err)
+		assert.Len(t, jobs, 0)
+	})
+
+	t.Run("ExpiredClaimAtAttemptLimit", func(t *testing.T) {
+		job, err := persistence.Enqueue("", "import", nil, time.Now(), 1)
+		assert.Nil(t, err)
+
+		jobs, err := persistence.ClaimDue("", "worker1", 10*time.Millisecond, 10)
+		assert.Nil(t, err)
+		assert.Len(t, jobs, 1)
+
+		time.Sleep(100 * time.Millisecond)
+
+		// The expired claim is not retried after the last attempt
+		jobs, err = persistence.ClaimDue("", "worker2", 10*time.Second, 10)
+		assert.Nil(t, err)
+		assert.Len(t, jobs, 0)
+
+		item, err := persistence.GetOneById("", job.Id)
+		assert.Nil(t, err)
+		assert.Equal(t, persist.JobStatusFailed, item.(persist.Job).Status)
+		assert.Equal(t, 1, item.(persist.Job).Attempts)
+
+		err = persistence.Complete("", job.Id, "worker1")
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,78 @@
+package test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaterializedViewPostgresPersistence(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := newDummySalesReportPersistence()
+	persistence.Configure(db.Config)
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	_, err = persistence.Client.Exec(context.Background(), "DELETE FROM "+tf.QuotedName(db.Schema, "dummies_sales"))
+	assert.Nil(t, err)
+	err = persistence.Refresh("", false)
+	assert.Nil(t, err)
+
+	_, err = persistence.Client.Exec(context.Background(),
+		"INSERT INTO "+tf.QuotedName(db.Schema, "dummies_sales")+" (\"region\", \"amount\") VALUES ('east', 10), ('east', 5), ('west', 7)")
+	assert.Nil(t, err)
+
+	// Data is visible only after refresh
+	count, err := persistence.GetCountByFilter("", "")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+
+	err = persistence.Refresh("", true)
+	assert.Nil(t, err)
+
+	items, err := persistence.GetListByFilter("", "", "\"region\"", nil)
+	assert.Nil(t, err)
+	assert.Len(t, items, 2)
+	assert.Equal(t, dummySalesReport{Region: "east", Total: 15}, items[0])
+	assert.Equal(t, dummySalesReport{Region: "west", Total: 7}, items[1])
+
+	// Writes are not supported
+	_, err = persistence.Create("", dummySalesReport{Region: "north", Total: 1})
+	assert.NotNil(t, err)
+	err = persistence.DeleteByFilter("", "")
+	assert.NotNil(t, err)
+}
+
+type dummySalesReport struct {
+	Region string  `json:"region"`
+	Total  float64 `json:"total"`
+}
+
+type dummySalesReportPersistence struct {
+	*persist.MaterializedViewPostgresPersistence
+}
+
+func newDummySalesReportPersistence() *dummySalesReportPersistence {
+	c := &dummySalesReportPersistence{}
+	c.MaterializedViewPostgresPersistence = persist.InheritMaterializedViewPostgresPersistence(c,
+		reflect.TypeOf(dummySalesReport{}), "dummies_sales_report")
+	return c
+}
+
+func (c *dummySalesReportPersistence) DefineSchema() {
+	c.ClearSchema()
+	c.MaterializedViewPostgresPersistence.DefineSchema()
+	c.EnsureSchema("CREATE TABLE IF NOT EXISTS " + tf.QuotedName(c.SchemaName, "dummies_sales") + " (\"region\" TEXT NOT NULL, \"amount\" FLOAT NOT NULL)")
+	c.EnsureMaterializedView("SELECT \"region\", sum(\"amount\") AS \"total\" FROM " + tf.QuotedName(c.SchemaName, "dummies_sales") + " GROUP BY \"region\"")
+	c.EnsureIndex(c.TableName+"_region", map[string]string{"\"region\"": "1"}, map[string]string{"unique": "true"})
+}
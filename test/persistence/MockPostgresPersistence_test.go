@@ -0,0 +1,55 @@
+package test
+
+import (
+	"testing"
+
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockPostgresPersistence(t *testing.T) {
+	persistence := NewDummyMockPostgresPersistence()
+	fixture := *tf.NewDummyPersistenceFixture(persistence)
+
+	opnErr := persistence.Open("")
+	if opnErr != nil {
+		t.Error("Error opened persistence", opnErr)
+		return
+	}
+	defer persistence.Close("")
+
+	t.Run("MockPostgresPersistence:CRUD", fixture.TestCrudOperations)
+
+	persistence.Clear("")
+	t.Run("MockPostgresPersistence:Batch", fixture.TestBatchOperations)
+
+	persistence.Clear("")
+	t.Run("MockPostgresPersistence:Random", fixture.TestRandomOperation)
+
+	persistence.Clear("")
+	t.Run("MockPostgresPersistence:Paging", fixture.TestPagingOperations)
+
+	persistence.Clear("")
+	t.Run("MockPostgresPersistence:Sorting", fixture.TestSortingOperations)
+
+	persistence.Clear("")
+	t.Run("MockPostgresPersistence:Filters", func(t *testing.T) {
+		_, err := persistence.Create("", tf.Dummy{Id: "1", Key: "Key 1", Content: "Content 1"})
+		assert.Nil(t, err)
+		_, err = persistence.Create("", tf.Dummy{Id: "1", Key: "Key 2", Content: "Content 2"})
+		assert.NotNil(t, err)
+
+		count, err := persistence.MockPostgresPersistence.GetCountByFilter("", func(item interface{}) bool {
+			return item.(tf.Dummy).Content == "Content 1"
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), count)
+
+		persistence.FilterMatcher = nil
+		_, err = persistence.GetCountByFilter("", cdata.NewFilterParamsFromTuples("Key", "Key 1"))
+		assert.NotNil(t, err)
+		assert.Equal(t, "FILTER_NOT_SUPPORTED", err.(*cerr.ApplicationError).Code)
+	})
+}
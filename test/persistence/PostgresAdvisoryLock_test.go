@@ -0,0 +1,76 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresAdvisoryLock(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := NewDummyPostgresPersistence()
+	persistence.Configure(db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.table_suffix", "_lock",
+	)))
+	other := NewDummyPostgresPersistence()
+	other.Configure(db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.table_suffix", "_lock",
+	)))
+	another := NewDummyPostgresPersistence()
+	another.Configure(db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.table_suffix", "_lock2",
+	)))
+
+	for _, p := range []*DummyPostgresPersistence{persistence, other, another} {
+		err := p.Open("")
+		if err != nil {
+			t.Error("Error opened persistence", err)
+			return
+		}
+		defer p.Close("")
+		defer p.Client.Exec(context.Background(), "DROP TABLE IF EXISTS "+p.QuotedTableName())
+	}
+
+	err := persistence.AcquireAdvisoryLock("", "counter", 1000)
+	assert.Nil(t, err)
+
+	// The key is held for the same table
+	ok, err := other.TryAcquireAdvisoryLock("", "counter")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	err = other.AcquireAdvisoryLock("", "counter", 200)
+	assert.NotNil(t, err)
+	assert.Equal(t, "LOCK_TIMEOUT", err.(*cerr.ApplicationError).Code)
+
+	// Keys of other tables do not conflict
+	ok, err = another.TryAcquireAdvisoryLock("", "counter")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	err = another.ReleaseAdvisoryLock("", "counter")
+	assert.Nil(t, err)
+
+	err = persistence.ReleaseAdvisoryLock("", "counter")
+	assert.Nil(t, err)
+
+	ok, err = other.TryAcquireAdvisoryLock("", "counter")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	// Held locks are released on close
+	err = other.Close("")
+	assert.Nil(t, err)
+
+	ok, err = persistence.TryAcquireAdvisoryLock("", "counter")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	// Releasing keys that are not held is ignored
+	err = persistence.ReleaseAdvisoryLock("", "missing")
+	assert.Nil(t, err)
+}
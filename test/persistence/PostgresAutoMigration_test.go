@@ -0,0 +1,53 @@
+package test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+type autoMigrationBase struct {
+	Id string `json:"id"`
+}
+
+type autoMigrationItem struct {
+	autoMigrationBase
+	Key      string                 `json:"key" postgres:"type=VARCHAR(64),notnull,unique"`
+	Count    int64                  `json:"count"`
+	Price    *float64               `json:"price"`
+	Created  time.Time              `json:"created" postgres:"index"`
+	Data     []byte                 `json:"data"`
+	Tags     []string               `json:"tags" postgres:"labels"`
+	Extra    map[string]interface{} `json:"extra"`
+	Internal string                 `json:"-"`
+}
+
+func TestGetPrototypeColumns(t *testing.T) {
+	columns := persist.GetPrototypeColumns(reflect.TypeOf(autoMigrationItem{}))
+	assert.Len(t, columns, 8)
+
+	assert.Equal(t, "id", columns[0].Name)
+	assert.Equal(t, "TEXT", columns[0].Type)
+	assert.True(t, columns[0].PrimaryKey)
+
+	assert.Equal(t, "key", columns[1].Name)
+	assert.Equal(t, "VARCHAR(64)", columns[1].Type)
+	assert.True(t, columns[1].NotNull)
+	assert.True(t, columns[1].Unique)
+
+	assert.Equal(t, "BIGINT", columns[2].Type)
+	assert.Equal(t, "DOUBLE PRECISION", columns[3].Type)
+	assert.Equal(t, "TIMESTAMPTZ", columns[4].Type)
+	assert.True(t, columns[4].Index)
+	assert.Equal(t, "BYTEA", columns[5].Type)
+	assert.Equal(t, "labels", columns[6].Name)
+	assert.Equal(t, "JSONB", columns[6].Type)
+	assert.Equal(t, "JSONB", columns[7].Type)
+
+	columns = persist.GetPrototypeColumns(reflect.TypeOf(tf.Dummy{}))
+	assert.Len(t, columns, 3)
+}
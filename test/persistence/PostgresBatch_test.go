@@ -0,0 +1,64 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresBatch(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := NewDummyPostgresPersistence()
+	persistence.Configure(db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.table_suffix", "_batch",
+	)))
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+	defer persistence.Client.Exec(context.Background(), "DROP TABLE IF EXISTS "+persistence.QuotedTableName())
+
+	err = persistence.Clear("")
+	assert.Nil(t, err)
+	_, err = persistence.Create("", tf.Dummy{Id: "1", Key: "Key 1", Content: "Content 1"})
+	assert.Nil(t, err)
+	_, err = persistence.Create("", tf.Dummy{Id: "2", Key: "Key 2", Content: "Content 2"})
+	assert.Nil(t, err)
+
+	batch := persist.NewPostgresBatch()
+	item1 := persistence.QueueGetOneById(batch, "", "1")
+	missing := persistence.QueueGetOneById(batch, "", "3")
+	updated := persistence.QueueExecNonQuery(batch, "",
+		"UPDATE "+persistence.QuotedTableName()+" SET \"content\"=$1", "Updated")
+	list := persistence.QueueQuery(batch, "",
+		"SELECT * FROM "+persistence.QuotedTableName()+" ORDER BY \"id\"")
+	assert.Equal(t, 4, batch.Len())
+
+	err = persistence.SendBatch("", batch)
+	assert.Nil(t, err)
+
+	assert.Nil(t, item1.Err)
+	assert.Equal(t, "Content 1", item1.Item.(tf.Dummy).Content)
+	assert.Nil(t, missing.Err)
+	assert.Nil(t, missing.Item)
+	assert.Nil(t, updated.Err)
+	assert.Equal(t, int64(2), updated.Count)
+	assert.Nil(t, list.Err)
+	assert.Len(t, list.Items, 2)
+	assert.Equal(t, "Updated", list.Items[1].(tf.Dummy).Content)
+
+	// Errors are reported by results of failed statements
+	batch = persist.NewPostgresBatch()
+	failed := persistence.QueueQuery(batch, "", "SELECT * FROM \"missing_table\"")
+	err = persistence.SendBatch("", batch)
+	assert.NotNil(t, err)
+	assert.NotNil(t, failed.Err)
+}
@@ -0,0 +1,99 @@
+package test
+
+import (
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	bench "github.com/pip-services3-go/pip-services3-postgres-go/benchmark"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+)
+
+var benchmarkCounter int64
+
+func openBenchmarkPersistence(b *testing.B) *DummyPostgresPersistence {
+	database := tf.StartPostgresTestDatabase(b)
+
+	persistence := NewDummyPostgresPersistence()
+	persistence.Configure(database.Config)
+	if err := persistence.Open(""); err != nil {
+		b.Fatal("Error opened persistence", err)
+	}
+	b.Cleanup(func() { persistence.Close("") })
+	return persistence
+}
+
+func newBenchmarkDummy() tf.Dummy {
+	return tf.Dummy{
+		Key:     "Key " + strconv.FormatInt(atomic.AddInt64(&benchmarkCounter, 1), 10),
+		Content: "Content",
+	}
+}
+
+func BenchmarkPostgresCreate(b *testing.B) {
+	persistence := openBenchmarkPersistence(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := persistence.Create("", newBenchmarkDummy()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPostgresGetOneById(b *testing.B) {
+	persistence := openBenchmarkPersistence(b)
+	item, err := persistence.Create("", newBenchmarkDummy())
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := persistence.GetOneById("", item.Id); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPostgresGetPageByFilter(b *testing.B) {
+	persistence := openBenchmarkPersistence(b)
+	for i := 0; i < 100; i++ {
+		if _, err := persistence.Create("", newBenchmarkDummy()); err != nil {
+			b.Fatal(err)
+		}
+	}
+	paging := cdata.NewPagingParams(0, 100, false)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := persistence.GetPageByFilter("", cdata.NewEmptyFilterParams(), paging); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPostgresLoadRunner(b *testing.B) {
+	persistence := openBenchmarkPersistence(b)
+	runner := bench.NewPostgresLoadRunner()
+	runner.Configure(cconf.NewConfigParamsFromTuples(
+		"options.concurrency", 8,
+		"options.iterations", b.N,
+	))
+
+	b.ResetTimer()
+	stats, err := runner.RunCrud("", &persistence.IdentifiablePostgresPersistence, func(iteration int64) interface{} {
+		return newBenchmarkDummy()
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.StopTimer()
+
+	for _, s := range stats {
+		b.ReportMetric(s.Throughput, s.Name+"_ops/s")
+		b.ReportMetric(float64(s.P95Latency.Microseconds()), s.Name+"_p95_us")
+	}
+}
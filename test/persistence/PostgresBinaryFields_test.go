@@ -0,0 +1,69 @@
+package test
+
+import (
+	"reflect"
+	"testing"
+
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresBinaryFields(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := newDummyBinaryPersistence()
+	persistence.Configure(db.Config)
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	err = persistence.Clear("")
+	assert.Nil(t, err)
+
+	data := []byte{0, 1, 2, 254, 255}
+	_, err = persistence.Create("", dummyBinary{Id: "1", Data: data})
+	assert.Nil(t, err)
+
+	// Bytes are stored as they are, not as base64 text
+	count, err := persistence.GetCountByFilter("", "\"data\"='\\x000102feff'::bytea")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+
+	item, err := persistence.GetOneById("", "1")
+	assert.Nil(t, err)
+	assert.Equal(t, data, item.(dummyBinary).Data)
+
+	_, err = persistence.Create("", dummyBinary{Id: "2"})
+	assert.Nil(t, err)
+
+	item, err = persistence.GetOneById("", "2")
+	assert.Nil(t, err)
+	assert.Nil(t, item.(dummyBinary).Data)
+}
+
+type dummyBinary struct {
+	Id   string `json:"id"`
+	Data []byte `json:"data"`
+}
+
+type dummyBinaryPersistence struct {
+	*persist.IdentifiablePostgresPersistence
+}
+
+func newDummyBinaryPersistence() *dummyBinaryPersistence {
+	c := &dummyBinaryPersistence{}
+	c.IdentifiablePostgresPersistence = persist.InheritIdentifiablePostgresPersistence(c,
+		reflect.TypeOf(dummyBinary{}), "dummies_binary")
+	return c
+}
+
+func (c *dummyBinaryPersistence) DefineSchema() {
+	c.ClearSchema()
+	c.IdentifiablePostgresPersistence.DefineSchema()
+	c.EnsureSchema("CREATE TABLE " + c.QuotedTableName() + " (\"id\" TEXT PRIMARY KEY, \"data\" BYTEA)")
+}
@@ -0,0 +1,79 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// Fake client that records statements and returns empty results
+type fakePostgresClient struct {
+	statements []string
+}
+
+func (c *fakePostgresClient) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	c.statements = append(c.statements, sql)
+	return &fakePostgresRows{}, nil
+}
+
+func (c *fakePostgresClient) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	c.statements = append(c.statements, sql)
+	return &fakePostgresRows{}
+}
+
+func (c *fakePostgresClient) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	c.statements = append(c.statements, sql)
+	return pgconn.CommandTag("DELETE 0"), nil
+}
+
+func (c *fakePostgresClient) Begin(ctx context.Context) (pgx.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+func (c *fakePostgresClient) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string,
+	rowSrc pgx.CopyFromSource) (int64, error) {
+	return 0, errors.New("copy is not supported")
+}
+
+// Empty result. A single row scan reports that the table exists.
+type fakePostgresRows struct{}
+
+func (c *fakePostgresRows) Close()                                         {}
+func (c *fakePostgresRows) Err() error                                     { return nil }
+func (c *fakePostgresRows) CommandTag() pgconn.CommandTag                  { return nil }
+func (c *fakePostgresRows) FieldDescriptions() []pgproto3.FieldDescription { return nil }
+func (c *fakePostgresRows) Next() bool                                     { return false }
+func (c *fakePostgresRows) Values() ([]interface{}, error)                 { return nil, nil }
+func (c *fakePostgresRows) RawValues() [][]byte                            { return nil }
+
+func (c *fakePostgresRows) Scan(dest ...interface{}) error {
+	if exists, ok := dest[0].(*bool); ok {
+		*exists = true
+	}
+	return nil
+}
+
+func TestPostgresClient(t *testing.T) {
+	client := &fakePostgresClient{}
+
+	persistence := NewDummyPostgresPersistence()
+	persistence.SetClient(client)
+
+	err := persistence.Open("")
+	assert.Nil(t, err)
+	defer persistence.Close("")
+	assert.True(t, persistence.IsOpen())
+
+	err = persistence.DeleteByFilter("", "key='Key 1'")
+	assert.Nil(t, err)
+	assert.Contains(t, client.statements[len(client.statements)-1], "DELETE FROM \"dummies\"")
+	assert.Contains(t, client.statements[len(client.statements)-1], "key='Key 1'")
+
+	_, err = persistence.AcquireConnection(context.Background(), persistence.Client)
+	assert.NotNil(t, err)
+}
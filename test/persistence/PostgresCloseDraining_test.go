@@ -0,0 +1,93 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresCloseDraining(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := NewDummyPostgresPersistence()
+	persistence.Configure(db.Config)
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+
+	// Start an operation that is in flight when the persistence is closed
+	queryDone := make(chan error, 1)
+	go func() {
+		_, qErr := persistence.ExecNonQuery("", "SELECT pg_sleep(0.5)")
+		queryDone <- qErr
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	closeDone := make(chan error, 1)
+	go func() {
+		closeDone <- persistence.Close("")
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	// New operations are rejected while closing
+	_, err = persistence.ExecNonQuery("", "SELECT 1")
+	assert.NotNil(t, err)
+
+	// The operation in flight completes before the pool is closed
+	assert.Nil(t, <-queryDone)
+	assert.Nil(t, <-closeDone)
+	assert.False(t, persistence.IsOpen())
+
+	// Operations are accepted again after reopening
+	err = persistence.Open("")
+	assert.Nil(t, err)
+	defer persistence.Close("")
+
+	_, err = persistence.ExecNonQuery("", "SELECT 1")
+	assert.Nil(t, err)
+}
+
+func TestPostgresCloseDrainingTimeout(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := NewDummyPostgresPersistence()
+	persistence.Configure(db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.close_timeout", 100,
+	)))
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+
+	queryDone := make(chan error, 1)
+	go func() {
+		_, qErr := persistence.ExecNonQuery("", "SELECT pg_sleep(0.5)")
+		queryDone <- qErr
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	// Close does not wait for the operation longer than the timeout
+	start := time.Now()
+	err = persistence.Close("")
+	assert.Nil(t, err)
+	assert.True(t, time.Since(start) < 400*time.Millisecond)
+	<-queryDone
+
+	// The persistence can be reopened and drained again after the timeout
+	err = persistence.Open("")
+	assert.Nil(t, err)
+
+	_, err = persistence.ExecNonQuery("", "SELECT 1")
+	assert.Nil(t, err)
+
+	err = persistence.Close("")
+	assert.Nil(t, err)
+}
@@ -0,0 +1,66 @@
+package test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresColumnDefaults(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := newDummyDefaultsPersistence()
+	persistence.Configure(db.Config)
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	err = persistence.Clear("")
+	assert.Nil(t, err)
+
+	// Zero values are omitted so defaults apply
+	item, err := persistence.Create("", dummyDefaults{Id: "1"})
+	assert.Nil(t, err)
+	result := item.(dummyDefaults)
+	assert.Equal(t, "new", result.Status)
+	assert.False(t, result.Created.IsZero())
+
+	created := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	item, err = persistence.Create("", dummyDefaults{Id: "2", Status: "done", Created: created})
+	assert.Nil(t, err)
+	result = item.(dummyDefaults)
+	assert.Equal(t, "done", result.Status)
+	assert.True(t, created.Equal(result.Created))
+}
+
+type dummyDefaults struct {
+	Id      string    `json:"id"`
+	Status  string    `json:"status"`
+	Created time.Time `json:"created" postgres:"notnull,default=now()"`
+}
+
+type dummyDefaultsPersistence struct {
+	*persist.IdentifiablePostgresPersistence
+}
+
+func newDummyDefaultsPersistence() *dummyDefaultsPersistence {
+	c := &dummyDefaultsPersistence{}
+	c.IdentifiablePostgresPersistence = persist.InheritIdentifiablePostgresPersistence(c,
+		reflect.TypeOf(dummyDefaults{}), "dummies_defaults")
+	return c
+}
+
+func (c *dummyDefaultsPersistence) DefineSchema() {
+	c.ClearSchema()
+	c.IdentifiablePostgresPersistence.DefineSchema()
+	c.EnsureTableFromPrototype()
+	c.EnsureColumnDefault("status", "'new'")
+}
@@ -0,0 +1,111 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresColumnTenancy(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := NewDummyJsonPostgresPersistence()
+	persistence.Configure(db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.table_suffix", "_tenants",
+		"options.tenancy", persist.TenancyColumn,
+	)))
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+	defer persistence.Client.Exec(context.Background(), "DROP TABLE IF EXISTS "+persistence.QuotedTableName())
+
+	// Operations require a tenant
+	_, err = persistence.GetCountByFilter("", nil)
+	assert.NotNil(t, err)
+
+	tenantA := persistence.IdentifiableJsonPostgresPersistence.ForTenant("a")
+	tenantB := persistence.IdentifiableJsonPostgresPersistence.WithContext(persist.WithTenant(context.Background(), "b"))
+	err = tenantA.Clear("")
+	assert.Nil(t, err)
+	err = tenantB.Clear("")
+	assert.Nil(t, err)
+
+	// The tenant column is set on inserts
+	_, err = tenantA.Create("", tf.Dummy{Id: "1", Key: "Key 1", Content: "Content 1"})
+	assert.Nil(t, err)
+
+	count, err := tenantA.GetCountByFilter("", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+
+	// Other tenants do not see, update or delete the item
+	count, err = tenantB.GetCountByFilter("", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+
+	item, err := tenantB.GetOneById("", "1")
+	assert.Nil(t, err)
+	assert.Nil(t, item)
+
+	item, err = tenantB.Update("", tf.Dummy{Id: "1", Key: "Key 1", Content: "Content 2"})
+	assert.Nil(t, err)
+	assert.Nil(t, item)
+
+	// Upserts do not overwrite items of other tenants with the same id
+	_, err = tenantB.Set("", tf.Dummy{Id: "1", Key: "Key 2", Content: "Content 2"})
+	assert.Nil(t, err)
+
+	item, err = tenantB.DeleteById("", "1")
+	assert.Nil(t, err)
+	assert.Nil(t, item)
+
+	item, err = tenantA.GetOneById("", "1")
+	assert.Nil(t, err)
+	assert.NotNil(t, item)
+	assert.Equal(t, "Content 1", item.(tf.Dummy).Content)
+	// Query builders on the persistence table are limited to the tenant
+	items, err := tenantB.Select().Where("id", "=", "1").ToList("")
+	assert.Nil(t, err)
+	assert.Len(t, items, 0)
+
+	items, err = tenantA.Select().Where("id", "=", "1").ToList("")
+	assert.Nil(t, err)
+	assert.Len(t, items, 1)
+
+	query, _, err := tenantA.Select().Where("id", "=", "1").Build()
+	assert.Nil(t, err)
+	assert.Contains(t, query, "WHERE \"id\" = $1 AND "+persistence.QuotedTableName()+".\"tenant_id\"='a'")
+}
+
+func TestPostgresColumnTenancyUnsupported(t *testing.T) {
+	config := cconf.NewConfigParamsFromTuples(
+		"options.tenancy", persist.TenancyColumn,
+	)
+
+	persistences := map[string]interface {
+		Configure(config *cconf.ConfigParams)
+		Open(correlationId string) error
+	}{
+		"blobs":  persist.NewBlobPostgresPersistence("blobs"),
+		"events": persist.NewEventStorePostgresPersistence("events"),
+		"jobs":   persist.NewJobQueuePostgresPersistence("jobs"),
+		"sagas":  persist.NewSagaStatePostgresPersistence("sagas"),
+	}
+	for name, persistence := range persistences {
+		persistence.Configure(config)
+		err := persistence.Open("")
+		assert.NotNil(t, err, name)
+		if err != nil {
+			assert.Equal(t, "TENANCY_NOT_SUPPORTED", err.(*cerr.ApplicationError).Code, name)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresConstraints(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := newDummyChildPersistence()
+	persistence.Configure(db.Config)
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	_, err = persistence.Client.Exec(context.Background(), "DELETE FROM "+tf.QuotedName(db.Schema, "dummies_parent"))
+	assert.Nil(t, err)
+	err = persistence.Clear("")
+	assert.Nil(t, err)
+
+	// Empty id violates the check constraint
+	_, err = persistence.Client.Exec(context.Background(), "INSERT INTO "+tf.QuotedName(db.Schema, "dummies_parent")+" (\"id\") VALUES ('')")
+	assert.Nil(t, err)
+	_, err = persistence.Client.Exec(context.Background(), "INSERT INTO "+persistence.QuotedTableName()+" (\"id\", \"parent_id\") VALUES ('', '')")
+	assert.NotNil(t, err)
+
+	// Unknown parent violates the constraint
+	_, err = persistence.Create("", dummyChild{Id: "1", ParentId: "1"})
+	assert.NotNil(t, err)
+
+	_, err = persistence.Client.Exec(context.Background(), "INSERT INTO "+tf.QuotedName(db.Schema, "dummies_parent")+" (\"id\") VALUES ('1')")
+	assert.Nil(t, err)
+	_, err = persistence.Create("", dummyChild{Id: "1", ParentId: "1"})
+	assert.Nil(t, err)
+
+	// Children are deleted together with the parent
+	_, err = persistence.Client.Exec(context.Background(), "DELETE FROM "+tf.QuotedName(db.Schema, "dummies_parent")+" WHERE \"id\"='1'")
+	assert.Nil(t, err)
+	count, err := persistence.GetCountByFilter("", "")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+}
+
+type dummyChild struct {
+	Id       string `json:"id"`
+	ParentId string `json:"parent_id"`
+}
+
+type dummyChildPersistence struct {
+	*persist.IdentifiablePostgresPersistence
+}
+
+func newDummyChildPersistence() *dummyChildPersistence {
+	c := &dummyChildPersistence{}
+	c.IdentifiablePostgresPersistence = persist.InheritIdentifiablePostgresPersistence(c,
+		reflect.TypeOf(dummyChild{}), "dummies_child")
+	return c
+}
+
+func (c *dummyChildPersistence) DefineSchema() {
+	c.ClearSchema()
+	c.IdentifiablePostgresPersistence.DefineSchema()
+	c.EnsureSchema("CREATE TABLE IF NOT EXISTS " + tf.QuotedName(c.SchemaName, "dummies_parent") + " (\"id\" TEXT PRIMARY KEY)")
+	c.EnsureSchema("CREATE TABLE " + c.QuotedTableName() + " (\"id\" TEXT PRIMARY KEY, \"parent_id\" TEXT NOT NULL)")
+	c.EnsureForeignKey("parent_id", "dummies_parent", "id", "cascade")
+	c.EnsureCheck(c.TableName+"_id_check", "\"id\" <> ''")
+}
@@ -0,0 +1,39 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresContextCancellation(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := NewDummyPostgresPersistence()
+	persistence.Configure(db.Config)
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	// Queries are cancelled when the caller's context is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+	start := time.Now()
+	_, err = persistence.WithContext(ctx).ExecNonQuery("", "SELECT pg_sleep(2)")
+	assert.NotNil(t, err)
+	assert.True(t, time.Since(start) < time.Second)
+
+	// The original persistence is not bound to the cancelled context
+	_, err = persistence.ExecNonQuery("", "SELECT 1")
+	assert.Nil(t, err)
+}
@@ -0,0 +1,54 @@
+package test
+
+import (
+	"testing"
+
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresCountStrategy(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := NewDummyPostgresPersistence()
+	persistence.Configure(db.Config)
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	err = persistence.Clear("")
+	assert.Nil(t, err)
+
+	for _, key := range []string{"Key 1", "Key 2", "Key 3"} {
+		_, err = persistence.Create("", tf.Dummy{Key: key, Content: "Content"})
+		assert.Nil(t, err)
+	}
+
+	paging := persist.NewPostgresPagingParams(0, 1, true, persist.CountStrategyExact)
+	page, err := persistence.GetPageByFilterWithPaging("", "", paging, nil, nil)
+	assert.Nil(t, err)
+	assert.Len(t, page.Data, 1)
+	assert.Equal(t, int64(3), *page.Total)
+
+	paging = persist.NewPostgresPagingParams(0, 1, true, persist.CountStrategyCapped)
+	paging.CountLimit = 2
+	page, err = persistence.GetPageByFilterWithPaging("", "", paging, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), *page.Total)
+
+	paging = persist.NewPostgresPagingParams(0, 1, true, persist.CountStrategyEstimated)
+	page, err = persistence.GetPageByFilterWithPaging("", "\"key\"='Key 1'", paging, nil, nil)
+	assert.Nil(t, err)
+	assert.NotNil(t, page.Total)
+
+	paging = persist.NewPostgresPagingParams(0, 1, true, persist.CountStrategyNone)
+	page, err = persistence.GetPageByFilterWithPaging("", "", paging, nil, nil)
+	assert.Nil(t, err)
+	assert.Len(t, page.Data, 1)
+	assert.Nil(t, page.Total)
+}
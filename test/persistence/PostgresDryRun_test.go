@@ -0,0 +1,96 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresDryRun(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := NewDummyPostgresPersistence()
+	persistence.Configure(db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.table_suffix", "_dry",
+	)))
+	dryRun := NewDummyPostgresPersistence()
+	dryRun.Configure(db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.table_suffix", "_dry",
+		"options.dry_run", true,
+	)))
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+	defer persistence.Client.Exec(context.Background(), "DROP TABLE IF EXISTS "+persistence.QuotedTableName())
+
+	err = dryRun.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer dryRun.Close("")
+
+	err = persistence.Clear("")
+	assert.Nil(t, err)
+	_, err = persistence.Create("", tf.Dummy{Id: "1", Key: "Key 1", Content: "Content 1"})
+	assert.Nil(t, err)
+
+	// Writes return would-be results without changing the table
+	created, err := dryRun.Create("", tf.Dummy{Id: "2", Key: "Key 2", Content: "Content 2"})
+	assert.Nil(t, err)
+	assert.Equal(t, "2", created.Id)
+
+	updated, err := dryRun.Update("", tf.Dummy{Id: "1", Key: "Key 1", Content: "Updated"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Updated", updated.Content)
+
+	updated, err = dryRun.UpdatePartially("", "1", cdata.NewAnyValueMapFromTuples("content", "Partially updated"))
+	assert.Nil(t, err)
+	assert.Equal(t, "Key 1", updated.Key)
+	assert.Equal(t, "Partially updated", updated.Content)
+
+	deleted, err := dryRun.DeleteById("", "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", deleted.Id)
+
+	err = dryRun.DeleteByIds("", []string{"1"})
+	assert.Nil(t, err)
+
+	count, err := persistence.GetCountByFilter("", cdata.NewEmptyFilterParams())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+
+	item, err := persistence.GetOneById("", "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "Content 1", item.Content)
+
+	// Writes without dry run support are rejected
+	err = dryRun.Clear("")
+	assert.NotNil(t, err)
+
+	// Raw statements are rejected before they reach the database
+	_, err = dryRun.ExecNonQuery("", "DELETE FROM "+dryRun.QuotedTableName())
+	assert.NotNil(t, err)
+	assert.Equal(t, "DRY_RUN_NOT_SUPPORTED", err.(*cerr.ApplicationError).Code)
+
+	result := dryRun.QueueExecNonQuery(persist.NewPostgresBatch(), "", "DELETE FROM "+dryRun.QuotedTableName())
+	assert.NotNil(t, result.Err)
+
+	err = dryRun.CallProcedure("", "dummy_cleanup")
+	assert.NotNil(t, err)
+	assert.Equal(t, "DRY_RUN_NOT_SUPPORTED", err.(*cerr.ApplicationError).Code)
+
+	count, err = persistence.GetCountByFilter("", cdata.NewEmptyFilterParams())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+}
@@ -0,0 +1,33 @@
+package test
+
+import (
+	"testing"
+
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+type dummyStatus string
+
+const (
+	dummyStatusActive   dummyStatus = "active"
+	dummyStatusArchived dummyStatus = "archived"
+)
+
+func TestEnumToString(t *testing.T) {
+	assert.Equal(t, "active", persist.EnumToString(dummyStatusActive))
+	status := dummyStatusArchived
+	assert.Equal(t, "archived", persist.EnumToString(&status))
+	assert.Equal(t, "", persist.EnumToString(nil))
+}
+
+func TestValidateEnumValue(t *testing.T) {
+	persistence := NewDummyPostgresPersistence()
+	persistence.EnsureEnumType("dummy_status", "active", "archived")
+
+	assert.Equal(t, []string{"active", "archived"}, persistence.GetEnumValues("dummy_status"))
+	assert.Nil(t, persistence.GetEnumValues("unknown"))
+
+	assert.Nil(t, persistence.ValidateEnumValue("", "dummy_status", dummyStatusActive))
+	assert.NotNil(t, persistence.ValidateEnumValue("", "dummy_status", "deleted"))
+}
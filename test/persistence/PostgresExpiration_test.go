@@ -0,0 +1,70 @@
+package test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+type dummyExpiringPersistence struct {
+	*persist.PostgresPersistence
+}
+
+func newDummyExpiringPersistence() *dummyExpiringPersistence {
+	c := &dummyExpiringPersistence{}
+	c.PostgresPersistence = persist.InheritPostgresPersistence(c, reflect.TypeOf(map[string]interface{}{}), "dummies_expiring")
+	return c
+}
+
+func (c *dummyExpiringPersistence) DefineSchema() {
+	c.ClearSchema()
+	c.EnsureSchema("CREATE TABLE " + c.QuotedTableName() + " (\"id\" TEXT PRIMARY KEY, \"expire_time\" TIMESTAMPTZ NOT NULL)")
+	c.EnsureSchema("CREATE TABLE IF NOT EXISTS " + tf.QuotedName(c.SchemaName, "dummies_expired") + " (\"id\" TEXT PRIMARY KEY, \"expire_time\" TIMESTAMPTZ NOT NULL)")
+}
+
+func TestPostgresExpiration(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := newDummyExpiringPersistence()
+	persistence.Configure(db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.expire_column", "expire_time",
+		"options.expire_batch_size", 2,
+		"options.expire_archive_table", "dummies_expired",
+		"options.expire_period", 0,
+	)))
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+	defer persistence.Client.Exec(context.Background(), "DROP TABLE IF EXISTS "+persistence.QuotedTableName()+", "+tf.QuotedName(db.Schema, "dummies_expired"))
+
+	_, err = persistence.ExecNonQuery("", "INSERT INTO "+persistence.QuotedTableName()+" VALUES"+
+		" ('1', now() - interval '1 hour'), ('2', now() - interval '1 minute'),"+
+		" ('3', now() - interval '1 second'), ('4', now() + interval '1 hour')")
+	assert.Nil(t, err)
+
+	// Expired rows are moved in batches
+	count, err := persistence.DeleteExpired("")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), count)
+
+	var total int
+	err = persistence.Client.QueryRow(context.Background(), "SELECT COUNT(*) FROM "+persistence.QuotedTableName()).Scan(&total)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, total)
+	err = persistence.Client.QueryRow(context.Background(), "SELECT COUNT(*) FROM "+tf.QuotedName(db.Schema, "dummies_expired")).Scan(&total)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, total)
+
+	count, err = persistence.DeleteExpired("")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+}
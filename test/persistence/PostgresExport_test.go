@@ -0,0 +1,53 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresExport(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := NewDummyPostgresPersistence()
+	persistence.Configure(db.Config)
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	err = persistence.Clear("")
+	assert.Nil(t, err)
+	_, err = persistence.Create("", tf.Dummy{Id: "1", Key: "Key 1", Content: "Content \"1\"\nline"})
+	assert.Nil(t, err)
+	_, err = persistence.Create("", tf.Dummy{Id: "2", Key: "Key 2", Content: "Content 2"})
+	assert.Nil(t, err)
+
+	var buffer bytes.Buffer
+	count, err := persistence.IdentifiablePostgresPersistence.ExportByFilter("", "\"key\"='Key 1'", persist.DataFormatJson, &buffer)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+
+	var item map[string]interface{}
+	err = json.Unmarshal(buffer.Bytes(), &item)
+	assert.Nil(t, err)
+	assert.Equal(t, "1", item["id"])
+	assert.Equal(t, "Content \"1\"\nline", item["content"])
+
+	buffer.Reset()
+	count, err = persistence.IdentifiablePostgresPersistence.ExportByFilter("", "", persist.DataFormatCsv, &buffer)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), count)
+	assert.True(t, strings.HasPrefix(buffer.String(), "id,"))
+
+	_, err = persistence.IdentifiablePostgresPersistence.ExportByFilter("", "", "xml", &buffer)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,59 @@
+package test
+
+import (
+	"reflect"
+	"testing"
+
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresExpressionIndex(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := newDummyExpressionIndexPersistence()
+	persistence.Configure(db.Config)
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	err = persistence.Clear("")
+	assert.Nil(t, err)
+
+	_, err = persistence.Create("", tf.Dummy{Key: "Key 1", Content: "Content 1"})
+	assert.Nil(t, err)
+
+	// Unique expression index on lower("key") rejects keys that differ only in case
+	_, err = persistence.Create("", tf.Dummy{Key: "KEY 1", Content: "Content 2"})
+	assert.NotNil(t, err)
+}
+
+type dummyExpressionIndexPersistence struct {
+	*DummyPostgresPersistence
+}
+
+func newDummyExpressionIndexPersistence() *dummyExpressionIndexPersistence {
+	c := &dummyExpressionIndexPersistence{}
+	c.DummyPostgresPersistence = &DummyPostgresPersistence{}
+	c.IdentifiablePostgresPersistence = *persist.InheritIdentifiablePostgresPersistence(c,
+		reflect.TypeOf(tf.Dummy{}), "dummies_expression_index")
+	return c
+}
+
+func (c *dummyExpressionIndexPersistence) DefineSchema() {
+	c.ClearSchema()
+	c.IdentifiablePostgresPersistence.DefineSchema()
+	c.EnsureSchema("CREATE TABLE " + c.QuotedTableName() + " (\"id\" TEXT PRIMARY KEY, \"key\" TEXT, \"content\" TEXT)")
+	c.EnsureIndexKeys(c.TableName+"_key", []*persist.PostgresIndexKey{
+		persist.NewIndexExpression("lower(\"key\")", false),
+		persist.NewIndexColumn("id", true),
+	}, nil)
+	c.EnsureIndexKeys(c.TableName+"_lower_key", []*persist.PostgresIndexKey{
+		persist.NewIndexExpression("lower(\"key\")", false),
+	}, map[string]string{"unique": "true"})
+}
@@ -0,0 +1,49 @@
+package test
+
+import (
+	"testing"
+
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresFieldsSelect(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := NewDummyPostgresPersistence()
+	persistence.Configure(db.Config)
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	err = persistence.Clear("")
+	assert.Nil(t, err)
+
+	_, err = persistence.Create("", tf.Dummy{Id: "1", Key: "Key 1", Content: "Content 1"})
+	assert.Nil(t, err)
+
+	sel, err := persistence.ComposeFieldsSelect("", cdata.NewFilterParamsFromTuples("fields", "id, key"))
+	assert.Nil(t, err)
+	assert.Equal(t, "\"id\",\"key\"", sel)
+
+	page, err := persistence.IdentifiablePostgresPersistence.GetPageByFilter("", "", nil, nil, sel)
+	assert.Nil(t, err)
+	assert.Len(t, page.Data, 1)
+	dummy := page.Data[0].(tf.Dummy)
+	assert.Equal(t, "Key 1", dummy.Key)
+	assert.Equal(t, "", dummy.Content)
+
+	// All columns are selected without fields
+	sel, err = persistence.ComposeFieldsSelect("", cdata.NewFilterParamsFromTuples("key", "Key 1"))
+	assert.Nil(t, err)
+	assert.Equal(t, "", sel)
+
+	// Unknown fields are rejected
+	_, err = persistence.ComposeFieldsSelect("", cdata.NewFilterParamsFromTuples("fields", "id,content;DROP TABLE dummies"))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,75 @@
+package test
+
+import (
+	"reflect"
+	"testing"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresFullTextSearch(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	for _, mode := range []string{persist.SearchVectorGenerated, persist.SearchVectorTrigger} {
+		t.Run(mode, func(t *testing.T) {
+			persistence := newDummyFullTextPersistence()
+			persistence.Configure(db.Config.Override(cconf.NewConfigParamsFromTuples(
+				"table", "dummies_text_"+mode,
+				"options.search_vector_mode", mode,
+			)))
+
+			err := persistence.Open("")
+			if err != nil {
+				t.Error("Error opened persistence", err)
+				return
+			}
+			defer persistence.Close("")
+
+			err = persistence.Clear("")
+			assert.Nil(t, err)
+
+			_, err = persistence.Create("", tf.Dummy{Key: "Key 1", Content: "Running dogs and cats"})
+			assert.Nil(t, err)
+			_, err = persistence.Create("", tf.Dummy{Key: "Key 2", Content: "A dog runs after a dog"})
+			assert.Nil(t, err)
+			_, err = persistence.Create("", tf.Dummy{Key: "Key 3", Content: "Birds fly"})
+			assert.Nil(t, err)
+
+			page, err := persistence.SearchByText("", "dog", cdata.NewPagingParams(0, 10, true))
+			assert.Nil(t, err)
+			assert.NotNil(t, page)
+			assert.Len(t, page.Data, 2)
+			assert.Equal(t, int64(2), *page.Total)
+			// More matches give higher rank
+			assert.Equal(t, "Key 2", page.Data[0].(tf.Dummy).Key)
+
+			page, err = persistence.SearchByText("", "dog -cats", nil)
+			assert.Nil(t, err)
+			assert.Len(t, page.Data, 1)
+		})
+	}
+}
+
+type dummyFullTextPersistence struct {
+	*DummyPostgresPersistence
+}
+
+func newDummyFullTextPersistence() *dummyFullTextPersistence {
+	c := &dummyFullTextPersistence{}
+	c.DummyPostgresPersistence = &DummyPostgresPersistence{}
+	c.IdentifiablePostgresPersistence = *persist.InheritIdentifiablePostgresPersistence(c,
+		reflect.TypeOf(tf.Dummy{}), "dummies_text")
+	return c
+}
+
+func (c *dummyFullTextPersistence) DefineSchema() {
+	c.ClearSchema()
+	c.IdentifiablePostgresPersistence.DefineSchema()
+	c.EnsureSchema("CREATE TABLE " + c.QuotedTableName() + " (\"id\" TEXT PRIMARY KEY, \"key\" TEXT, \"content\" TEXT)")
+	c.EnsureSearchVectorColumn("search_vector", "key", "content")
+	c.EnsureFullTextIndex(c.TableName + "_search")
+}
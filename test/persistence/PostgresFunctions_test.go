@@ -0,0 +1,66 @@
+package test
+
+import (
+	"reflect"
+	"testing"
+
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresFunctions(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := newDummyFunctionsPersistence()
+	persistence.Configure(db.Config)
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	err = persistence.Clear("")
+	assert.Nil(t, err)
+
+	err = persistence.CallProcedure("", "dummies_functions_add", "1", "Key 1")
+	assert.Nil(t, err)
+	err = persistence.CallProcedure("", "dummies_functions_add", "2", "Key 2")
+	assert.Nil(t, err)
+
+	items, err := persistence.CallFunction("", "dummies_functions_find", "Key 2")
+	assert.Nil(t, err)
+	assert.Len(t, items, 1)
+	assert.Equal(t, dummyFunctionItem{Id: "2", Key: "Key 2"}, items[0])
+
+	_, err = persistence.CallFunction("", "dummies_functions_missing")
+	assert.NotNil(t, err)
+}
+
+type dummyFunctionItem struct {
+	Id  string `json:"id"`
+	Key string `json:"key"`
+}
+
+type dummyFunctionsPersistence struct {
+	*persist.IdentifiablePostgresPersistence
+}
+
+func newDummyFunctionsPersistence() *dummyFunctionsPersistence {
+	c := &dummyFunctionsPersistence{}
+	c.IdentifiablePostgresPersistence = persist.InheritIdentifiablePostgresPersistence(c,
+		reflect.TypeOf(dummyFunctionItem{}), "dummies_functions")
+	return c
+}
+
+func (c *dummyFunctionsPersistence) DefineSchema() {
+	c.ClearSchema()
+	c.IdentifiablePostgresPersistence.DefineSchema()
+	c.EnsureSchema("CREATE TABLE " + c.QuotedTableName() + " (\"id\" TEXT PRIMARY KEY, \"key\" TEXT)")
+	c.EnsureSchema("CREATE OR REPLACE FUNCTION " + tf.QuotedName(c.SchemaName, "dummies_functions_find") + "(k TEXT) RETURNS SETOF " + c.QuotedTableName() +
+		" AS $$ SELECT * FROM " + c.QuotedTableName() + " WHERE \"key\"=k $$ LANGUAGE sql")
+	c.EnsureSchema("CREATE OR REPLACE PROCEDURE " + tf.QuotedName(c.SchemaName, "dummies_functions_add") + "(i TEXT, k TEXT)" +
+		" AS $$ INSERT INTO " + c.QuotedTableName() + " (\"id\", \"key\") VALUES (i, k) $$ LANGUAGE sql")
+}
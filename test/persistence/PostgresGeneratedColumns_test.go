@@ -0,0 +1,63 @@
+package test
+
+import (
+	"reflect"
+	"testing"
+
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresGeneratedColumns(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := newDummyGeneratedPersistence()
+	persistence.Configure(db.Config)
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	err = persistence.Clear("")
+	assert.Nil(t, err)
+
+	assert.True(t, persistence.IsGeneratedColumn("key_upper"))
+
+	// Generated column is not written even when the field is set
+	item, err := persistence.Create("", dummyGenerated{Id: "1", Key: "key 1", KeyUpper: "ignored"})
+	assert.Nil(t, err)
+	assert.Equal(t, "KEY 1", item.(dummyGenerated).KeyUpper)
+
+	item, err = persistence.Update("", dummyGenerated{Id: "1", Key: "key 2"})
+	assert.Nil(t, err)
+	assert.Equal(t, "KEY 2", item.(dummyGenerated).KeyUpper)
+}
+
+type dummyGenerated struct {
+	Id       string `json:"id"`
+	Key      string `json:"key"`
+	KeyUpper string `json:"key_upper" postgres:"generated"`
+}
+
+type dummyGeneratedPersistence struct {
+	*persist.IdentifiablePostgresPersistence
+}
+
+func newDummyGeneratedPersistence() *dummyGeneratedPersistence {
+	c := &dummyGeneratedPersistence{}
+	c.IdentifiablePostgresPersistence = persist.InheritIdentifiablePostgresPersistence(c,
+		reflect.TypeOf(dummyGenerated{}), "dummies_generated")
+	return c
+}
+
+func (c *dummyGeneratedPersistence) DefineSchema() {
+	c.ClearSchema()
+	c.IdentifiablePostgresPersistence.DefineSchema()
+	c.EnsureTableFromPrototype()
+	c.EnsureGeneratedColumn("key_upper", "TEXT", "upper(\"key\")")
+	c.EnsureIndex(c.TableName+"_key_upper", map[string]string{"key_upper": "1"}, nil)
+}
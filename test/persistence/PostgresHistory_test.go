@@ -0,0 +1,80 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresHistory(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := NewDummyPostgresPersistence()
+	persistence.Configure(db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.table_suffix", "_versioned",
+		"options.history", true,
+	)))
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+	defer persistence.Client.Exec(context.Background(), "DROP TABLE IF EXISTS "+persistence.QuotedTableName()+
+		", "+persistence.QuoteIdentifier(persistence.TableName+"_history"))
+
+	serverTime := func() time.Time {
+		var now time.Time
+		err := persistence.Client.QueryRow(context.Background(), "SELECT now()").Scan(&now)
+		assert.Nil(t, err)
+		return now
+	}
+
+	_, err = persistence.Create("", tf.Dummy{Id: "1", Key: "Key 1", Content: "Content 1"})
+	assert.Nil(t, err)
+	created := serverTime()
+
+	_, err = persistence.Update("", tf.Dummy{Id: "1", Key: "Key 1", Content: "Content 2"})
+	assert.Nil(t, err)
+	updated := serverTime()
+
+	_, err = persistence.DeleteById("", "1")
+	assert.Nil(t, err)
+
+	// Every write is kept as a version
+	versions, err := persistence.GetHistoryById("", "1")
+	assert.Nil(t, err)
+	assert.Len(t, versions, 3)
+	if len(versions) == 3 {
+		assert.Equal(t, persist.HistoryInsert, versions[0].Operation)
+		assert.Equal(t, "Content 1", versions[0].Item.(tf.Dummy).Content)
+		assert.Equal(t, persist.HistoryUpdate, versions[1].Operation)
+		assert.Equal(t, "Content 2", versions[1].Item.(tf.Dummy).Content)
+		assert.Equal(t, persist.HistoryDelete, versions[2].Operation)
+	}
+
+	// Items are restored as they were at a time
+	items, err := persistence.GetAsOf("", "", created)
+	assert.Nil(t, err)
+	assert.Len(t, items, 1)
+	if len(items) == 1 {
+		assert.Equal(t, "Content 1", items[0].(tf.Dummy).Content)
+	}
+
+	items, err = persistence.GetAsOf("", "\"key\"='Key 1'", updated)
+	assert.Nil(t, err)
+	assert.Len(t, items, 1)
+	if len(items) == 1 {
+		assert.Equal(t, "Content 2", items[0].(tf.Dummy).Content)
+	}
+
+	items, err = persistence.GetAsOf("", "", serverTime())
+	assert.Nil(t, err)
+	assert.Len(t, items, 0)
+}
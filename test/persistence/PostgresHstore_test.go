@@ -0,0 +1,37 @@
+package test
+
+import (
+	"testing"
+
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHstoreConversion(t *testing.T) {
+	value := persist.HstoreToString(map[string]string{"b": "say \"hi\"", "a": "1"})
+	assert.Equal(t, "\"a\"=>\"1\", \"b\"=>\"say \\\"hi\\\"\"", value)
+
+	values, err := persist.ParseHstore(value)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"a": "1", "b": "say \"hi\""}, values)
+
+	values, err = persist.ParseHstore("\"a\"=>\"1\", \"b\"=>NULL")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"a": "1"}, values)
+
+	values, err = persist.ParseHstore("")
+	assert.Nil(t, err)
+	assert.Len(t, values, 0)
+
+	_, err = persist.ParseHstore("\"a\" \"1\"")
+	assert.NotNil(t, err)
+}
+
+func TestComposeHstoreFilters(t *testing.T) {
+	persistence := NewDummyPostgresPersistence()
+
+	assert.Equal(t, "\"attrs\" ? 'color'", persistence.ComposeHstoreKeyFilter("attrs", "color"))
+	assert.Equal(t, "\"attrs\" -> 'color' = 'red'", persistence.ComposeHstoreValueFilter("attrs", "color", "red"))
+	assert.Equal(t, "\"attrs\" @> '\"color\"=>\"red\"'::hstore",
+		persistence.ComposeHstoreContainsFilter("attrs", map[string]string{"color": "red"}))
+}
@@ -0,0 +1,65 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresImport(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := NewDummyPostgresPersistence()
+	persistence.Configure(db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.table_suffix", "_import",
+	)))
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+	defer persistence.Client.Exec(context.Background(), "DROP TABLE IF EXISTS "+persistence.QuotedTableName())
+
+	// Invalid records and rows that fail to load are reported
+	data := "{\"id\":\"1\",\"key\":\"Key 1\",\"content\":\"Content\\twith\\ttabs\"}\n" +
+		"not json\n" +
+		"{\"id\":\"2\",\"key\":\"Key 2\"}\n" +
+		"{\"id\":\"1\",\"key\":\"Key 3\"}\n"
+	result, err := persistence.IdentifiablePostgresPersistence.ImportFromReader("", persist.DataFormatJson,
+		strings.NewReader(data), &persist.PostgresImportOptions{BatchSize: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), result.Imported)
+	assert.Len(t, result.Errors, 2)
+	if len(result.Errors) == 2 {
+		assert.Equal(t, 2, result.Errors[0].Record)
+		assert.Equal(t, 4, result.Errors[1].Record)
+	}
+
+	item, err := persistence.GetOneById("", "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "Content\twith\ttabs", item.Content)
+
+	// Existing items are updated in upsert mode
+	data = "id,key,content\n" +
+		"1,Key 1,Content 1\n" +
+		"3,Key 3,\"Content, 3\"\n"
+	result, err = persistence.IdentifiablePostgresPersistence.ImportFromReader("", persist.DataFormatCsv,
+		strings.NewReader(data), &persist.PostgresImportOptions{Upsert: true})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), result.Imported)
+	assert.Len(t, result.Errors, 0)
+
+	item, err = persistence.GetOneById("", "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "Content 1", item.Content)
+	item, err = persistence.GetOneById("", "3")
+	assert.Nil(t, err)
+	assert.Equal(t, "Content, 3", item.Content)
+}
@@ -0,0 +1,50 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresInterceptors(t *testing.T) {
+	client := &fakePostgresClient{}
+	traced := make([]string, 0)
+	rejected := false
+
+	persistence := NewDummyPostgresPersistence()
+	persistence.SetClient(client)
+	persistence.AddInterceptor(func(ctx context.Context, correlationId string, sql string, args []interface{},
+		next persist.PostgresStatementFunc) (interface{}, error) {
+		traced = append(traced, correlationId+": "+sql)
+		return next(ctx, sql, args)
+	})
+	persistence.AddInterceptor(func(ctx context.Context, correlationId string, sql string, args []interface{},
+		next persist.PostgresStatementFunc) (interface{}, error) {
+		if rejected {
+			return nil, errors.New("statement rejected")
+		}
+		return next(ctx, strings.Replace(sql, "Key 1", "Key 2", 1), args)
+	})
+
+	err := persistence.Open("")
+	assert.Nil(t, err)
+	defer persistence.Close("")
+
+	err = persistence.DeleteByFilter("123", "key='Key 1'")
+	assert.Nil(t, err)
+
+	// The first interceptor sees the original statement, the client gets the rewritten one
+	assert.True(t, strings.HasPrefix(traced[len(traced)-1], "123: DELETE FROM \"dummies\""))
+	assert.Contains(t, traced[len(traced)-1], "key='Key 1'")
+	assert.Contains(t, client.statements[len(client.statements)-1], "key='Key 2'")
+
+	rejected = true
+	statements := len(client.statements)
+	err = persistence.DeleteByFilter("123", "key='Key 1'")
+	assert.NotNil(t, err)
+	assert.Equal(t, statements, len(client.statements))
+}
@@ -0,0 +1,82 @@
+package test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresJoins(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := newDummyJoinOrderPersistence()
+	persistence.Configure(db.Config)
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	err = persistence.Clear("")
+	assert.Nil(t, err)
+	_, err = persistence.Client.Exec(context.Background(), "DELETE FROM "+tf.QuotedName(db.Schema, "dummies_join_customers"))
+	assert.Nil(t, err)
+	_, err = persistence.Client.Exec(context.Background(),
+		"INSERT INTO "+tf.QuotedName(db.Schema, "dummies_join_customers")+" (\"id\", \"country\") VALUES ('1', 'US'), ('2', 'CA')")
+	assert.Nil(t, err)
+
+	_, err = persistence.Create("", dummyJoinOrder{Id: "1", CustomerId: "1", Amount: 10})
+	assert.Nil(t, err)
+	_, err = persistence.Create("", dummyJoinOrder{Id: "2", CustomerId: "2", Amount: 20})
+	assert.Nil(t, err)
+	_, err = persistence.Create("", dummyJoinOrder{Id: "3", CustomerId: "1", Amount: 30})
+	assert.Nil(t, err)
+
+	filter := persist.NewPostgresJoinFilter("c.\"country\"='US'",
+		persist.NewPostgresJoin("dummies_join_customers", "c", "c.\"id\"="+persistence.QuotedTableName()+".\"customer_id\""))
+
+	page, err := persistence.GetPageByFilter("", filter, cdata.NewPagingParams(0, 10, true), "\"amount\"", nil)
+	assert.Nil(t, err)
+	assert.Len(t, page.Data, 2)
+	assert.Equal(t, int64(2), *page.Total)
+	assert.Equal(t, dummyJoinOrder{Id: "1", CustomerId: "1", Amount: 10}, page.Data[0])
+
+	items, err := persistence.GetListByFilter("", filter, nil, nil)
+	assert.Nil(t, err)
+	assert.Len(t, items, 2)
+
+	count, err := persistence.GetCountByFilter("", filter)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+type dummyJoinOrder struct {
+	Id         string  `json:"id"`
+	CustomerId string  `json:"customer_id"`
+	Amount     float64 `json:"amount"`
+}
+
+type dummyJoinOrderPersistence struct {
+	*persist.IdentifiablePostgresPersistence
+}
+
+func newDummyJoinOrderPersistence() *dummyJoinOrderPersistence {
+	c := &dummyJoinOrderPersistence{}
+	c.IdentifiablePostgresPersistence = persist.InheritIdentifiablePostgresPersistence(c,
+		reflect.TypeOf(dummyJoinOrder{}), "dummies_join_orders")
+	return c
+}
+
+func (c *dummyJoinOrderPersistence) DefineSchema() {
+	c.ClearSchema()
+	c.IdentifiablePostgresPersistence.DefineSchema()
+	c.EnsureSchema("CREATE TABLE IF NOT EXISTS " + tf.QuotedName(c.SchemaName, "dummies_join_customers") + " (\"id\" TEXT PRIMARY KEY, \"country\" TEXT)")
+	c.EnsureSchema("CREATE TABLE " + c.QuotedTableName() + " (\"id\" TEXT PRIMARY KEY, \"customer_id\" TEXT, \"amount\" FLOAT)")
+}
@@ -0,0 +1,24 @@
+package test
+
+import (
+	"testing"
+
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLtreeConversion(t *testing.T) {
+	value := persist.LabelsToLtree([]string{"Top", "Social Science", "econ-omics"})
+	assert.Equal(t, "Top.Social_Science.econ_omics", value)
+
+	assert.Equal(t, []string{"Top", "Social_Science", "econ_omics"}, persist.ParseLtree(value))
+	assert.Equal(t, []string{}, persist.ParseLtree(""))
+}
+
+func TestComposeLtreeFilters(t *testing.T) {
+	persistence := NewDummyPostgresPersistence()
+
+	assert.Equal(t, "\"path\" @> 'top.science'::ltree", persistence.ComposeAncestorFilter("path", "top.science"))
+	assert.Equal(t, "\"path\" <@ 'top.science'::ltree", persistence.ComposeDescendantFilter("path", "top.science"))
+	assert.Equal(t, "\"path\" ~ '*.science.*'::lquery", persistence.ComposeLtreeMatchFilter("path", "*.science.*"))
+}
@@ -0,0 +1,43 @@
+package test
+
+import (
+	"testing"
+
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresMaintenance(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := NewDummyPostgresPersistence()
+	persistence.Configure(db.Config)
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	err = persistence.Clear("")
+	assert.Nil(t, err)
+	_, err = persistence.Create("", tf.Dummy{Id: "1", Key: "Key 1", Content: "Content 1"})
+	assert.Nil(t, err)
+	_, err = persistence.DeleteById("", "1")
+	assert.Nil(t, err)
+
+	err = persistence.Vacuum("", false)
+	assert.Nil(t, err)
+	err = persistence.Analyze("")
+	assert.Nil(t, err)
+	err = persistence.Reindex("")
+	assert.Nil(t, err)
+	err = persistence.Vacuum("", true)
+	assert.Nil(t, err)
+
+	stats, err := persistence.GetTableStats("")
+	assert.Nil(t, err)
+	assert.NotNil(t, stats)
+	assert.Equal(t, int64(0), stats.RowEstimate)
+}
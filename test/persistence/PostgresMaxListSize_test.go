@@ -0,0 +1,102 @@
+package test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+// Logger that keeps warnings to check them in tests
+type warningLogger struct {
+	lock     sync.Mutex
+	warnings []string
+}
+
+func (c *warningLogger) Level() int         { return 6 }
+func (c *warningLogger) SetLevel(value int) {}
+
+func (c *warningLogger) Log(level int, correlationId string, err error, message string, args ...interface{}) {
+	if level == 3 {
+		c.Warn(correlationId, message, args...)
+	}
+}
+
+func (c *warningLogger) Fatal(correlationId string, err error, message string, args ...interface{}) {}
+func (c *warningLogger) Error(correlationId string, err error, message string, args ...interface{}) {}
+func (c *warningLogger) Info(correlationId string, message string, args ...interface{})             {}
+func (c *warningLogger) Debug(correlationId string, message string, args ...interface{})            {}
+func (c *warningLogger) Trace(correlationId string, message string, args ...interface{})            {}
+
+func (c *warningLogger) Warn(correlationId string, message string, args ...interface{}) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.warnings = append(c.warnings, fmt.Sprintf(message, args...))
+}
+
+func (c *warningLogger) Warnings() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]string{}, c.warnings...)
+}
+
+func TestPostgresMaxListSize(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := NewDummyPostgresPersistence()
+	persistence.Configure(db.Config)
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	limited := NewDummyPostgresPersistence()
+	limited.Configure(db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.max_list_size", 2,
+	)))
+	logger := &warningLogger{}
+	limited.SetReferences(cref.NewReferencesFromTuples(
+		cref.NewDescriptor("pip-services", "logger", "warning", "default", "1.0"), logger,
+	))
+	err = limited.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer limited.Close("")
+
+	for index := 1; index <= 3; index++ {
+		_, err = persistence.Create("", tf.Dummy{
+			Id: fmt.Sprint(index), Key: fmt.Sprint("Key ", index), Content: "Content",
+		})
+		assert.Nil(t, err)
+	}
+
+	// Lists are not limited by default
+	items, err := persistence.GetListByFilter("", "", nil, nil)
+	assert.Nil(t, err)
+	assert.Len(t, items, 3)
+
+	// Filtered lists are not limited
+	items, err = limited.GetListByFilter("", "\"content\"='Content'", nil, nil)
+	assert.Nil(t, err)
+	assert.Len(t, items, 3)
+	assert.Len(t, logger.Warnings(), 0)
+
+	// Unfiltered lists are truncated with a warning
+	items, err = limited.GetListByFilter("", "", "\"id\"", nil)
+	assert.Nil(t, err)
+	assert.Len(t, items, 2)
+
+	warnings := logger.Warnings()
+	if assert.Len(t, warnings, 1) {
+		assert.True(t, strings.Contains(warnings[0], "truncated to 2 items"))
+	}
+}
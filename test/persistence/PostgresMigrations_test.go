@@ -0,0 +1,101 @@
+package test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/jackc/pgx/v4"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresMigrations(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := NewDummyPostgresPersistence()
+	persistence.Configure(db.Config)
+	persistence.EnsureReversibleMigration(1, "Add tags column",
+		"ALTER TABLE "+persistence.QuotedTableName()+" ADD COLUMN IF NOT EXISTS \"tags\" TEXT",
+		"ALTER TABLE "+persistence.QuotedTableName()+" DROP COLUMN IF EXISTS \"tags\"")
+	persistence.EnsureMigrationFunc(2, "Index tags", func(correlationId string, tx pgx.Tx) error {
+		_, err := tx.Exec(context.Background(), "CREATE INDEX IF NOT EXISTS \"dummies_tags\" ON "+
+			persistence.QuotedTableName()+" (\"tags\")")
+		return err
+	})
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	version, err := persistence.GetMigrationVersion("")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), version)
+
+	// Applied migrations are skipped
+	err = persistence.Migrate("")
+	assert.Nil(t, err)
+
+	// Migration 2 has no down step
+	err = persistence.Downgrade("", 0)
+	assert.NotNil(t, err)
+
+	version, err = persistence.GetMigrationVersion("")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), version)
+
+	persistence.EnsureReversibleMigrationFunc(2, "Index tags", func(correlationId string, tx pgx.Tx) error {
+		_, err := tx.Exec(context.Background(), "CREATE INDEX IF NOT EXISTS \"dummies_tags\" ON "+
+			persistence.QuotedTableName()+" (\"tags\")")
+		return err
+	}, func(correlationId string, tx pgx.Tx) error {
+		_, err := tx.Exec(context.Background(), "DROP INDEX IF EXISTS \"dummies_tags\"")
+		return err
+	})
+
+	err = persistence.Downgrade("", 0)
+	assert.Nil(t, err)
+
+	version, err = persistence.GetMigrationVersion("")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), version)
+
+	err = persistence.Migrate("")
+	assert.Nil(t, err)
+}
+
+func TestPostgresConcurrentOpen(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	// Instances that create the same table and apply the same migrations at once
+	// are serialized by the schema lock
+	var wg sync.WaitGroup
+	persistences := make([]*DummyPostgresPersistence, 5)
+	errs := make([]error, len(persistences))
+	for index := range persistences {
+		persistence := NewDummyPostgresPersistence()
+		persistence.Configure(db.Config)
+		persistence.EnsureMigration(1, "Add tags column",
+			"ALTER TABLE "+persistence.QuotedTableName()+" ADD COLUMN \"tags\" TEXT")
+		persistences[index] = persistence
+
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			errs[index] = persistences[index].Open("")
+		}(index)
+	}
+	wg.Wait()
+
+	for index, persistence := range persistences {
+		assert.Nil(t, errs[index])
+		defer persistence.Close("")
+	}
+
+	version, err := persistences[0].GetMigrationVersion("")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), version)
+}
@@ -0,0 +1,47 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBindNamedParameters(t *testing.T) {
+	query, args, err := persist.BindNamedParameters(
+		"SELECT * FROM \"dummies\" WHERE \"key\"=:key AND \"content\"::text <> ':key' AND (\"id\"=:id OR \"key\"=:key)",
+		map[string]interface{}{"key": "Key 1", "id": "1"})
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM \"dummies\" WHERE \"key\"=$1 AND \"content\"::text <> ':key' AND (\"id\"=$2 OR \"key\"=$1)", query)
+	assert.Equal(t, []interface{}{"Key 1", "1"}, args)
+
+	query, _, err = persist.BindNamedParameters("SELECT $$:key$$, \":key\" -- :key\nWHERE \"key\"=:key",
+		map[string]interface{}{"key": "Key 1"})
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT $$:key$$, \":key\" -- :key\nWHERE \"key\"=$1", query)
+
+	_, _, err = persist.BindNamedParameters("\"key\"=:key", map[string]interface{}{})
+	assert.NotNil(t, err)
+}
+
+func TestComposeNamedFilter(t *testing.T) {
+	persistence := NewDummyPostgresPersistence()
+
+	filter, err := persistence.ComposeNamedFilter("\"key\"=:key AND \"id\" = ANY(:ids) AND \"time\">:time AND \"deleted\"=:deleted",
+		map[string]interface{}{
+			"key":     "O'Brian",
+			"ids":     []string{"1", "2"},
+			"time":    time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			"deleted": false,
+		})
+	assert.Nil(t, err)
+	assert.Equal(t, "\"key\"='O''Brian' AND \"id\" = ANY(ARRAY['1','2']) AND \"time\">'2020-01-01T00:00:00Z' AND \"deleted\"=FALSE", filter)
+
+	filter, err = persistence.ComposeNamedFilter("\"count\">=:count", map[string]interface{}{"count": 10})
+	assert.Nil(t, err)
+	assert.Equal(t, "\"count\">=10", filter)
+
+	_, err = persistence.ComposeNamedFilter("\"key\"=:key", map[string]interface{}{"key": struct{}{}})
+	assert.NotNil(t, err)
+}
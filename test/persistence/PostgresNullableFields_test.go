@@ -0,0 +1,75 @@
+package test
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresNullableFields(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := newDummyNullablePersistence()
+	persistence.Configure(db.Config)
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	err = persistence.Clear("")
+	assert.Nil(t, err)
+
+	// Nil and invalid values are written as NULL
+	_, err = persistence.Create("", dummyNullable{Id: "1"})
+	assert.Nil(t, err)
+
+	count, err := persistence.GetCountByFilter("", "\"name\" IS NULL AND \"rank\" IS NULL")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+
+	item, err := persistence.GetOneById("", "1")
+	assert.Nil(t, err)
+	result := item.(dummyNullable)
+	assert.Nil(t, result.Name)
+	assert.False(t, result.Rank.Valid)
+
+	name := "Name 2"
+	_, err = persistence.Create("", dummyNullable{Id: "2", Name: &name, Rank: sql.NullInt64{Int64: 5, Valid: true}})
+	assert.Nil(t, err)
+
+	item, err = persistence.GetOneById("", "2")
+	assert.Nil(t, err)
+	result = item.(dummyNullable)
+	assert.Equal(t, "Name 2", *result.Name)
+	assert.Equal(t, sql.NullInt64{Int64: 5, Valid: true}, result.Rank)
+}
+
+type dummyNullable struct {
+	Id   string        `json:"id"`
+	Name *string       `json:"name"`
+	Rank sql.NullInt64 `json:"rank"`
+}
+
+type dummyNullablePersistence struct {
+	*persist.IdentifiablePostgresPersistence
+}
+
+func newDummyNullablePersistence() *dummyNullablePersistence {
+	c := &dummyNullablePersistence{}
+	c.IdentifiablePostgresPersistence = persist.InheritIdentifiablePostgresPersistence(c,
+		reflect.TypeOf(dummyNullable{}), "dummies_nullable")
+	return c
+}
+
+func (c *dummyNullablePersistence) DefineSchema() {
+	c.ClearSchema()
+	c.IdentifiablePostgresPersistence.DefineSchema()
+	c.EnsureSchema("CREATE TABLE " + c.QuotedTableName() + " (\"id\" TEXT PRIMARY KEY, \"name\" TEXT, \"rank\" BIGINT)")
+}
@@ -0,0 +1,38 @@
+package test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/jackc/pgtype"
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNumericToString(t *testing.T) {
+	value := pgtype.Numeric{Int: big.NewInt(-12345), Exp: -4, Status: pgtype.Present}
+	assert.Equal(t, "-1.2345", persist.NumericToString(value))
+
+	value = pgtype.Numeric{Int: big.NewInt(5), Exp: -3, Status: pgtype.Present}
+	assert.Equal(t, "0.005", persist.NumericToString(value))
+
+	value = pgtype.Numeric{Int: big.NewInt(12), Exp: 2, Status: pgtype.Present}
+	assert.Equal(t, "1200", persist.NumericToString(value))
+
+	digits, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	value = pgtype.Numeric{Int: digits, Exp: -10, Status: pgtype.Present}
+	assert.Equal(t, "12345678901234567890.1234567890", persist.NumericToString(value))
+
+	assert.Equal(t, "NaN", persist.NumericToString(pgtype.Numeric{NaN: true, Status: pgtype.Present}))
+	assert.Equal(t, "", persist.NumericToString(pgtype.Numeric{Status: pgtype.Null}))
+}
+
+func TestNumericToRat(t *testing.T) {
+	value := pgtype.Numeric{Int: big.NewInt(125), Exp: -2, Status: pgtype.Present}
+	rat, err := persist.NumericToRat(value)
+	assert.Nil(t, err)
+	assert.Equal(t, "5/4", rat.String())
+
+	_, err = persist.NumericToRat(pgtype.Numeric{NaN: true, Status: pgtype.Present})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,37 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresOperationTimeout(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := NewDummyPostgresPersistence()
+	persistence.Configure(db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.operation_timeout", 100,
+	)))
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	// Operations that exceed the timeout are cancelled
+	start := time.Now()
+	_, err = persistence.ExecNonQuery("", "SELECT pg_sleep(2)")
+	assert.NotNil(t, err)
+	assert.True(t, time.Since(start) < time.Second)
+
+	// Operations without timeout run until completed
+	persistence.SetOperationTimeout(0)
+	_, err = persistence.ExecNonQuery("", "SELECT pg_sleep(0.2)")
+	assert.Nil(t, err)
+}
@@ -0,0 +1,59 @@
+package test
+
+import (
+	"testing"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresPageSize(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := NewDummyPostgresPersistence()
+	persistence.Configure(db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.max_page_size", 2,
+	)))
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	err = persistence.Clear("")
+	assert.Nil(t, err)
+
+	for _, key := range []string{"Key 1", "Key 2", "Key 3"} {
+		_, err = persistence.Create("", tf.Dummy{Key: key, Content: "Content"})
+		assert.Nil(t, err)
+	}
+
+	// Large pages are clamped by default
+	page, err := persistence.GetPageByFilterWithPaging("", "", persist.NewPostgresPagingParams(0, 10, false, ""), nil, nil)
+	assert.Nil(t, err)
+	assert.Len(t, page.Data, 2)
+
+	// Negative values are rejected
+	_, err = persistence.GetPageByFilterWithPaging("", "", persist.NewPostgresPagingParams(-1, 1, false, ""), nil, nil)
+	assert.NotNil(t, err)
+
+	_, err = persistence.GetPageByFilterWithPaging("", "", persist.NewPostgresPagingParams(0, -1, false, ""), nil, nil)
+	assert.NotNil(t, err)
+
+	// Large pages are rejected in strict mode
+	persistence.Configure(db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.max_page_size", 2,
+		"options.strict_paging", true,
+	)))
+
+	_, err = persistence.GetPageByFilterWithPaging("", "", persist.NewPostgresPagingParams(0, 10, false, ""), nil, nil)
+	assert.NotNil(t, err)
+
+	page, err = persistence.GetPageByFilterWithPaging("", "", persist.NewPostgresPagingParams(0, 2, false, ""), nil, nil)
+	assert.Nil(t, err)
+	assert.Len(t, page.Data, 2)
+}
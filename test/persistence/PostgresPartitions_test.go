@@ -0,0 +1,77 @@
+package test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+type dummyPartitionedPersistence struct {
+	*persist.PostgresPersistence
+}
+
+func newDummyPartitionedPersistence() *dummyPartitionedPersistence {
+	c := &dummyPartitionedPersistence{}
+	c.PostgresPersistence = persist.InheritPostgresPersistence(c, reflect.TypeOf(map[string]interface{}{}), "dummies_partitioned")
+	return c
+}
+
+func (c *dummyPartitionedPersistence) DefineSchema() {
+	c.ClearSchema()
+	c.EnsureSchema("CREATE TABLE " + c.QuotedTableName() + " (\"id\" TEXT, \"time\" TIMESTAMPTZ NOT NULL," +
+		" PRIMARY KEY (\"id\", \"time\")) PARTITION BY RANGE (\"time\")")
+}
+
+func TestPostgresPartitions(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := newDummyPartitionedPersistence()
+	persistence.Configure(db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.partition_column", "time",
+		"options.partition_interval", persist.PartitionIntervalDay,
+		"options.partition_premake", 2,
+		"options.partition_retention", 3,
+		"options.partition_expire", persist.PartitionExpireDrop,
+	)))
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+	defer persistence.Client.Exec(context.Background(), "DROP TABLE IF EXISTS "+persistence.QuotedTableName())
+
+	// Partitions for today and upcoming days are created on open
+	var count int
+	query := "SELECT COUNT(*) FROM pg_inherits JOIN pg_class ON pg_inherits.inhparent=pg_class.oid WHERE pg_class.relname=$1"
+	err = persistence.Client.QueryRow(context.Background(), query, persistence.TableName).Scan(&count)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, count)
+
+	_, err = persistence.ExecNonQuery("", "INSERT INTO "+persistence.QuotedTableName()+" VALUES ('1', now())")
+	assert.Nil(t, err)
+
+	// Partitions older than the retention period are dropped
+	old := time.Now().UTC().AddDate(0, 0, -10)
+	oldStart := time.Date(old.Year(), old.Month(), old.Day(), 0, 0, 0, 0, time.UTC)
+	oldName := persistence.TableName + "_p" + oldStart.Format("20060102")
+	_, err = persistence.Client.Exec(context.Background(), "CREATE TABLE \""+oldName+"\" PARTITION OF "+
+		persistence.QuotedTableName()+" FOR VALUES FROM ('"+oldStart.Format(time.RFC3339)+"') TO ('"+
+		oldStart.AddDate(0, 0, 1).Format(time.RFC3339)+"')")
+	assert.Nil(t, err)
+
+	expired, err := persistence.ExpirePartitions("")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{oldName}, expired)
+
+	err = persistence.Client.QueryRow(context.Background(), query, persistence.TableName).Scan(&count)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, count)
+}
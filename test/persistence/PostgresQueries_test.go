@@ -0,0 +1,51 @@
+package test
+
+import (
+	"testing"
+
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresQueries(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := NewDummyPostgresPersistence()
+	persistence.Configure(db.Config)
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	err = persistence.Clear("")
+	assert.Nil(t, err)
+
+	count, err := persistence.ExecNonQuery("",
+		"INSERT INTO "+persistence.QuotedTableName()+" (\"id\", \"key\", \"content\") VALUES ($1, $2, $3), ($4, $5, $6)",
+		"1", "Key 1", "Content 1", "2", "Key 2", "Content 2")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), count)
+
+	items, err := persistence.QueryRows("",
+		"SELECT * FROM "+persistence.QuotedTableName()+" WHERE \"key\"=$1", "Key 2")
+	assert.Nil(t, err)
+	assert.Len(t, items, 1)
+	assert.Equal(t, tf.Dummy{Id: "2", Key: "Key 2", Content: "Content 2"}, items[0])
+
+	// Constraint violations are mapped into application errors
+	_, err = persistence.ExecNonQuery("",
+		"INSERT INTO "+persistence.QuotedTableName()+" (\"id\", \"key\") VALUES ($1, $2)", "3", "Key 1")
+	appErr, ok := err.(*cerr.ApplicationError)
+	assert.True(t, ok)
+	if ok {
+		assert.Equal(t, "DUPLICATE_KEY", appErr.Code)
+	}
+
+	count, err = persistence.ExecNonQuery("", "DELETE FROM "+persistence.QuotedTableName()+" WHERE \"id\"=$1", "1")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+}
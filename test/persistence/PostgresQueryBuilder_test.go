@@ -0,0 +1,87 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresQueryBuilder(t *testing.T) {
+	persistence := NewDummyPostgresPersistence()
+
+	query, args, err := persistence.Select().Build()
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM \"dummies\"", query)
+	assert.Len(t, args, 0)
+
+	query, args, err = persistence.Select("id", "key").
+		Where("key", "like", "Key%").
+		WhereIn("id", "1", "2").
+		WhereNotNull("content").
+		OrderBy("key", true).
+		OrderBy("id", false).
+		Limit(10).
+		Offset(20).
+		Build()
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT \"id\",\"key\" FROM \"dummies\" WHERE \"key\" LIKE $1 AND \"id\" IN ($2,$3)"+
+		" AND \"content\" IS NOT NULL ORDER BY \"key\" DESC,\"id\" LIMIT 10 OFFSET 20", query)
+	assert.Equal(t, []interface{}{"Key%", "1", "2"}, args)
+
+	_, _, err = persistence.Select().Where("key", "; DROP TABLE", "1").Build()
+	assert.NotNil(t, err)
+}
+
+func TestPostgresQueryBuilderSubqueries(t *testing.T) {
+	persistence := NewDummyPostgresPersistence()
+
+	query, args, err := persistence.Select().
+		Where("key", "=", "Key 1").
+		WhereExists(persistence.SelectFrom("permissions", "p").
+			WhereColumns("p.item_id", "=", "dummies.id").
+			Where("p.user_id", "=", "1")).
+		WhereNotInSubquery("id", persistence.SelectFrom("archive", "", "item_id").
+			Where("reason", "=", "deleted")).
+		Where("content", "<>", "").
+		Build()
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM \"dummies\" WHERE \"key\" = $1"+
+		" AND EXISTS (SELECT * FROM \"permissions\" \"p\" WHERE \"p\".\"item_id\" = \"dummies\".\"id\" AND \"p\".\"user_id\" = $2)"+
+		" AND \"id\" NOT IN (SELECT \"item_id\" FROM \"archive\" WHERE \"reason\" = $3)"+
+		" AND \"content\" <> $4", query)
+	assert.Equal(t, []interface{}{"Key 1", "1", "deleted", ""}, args)
+
+	// Subqueries can be built separately as well
+	subquery := persistence.SelectFrom("permissions", "p").Where("p.user_id", "=", "1")
+	query, args, err = subquery.Build()
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM \"permissions\" \"p\" WHERE \"p\".\"user_id\" = $1", query)
+	assert.Equal(t, []interface{}{"1"}, args)
+
+	_, _, err = persistence.Select().WhereNotExists(persistence.SelectFrom("permissions", "").
+		WhereColumns("item_id", "is", "id")).Build()
+	assert.NotNil(t, err)
+}
+
+func TestPostgresQueryBuilderWindows(t *testing.T) {
+	persistence := NewDummyPostgresPersistence()
+
+	query, _, err := persistence.Select("id", "key").
+		AddRowNumber("position", []string{"key"}, "id DESC").
+		AddLag("content", 1, "previous", nil, "id").
+		Build()
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT \"id\",\"key\",row_number() OVER (PARTITION BY \"key\" ORDER BY \"id\" DESC) AS \"position\""+
+		",lag(\"content\", 1) OVER (ORDER BY \"id\") AS \"previous\" FROM \"dummies\"", query)
+
+	query, args, err := persistence.Select().
+		Where("content", "<>", "").
+		FirstPerGroup([]string{"key"}, "id desc").
+		OrderBy("key", false).
+		Limit(10).
+		Build()
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM (SELECT *,row_number() OVER (PARTITION BY \"key\" ORDER BY \"id\" DESC) AS \"row_number\""+
+		" FROM \"dummies\" WHERE \"content\" <> $1) AS \"ranked\" WHERE \"row_number\"=1 ORDER BY \"key\" LIMIT 10", query)
+	assert.Equal(t, []interface{}{""}, args)
+}
@@ -0,0 +1,74 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresReadOnly(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := NewDummyPostgresPersistence()
+	persistence.Configure(db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.table_suffix", "_readonly",
+	)))
+	readonly := NewDummyPostgresPersistence()
+	readonly.Configure(db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.table_suffix", "_readonly",
+		"options.readonly", true,
+		"options.default_transaction_read_only", true,
+	)))
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+	defer persistence.Client.Exec(context.Background(), "DROP TABLE IF EXISTS "+persistence.QuotedTableName())
+
+	err = readonly.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer readonly.Close("")
+	assert.True(t, readonly.IsReadOnly())
+
+	err = persistence.Clear("")
+	assert.Nil(t, err)
+	_, err = persistence.Create("", tf.Dummy{Id: "1", Key: "Key 1", Content: "Content 1"})
+	assert.Nil(t, err)
+
+	// Reads are served
+	item, err := readonly.GetOneById("", "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "Content 1", item.Content)
+
+	// Changes of data are rejected
+	_, err = readonly.Create("", tf.Dummy{Id: "2", Key: "Key 2", Content: "Content 2"})
+	assert.NotNil(t, err)
+	assert.Equal(t, "READ_ONLY", err.(*cerr.ApplicationError).Code)
+
+	_, err = readonly.DeleteById("", "1")
+	assert.NotNil(t, err)
+
+	err = readonly.Clear("")
+	assert.NotNil(t, err)
+
+	_, err = readonly.ExecNonQuery("", "DELETE FROM "+readonly.QuotedTableName())
+	assert.NotNil(t, err)
+
+	// Sessions of the connection are read-only on the server
+	_, err = readonly.Client.Exec(context.Background(), "DELETE FROM "+readonly.QuotedTableName())
+	assert.NotNil(t, err)
+
+	item, err = persistence.GetOneById("", "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", item.Id)
+}
@@ -0,0 +1,70 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresReconnect(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := NewDummyPostgresPersistence()
+	persistence.Configure(db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.application_name", "reconnect_test",
+		"options.health_check_period", 100,
+		"options.reconnect_delay", 10,
+	)))
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	killer := NewDummyPostgresPersistence()
+	killer.Configure(db.Config)
+	err = killer.Open("")
+	assert.Nil(t, err)
+	defer killer.Close("")
+
+	// Copies are made before reconnect
+	copy := persistence.WithContext(context.Background())
+	tenant := persistence.ForTenant("a")
+
+	reconnected := make(chan bool, 1)
+	persistence.Connection.AddReconnectListener(func() {
+		reconnected <- true
+	})
+
+	_, err = persistence.Create("", tf.Dummy{Id: "1", Key: "Key 1", Content: "Content 1"})
+	assert.Nil(t, err)
+
+	// Kill sessions of the persistence to simulate a server restart
+	_, err = killer.Client.Exec(context.Background(),
+		"SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE application_name='reconnect_test'")
+	assert.Nil(t, err)
+
+	select {
+	case <-reconnected:
+	case <-time.After(5 * time.Second):
+		t.Error("Connection was not restored")
+		return
+	}
+
+	item, err := persistence.GetOneById("", "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", item.Id)
+
+	count, err := copy.GetCountByFilter("", "")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+
+	count, err = tenant.GetCountByFilter("", "")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+}
@@ -0,0 +1,89 @@
+package test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresRelations(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := newDummyRelationOrderPersistence()
+	persistence.Configure(db.Config)
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	_, err = persistence.Client.Exec(context.Background(), "DELETE FROM "+tf.QuotedName(db.Schema, "dummies_relation_lines"))
+	assert.Nil(t, err)
+	err = persistence.Clear("")
+	assert.Nil(t, err)
+
+	// Relation fields are not written
+	_, err = persistence.Create("", dummyRelationOrder{Id: "1", Lines: []dummyRelationLine{{Id: "1", OrderId: "1"}}})
+	assert.Nil(t, err)
+	_, err = persistence.Create("", dummyRelationOrder{Id: "2"})
+	assert.Nil(t, err)
+
+	_, err = persistence.Client.Exec(context.Background(),
+		"INSERT INTO "+tf.QuotedName(db.Schema, "dummies_relation_lines")+" (\"id\", \"order_id\", \"product\") VALUES ('1', '1', 'A'), ('2', '1', 'B'), ('3', '2', 'C')")
+	assert.Nil(t, err)
+
+	item, err := persistence.GetOneByIdWithRelations("", "1")
+	assert.Nil(t, err)
+	order := item.(dummyRelationOrder)
+	assert.Len(t, order.Lines, 2)
+	assert.Equal(t, dummyRelationLine{Id: "1", OrderId: "1", Product: "A"}, order.Lines[0])
+	assert.Equal(t, "B", order.Lines[1].Product)
+
+	page, err := persistence.GetPageByFilterWithRelations("", "", nil, "\"id\"")
+	assert.Nil(t, err)
+	assert.Len(t, page.Data, 2)
+	assert.Len(t, page.Data[0].(dummyRelationOrder).Lines, 2)
+	assert.Len(t, page.Data[1].(dummyRelationOrder).Lines, 1)
+
+	// Relations are not loaded by default
+	item, err = persistence.GetOneById("", "1")
+	assert.Nil(t, err)
+	assert.Len(t, item.(dummyRelationOrder).Lines, 0)
+}
+
+type dummyRelationLine struct {
+	Id      string `json:"id"`
+	OrderId string `json:"order_id"`
+	Product string `json:"product"`
+}
+
+type dummyRelationOrder struct {
+	Id    string              `json:"id"`
+	Lines []dummyRelationLine `json:"lines"`
+}
+
+type dummyRelationOrderPersistence struct {
+	*persist.IdentifiablePostgresPersistence
+}
+
+func newDummyRelationOrderPersistence() *dummyRelationOrderPersistence {
+	c := &dummyRelationOrderPersistence{}
+	c.IdentifiablePostgresPersistence = persist.InheritIdentifiablePostgresPersistence(c,
+		reflect.TypeOf(dummyRelationOrder{}), "dummies_relation_orders")
+	c.DefineRelation("lines", "dummies_relation_lines", "order_id", reflect.TypeOf(dummyRelationLine{})).
+		Sort = "\"id\""
+	return c
+}
+
+func (c *dummyRelationOrderPersistence) DefineSchema() {
+	c.ClearSchema()
+	c.IdentifiablePostgresPersistence.DefineSchema()
+	c.EnsureSchema("CREATE TABLE IF NOT EXISTS " + tf.QuotedName(c.SchemaName, "dummies_relation_lines") + " (\"id\" TEXT PRIMARY KEY, \"order_id\" TEXT, \"product\" TEXT)")
+	c.EnsureSchema("CREATE TABLE " + c.QuotedTableName() + " (\"id\" TEXT PRIMARY KEY)")
+}
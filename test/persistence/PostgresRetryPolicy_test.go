@@ -0,0 +1,120 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgconn"
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresRetryPolicyDelays(t *testing.T) {
+	policy := persist.NewPostgresRetryPolicy()
+	policy.Delay = 10 * time.Millisecond
+	policy.MaxDelay = 30 * time.Millisecond
+	policy.Jitter = false
+
+	policy.Backoff = persist.RetryBackoffExponential
+	assert.Equal(t, 10*time.Millisecond, policy.GetDelay(1))
+	assert.Equal(t, 20*time.Millisecond, policy.GetDelay(2))
+	assert.Equal(t, 30*time.Millisecond, policy.GetDelay(3))
+
+	policy.Backoff = persist.RetryBackoffLinear
+	assert.Equal(t, 20*time.Millisecond, policy.GetDelay(2))
+
+	policy.Backoff = persist.RetryBackoffConstant
+	assert.Equal(t, 10*time.Millisecond, policy.GetDelay(3))
+
+	policy.Jitter = true
+	delay := policy.GetDelay(1)
+	assert.True(t, delay >= 5*time.Millisecond && delay <= 10*time.Millisecond)
+
+	assert.True(t, policy.IsRetryable(&pgconn.PgError{Code: "40P01"}))
+	assert.True(t, policy.IsRetryable(&pgconn.PgError{Code: "40001"}))
+	assert.False(t, policy.IsRetryable(&pgconn.PgError{Code: "23505"}))
+
+	policy = persist.NewPostgresRetryPolicyFromConfig(cconf.NewConfigParamsFromTuples(
+		"options.retry_sql_states", "40P01, 55P03",
+		"options.retry_max_attempts", 5,
+		"options.retry_backoff", "linear",
+		"options.retry_delay", 100,
+	))
+	assert.Equal(t, []string{"40P01", "55P03"}, policy.SqlStates)
+	assert.Equal(t, 5, policy.MaxAttempts)
+	assert.Equal(t, persist.RetryBackoffLinear, policy.Backoff)
+	assert.Equal(t, 100*time.Millisecond, policy.Delay)
+}
+
+func TestPostgresRetryPolicy(t *testing.T) {
+	persistence := NewDummyPostgresPersistence()
+	persistence.Configure(cconf.NewConfigParamsFromTuples(
+		"options.retry_max_attempts", 3,
+		"options.retry_delay", 1,
+	))
+	persistence.SetClient(&fakePostgresClient{})
+
+	// Deletes fail with the configured error the configured number of times
+	attempts, failures := 0, 0
+	code := "40P01"
+	persistence.AddInterceptor(func(ctx context.Context, correlationId string, sql string, args []interface{},
+		next persist.PostgresStatementFunc) (interface{}, error) {
+		if len(sql) < 6 || sql[:6] != "DELETE" {
+			return next(ctx, sql, args)
+		}
+		attempts++
+		if attempts <= failures {
+			return nil, &pgconn.PgError{Code: code}
+		}
+		return next(ctx, sql, args)
+	})
+
+	err := persistence.Open("")
+	assert.Nil(t, err)
+	defer persistence.Close("")
+
+	// Deadlocks are retried
+	attempts, failures = 0, 2
+	err = persistence.DeleteByFilter("", "key='Key 1'")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, attempts)
+
+	// Attempts are limited
+	attempts, failures = 0, 5
+	err = persistence.DeleteByFilter("", "key='Key 1'")
+	assert.NotNil(t, err)
+	assert.Equal(t, 3, attempts)
+
+	// Other errors are not retried
+	attempts, failures, code = 0, 2, "23505"
+	_, err = persistence.ExecNonQuery("", "DELETE FROM dummies")
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, attempts)
+
+	// Operations can override the policy
+	policy := persist.NewPostgresRetryPolicy()
+	policy.MaxAttempts = 1
+	persistence.SetOperationRetryPolicy("delete", policy)
+	attempts, failures, code = 0, 2, "40001"
+	err = persistence.DeleteByFilter("", "key='Key 1'")
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, attempts)
+	assert.Equal(t, policy, persistence.GetOperationRetryPolicy("DELETE"))
+
+	persistence.SetOperationRetryPolicy("delete", nil)
+	assert.Equal(t, persistence.GetRetryPolicy(), persistence.GetOperationRetryPolicy("delete"))
+
+	// Actions like transactions are retried as a whole
+	runs := 0
+	err = persistence.RunWithRetry("", "transfer", func() error {
+		runs++
+		if runs < 3 {
+			return &pgconn.PgError{Code: "40001"}
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, runs)
+}
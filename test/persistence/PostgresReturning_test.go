@@ -0,0 +1,62 @@
+package test
+
+import (
+	"testing"
+
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresReturning(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := NewDummyPostgresPersistence()
+	persistence.Configure(db.Config)
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	err = persistence.Clear("")
+	assert.Nil(t, err)
+
+	// Only selected columns are returned
+	persistence.SetReturningColumns("id", "key")
+	result, err := persistence.Create("", tf.Dummy{Id: "1", Key: "Key 1", Content: "Content 1"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Key 1", result.Key)
+	assert.Equal(t, "", result.Content)
+
+	result, err = persistence.Update("", tf.Dummy{Id: "1", Key: "Key 1", Content: "Content 2"})
+	assert.Nil(t, err)
+	assert.Equal(t, "", result.Content)
+
+	// Nothing but the id is returned
+	persistence.SetReturningColumns(persist.ReturningNone)
+	result, err = persistence.Create("", tf.Dummy{Id: "2", Key: "Key 2", Content: "Content 2"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Content 2", result.Content)
+
+	result, err = persistence.Update("", tf.Dummy{Id: "2", Key: "Key 2", Content: "Content 3"})
+	assert.Nil(t, err)
+	assert.Equal(t, "2", result.Id)
+	assert.Equal(t, "", result.Key)
+
+	result, err = persistence.Update("", tf.Dummy{Id: "3", Key: "Key 3", Content: "Content 3"})
+	assert.Nil(t, err)
+	assert.Equal(t, "", result.Id)
+
+	result, err = persistence.DeleteById("", "2")
+	assert.Nil(t, err)
+	assert.Equal(t, "2", result.Id)
+
+	// All columns are returned again
+	persistence.SetReturningColumns()
+	result, err = persistence.DeleteById("", "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "Content 2", result.Content)
+}
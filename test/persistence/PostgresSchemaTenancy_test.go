@@ -0,0 +1,52 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresSchemaTenancy(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := NewDummyPostgresPersistence()
+	persistence.Configure(db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.tenancy", persist.TenancySchema,
+	)))
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+	defer persistence.Client.Exec(context.Background(), "DROP SCHEMA IF EXISTS tenant_a CASCADE")
+	defer persistence.Client.Exec(context.Background(), "DROP SCHEMA IF EXISTS tenant_b CASCADE")
+
+	// Operations require a tenant
+	_, err = persistence.GetCountByFilter("", nil)
+	assert.NotNil(t, err)
+
+	// Schemas of tenants are created on the first use
+	tenantA := persistence.IdentifiablePostgresPersistence.ForTenant("a")
+	_, err = tenantA.Create("", tf.Dummy{Id: "1", Key: "Key 1", Content: "Content 1"})
+	assert.Nil(t, err)
+
+	count, err := tenantA.GetCountByFilter("", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+
+	// Tenants do not see data of each other
+	ctx := persist.WithTenant(context.Background(), "b")
+	count, err = persistence.IdentifiablePostgresPersistence.WithContext(ctx).GetCountByFilter("", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+
+	// Tenants are validated to be safe schema names
+	_, err = persistence.IdentifiablePostgresPersistence.ForTenant("a;b").GetCountByFilter("", nil)
+	assert.NotNil(t, err)
+}
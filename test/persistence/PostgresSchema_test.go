@@ -0,0 +1,40 @@
+package test
+
+import (
+	"testing"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuotedTableName(t *testing.T) {
+	persistence := NewDummyPostgresPersistence()
+	assert.Equal(t, "\"dummies\"", persistence.QuotedTableName())
+
+	persistence.Configure(cconf.NewConfigParamsFromTuples(
+		"schema", "test_schema",
+	))
+	assert.Equal(t, "\"test_schema\".\"dummies\"", persistence.QuotedTableName())
+
+	assert.Equal(t, "\"my\"\"table\"", persistence.QuoteIdentifier("my\"table"))
+	assert.Equal(t, "\"quoted\"", persistence.QuoteIdentifier("\"quoted\""))
+	assert.Equal(t, "\"my\"\"table\"", persistence.QuoteIdentifier("\"my\"\"table\""))
+	assert.Equal(t, "\"\"\"a\"\" OR \"\"b\"\"\"", persistence.QuoteIdentifier("\"a\" OR \"b\""))
+	assert.Equal(t, "\"\"\"\"\"\"", persistence.QuoteIdentifier("\"\""))
+	assert.Equal(t, "'it''s'", persistence.QuoteLiteral("it's"))
+}
+
+func TestTablePrefixAndSuffix(t *testing.T) {
+	persistence := NewDummyPostgresPersistence()
+
+	config := cconf.NewConfigParamsFromTuples(
+		"options.table_prefix", "dev_",
+		"options.table_suffix", "_v2",
+	)
+	persistence.Configure(config)
+	assert.Equal(t, "dev_dummies_v2", persistence.TableName)
+
+	// Reconfiguration does not stack prefixes
+	persistence.Configure(config)
+	assert.Equal(t, "dev_dummies_v2", persistence.TableName)
+}
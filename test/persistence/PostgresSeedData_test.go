@@ -0,0 +1,70 @@
+package test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+type dummySeededPersistence struct {
+	*persist.IdentifiablePostgresPersistence
+}
+
+func newDummySeededPersistence() *dummySeededPersistence {
+	c := &dummySeededPersistence{}
+	c.IdentifiablePostgresPersistence = persist.InheritIdentifiablePostgresPersistence(c, reflect.TypeOf(tf.Dummy{}), "dummies_seeded")
+	return c
+}
+
+func (c *dummySeededPersistence) DefineSchema() {
+	c.ClearSchema()
+	c.EnsureSchema("CREATE TABLE " + c.QuotedTableName() + " (\"id\" TEXT PRIMARY KEY, \"key\" TEXT, \"content\" TEXT)")
+	c.EnsureSeedData(
+		tf.Dummy{Id: "1", Key: "Key 1", Content: "Content 1"},
+		tf.Dummy{Id: "2", Key: "Key 2", Content: "Content 2"},
+	)
+	c.EnsureSeedFile(fstest.MapFS{
+		"seed.csv": &fstest.MapFile{Data: []byte("id,key,content\n3,Key 3,Content 3\n")},
+	}, "seed.csv")
+}
+
+func TestPostgresSeedData(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := newDummySeededPersistence()
+	persistence.Configure(db.Config)
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+
+	count, err := persistence.GetCountByFilter("", "")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), count)
+
+	// Changed items are kept on the next opening
+	_, err = persistence.Update("", tf.Dummy{Id: "1", Key: "Key 1", Content: "Changed"})
+	assert.Nil(t, err)
+	err = persistence.Close("")
+	assert.Nil(t, err)
+
+	err = persistence.Open("")
+	assert.Nil(t, err)
+	defer persistence.Close("")
+	defer persistence.Client.Exec(context.Background(), "DROP TABLE IF EXISTS "+persistence.QuotedTableName())
+
+	count, err = persistence.GetCountByFilter("", "")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), count)
+
+	item, err := persistence.GetOneById("", "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "Changed", item.(tf.Dummy).Content)
+}
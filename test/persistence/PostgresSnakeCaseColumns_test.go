@@ -0,0 +1,75 @@
+package test
+
+import (
+	"reflect"
+	"testing"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresSnakeCaseColumns(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := newDummySnakePersistence()
+	persistence.Configure(db.Config.Override(cconf.NewConfigParamsFromTuples(
+		"options.column_naming", "snake_case",
+	)))
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	err = persistence.Clear("")
+	assert.Nil(t, err)
+
+	_, err = persistence.Create("", dummySnake{Id: "1", DisplayName: "Name 1", UserID: "User 1"})
+	assert.Nil(t, err)
+
+	count, err := persistence.GetCountByFilter("", "\"display_name\"='Name 1' AND \"user_id\"='User 1'")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+
+	item, err := persistence.GetOneById("", "1")
+	assert.Nil(t, err)
+	assert.Equal(t, dummySnake{Id: "1", DisplayName: "Name 1", UserID: "User 1"}, item)
+}
+
+func TestToSnakeCase(t *testing.T) {
+	assert.Equal(t, "create_time", persist.ToSnakeCase("CreateTime"))
+	assert.Equal(t, "create_time", persist.ToSnakeCase("createTime"))
+	assert.Equal(t, "user_id", persist.ToSnakeCase("UserID"))
+	assert.Equal(t, "http_server", persist.ToSnakeCase("HTTPServer"))
+	assert.Equal(t, "already_snake", persist.ToSnakeCase("already_snake"))
+
+	assert.Equal(t, "createTime", persist.ToCamelCase("create_time"))
+	assert.Equal(t, "id", persist.ToCamelCase("id"))
+}
+
+type dummySnake struct {
+	Id          string `json:"id"`
+	DisplayName string `json:"displayName"`
+	UserID      string
+}
+
+type dummySnakePersistence struct {
+	*persist.IdentifiablePostgresPersistence
+}
+
+func newDummySnakePersistence() *dummySnakePersistence {
+	c := &dummySnakePersistence{}
+	c.IdentifiablePostgresPersistence = persist.InheritIdentifiablePostgresPersistence(c,
+		reflect.TypeOf(dummySnake{}), "dummies_snake")
+	return c
+}
+
+func (c *dummySnakePersistence) DefineSchema() {
+	c.ClearSchema()
+	c.IdentifiablePostgresPersistence.DefineSchema()
+	c.EnsureTableFromPrototype()
+}
@@ -0,0 +1,82 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresSoftDelete(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := NewDummyPostgresPersistence()
+	persistence.Configure(db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.table_suffix", "_soft",
+		"options.soft_delete_column", "deleted_time",
+	)))
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+	defer persistence.Client.Exec(context.Background(), "DROP TABLE IF EXISTS "+persistence.QuotedTableName())
+
+	_, err = persistence.ExecNonQuery("", "ALTER TABLE "+persistence.QuotedTableName()+
+		" ADD COLUMN IF NOT EXISTS \"deleted_time\" TIMESTAMPTZ")
+	assert.Nil(t, err)
+	err = persistence.Clear("")
+	assert.Nil(t, err)
+
+	_, err = persistence.Create("", tf.Dummy{Id: "1", Key: "Key 1", Content: "Content 1"})
+	assert.Nil(t, err)
+	_, err = persistence.Create("", tf.Dummy{Id: "2", Key: "Key 2", Content: "Content 2"})
+	assert.Nil(t, err)
+
+	// Deleted items are hidden, but kept in the table
+	_, err = persistence.DeleteById("", "1")
+	assert.Nil(t, err)
+
+	item, err := persistence.GetOneById("", "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "", item.Id)
+
+	count, err := persistence.IdentifiablePostgresPersistence.GetCountByFilter("", "")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+
+	page, err := persistence.IdentifiablePostgresPersistence.GetDeletedPageByFilter("", "", nil, nil, nil)
+	assert.Nil(t, err)
+	assert.Len(t, page.Data, 1)
+
+	// Deleted items are restored
+	restored, err := persistence.IdentifiablePostgresPersistence.RestoreById("", "1")
+	assert.Nil(t, err)
+	assert.NotNil(t, restored)
+
+	item, err = persistence.GetOneById("", "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", item.Id)
+
+	// Only items deleted before the age are purged
+	err = persistence.DeleteByIds("", []string{"1", "2"})
+	assert.Nil(t, err)
+
+	purged, err := persistence.IdentifiablePostgresPersistence.PurgeDeletedOlderThan("", time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), purged)
+
+	time.Sleep(10 * time.Millisecond)
+	purged, err = persistence.IdentifiablePostgresPersistence.PurgeDeletedOlderThan("", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), purged)
+
+	page, err = persistence.IdentifiablePostgresPersistence.GetDeletedPageByFilter("", "", nil, nil, nil)
+	assert.Nil(t, err)
+	assert.Len(t, page.Data, 0)
+}
@@ -0,0 +1,35 @@
+package test
+
+import (
+	"testing"
+
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeoPointConversion(t *testing.T) {
+	value := persist.GeoPointToString(persist.GeoPoint{Lon: 30, Lat: 10.5})
+	assert.Equal(t, "SRID=4326;POINT(30 10.5)", value)
+
+	point, err := persist.ParseGeoPoint(value)
+	assert.Nil(t, err)
+	assert.Equal(t, persist.GeoPoint{Lon: 30, Lat: 10.5}, point)
+
+	// Hex EWKB of SRID=4326;POINT(30 10)
+	point, err = persist.ParseGeoPoint("0101000020E61000000000000000003E400000000000002440")
+	assert.Nil(t, err)
+	assert.Equal(t, persist.GeoPoint{Lon: 30, Lat: 10}, point)
+
+	_, err = persist.ParseGeoPoint("0102000000")
+	assert.NotNil(t, err)
+}
+
+func TestComposeSpatialFilters(t *testing.T) {
+	persistence := NewDummyPostgresPersistence()
+
+	filter := persistence.ComposeWithinDistanceFilter("location", persist.GeoPoint{Lon: 30, Lat: 10}, 500)
+	assert.Equal(t, "ST_DWithin(\"location\"::geography, ST_SetSRID(ST_MakePoint(30, 10), 4326)::geography, 500)", filter)
+
+	filter = persistence.ComposeBoundingBoxFilter("location", persist.GeoPoint{Lon: 1, Lat: 2}, persist.GeoPoint{Lon: 3, Lat: 4})
+	assert.Equal(t, "\"location\"::geometry && ST_MakeEnvelope(1, 2, 3, 4, 4326)", filter)
+}
@@ -0,0 +1,41 @@
+package test
+
+import (
+	"testing"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresTableDefinition(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+	dbConfig := db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"table", "dummies_defined",
+		"table_definition.columns.id", "TEXT PRIMARY KEY",
+		"table_definition.columns.key", "VARCHAR(64) NOT NULL",
+		"table_definition.columns.content", "TEXT",
+		"table_definition.unique_indexes.dummies_defined_key", "key",
+	))
+
+	persistence := NewDummyPostgresPersistence()
+	persistence.Configure(dbConfig)
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	err = persistence.Clear("")
+	assert.Nil(t, err)
+
+	dummy, err := persistence.Create("", tf.Dummy{Key: "Key 1", Content: "Content 1"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Key 1", dummy.Key)
+
+	// Unique index from configuration rejects duplicates
+	_, err = persistence.Create("", tf.Dummy{Key: "Key 1", Content: "Content 2"})
+	assert.NotNil(t, err)
+}
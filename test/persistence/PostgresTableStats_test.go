@@ -0,0 +1,37 @@
+package test
+
+import (
+	"testing"
+
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresTableStats(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := NewDummyPostgresPersistence()
+	persistence.Configure(db.Config)
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	err = persistence.Clear("")
+	assert.Nil(t, err)
+	_, err = persistence.Create("", tf.Dummy{Id: "1", Key: "Key 1", Content: "Content 1"})
+	assert.Nil(t, err)
+	_, err = persistence.ExecNonQuery("", "ANALYZE "+persistence.QuotedTableName())
+	assert.Nil(t, err)
+
+	stats, err := persistence.GetTableStats("")
+	assert.Nil(t, err)
+	assert.NotNil(t, stats)
+	assert.True(t, stats.TableSize > 0)
+	assert.True(t, stats.IndexesSize > 0)
+	assert.True(t, stats.TotalSize >= stats.TableSize+stats.IndexesSize)
+	assert.NotNil(t, stats.LastAnalyze)
+}
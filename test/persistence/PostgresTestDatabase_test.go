@@ -0,0 +1,24 @@
+package test
+
+import (
+	"testing"
+
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+)
+
+func TestPostgresTestDatabase(t *testing.T) {
+	database := tf.StartPostgresTestDatabase(t)
+
+	persistence := NewDummyPostgresPersistence()
+	fixture := tf.NewDummyPersistenceFixture(persistence)
+	persistence.Configure(database.Config)
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	t.Run("PostgresTestDatabase:CRUD", fixture.TestCrudOperations)
+}
@@ -0,0 +1,87 @@
+package test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresTimeConversion(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := newDummyTimePersistence()
+	persistence.Configure(db.Config.Override(cconf.NewConfigParamsFromTuples(
+		"options.time_zone", "utc",
+		"options.time_precision", "ms",
+	)))
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	err = persistence.Clear("")
+	assert.Nil(t, err)
+
+	zone := time.FixedZone("UTC+3", 3*60*60)
+	created := time.Date(2021, 5, 10, 15, 30, 45, 123456789, zone)
+	_, err = persistence.Create("", dummyTime{Id: "1", Created: created})
+	assert.Nil(t, err)
+
+	item, err := persistence.GetOneById("", "1")
+	assert.Nil(t, err)
+	result, ok := item.(dummyTime)
+	assert.True(t, ok)
+	assert.Equal(t, time.UTC, result.Created.Location())
+	assert.True(t, created.Truncate(time.Millisecond).Equal(result.Created))
+
+	// Map prototypes get times instead of strings
+	mapPersistence := newDummyTimeMapPersistence()
+	mapPersistence.Configure(db.Config)
+	err = mapPersistence.Open("")
+	assert.Nil(t, err)
+	defer mapPersistence.Close("")
+
+	item, err = mapPersistence.GetOneById("", "1")
+	assert.Nil(t, err)
+	values, ok := item.(map[string]interface{})
+	assert.True(t, ok)
+	_, ok = values["created"].(time.Time)
+	assert.True(t, ok)
+}
+
+type dummyTime struct {
+	Id      string    `json:"id"`
+	Created time.Time `json:"created"`
+}
+
+type dummyTimePersistence struct {
+	*persist.IdentifiablePostgresPersistence
+}
+
+func newDummyTimePersistence() *dummyTimePersistence {
+	c := &dummyTimePersistence{}
+	c.IdentifiablePostgresPersistence = persist.InheritIdentifiablePostgresPersistence(c,
+		reflect.TypeOf(dummyTime{}), "dummies_time")
+	return c
+}
+
+func newDummyTimeMapPersistence() *dummyTimePersistence {
+	c := &dummyTimePersistence{}
+	c.IdentifiablePostgresPersistence = persist.InheritIdentifiablePostgresPersistence(c,
+		reflect.TypeOf(map[string]interface{}{}), "dummies_time")
+	return c
+}
+
+func (c *dummyTimePersistence) DefineSchema() {
+	c.ClearSchema()
+	c.IdentifiablePostgresPersistence.DefineSchema()
+	c.EnsureSchema("CREATE TABLE " + c.QuotedTableName() + " (\"id\" TEXT PRIMARY KEY, \"created\" TIMESTAMPTZ)")
+}
@@ -0,0 +1,68 @@
+package test
+
+import (
+	"testing"
+
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresTokenPaging(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := NewDummyPostgresPersistence()
+	persistence.Configure(db.Config)
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	err = persistence.Clear("")
+	assert.Nil(t, err)
+
+	for _, key := range []string{"Key 1", "Key 2", "Key 3", "Key 4", "Key 5"} {
+		_, err = persistence.Create("", tf.Dummy{Key: key, Content: "Content"})
+		assert.Nil(t, err)
+	}
+
+	page, err := persistence.GetPageByToken("", "", "", 2, "key")
+	assert.Nil(t, err)
+	assert.Len(t, page.Data, 2)
+	assert.NotEmpty(t, page.Token)
+	assert.Equal(t, "Key 2", page.Data[1].(tf.Dummy).Key)
+
+	// Items inserted before the position do not shift the next page
+	_, err = persistence.Create("", tf.Dummy{Key: "Key 0", Content: "Content"})
+	assert.Nil(t, err)
+
+	page, err = persistence.GetPageByToken("", "", page.Token, 2, "key")
+	assert.Nil(t, err)
+	assert.Len(t, page.Data, 2)
+	assert.Equal(t, "Key 3", page.Data[0].(tf.Dummy).Key)
+
+	page, err = persistence.GetPageByToken("", "", page.Token, 2, "key")
+	assert.Nil(t, err)
+	assert.Len(t, page.Data, 1)
+	assert.Equal(t, "Key 5", page.Data[0].(tf.Dummy).Key)
+	assert.Empty(t, page.Token)
+
+	// Descending order with a filter
+	page, err = persistence.GetPageByToken("", "\"key\"<>'Key 0'", "", 3, "key DESC")
+	assert.Nil(t, err)
+	assert.Len(t, page.Data, 3)
+	assert.Equal(t, "Key 5", page.Data[0].(tf.Dummy).Key)
+
+	page, err = persistence.GetPageByToken("", "\"key\"<>'Key 0'", page.Token, 3, "key DESC")
+	assert.Nil(t, err)
+	assert.Len(t, page.Data, 2)
+	assert.Equal(t, "Key 1", page.Data[1].(tf.Dummy).Key)
+
+	// Tokens of another sort order are rejected
+	page, err = persistence.GetPageByToken("", "", "", 2, "key")
+	assert.Nil(t, err)
+	_, err = persistence.GetPageByToken("", "", page.Token, 2, "id")
+	assert.NotNil(t, err)
+}
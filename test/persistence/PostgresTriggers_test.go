@@ -0,0 +1,67 @@
+package test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresTriggers(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := newDummyTriggersPersistence()
+	persistence.Configure(db.Config)
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	err = persistence.Clear("")
+	assert.Nil(t, err)
+
+	old := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	item, err := persistence.Create("", dummyTriggers{Id: "1", Name: "Test", Updated: old})
+	assert.Nil(t, err)
+	result := item.(dummyTriggers)
+	assert.True(t, result.Updated.After(old))
+	assert.Equal(t, "TEST", result.Code)
+
+	item, err = persistence.Update("", dummyTriggers{Id: "1", Name: "Changed", Updated: old})
+	assert.Nil(t, err)
+	result = item.(dummyTriggers)
+	assert.True(t, result.Updated.After(old))
+	assert.Equal(t, "CHANGED", result.Code)
+}
+
+type dummyTriggers struct {
+	Id      string    `json:"id"`
+	Name    string    `json:"name"`
+	Code    string    `json:"code"`
+	Updated time.Time `json:"updated"`
+}
+
+type dummyTriggersPersistence struct {
+	*persist.IdentifiablePostgresPersistence
+}
+
+func newDummyTriggersPersistence() *dummyTriggersPersistence {
+	c := &dummyTriggersPersistence{}
+	c.IdentifiablePostgresPersistence = persist.InheritIdentifiablePostgresPersistence(c,
+		reflect.TypeOf(dummyTriggers{}), "dummies_triggers")
+	return c
+}
+
+func (c *dummyTriggersPersistence) DefineSchema() {
+	c.ClearSchema()
+	c.IdentifiablePostgresPersistence.DefineSchema()
+	c.EnsureTableFromPrototype()
+	c.EnsureUpdateTimeTrigger("updated")
+	c.EnsureTrigger("code", "before", "insert or update", "NEW.\"code\" = upper(NEW.\"name\"); RETURN NEW;")
+}
@@ -0,0 +1,20 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComposeSimilarityFilter(t *testing.T) {
+	persistence := NewDummyPostgresPersistence()
+
+	filter := persistence.ComposeSimilarityFilter("name", "O'Brian", 0)
+	assert.Equal(t, "\"name\" % 'O''Brian'", filter)
+
+	filter = persistence.ComposeSimilarityFilter("name", "jon", 0.5)
+	assert.Equal(t, "similarity(\"name\", 'jon') >= 0.5", filter)
+
+	sort := persistence.ComposeSimilaritySort("name", "jon")
+	assert.Equal(t, "similarity(\"name\", 'jon') DESC", sort)
+}
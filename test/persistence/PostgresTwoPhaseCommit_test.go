@@ -0,0 +1,101 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresTwoPhaseCommit(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := NewDummyPostgresPersistence()
+	persistence.Configure(db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.table_suffix", "_2pc",
+	)))
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+	defer persistence.Client.Exec(context.Background(), "DROP TABLE IF EXISTS "+persistence.QuotedTableName())
+
+	var maxPrepared int
+	err = persistence.Client.QueryRow(context.Background(), "SELECT current_setting('max_prepared_transactions')::int").Scan(&maxPrepared)
+	assert.Nil(t, err)
+	if maxPrepared == 0 {
+		t.Skip("Prepared transactions are disabled by max_prepared_transactions")
+	}
+
+	err = persistence.Clear("")
+	assert.Nil(t, err)
+
+	insert := func(id string) func(tx pgx.Tx) error {
+		return func(tx pgx.Tx) error {
+			_, err := tx.Exec(context.Background(), "INSERT INTO "+persistence.QuotedTableName()+
+				" (\"id\", \"key\", \"content\") VALUES ($1, $1, 'Content')", id)
+			return err
+		}
+	}
+
+	// Prepared changes are visible only after commit
+	err = persistence.PrepareTransaction("", "test_2pc_1", insert("1"))
+	assert.Nil(t, err)
+
+	item, err := persistence.GetOneById("", "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "", item.Id)
+
+	err = persistence.CommitPrepared("", "test_2pc_1")
+	assert.Nil(t, err)
+
+	item, err = persistence.GetOneById("", "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", item.Id)
+
+	// Transactions left behind are found and resolved by recovery
+	err = persistence.PrepareTransaction("", "test_2pc_2", insert("2"))
+	assert.Nil(t, err)
+	err = persistence.PrepareTransaction("", "test_2pc_3", insert("3"))
+	assert.Nil(t, err)
+
+	items, err := persistence.GetPreparedTransactions("", "test_2pc_")
+	assert.Nil(t, err)
+	assert.Len(t, items, 2)
+
+	count, err := persistence.RecoverPreparedTransactions("", "test_2pc_", 0,
+		func(item *persist.PostgresPreparedTransaction) (bool, error) {
+			return item.Gid == "test_2pc_2", nil
+		})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, count)
+
+	item, err = persistence.GetOneById("", "2")
+	assert.Nil(t, err)
+	assert.Equal(t, "2", item.Id)
+	item, err = persistence.GetOneById("", "3")
+	assert.Nil(t, err)
+	assert.Equal(t, "", item.Id)
+
+	items, err = persistence.GetPreparedTransactions("", "test_2pc_")
+	assert.Nil(t, err)
+	assert.Len(t, items, 0)
+
+	// Failed actions are rolled back without preparing
+	err = persistence.PrepareTransaction("", "test_2pc_4", insert("1"))
+	assert.NotNil(t, err)
+
+	_, err = persistence.RecoverPreparedTransactions("", "test_2pc_", time.Hour,
+		func(item *persist.PostgresPreparedTransaction) (bool, error) {
+			return true, nil
+		})
+	assert.Nil(t, err)
+}
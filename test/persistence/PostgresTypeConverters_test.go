@@ -0,0 +1,87 @@
+package test
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresTypeConverters(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := newDummyConvertedPersistence()
+	persistence.Configure(db.Config)
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	err = persistence.Clear("")
+	assert.Nil(t, err)
+
+	_, err = persistence.Create("", dummyConverted{Id: "1", Address: net.ParseIP("192.168.0.1"), Code: "abc"})
+	assert.Nil(t, err)
+
+	// Column converter writes codes in upper case
+	count, err := persistence.GetCountByFilter("", "\"code\"='ABC' AND \"address\"='192.168.0.1'::inet")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+
+	item, err := persistence.GetOneById("", "1")
+	assert.Nil(t, err)
+	result := item.(dummyConverted)
+	assert.True(t, net.ParseIP("192.168.0.1").Equal(result.Address))
+	assert.Equal(t, "abc", result.Code)
+}
+
+type dummyConverted struct {
+	Id      string `json:"id"`
+	Address net.IP `json:"address"`
+	Code    string `json:"code"`
+}
+
+type dummyConvertedPersistence struct {
+	*persist.IdentifiablePostgresPersistence
+}
+
+func newDummyConvertedPersistence() *dummyConvertedPersistence {
+	c := &dummyConvertedPersistence{}
+	c.IdentifiablePostgresPersistence = persist.InheritIdentifiablePostgresPersistence(c,
+		reflect.TypeOf(dummyConverted{}), "dummies_converted")
+
+	c.RegisterTypeConverter(reflect.TypeOf(net.IP{}), &persist.PostgresTypeConverter{
+		Encode: func(value interface{}) (interface{}, error) {
+			return value.(net.IP).String(), nil
+		},
+		Decode: func(value interface{}) (interface{}, error) {
+			if network, ok := value.(*net.IPNet); ok {
+				return network.IP, nil
+			}
+			return nil, fmt.Errorf("unexpected address %v", value)
+		},
+	})
+	c.RegisterColumnConverter("code", &persist.PostgresTypeConverter{
+		Encode: func(value interface{}) (interface{}, error) {
+			return strings.ToUpper(value.(string)), nil
+		},
+		Decode: func(value interface{}) (interface{}, error) {
+			return strings.ToLower(value.(string)), nil
+		},
+	})
+	return c
+}
+
+func (c *dummyConvertedPersistence) DefineSchema() {
+	c.ClearSchema()
+	c.IdentifiablePostgresPersistence.DefineSchema()
+	c.EnsureSchema("CREATE TABLE " + c.QuotedTableName() + " (\"id\" TEXT PRIMARY KEY, \"address\" INET, \"code\" TEXT)")
+}
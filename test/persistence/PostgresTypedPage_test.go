@@ -0,0 +1,38 @@
+package test
+
+import (
+	"testing"
+
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopyPageData(t *testing.T) {
+	total := int64(2)
+	page := cdata.NewDataPage(&total, []interface{}{
+		tf.Dummy{Id: "1", Key: "Key 1"},
+		&tf.Dummy{Id: "2", Key: "Key 2"},
+	})
+
+	var items []tf.Dummy
+	count, err := persist.CopyPageData(page, &items)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), *count)
+	assert.Len(t, items, 2)
+	assert.Equal(t, "Key 2", items[1].Key)
+
+	var pointers []*tf.Dummy
+	_, err = persist.CopyPageData(page, &pointers)
+	assert.Nil(t, err)
+	assert.Len(t, pointers, 2)
+	assert.Equal(t, "Key 1", pointers[0].Key)
+
+	var strings []string
+	_, err = persist.CopyPageData(page, &strings)
+	assert.NotNil(t, err)
+
+	_, err = persist.CopyPageData(page, items)
+	assert.NotNil(t, err)
+}
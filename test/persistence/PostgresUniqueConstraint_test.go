@@ -0,0 +1,70 @@
+package test
+
+import (
+	"reflect"
+	"testing"
+
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresUniqueConstraint(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := newDummyAccountPersistence()
+	persistence.Configure(db.Config)
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	err = persistence.Clear("")
+	assert.Nil(t, err)
+
+	result, err := persistence.Set("", dummyAccount{Id: "1", Login: "user1", Name: "User 1"})
+	assert.Nil(t, err)
+	assert.Equal(t, "1", result.(dummyAccount).Id)
+
+	// Existing item is found by login and keeps its id
+	result, err = persistence.Set("", dummyAccount{Id: "2", Login: "user1", Name: "User 2"})
+	assert.Nil(t, err)
+	account := result.(dummyAccount)
+	assert.Equal(t, "1", account.Id)
+	assert.Equal(t, "User 2", account.Name)
+
+	count, err := persistence.GetCountByFilter("", "")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+
+	// Duplicated login violates the constraint
+	_, err = persistence.Create("", dummyAccount{Id: "3", Login: "user1", Name: "User 3"})
+	assert.NotNil(t, err)
+}
+
+type dummyAccount struct {
+	Id    string `json:"id"`
+	Login string `json:"login"`
+	Name  string `json:"name"`
+}
+
+type dummyAccountPersistence struct {
+	*persist.IdentifiablePostgresPersistence
+}
+
+func newDummyAccountPersistence() *dummyAccountPersistence {
+	c := &dummyAccountPersistence{}
+	c.IdentifiablePostgresPersistence = persist.InheritIdentifiablePostgresPersistence(c,
+		reflect.TypeOf(dummyAccount{}), "dummies_accounts")
+	return c
+}
+
+func (c *dummyAccountPersistence) DefineSchema() {
+	c.ClearSchema()
+	c.IdentifiablePostgresPersistence.DefineSchema()
+	c.EnsureSchema("CREATE TABLE " + c.QuotedTableName() + " (\"id\" TEXT PRIMARY KEY, \"login\" TEXT NOT NULL, \"name\" TEXT)")
+	c.SetUpsertConstraint(c.EnsureUniqueConstraint("login"))
+}
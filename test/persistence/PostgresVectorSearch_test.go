@@ -0,0 +1,129 @@
+package test
+
+import (
+	"reflect"
+	"testing"
+
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVectorConversion(t *testing.T) {
+	value := persist.VectorToString([]float32{1, 0.5, -2.25})
+	assert.Equal(t, "[1,0.5,-2.25]", value)
+
+	embedding, err := persist.ParseVector(value)
+	assert.Nil(t, err)
+	assert.Equal(t, []float32{1, 0.5, -2.25}, embedding)
+
+	embedding, err = persist.ParseVector("[]")
+	assert.Nil(t, err)
+	assert.Len(t, embedding, 0)
+
+	_, err = persist.ParseVector("[1,abc]")
+	assert.NotNil(t, err)
+}
+
+type DummyVectorPostgresPersistence struct {
+	persist.IdentifiablePostgresPersistence
+	indexFirst bool
+}
+
+func NewDummyVectorPostgresPersistence(indexFirst bool) *DummyVectorPostgresPersistence {
+	c := &DummyVectorPostgresPersistence{indexFirst: indexFirst}
+	c.IdentifiablePostgresPersistence = *persist.InheritIdentifiablePostgresPersistence(c,
+		reflect.TypeOf(tf.Dummy{}), "vector_dummies")
+	return c
+}
+
+func (c *DummyVectorPostgresPersistence) DefineSchema() {
+	c.ClearSchema()
+	c.IdentifiablePostgresPersistence.DefineSchema()
+	c.EnsureSchema("CREATE TABLE " + c.QuotedTableName() + " (\"id\" TEXT PRIMARY KEY, \"key\" TEXT, \"content\" TEXT)")
+	if c.indexFirst {
+		c.EnsureVectorIndex(c.TableName+"_embedding", "", nil)
+	}
+	c.EnsureVectorColumn("embedding", 3)
+	if !c.indexFirst {
+		c.EnsureVectorIndex(c.TableName+"_embedding", "", nil)
+	}
+}
+
+func TestPostgresVectorSearch(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	probe := NewDummyPostgresPersistence()
+	probe.Configure(db.Config)
+	err := probe.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	_, err = probe.ExecNonQuery("", "CREATE EXTENSION IF NOT EXISTS vector")
+	probe.Close("")
+	if err != nil {
+		t.Skip("pgvector extension is not available")
+	}
+
+	persistence := NewDummyVectorPostgresPersistence(false)
+	persistence.Configure(db.Config)
+	err = persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	embeddings := map[string]string{
+		"1": persist.VectorToString([]float32{1, 0, 0}),
+		"2": persist.VectorToString([]float32{0, 1, 0}),
+		"3": persist.VectorToString([]float32{0.9, 0.1, 0}),
+	}
+	for id, embedding := range embeddings {
+		_, err = persistence.Create("", tf.Dummy{Id: id, Key: "Key " + id, Content: "Content " + id})
+		assert.Nil(t, err)
+		_, err = persistence.ExecNonQuery("",
+			"UPDATE "+persistence.QuotedTableName()+" SET \"embedding\"=$2::vector WHERE \"id\"=$1", id, embedding)
+		assert.Nil(t, err)
+	}
+
+	// Items are ordered by distance and limited by topK
+	items, err := persistence.SearchBySimilarity("", []float32{1, 0, 0}, 2, nil)
+	assert.Nil(t, err)
+	if assert.Len(t, items, 2) {
+		assert.Equal(t, "1", items[0].(tf.Dummy).Id)
+		assert.Equal(t, "3", items[1].(tf.Dummy).Id)
+	}
+
+	// Filters are applied before the search
+	items, err = persistence.SearchBySimilarity("", []float32{1, 0, 0}, 1, "\"id\"<>'1'")
+	assert.Nil(t, err)
+	if assert.Len(t, items, 1) {
+		assert.Equal(t, "3", items[0].(tf.Dummy).Id)
+	}
+
+	// Zero topK returns all items
+	items, err = persistence.SearchBySimilarity("", []float32{1, 0, 0}, 0, nil)
+	assert.Nil(t, err)
+	assert.Len(t, items, 3)
+
+	// Negative topK is rejected
+	_, err = persistence.SearchBySimilarity("", []float32{1, 0, 0}, -1, nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, "INVALID_TOP_K", err.(*cerr.ApplicationError).Code)
+
+	// Filters other than strings are rejected
+	_, err = persistence.SearchBySimilarity("", []float32{1, 0, 0}, 1, map[string]interface{}{"id": "1"})
+	assert.NotNil(t, err)
+	assert.Equal(t, "INVALID_FILTER", err.(*cerr.ApplicationError).Code)
+
+	// An index defined before the vector column fails opening
+	invalid := NewDummyVectorPostgresPersistence(true)
+	invalid.Configure(db.Config)
+	err = invalid.Open("")
+	defer invalid.Close("")
+	assert.NotNil(t, err)
+	assert.Equal(t, "NO_VECTOR_COLUMN", err.(*cerr.ApplicationError).Code)
+}
@@ -0,0 +1,82 @@
+package test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresView(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := newDummyOrderViewPersistence()
+	persistence.Configure(db.Config)
+
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	_, err = persistence.Client.Exec(context.Background(), "DELETE FROM "+tf.QuotedName(db.Schema, "dummies_orders"))
+	assert.Nil(t, err)
+	_, err = persistence.Client.Exec(context.Background(), "DELETE FROM "+tf.QuotedName(db.Schema, "dummies_customers"))
+	assert.Nil(t, err)
+	_, err = persistence.Client.Exec(context.Background(),
+		"INSERT INTO "+tf.QuotedName(db.Schema, "dummies_customers")+" (\"id\", \"name\") VALUES ('1', 'Customer 1')")
+	assert.Nil(t, err)
+	_, err = persistence.Client.Exec(context.Background(),
+		"INSERT INTO "+tf.QuotedName(db.Schema, "dummies_orders")+" (\"id\", \"customer_id\", \"amount\") VALUES ('1', '1', 10)")
+	assert.Nil(t, err)
+
+	assert.True(t, persistence.IsReadOnly())
+
+	item, err := persistence.GetOneById("", "1")
+	assert.Nil(t, err)
+	assert.Equal(t, dummyOrderView{Id: "1", CustomerName: "Customer 1", Amount: 10}, item)
+
+	// Changes are rejected
+	_, err = persistence.Create("", dummyOrderView{Id: "2", CustomerName: "Customer 2", Amount: 5})
+	assert.NotNil(t, err)
+	_, err = persistence.Update("", dummyOrderView{Id: "1", CustomerName: "Customer 1", Amount: 5})
+	assert.NotNil(t, err)
+	_, err = persistence.DeleteById("", "1")
+	assert.NotNil(t, err)
+	err = persistence.Clear("")
+	assert.NotNil(t, err)
+
+	count, err := persistence.GetCountByFilter("", "")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+type dummyOrderView struct {
+	Id           string  `json:"id"`
+	CustomerName string  `json:"customer_name"`
+	Amount       float64 `json:"amount"`
+}
+
+type dummyOrderViewPersistence struct {
+	*persist.IdentifiablePostgresPersistence
+}
+
+func newDummyOrderViewPersistence() *dummyOrderViewPersistence {
+	c := &dummyOrderViewPersistence{}
+	c.IdentifiablePostgresPersistence = persist.InheritIdentifiablePostgresPersistence(c,
+		reflect.TypeOf(dummyOrderView{}), "dummies_order_view")
+	return c
+}
+
+func (c *dummyOrderViewPersistence) DefineSchema() {
+	c.ClearSchema()
+	c.IdentifiablePostgresPersistence.DefineSchema()
+	c.EnsureSchema("CREATE TABLE IF NOT EXISTS " + tf.QuotedName(c.SchemaName, "dummies_customers") + " (\"id\" TEXT PRIMARY KEY, \"name\" TEXT)")
+	c.EnsureSchema("CREATE TABLE IF NOT EXISTS " + tf.QuotedName(c.SchemaName, "dummies_orders") + " (\"id\" TEXT PRIMARY KEY, \"customer_id\" TEXT, \"amount\" FLOAT)")
+	c.EnsureView("SELECT o.\"id\", c.\"name\" AS \"customer_name\", o.\"amount\"" +
+		" FROM " + tf.QuotedName(c.SchemaName, "dummies_orders") + " o JOIN " + tf.QuotedName(c.SchemaName, "dummies_customers") + " c ON c.\"id\"=o.\"customer_id\"")
+}
@@ -0,0 +1,52 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSagaStatePostgresPersistence(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	persistence := persist.NewSagaStatePostgresPersistence("sagas")
+	persistence.Configure(db.Config)
+	err := persistence.Open("")
+	if err != nil {
+		t.Error("Error opened persistence", err)
+		return
+	}
+	defer persistence.Close("")
+
+	err = persistence.Clear("")
+	assert.Nil(t, err)
+
+	saga, err := persistence.Start("", "checkout", map[string]interface{}{"order": "1"}, time.Minute)
+	assert.Nil(t, err)
+	assert.NotNil(t, saga)
+	assert.Equal(t, persist.SagaStatusRunning, saga.Status)
+
+	sagas, err := persistence.ClaimActive("", "worker1", 10*time.Second, 10)
+	assert.Nil(t, err)
+	assert.Len(t, sagas, 1)
+
+	// Claimed saga is not visible to other workers
+	sagas, err = persistence.ClaimActive("", "worker2", 10*time.Second, 10)
+	assert.Nil(t, err)
+	assert.Len(t, sagas, 0)
+
+	_, err = persistence.SaveProgress("", saga.Id, "worker2", 1, persist.SagaStatusRunning, nil)
+	assert.NotNil(t, err)
+
+	saga, err = persistence.SaveProgress("", saga.Id, "worker1", 1, persist.SagaStatusCompleted, saga.Payload)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, saga.Step)
+	assert.Equal(t, persist.SagaStatusCompleted, saga.Status)
+
+	sagas, err = persistence.ClaimActive("", "worker2", 10*time.Second, 10)
+	assert.Nil(t, err)
+	assert.Len(t, sagas, 0)
+}
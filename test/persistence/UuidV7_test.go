@@ -0,0 +1,21 @@
+package test
+
+import (
+	"regexp"
+	"testing"
+
+	persist "github.com/pip-services3-go/pip-services3-postgres-go/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewUuidV7(t *testing.T) {
+	pattern := regexp.MustCompile("^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$")
+
+	previous := ""
+	for i := 0; i < 1000; i++ {
+		id := persist.NewUuidV7()
+		assert.Regexp(t, pattern, id)
+		assert.True(t, id > previous, "UUIDs must be time ordered")
+		previous = id
+	}
+}
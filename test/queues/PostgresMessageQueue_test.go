@@ -0,0 +1,204 @@
+package test_queues
+
+import (
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cqueues "github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	queues "github.com/pip-services3-go/pip-services3-postgres-go/queues"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresMessageQueue(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+	dbConfig := db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.max_deliveries", 2,
+	))
+
+	queue := queues.NewPostgresMessageQueue("test_queue")
+	queue.Configure(dbConfig)
+
+	err := queue.Open("")
+	if err != nil {
+		t.Error("Error opened queue", err)
+		return
+	}
+	defer queue.Close("")
+
+	err = queue.Clear("")
+	assert.Nil(t, err)
+
+	t.Run("SendReceiveMessage", func(t *testing.T) {
+		envelope := cqueues.NewMessageEnvelope("123", "Test", []byte("Test message"))
+		err := queue.Send("", envelope)
+		assert.Nil(t, err)
+
+		count, err := queue.ReadMessageCount()
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), count)
+
+		peeked, err := queue.Peek("")
+		assert.Nil(t, err)
+		assert.NotNil(t, peeked)
+		assert.Equal(t, envelope.MessageId, peeked.MessageId)
+
+		received, err := queue.Receive("", 10*time.Second)
+		assert.Nil(t, err)
+		assert.NotNil(t, received)
+		assert.Equal(t, envelope.MessageId, received.MessageId)
+		assert.Equal(t, "Test message", string(received.Message))
+
+		// Locked message is not visible to other receivers
+		other, err := queue.Receive("", 100*time.Millisecond)
+		assert.Nil(t, err)
+		assert.Nil(t, other)
+
+		err = queue.Complete(received)
+		assert.Nil(t, err)
+
+		count, err = queue.ReadMessageCount()
+		assert.Nil(t, err)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("ReceiveWaitsForMessage", func(t *testing.T) {
+		envelope := cqueues.NewMessageEnvelope("123", "Test", []byte("Delayed message"))
+		go func() {
+			time.Sleep(200 * time.Millisecond)
+			queue.Send("", envelope)
+		}()
+
+		received, err := queue.Receive("", 5*time.Second)
+		assert.Nil(t, err)
+		assert.NotNil(t, received)
+		assert.Equal(t, envelope.MessageId, received.MessageId)
+
+		err = queue.Complete(received)
+		assert.Nil(t, err)
+	})
+
+	t.Run("DeadLetterAfterMaxDeliveries", func(t *testing.T) {
+		envelope := cqueues.NewMessageEnvelope("123", "Test", []byte("Poison message"))
+		err := queue.Send("", envelope)
+		assert.Nil(t, err)
+
+		for i := 0; i < 2; i++ {
+			received, err := queue.Receive("", time.Second)
+			assert.Nil(t, err)
+			assert.NotNil(t, received)
+			err = queue.Abandon(received)
+			assert.Nil(t, err)
+		}
+
+		received, err := queue.Receive("", 100*time.Millisecond)
+		assert.Nil(t, err)
+		assert.Nil(t, received)
+
+		count, err := queue.ReadMessageCount()
+		assert.Nil(t, err)
+		assert.Equal(t, int64(0), count)
+	})
+}
+
+func TestPostgresMessageQueueClose(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	queue := queues.NewPostgresMessageQueue("test_queue_close")
+	queue.Configure(db.Config)
+
+	err := queue.Open("")
+	if err != nil {
+		t.Error("Error opened queue", err)
+		return
+	}
+
+	// A receiver waits for a message when the queue is closed
+	receiveDone := make(chan error, 1)
+	go func() {
+		received, rErr := queue.Receive("", 10*time.Second)
+		assert.Nil(t, received)
+		receiveDone <- rErr
+	}()
+	time.Sleep(200 * time.Millisecond)
+
+	start := time.Now()
+	err = queue.Close("")
+	assert.Nil(t, err)
+	assert.True(t, time.Since(start) < 5*time.Second)
+	assert.Nil(t, <-receiveDone)
+
+	// Operations are rejected after the queue is closed
+	err = queue.Send("", cqueues.NewMessageEnvelope("123", "Test", []byte("Late message")))
+	assert.NotNil(t, err)
+}
+
+func TestPostgresMessageQueueConfiguredName(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	queue := queues.NewPostgresMessageQueue("")
+	queue.Configure(db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"name", "configured_queue",
+		"options.table_prefix", "app_",
+	)))
+	assert.Equal(t, "configured_queue", queue.Name())
+	assert.Equal(t, "app_configured_queue", queue.TableName)
+
+	err := queue.Open("")
+	if err != nil {
+		t.Error("Error opened queue", err)
+		return
+	}
+	defer queue.Close("")
+
+	envelope := cqueues.NewMessageEnvelope("123", "Test", []byte("Configured message"))
+	err = queue.Send("", envelope)
+	assert.Nil(t, err)
+
+	received, err := queue.Receive("", time.Second)
+	assert.Nil(t, err)
+	if assert.NotNil(t, received) {
+		assert.Equal(t, envelope.MessageId, received.MessageId)
+	}
+}
+
+func TestPostgresMessageQueueSharedListener(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	queue := queues.NewPostgresMessageQueue("test_queue_shared")
+	queue.Configure(db.Config.SetDefaults(cconf.NewConfigParamsFromTuples(
+		"options.max_pool_size", 2,
+	)))
+
+	err := queue.Open("")
+	if err != nil {
+		t.Error("Error opened queue", err)
+		return
+	}
+	defer queue.Close("")
+
+	// Waiting receivers share one connection, so they don't exhaust the pool
+	receivers := 4
+	receiveDone := make(chan *cqueues.MessageEnvelope, receivers)
+	for index := 0; index < receivers; index++ {
+		go func() {
+			received, rErr := queue.Receive("", 5*time.Second)
+			assert.Nil(t, rErr)
+			receiveDone <- received
+		}()
+	}
+	time.Sleep(200 * time.Millisecond)
+
+	for index := 0; index < receivers; index++ {
+		err = queue.Send("", cqueues.NewMessageEnvelope("123", "Test", []byte("Shared message")))
+		assert.Nil(t, err)
+	}
+
+	for index := 0; index < receivers; index++ {
+		received := <-receiveDone
+		if assert.NotNil(t, received) {
+			assert.Nil(t, queue.Complete(received))
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package test_state
+
+import (
+	"testing"
+
+	state "github.com/pip-services3-go/pip-services3-postgres-go/state"
+	tf "github.com/pip-services3-go/pip-services3-postgres-go/test/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresStateStore(t *testing.T) {
+	db := tf.StartPostgresTestDatabase(t)
+
+	store := state.NewPostgresStateStore()
+	store.Configure(db.Config)
+	err := store.Open("")
+	if err != nil {
+		t.Error("Error opened state store", err)
+		return
+	}
+	defer store.Close("")
+
+	err = store.Clear("")
+	assert.Nil(t, err)
+
+	t.Run("SaveAndLoad", func(t *testing.T) {
+		_, err := store.Save("", "key1", "value1")
+		assert.Nil(t, err)
+		_, err = store.Save("", "key2", "value2")
+		assert.Nil(t, err)
+
+		value, err := store.Load("", "key1")
+		assert.Nil(t, err)
+		assert.Equal(t, "value1", value)
+
+		states, err := store.LoadBulk("", []string{"key1", "key2", "key3"})
+		assert.Nil(t, err)
+		assert.Len(t, states, 2)
+
+		value, err = store.Delete("", "key2")
+		assert.Nil(t, err)
+		assert.Equal(t, "value2", value)
+
+		value, err = store.Load("", "key2")
+		assert.Nil(t, err)
+		assert.Nil(t, value)
+	})
+
+	t.Run("OptimisticConcurrency", func(t *testing.T) {
+		saved, err := store.SaveWithVersion("", "key3", "value3", 0)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), saved.Version)
+
+		_, err = store.SaveWithVersion("", "key3", "value3", 0)
+		assert.NotNil(t, err)
+
+		saved, err = store.SaveWithVersion("", "key3", "value4", saved.Version)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(2), saved.Version)
+
+		_, err = store.SaveWithVersion("", "key3", "value5", 1)
+		assert.NotNil(t, err)
+
+		loaded, err := store.LoadWithVersion("", "key3")
+		assert.Nil(t, err)
+		assert.Equal(t, "value4", loaded.Value)
+		assert.Equal(t, int64(2), loaded.Version)
+	})
+}